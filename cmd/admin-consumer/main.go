@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -13,7 +14,9 @@ import (
 	"AdvProg2/domain"
 	"AdvProg2/pkg/cache"
 	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/email"
 	"AdvProg2/infrastructure/messaging"
+	"AdvProg2/pkg/eventbus"
 	"AdvProg2/usecase"
 )
 
@@ -49,44 +52,75 @@ func main() {
 	}
 	defer nc.Close()
 	log.Println("Connected to NATS messaging system")
-	consumer := messaging.NewNatsConsumer(nc)
+	consumer, err := messaging.NewConsumerFromEnv(nc, "admin-consumer")
+	if err != nil {
+		log.Fatalf("Failed to create NATS consumer: %v", err)
+	}
 	defer consumer.Close()
 	
-	producer := messaging.NewNatsProducer(nc)
+	producer, err := messaging.NewProducerFromEnv(nc)
+	if err != nil {
+		log.Fatalf("Failed to create NATS producer: %v", err)
+	}
 	cacheInstance := cache.New()
 
-	messageUseCase := usecase.NewMessageUseCase(producer, productRepo, cacheInstance)
+	processedRepo := db.NewPostgresProcessedMessageRepository(dbConn, "admin-consumer")
+	messageUseCase := usecase.NewMessageUseCase(producer, productRepo, cacheInstance, processedRepo)
 
-	err = consumer.SubscribeToProductCreated(func(event domain.ProductCreatedEvent) error {
+	emailServiceURL := os.Getenv("EMAIL_SERVICE_URL")
+	if emailServiceURL == "" {
+		emailServiceURL = "http://localhost:8086"
+	}
+
+	adminEmails := email.ParseAddresses(os.Getenv("ADMIN_EMAILS"))
+	if len(adminEmails) == 0 {
+		log.Println("Warning: ADMIN_EMAILS is not set, low stock alerts will only be logged")
+	}
+	alertUseCase := usecase.NewInventoryAlertUseCase(productRepo, email.NewHTTPSender(emailServiceURL), adminEmails, processedRepo)
+
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go messageUseCase.RunProcessedMessageCleanup(cleanupCtx)
+
+	err = eventbus.Subscribe(consumer, domain.EventProductCreated, func(meta domain.MessageMetadata, event domain.ProductCreatedEvent) error {
 		log.Printf("Admin consumer: received product created event for product %s", event.ProductID)
-		return messageUseCase.HandleProductCreatedEvent(event)
+		return messageUseCase.HandleProductCreatedEvent(meta, event)
 	})
 	if err != nil {
 		log.Printf("Failed to subscribe to product.created events: %v", err)
 	}
 
-	err = consumer.SubscribeToProductUpdated(func(event domain.ProductUpdatedEvent) error {
+	err = eventbus.Subscribe(consumer, domain.EventProductUpdated, func(meta domain.MessageMetadata, event domain.ProductUpdatedEvent) error {
 		log.Printf("Admin consumer: received product.updated event for product %s (%s)",
 			event.ProductID, event.Name)
-		log.Printf("Admin consumer: product %s updated with price $%.2f and stock %d at %s",
+		log.Printf("Admin consumer: product %s updated with price %s and stock %d at %s",
 			event.ProductID, event.Price, event.Stock, event.UpdatedAt.Format(time.RFC3339))
-		return messageUseCase.HandleProductUpdatedEvent(event)
+		return messageUseCase.HandleProductUpdatedEvent(meta, event)
 	})
 	if err != nil {
 		log.Printf("Failed to subscribe to product.updated events: %v", err)
 	}
 
-	err = consumer.SubscribeToProductDeleted(func(event domain.ProductDeletedEvent) error {
+	err = eventbus.Subscribe(consumer, domain.EventProductDeleted, func(meta domain.MessageMetadata, event domain.ProductDeletedEvent) error {
 		log.Printf("Admin consumer: received product.deleted event for product %s", event.ProductID)
 		log.Printf("Admin consumer: product %s was deleted at %s",
 			event.ProductID, event.DeletedAt.Format(time.RFC3339))
-		return messageUseCase.HandleProductDeletedEvent(event)
+		return messageUseCase.HandleProductDeletedEvent(meta, event)
 	})
 	if err != nil {
 		log.Printf("Failed to subscribe to product.deleted events: %v", err)
 	}
 
-	log.Println("Admin consumer started, listening for product events...")
+	err = eventbus.Subscribe(consumer, domain.EventInventoryLowStock, func(meta domain.MessageMetadata, event domain.LowStockEvent) error {
+		log.Printf("Admin consumer: received inventory.low_stock event for product %s (%s), stock %d of threshold %d",
+			event.ProductID, event.Name, event.Stock, event.ReorderThreshold)
+		return alertUseCase.HandleLowStockEvent(meta, event)
+	})
+	if err != nil {
+		log.Printf("Failed to subscribe to inventory.low_stock events: %v", err)
+	}
+
+	log.Println("Admin consumer started, listening for product and inventory events...")
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
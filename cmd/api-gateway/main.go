@@ -3,6 +3,8 @@ package main
 import (
 	"log"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 )
 
@@ -56,6 +59,17 @@ func proxyToService(serviceURL string, invalidateCache func(c *gin.Context, resp
 			req.Header.Set("X-Request-ID", requestID.(string))
 		}
 
+		// Services trust these headers to identify the caller, so only the
+		// values taken from the validated token are passed on.
+		req.Header.Del("X-User-ID")
+		req.Header.Del("X-User-Role")
+		if userID, exists := c.Get("userID"); exists {
+			req.Header.Set("X-User-ID", userID.(string))
+		}
+		if userRole, exists := c.Get("userRole"); exists {
+			req.Header.Set("X-User-Role", userRole.(string))
+		}
+
 		resp, err := client.Do(req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Service unavailable: " + err.Error()})
@@ -89,6 +103,40 @@ func proxyToService(serviceURL string, invalidateCache func(c *gin.Context, resp
 	}
 }
 
+// streamToService forwards the request like proxyToService, but passes both
+// bodies through as they arrive and without a timeout, for transfers too
+// large to buffer such as catalog imports and exports.
+func streamToService(serviceURL string) gin.HandlerFunc {
+	target, err := url.Parse(serviceURL)
+	if err != nil {
+		log.Fatalf("Invalid service URL %q: %v", serviceURL, err)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.FlushInterval = -1
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("Stream to %s failed: %v", serviceURL, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	return func(c *gin.Context) {
+		if requestID, exists := c.Get("RequestID"); exists {
+			c.Request.Header.Set("X-Request-ID", requestID.(string))
+		}
+
+		c.Request.Header.Del("X-User-ID")
+		c.Request.Header.Del("X-User-Role")
+		if userID, exists := c.Get("userID"); exists {
+			c.Request.Header.Set("X-User-ID", userID.(string))
+		}
+		if userRole, exists := c.Get("userRole"); exists {
+			c.Request.Header.Set("X-User-Role", userRole.(string))
+		}
+
+		proxy.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
 func main() {
 	r := gin.New()
 	r.Use(gin.Recovery())
@@ -137,8 +185,8 @@ func main() {
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 
 			if err := json.Unmarshal(body, &orderData); err == nil {
-				if userID, ok := orderData["user_id"].(string); ok {
-					cacheKey := "user:" + userID + ":orders"
+				if userID, exists := c.Get("userID"); exists {
+					cacheKey := "user:" + userID.(string) + ":orders"
 					cacheClient.Delete(cacheKey)
 					log.Printf("Invalidated cache for user orders: %s", userID)
 				}
@@ -177,7 +225,7 @@ func main() {
 					c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 
 					if json.Unmarshal(body, &statusUpdate) == nil {
-						if status, ok := statusUpdate["status"].(string); ok && (status == "completed" || status == "cancelled") {
+						if status, ok := statusUpdate["status"].(string); ok && status == "cancelled" {
 							cacheClient.Delete("products:list")
 							log.Printf("Order %s status changed to %s, invalidated products cache", orderID, status)
 						}
@@ -191,6 +239,20 @@ func main() {
 		return false
 	}
 
+	// Checking out a cart places an order for the caller, taking stock from
+	// products that are not named in the request.
+	checkoutCacheInvalidator := func(c *gin.Context, resp *http.Response) bool {
+		userID, exists := c.Get("userID")
+		if !exists {
+			return false
+		}
+
+		cacheClient.Delete("user:" + userID.(string) + ":orders")
+		cacheClient.Delete("products:list")
+		log.Printf("Invalidated cache for user orders after checkout: %s", userID)
+		return true
+	}
+
 	userCacheInvalidator := func(c *gin.Context, resp *http.Response) bool {
 		method := c.Request.Method
 		path := c.Request.URL.Path
@@ -262,12 +324,19 @@ func main() {
 	inventoryAPI := r.Group("/api/products")
 	{
 		inventoryAPI.GET("", proxyToService(inventoryServiceURL, nil))
+		inventoryAPI.GET("/search", proxyToService(inventoryServiceURL, nil))
+		inventoryAPI.GET("/suggest", proxyToService(inventoryServiceURL, nil))
 		inventoryAPI.GET("/:id", proxyToService(inventoryServiceURL, nil))
+		inventoryAPI.GET("/:id/images", proxyToService(inventoryServiceURL, nil))
 		inventoryAPI.POST("", proxyToService(inventoryServiceURL, productCacheInvalidator))
 		inventoryAPI.PUT("/:id", proxyToService(inventoryServiceURL, productCacheInvalidator))
 		inventoryAPI.DELETE("/:id", proxyToService(inventoryServiceURL, productCacheInvalidator))
 	}
 
+	r.GET("/media/*path", proxyToService(inventoryServiceURL, nil))
+	r.GET("/api/categories", proxyToService(inventoryServiceURL, nil))
+	r.GET("/api/categories/:id", proxyToService(inventoryServiceURL, nil))
+
 	orderServiceURL := os.Getenv("ORDER_SERVICE_URL")
 	if orderServiceURL == "" {
 		orderServiceURL = "http://localhost:8083"
@@ -277,11 +346,39 @@ func main() {
 	{
 		orderAPI.GET("", proxyToService(orderServiceURL, nil))
 		orderAPI.GET("/:id", proxyToService(orderServiceURL, nil))
+		orderAPI.GET("/:id/history", proxyToService(orderServiceURL, nil))
 		orderAPI.POST("", proxyToService(orderServiceURL, orderCacheInvalidator))
 		orderAPI.PATCH("/:id", proxyToService(orderServiceURL, orderCacheInvalidator))
 		orderAPI.DELETE("/:id", proxyToService(orderServiceURL, orderCacheInvalidator))
 	}
 
+	cartAPI := r.Group("/api/cart")
+	{
+		cartAPI.GET("", proxyToService(orderServiceURL, nil))
+		cartAPI.DELETE("", proxyToService(orderServiceURL, nil))
+		cartAPI.POST("/items", proxyToService(orderServiceURL, nil))
+		cartAPI.PUT("/items/:productId", proxyToService(orderServiceURL, nil))
+		cartAPI.DELETE("/items/:productId", proxyToService(orderServiceURL, nil))
+		cartAPI.POST("/checkout", proxyToService(orderServiceURL, checkoutCacheInvalidator))
+	}
+
+	r.GET("/api/delivery-slots", proxyToService(orderServiceURL, nil))
+
+	paymentServiceURL := os.Getenv("PAYMENT_SERVICE_URL")
+	if paymentServiceURL == "" {
+		paymentServiceURL = "http://localhost:8087"
+	}
+
+	paymentAPI := r.Group("/api/payments")
+	{
+		paymentAPI.POST("", proxyToService(paymentServiceURL, nil))
+		paymentAPI.POST("/webhook", proxyToService(paymentServiceURL, nil))
+		paymentAPI.GET("/:id", proxyToService(paymentServiceURL, nil))
+	}
+	if simulatorEnabled, _ := strconv.ParseBool(os.Getenv("PAYMENT_SIMULATOR")); simulatorEnabled {
+		paymentAPI.POST("/:id/simulate", proxyToService(paymentServiceURL, nil))
+	}
+
 	userServiceURL := os.Getenv("USER_SERVICE_URL")
 	if userServiceURL == "" {
 		userServiceURL = "http://localhost:8085"
@@ -295,6 +392,15 @@ func main() {
 		userAPI.PATCH("/:id", proxyToService(userServiceURL, userCacheInvalidator))
 	}
 
+	addressAPI := r.Group("/api/addresses")
+	{
+		addressAPI.GET("", proxyToService(userServiceURL, nil))
+		addressAPI.POST("", proxyToService(userServiceURL, nil))
+		addressAPI.GET("/:id", proxyToService(userServiceURL, nil))
+		addressAPI.PUT("/:id", proxyToService(userServiceURL, nil))
+		addressAPI.DELETE("/:id", proxyToService(userServiceURL, nil))
+	}
+
 	r.GET("/api/users/:id", func(c *gin.Context) {
 		userId := c.Param("id")
 		userServiceURL := os.Getenv("USER_SERVICE_URL")
@@ -336,8 +442,29 @@ func main() {
 	adminAPI.Use(middleware.AdminRequired())
 	{
 		adminAPI.POST("/products", proxyToService(adminServiceURL, productCacheInvalidator))
+		adminAPI.POST("/products/import", streamToService(adminServiceURL))
+		adminAPI.GET("/products/export", streamToService(adminServiceURL))
 		adminAPI.PUT("/products/:id", proxyToService(adminServiceURL, productCacheInvalidator))
 		adminAPI.DELETE("/products/:id", proxyToService(adminServiceURL, productCacheInvalidator))
+		adminAPI.POST("/products/:id/images", proxyToService(inventoryServiceURL, productCacheInvalidator))
+		adminAPI.PUT("/products/:id/images", proxyToService(inventoryServiceURL, productCacheInvalidator))
+		adminAPI.DELETE("/products/:id/images/:imageId", proxyToService(inventoryServiceURL, productCacheInvalidator))
+		adminAPI.GET("/products/:id/stock-movements", proxyToService(adminServiceURL, nil))
+		adminAPI.PUT("/products/:id/reorder-threshold", proxyToService(adminServiceURL, productCacheInvalidator))
+		adminAPI.GET("/stock/low", proxyToService(adminServiceURL, nil))
+		adminAPI.GET("/stock/discrepancies", proxyToService(adminServiceURL, nil))
+		adminAPI.POST("/stock/reconcile", proxyToService(adminServiceURL, nil))
+		adminAPI.POST("/categories", proxyToService(adminServiceURL, nil))
+		adminAPI.PUT("/categories/:id", proxyToService(adminServiceURL, nil))
+		adminAPI.DELETE("/categories/:id", proxyToService(adminServiceURL, nil))
+		adminAPI.GET("/promotions", proxyToService(adminServiceURL, nil))
+		adminAPI.POST("/promotions", proxyToService(adminServiceURL, nil))
+		adminAPI.GET("/promotions/:id", proxyToService(adminServiceURL, nil))
+		adminAPI.PUT("/promotions/:id", proxyToService(adminServiceURL, nil))
+		adminAPI.DELETE("/promotions/:id", proxyToService(adminServiceURL, nil))
+		adminAPI.GET("/delivery-slots", proxyToService(adminServiceURL, nil))
+		adminAPI.POST("/delivery-slots", proxyToService(adminServiceURL, nil))
+		adminAPI.DELETE("/delivery-slots/:id", proxyToService(adminServiceURL, nil))
 	}
 
 	emailServiceURL := os.Getenv("EMAIL_SERVICE_URL")
@@ -1,6 +1,7 @@
 package main
 
 import (
+    "context"
     "log"
     "os"
     "os/signal"
@@ -13,6 +14,7 @@ import (
     "AdvProg2/pkg/cache"
     "AdvProg2/infrastructure/db"
     "AdvProg2/infrastructure/messaging"
+    "AdvProg2/pkg/eventbus"
     "AdvProg2/usecase"
 )
 
@@ -44,25 +46,49 @@ func main() {
         log.Fatalf("Failed to connect to NATS: %v", err)
     }
     defer nc.Close()
-    consumer := messaging.NewNatsConsumer(nc)
+    consumer, err := messaging.NewConsumerFromEnv(nc, "consumer-service")
+    if err != nil {
+        log.Fatalf("Failed to create NATS consumer: %v", err)
+    }
     defer consumer.Close()
     
-    producer := messaging.NewNatsProducer(nc)
+    producer, err := messaging.NewProducerFromEnv(nc)
+    if err != nil {
+        log.Fatalf("Failed to create NATS producer: %v", err)
+    }
     
     // Initialize cache
     cacheInstance := cache.New()
 
-    messageUseCase := usecase.NewMessageUseCase(producer, productRepo, cacheInstance)
+    processedRepo := db.NewPostgresProcessedMessageRepository(dbConn, "consumer-service")
+    messageUseCase := usecase.NewMessageUseCase(producer, productRepo, cacheInstance, processedRepo)
 
-    err = consumer.SubscribeToOrderCreated(func(event domain.OrderCreatedEvent) error {
-        return messageUseCase.HandleOrderCreatedEvent(event)
-    })
+    cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+    defer stopCleanup()
+    go messageUseCase.RunProcessedMessageCleanup(cleanupCtx)
+
+    err = eventbus.Subscribe(consumer, domain.EventOrderCreated, messageUseCase.HandleOrderCreatedEvent)
 
     if err != nil {
         log.Fatalf("Failed to subscribe to order.created events: %v", err)
     }
 
-    log.Println("Consumer service started, listening for order.created events")
+    err = eventbus.Subscribe(consumer, domain.EventOrderStatusChanged, messageUseCase.HandleOrderStatusChangedEvent)
+    if err != nil {
+        log.Fatalf("Failed to subscribe to order.status_changed events: %v", err)
+    }
+
+    err = eventbus.Subscribe(consumer, domain.EventOrderCancelled, messageUseCase.HandleOrderCancelledEvent)
+    if err != nil {
+        log.Fatalf("Failed to subscribe to order.cancelled events: %v", err)
+    }
+
+    err = eventbus.Subscribe(consumer, domain.EventOrderCompleted, messageUseCase.HandleOrderCompletedEvent)
+    if err != nil {
+        log.Fatalf("Failed to subscribe to order.completed events: %v", err)
+    }
+
+    log.Println("Consumer service started, listening for order events")
 
     quit := make(chan os.Signal, 1)
     signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -13,10 +13,13 @@ import (
 	grpcHandler "AdvProg2/handler/grpc"
 	httpHandler "AdvProg2/handler/http"
 	db "AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/delivery"
+	"AdvProg2/infrastructure/exchangerate"
 	"AdvProg2/infrastructure/messaging"
-	"AdvProg2/pkg/cache"
+	"AdvProg2/infrastructure/tax"
+	"AdvProg2/pkg/eventbus"
+	cartpb "AdvProg2/proto/cart"
 	pb "AdvProg2/proto/order"
-	"AdvProg2/repository"
 	"AdvProg2/usecase"
 	"AdvProg2/domain"
 	"github.com/gorilla/mux"
@@ -47,17 +50,23 @@ func main() {
 		natsURL = nats.DefaultURL
 	}
 
-	var messageProducer repository.MessageProducer
-	var messageUseCase *usecase.MessageUseCase
+	var messageProducer *messaging.NatsProducer
 	var messageConsumer *messaging.NatsConsumer
 
 	nc, err := messaging.NewNatsConnection(natsURL)
 	if err != nil {
 		log.Printf("Warning: Failed to connect to NATS: %v", err)
 		log.Println("Order service will run without messaging capabilities")
+	} else if messageProducer, err = messaging.NewProducerFromEnv(nc); err != nil {
+		log.Printf("Warning: Failed to create NATS producer: %v", err)
+		log.Println("Order service will run without messaging capabilities")
+		nc.Close()
+	} else if messageConsumer, err = messaging.NewConsumerFromEnv(nc, "order-service"); err != nil {
+		log.Printf("Warning: Failed to create NATS consumer: %v", err)
+		log.Println("Order service will run without messaging capabilities")
+		messageProducer = nil
+		nc.Close()
 	} else {
-		messageProducer = messaging.NewNatsProducer(nc)
-		messageConsumer = messaging.NewNatsConsumer(nc)
 		log.Println("Connected to NATS messaging system")
 		defer nc.Close()
 		defer messageProducer.Close()
@@ -66,17 +75,23 @@ func main() {
 
 	orderRepo := db.NewPostgresOrderRepository(dbConn)
 	productRepo := db.NewPostgresProductRepository(dbConn)
-	// Create message use case only if we have a producer
+	reservationRepo := db.NewPostgresStockReservationRepository(dbConn)
+	unitOfWork := db.NewPostgresUnitOfWork(dbConn)
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+
+	// Events are written to the outbox with the order and relayed from there,
+	// so orders created while NATS is down are published once it is back.
 	if messageProducer != nil {
-		cacheInstance := cache.New()
-		messageUseCase = usecase.NewMessageUseCase(messageProducer, productRepo, cacheInstance)
-		log.Println("Initialized message use case")
+		outboxRelay := usecase.NewOutboxRelay(unitOfWork, db.NewPostgresOutboxRepository(dbConn), messageProducer)
+		go outboxRelay.Run(relayCtx)
 	}
 
 	// Subscribe to product.created events
 	if messageConsumer != nil {
-		err = messageConsumer.SubscribeToProductCreated(func(event domain.ProductCreatedEvent) error {
-			log.Printf("Processed product.created event: ProductID=%s, Name=%s, Price=%.2f, Stock=%d",
+		err = eventbus.Subscribe(messageConsumer, domain.EventProductCreated, func(meta domain.MessageMetadata, event domain.ProductCreatedEvent) error {
+			log.Printf("Processed product.created event: ProductID=%s, Name=%s, Price=%s, Stock=%d",
 				event.ProductID, event.Name, event.Price, event.Stock)
 			return nil
 		})
@@ -85,10 +100,41 @@ func main() {
 		}
 	}
 
-	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, messageUseCase)
+	rates, err := exchangerate.NewProviderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err)
+	}
+
+	taxes, err := tax.NewCalculatorFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load tax rules: %v", err)
+	}
+
+	fees, err := delivery.NewFeeCalculatorFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load delivery zones: %v", err)
+	}
+
+	reservationTTL := usecase.DefaultStockReservationTTL
+	if ttl := os.Getenv("STOCK_RESERVATION_TTL"); ttl != "" {
+		reservationTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("Invalid STOCK_RESERVATION_TTL %q: %v", ttl, err)
+		}
+	}
+
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, reservationRepo, unitOfWork, rates, taxes, fees, reservationTTL)
 	log.Println("Initialized order use case")
 
-	grpcOrderHandler := grpcHandler.NewOrderHandler(orderUseCase)
+	// Pending orders that are not confirmed before their reservations run
+	// out are cancelled, putting the stock back on sale.
+	go orderUseCase.RunReservationReaper(relayCtx)
+
+	cartUseCase := usecase.NewCartUseCase(db.NewPostgresCartRepository(dbConn), productRepo, rates, orderUseCase)
+	deliverySlotUseCase := usecase.NewDeliverySlotUseCase(db.NewPostgresDeliverySlotRepository(dbConn))
+
+	grpcOrderHandler := grpcHandler.NewOrderHandler(orderUseCase, deliverySlotUseCase)
+	grpcCartHandler := grpcHandler.NewCartHandler(cartUseCase)
 
 	grpcPort := os.Getenv("ORDER_SERVICE_PORT")
 	if grpcPort == "" {
@@ -100,8 +146,9 @@ func main() {
 		log.Fatalf("Failed to listen on port %s: %v", grpcPort, err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(grpcHandler.ActorInterceptor))
 	pb.RegisterOrderServiceServer(grpcServer, grpcOrderHandler)
+	cartpb.RegisterCartServiceServer(grpcServer, grpcCartHandler)
 
 	reflection.Register(grpcServer)
 
@@ -117,7 +164,8 @@ func main() {
 		httpPort = "8093"
 	}
 
-	orderHTTPHandler := httpHandler.NewOrderHTTPHandler(orderUseCase)
+	orderHTTPHandler := httpHandler.NewOrderHTTPHandler(orderUseCase, deliverySlotUseCase)
+	cartHTTPHandler := httpHandler.NewCartHTTPHandler(cartUseCase)
 
 	router := mux.NewRouter()
 
@@ -138,16 +186,29 @@ func main() {
 
 	router.HandleFunc("/api/orders", orderHTTPHandler.CreateOrder).Methods("POST")
 	router.HandleFunc("/api/orders/{id}", orderHTTPHandler.GetOrder).Methods("GET")
+	router.HandleFunc("/api/orders/{id}/history", orderHTTPHandler.GetOrderHistory).Methods("GET")
 	router.HandleFunc("/api/orders", orderHTTPHandler.GetUserOrders).Methods("GET")
 	router.HandleFunc("/api/orders/{id}", orderHTTPHandler.UpdateOrderStatus).Methods("PATCH")
 	router.HandleFunc("/api/orders/{id}", orderHTTPHandler.CancelOrder).Methods("DELETE")
 
+	router.HandleFunc("/api/delivery-slots", orderHTTPHandler.ListDeliverySlots).Methods("GET")
+
+	router.HandleFunc("/api/cart", cartHTTPHandler.GetCart).Methods("GET")
+	router.HandleFunc("/api/cart", cartHTTPHandler.ClearCart).Methods("DELETE")
+	router.HandleFunc("/api/cart/items", cartHTTPHandler.AddItem).Methods("POST")
+	router.HandleFunc("/api/cart/items/{productId}", cartHTTPHandler.UpdateItem).Methods("PUT")
+	router.HandleFunc("/api/cart/items/{productId}", cartHTTPHandler.RemoveItem).Methods("DELETE")
+	router.HandleFunc("/api/cart/checkout", cartHTTPHandler.Checkout).Methods("POST")
+
 	router.HandleFunc("/api/orders", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}).Methods("OPTIONS")
 	router.HandleFunc("/api/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}).Methods("OPTIONS")
+	router.HandleFunc("/api/orders/{id}/history", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("OPTIONS")
 
 	httpServer := &http.Server{
 		Addr:    ":" + httpPort,
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/joho/godotenv"
+	"github.com/nats-io/nats.go"
+
+	"AdvProg2/domain"
+	httpHandler "AdvProg2/handler/http"
+	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/delivery"
+	"AdvProg2/infrastructure/exchangerate"
+	"AdvProg2/infrastructure/messaging"
+	"AdvProg2/infrastructure/payment"
+	"AdvProg2/infrastructure/tax"
+	"AdvProg2/pkg/eventbus"
+	"AdvProg2/usecase"
+)
+
+func main() {
+	log.Println("Starting payment service...")
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Printf("Warning: Error loading .env file: %v", err)
+	}
+
+	dbConn, err := db.NewPostgresConnection()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbConn.Close()
+	log.Println("Connected to database")
+
+	// Refunds are triggered by order.cancelled, so the service does not run
+	// without NATS.
+	natsURL := os.Getenv("NATS_URL")
+	if natsURL == "" {
+		natsURL = nats.DefaultURL
+	}
+
+	nc, err := messaging.NewNatsConnection(natsURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to NATS: %v", err)
+	}
+	defer nc.Close()
+	log.Println("Connected to NATS messaging system")
+
+	producer, err := messaging.NewProducerFromEnv(nc)
+	if err != nil {
+		log.Fatalf("Failed to create NATS producer: %v", err)
+	}
+	defer producer.Close()
+
+	consumer, err := messaging.NewConsumerFromEnv(nc, "payment-service")
+	if err != nil {
+		log.Fatalf("Failed to create NATS consumer: %v", err)
+	}
+	defer consumer.Close()
+
+	orderRepo := db.NewPostgresOrderRepository(dbConn)
+	productRepo := db.NewPostgresProductRepository(dbConn)
+	reservationRepo := db.NewPostgresStockReservationRepository(dbConn)
+	paymentRepo := db.NewPostgresPaymentRepository(dbConn)
+	unitOfWork := db.NewPostgresUnitOfWork(dbConn)
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+
+	// Payment events, and the order status changes payments make, are
+	// written to the outbox and relayed from there.
+	outboxRelay := usecase.NewOutboxRelay(unitOfWork, db.NewPostgresOutboxRepository(dbConn), producer)
+	go outboxRelay.Run(relayCtx)
+
+	rates, err := exchangerate.NewProviderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err)
+	}
+
+	taxes, err := tax.NewCalculatorFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load tax rules: %v", err)
+	}
+
+	fees, err := delivery.NewFeeCalculatorFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load delivery zones: %v", err)
+	}
+
+	// Orders are only read and moved between statuses here; the order
+	// service places them and reaps expired reservations.
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, reservationRepo, unitOfWork, rates, taxes, fees, 0)
+
+	provider, err := payment.NewProviderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create payment provider: %v", err)
+	}
+
+	processedRepo := db.NewPostgresProcessedMessageRepository(dbConn, "payment-service")
+	paymentUseCase := usecase.NewPaymentUseCase(paymentRepo, unitOfWork, orderUseCase, provider, processedRepo)
+	log.Println("Initialized payment use case")
+
+	err = eventbus.Subscribe(consumer, domain.EventOrderCancelled, func(meta domain.MessageMetadata, event domain.OrderCancelledEvent) error {
+		log.Printf("Payment service: received order.cancelled event for order %s", event.OrderID)
+		return paymentUseCase.HandleOrderCancelledEvent(meta, event)
+	})
+	if err != nil {
+		log.Fatalf("Failed to subscribe to order.cancelled events: %v", err)
+	}
+
+	// The fake provider can be told to complete payments, so there is a
+	// way to pay for orders in development. It lets callers mark their own
+	// orders paid, so it has to be switched on with PAYMENT_SIMULATOR.
+	var simulator httpHandler.WebhookSimulator
+	simulatorEnabled, _ := strconv.ParseBool(os.Getenv("PAYMENT_SIMULATOR"))
+	if fake, ok := provider.(*payment.FakeProvider); ok && simulatorEnabled {
+		simulator = fake
+	}
+	paymentHTTPHandler := httpHandler.NewPaymentHTTPHandler(paymentUseCase, simulator)
+
+	router := mux.NewRouter()
+
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	router.HandleFunc("/api/payments", paymentHTTPHandler.CreatePayment).Methods("POST")
+	router.HandleFunc("/api/payments/webhook", paymentHTTPHandler.Webhook).Methods("POST")
+	router.HandleFunc("/api/payments/{id}", paymentHTTPHandler.GetPayment).Methods("GET")
+	if simulator != nil {
+		router.HandleFunc("/api/payments/{id}/simulate", paymentHTTPHandler.SimulatePayment).Methods("POST")
+	}
+
+	httpPort := os.Getenv("PAYMENT_SERVICE_HTTP_PORT")
+	if httpPort == "" {
+		httpPort = "8087"
+	}
+
+	httpServer := &http.Server{
+		Addr:    ":" + httpPort,
+		Handler: router,
+	}
+
+	go func() {
+		log.Printf("Payment HTTP server started on port %s", httpPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to serve HTTP: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Fatalf("HTTP server shutdown error: %v", err)
+	}
+
+	log.Println("Payment service shutting down")
+}
@@ -3,12 +3,17 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -21,70 +26,213 @@ import (
 	"AdvProg2/domain"
 	grpcHandler "AdvProg2/handler/grpc"
 	"AdvProg2/pkg/cache"
+	"AdvProg2/infrastructure/blob"
 	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/exchangerate"
 	"AdvProg2/infrastructure/messaging"
+	"AdvProg2/pkg/eventbus"
 	pb "AdvProg2/proto/product"
+	"AdvProg2/repository"
 	"AdvProg2/usecase"
 )
 
 type ProductHTTPHandler struct {
-	productUseCase *usecase.ProductUseCase
+	productUseCase  *usecase.ProductUseCase
+	categoryUseCase *usecase.CategoryUseCase
+	imageUseCase    *usecase.ProductImageUseCase
 }
 
-func NewProductHTTPHandler(productUseCase *usecase.ProductUseCase) *ProductHTTPHandler {
+func NewProductHTTPHandler(productUseCase *usecase.ProductUseCase, categoryUseCase *usecase.CategoryUseCase, imageUseCase *usecase.ProductImageUseCase) *ProductHTTPHandler {
 	return &ProductHTTPHandler{
-		productUseCase: productUseCase,
+		productUseCase:  productUseCase,
+		categoryUseCase: categoryUseCase,
+		imageUseCase:    imageUseCase,
 	}
 }
 
-func (h *ProductHTTPHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// productErrorStatusCode reports a product placed in an unknown category as
+// a bad request.
+func productErrorStatusCode(err error) int {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
 
+// imageErrorStatusCode maps errors from the product image endpoints.
+func imageErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidImage), errors.Is(err, domain.ErrInvalidImageOrder):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrProductImageNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// pageFromQuery reads the page and per_page parameters, falling back to the
+// first page of 10.
+func pageFromQuery(query url.Values) (int, int) {
 	page := 1
 	limit := 10
-	var minPrice, maxPrice float64
 
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+	if pageStr := query.Get("page"); pageStr != "" {
 		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
 			page = p
 		}
 	}
 
-	if limitStr := r.URL.Query().Get("per_page"); limitStr != "" {
+	if limitStr := query.Get("per_page"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
 			limit = l
 		}
 	}
 
-	if minPriceStr := r.URL.Query().Get("min_price"); minPriceStr != "" {
-		if mp, err := strconv.ParseFloat(minPriceStr, 64); err == nil {
-			minPrice = mp
+	return page, limit
+}
+
+// productFilterFromQuery reads the filter and sort parameters shared by the
+// product listing and search endpoints.
+func productFilterFromQuery(query url.Values) (repository.ProductFilter, error) {
+	var filter repository.ProductFilter
+
+	// Price bounds and price sorts only compare products priced in
+	// price_currency, which defaults to the catalog currency.
+	filter.PriceCurrency = strings.ToUpper(strings.TrimSpace(query.Get("price_currency")))
+
+	if minPriceStr := query.Get("min_price"); minPriceStr != "" {
+		if mp, err := domain.ParseMoney(minPriceStr, filter.PriceCurrency); err == nil {
+			filter.MinPrice = mp
 		}
 	}
 
-	if maxPriceStr := r.URL.Query().Get("max_price"); maxPriceStr != "" {
-		if mp, err := strconv.ParseFloat(maxPriceStr, 64); err == nil {
-			maxPrice = mp
+	if maxPriceStr := query.Get("max_price"); maxPriceStr != "" {
+		if mp, err := domain.ParseMoney(maxPriceStr, filter.PriceCurrency); err == nil {
+			filter.MaxPrice = mp
 		}
 	}
 
-	var products []*domain.Product
-	var total int32
-	var err error
+	filter.Name = query.Get("name")
+	filter.CategoryID = query.Get("category_id")
+	filter.SortBy = repository.ProductSortField(query.Get("sort_by"))
 
-	if minPrice > 0 || maxPrice > 0 {
-		products, total, err = h.productUseCase.SearchByPriceRange(minPrice, maxPrice, int32(page), int32(limit))
-	} else {
-		products, total, err = h.productUseCase.ListProducts(int32(page), int32(limit))
+	// Tags may be repeated or comma separated: ?tags=vegan,organic
+	for _, tags := range query["tags"] {
+		filter.Tags = append(filter.Tags, strings.Split(tags, ",")...)
+	}
+
+	if inStock, err := strconv.ParseBool(query.Get("in_stock")); err == nil {
+		filter.InStock = inStock
+	}
+
+	switch direction := strings.ToLower(query.Get("sort_direction")); direction {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, fmt.Errorf("%w: sort_direction must be asc or desc", repository.ErrInvalidProductFilter)
+	}
+
+	return filter, nil
+}
+
+func (h *ProductHTTPHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	page, limit := pageFromQuery(r.URL.Query())
+	currency := r.URL.Query().Get("currency")
+
+	filter, err := productFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Requests with a page number keep getting numbered pages with a total,
+	// the rest are paged by position with page_token.
+	pageToken := r.URL.Query().Get("page_token")
+	if pageToken == "" && r.URL.Query().Has("page") {
+		products, total, err := h.productUseCase.SearchProducts(filter, int32(page), int32(limit), currency)
+		if errors.Is(err, domain.ErrUnsupportedCurrency) || errors.Is(err, repository.ErrInvalidProductFilter) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"products": products,
+			"total":    total,
+			"page":     page,
+			"per_page": limit,
+		}
+
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	includeTotal, _ := strconv.ParseBool(r.URL.Query().Get("include_total"))
+
+	result, err := h.productUseCase.ListProductsPage(filter, repository.PageRequest{
+		Token:     pageToken,
+		Limit:     int32(limit),
+		WithTotal: includeTotal,
+	}, currency)
+	if errors.Is(err, domain.ErrUnsupportedCurrency) || errors.Is(err, repository.ErrInvalidProductFilter) ||
+		errors.Is(err, repository.ErrInvalidPageToken) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"products":        result.Items,
+		"next_page_token": result.NextPageToken,
+		"per_page":        limit,
+	}
+	if result.Total != nil {
+		response["total"] = *result.Total
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// SearchProducts runs a full-text search for the q parameter. It takes the
+// same filters as GetProducts, but results are ordered by relevance.
+func (h *ProductHTTPHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	page, limit := pageFromQuery(r.URL.Query())
+	currency := r.URL.Query().Get("currency")
+
+	filter, err := productFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
+	results, total, err := h.productUseCase.SearchText(r.URL.Query().Get("q"), filter, int32(page), int32(limit), currency)
+	if errors.Is(err, domain.ErrUnsupportedCurrency) || errors.Is(err, repository.ErrInvalidProductFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if results == nil {
+		results = []*domain.ProductSearchResult{}
+	}
+
 	response := map[string]interface{}{
-		"products": products,
+		"results":  results,
 		"total":    total,
 		"page":     page,
 		"per_page": limit,
@@ -93,13 +241,37 @@ func (h *ProductHTTPHandler) GetProducts(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+func (h *ProductHTTPHandler) SuggestProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	suggestions, err := h.productUseCase.SuggestProducts(r.URL.Query().Get("q"), int32(limit))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if suggestions == nil {
+		suggestions = []*domain.ProductSuggestion{}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"suggestions": suggestions,
+	})
+}
+
 func (h *ProductHTTPHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	product, err := h.productUseCase.GetProduct(id)
+	product, err := h.productUseCase.GetProduct(id, r.URL.Query().Get("currency"))
+	if errors.Is(err, domain.ErrUnsupportedCurrency) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -117,9 +289,9 @@ func (h *ProductHTTPHandler) CreateProduct(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	createdProduct, err := h.productUseCase.CreateProduct(product.Name, product.Price, product.Stock)
+	createdProduct, err := h.productUseCase.CreateProduct(product.Name, product.Price, product.Stock, product.CategoryID, product.Tags)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), productErrorStatusCode(err))
 		return
 	}
 
@@ -133,15 +305,20 @@ func (h *ProductHTTPHandler) UpdateProduct(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var product domain.Product
+	// A missing category_id or tags leaves them unchanged, while "" and []
+	// clear them.
+	var product struct {
+		domain.Product
+		CategoryID *string `json:"category_id"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	updatedProduct, err := h.productUseCase.UpdateProduct(id, product.Name, product.Price, product.Stock)
+	updatedProduct, err := h.productUseCase.UpdateProduct(id, product.Name, &product.Price, product.Stock, product.CategoryID, product.Tags)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), productErrorStatusCode(err))
 		return
 	}
 
@@ -161,6 +338,130 @@ func (h *ProductHTTPHandler) DeleteProduct(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *ProductHTTPHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	categories, err := h.categoryUseCase.ListCategories()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if categories == nil {
+		categories = []*domain.Category{}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"categories": categories,
+	})
+}
+
+func (h *ProductHTTPHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	category, err := h.categoryUseCase.GetCategory(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *ProductHTTPHandler) GetProductImages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	images, err := h.imageUseCase.ListImages(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), imageErrorStatusCode(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"images": images,
+	})
+}
+
+// UploadProductImage adds the file in the "image" field of a multipart form
+// to the end of the product's gallery.
+func (h *ProductHTTPHandler) UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Header.Get("X-User-Role") != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	// Leave room for the rest of the form around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, usecase.MaxProductImageSize+1<<20)
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		http.Error(w, "An image file up to 10 MB is required in the image field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	image, err := h.imageUseCase.AddImage(mux.Vars(r)["id"], data)
+	if err != nil {
+		http.Error(w, err.Error(), imageErrorStatusCode(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(image)
+}
+
+// ReorderProductImages takes {"image_ids": [...]} listing every image of the
+// product in the new order.
+func (h *ProductHTTPHandler) ReorderProductImages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Header.Get("X-User-Role") != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		ImageIDs []string `json:"image_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	images, err := h.imageUseCase.ReorderImages(mux.Vars(r)["id"], request.ImageIDs)
+	if err != nil {
+		http.Error(w, err.Error(), imageErrorStatusCode(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"images": images,
+	})
+}
+
+func (h *ProductHTTPHandler) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-User-Role") != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := h.imageUseCase.DeleteImage(vars["id"], vars["imageId"]); err != nil {
+		http.Error(w, err.Error(), imageErrorStatusCode(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func main() {
 	log.Println("Starting product service...")
 
@@ -177,6 +478,8 @@ func main() {
 	log.Println("Connected to database")
 
 	productRepo := db.NewPostgresProductRepository(dbConn)
+	categoryRepo := db.NewPostgresCategoryRepository(dbConn)
+	unitOfWork := db.NewPostgresUnitOfWork(dbConn)
 
 	// Add this near your other initializations:
 	productCache := cache.New()
@@ -189,21 +492,41 @@ func main() {
 
 	var messageUseCase *usecase.MessageUseCase
 
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+
+	var producer *messaging.NatsProducer
+	var consumer *messaging.NatsConsumer
+
 	nc, err := messaging.NewNatsConnection(natsURL)
 	if err != nil {
 		log.Printf("Warning: Failed to connect to NATS: %v", err)
 		log.Println("Product service will run without messaging capabilities")
+	} else if producer, err = messaging.NewProducerFromEnv(nc); err != nil {
+		log.Printf("Warning: Failed to create NATS producer: %v", err)
+		log.Println("Product service will run without messaging capabilities")
+		nc.Close()
+	} else if consumer, err = messaging.NewConsumerFromEnv(nc, "product-service"); err != nil {
+		log.Printf("Warning: Failed to create NATS consumer: %v", err)
+		log.Println("Product service will run without messaging capabilities")
+		nc.Close()
 	} else {
-		consumer := messaging.NewNatsConsumer(nc)
-		messageUseCase = usecase.NewMessageUseCase(nil, productRepo, productCache)
+		processedRepo := db.NewPostgresProcessedMessageRepository(dbConn, "product-service")
+		messageUseCase = usecase.NewMessageUseCase(nil, productRepo, productCache, processedRepo)
+		go messageUseCase.RunProcessedMessageCleanup(relayCtx)
+
+		// Product events are queued in the outbox by ProductUseCase and
+		// published from here.
+		outboxRelay := usecase.NewOutboxRelay(unitOfWork, db.NewPostgresOutboxRepository(dbConn), producer)
+		go outboxRelay.Run(relayCtx)
 
 		log.Println("Subscribing to product.created")
-		err = consumer.SubscribeToProductCreated(func(event domain.ProductCreatedEvent) error {
+		err = eventbus.Subscribe(consumer, domain.EventProductCreated, func(meta domain.MessageMetadata, event domain.ProductCreatedEvent) error {
 			log.Printf("Received product.created event for product %s", event.ProductID)
 			log.Printf("Processing product created event for product %s", event.ProductID)
-			log.Printf("Admin user created product %s (%s) with price $%.2f and stock %d",
+			log.Printf("Admin user created product %s (%s) with price %s and stock %d",
 				event.ProductID, event.Name, event.Price, event.Stock)
-			return messageUseCase.HandleProductCreatedEvent(event)
+			return messageUseCase.HandleProductCreatedEvent(meta, event)
 		})
 		if err != nil {
 			log.Printf("Warning: Failed to subscribe to product.created events: %v", err)
@@ -212,13 +535,13 @@ func main() {
 		}
 
 		log.Println("Subscribing to product.updated")
-		err = consumer.SubscribeToProductUpdated(func(event domain.ProductUpdatedEvent) error {
+		err = eventbus.Subscribe(consumer, domain.EventProductUpdated, func(meta domain.MessageMetadata, event domain.ProductUpdatedEvent) error {
 			log.Printf("Received product.updated event for product %s", event.ProductID)
 			log.Printf("Processing product updated event for product %s", event.ProductID)
-			log.Printf("Admin user updated product %s (%s) to price $%.2f and stock %d",
+			log.Printf("Admin user updated product %s (%s) to price %s and stock %d",
 				event.ProductID, event.Name, event.Price, event.Stock)
 
-			result := messageUseCase.HandleProductUpdatedEvent(event)
+			result := messageUseCase.HandleProductUpdatedEvent(meta, event)
 			if result == nil {
 				log.Printf("Successfully processed product.updated event for %s", event.ProductID)
 			}
@@ -231,13 +554,13 @@ func main() {
 		}
 
 		log.Println("Subscribing to product.deleted")
-		err = consumer.SubscribeToProductDeleted(func(event domain.ProductDeletedEvent) error {
+		err = eventbus.Subscribe(consumer, domain.EventProductDeleted, func(meta domain.MessageMetadata, event domain.ProductDeletedEvent) error {
 			log.Printf("Received product.deleted event for product %s", event.ProductID)
 			log.Printf("Processing product deleted event for product %s", event.ProductID)
 			log.Printf("Admin user deleted product %s at %s",
 				event.ProductID, event.DeletedAt.Format(time.RFC3339))
 
-			result := messageUseCase.HandleProductDeletedEvent(event)
+			result := messageUseCase.HandleProductDeletedEvent(meta, event)
 			if result == nil {
 				log.Printf("Successfully processed product.deleted event for %s", event.ProductID)
 			}
@@ -249,14 +572,37 @@ func main() {
 			log.Println("Successfully subscribed to product.deleted")
 		}
 
+		// Orders reserve and release stock in the order service, so cached
+		// products go stale when their reservations change.
+		for _, subject := range []string{domain.EventStockReserved, domain.EventStockReservationReleased} {
+			err = eventbus.Subscribe(consumer, subject, messageUseCase.HandleStockReservationEvent)
+			if err != nil {
+				log.Printf("Warning: Failed to subscribe to %s events: %v", subject, err)
+			} else {
+				log.Printf("Successfully subscribed to %s", subject)
+			}
+		}
+
 		log.Println("Product service started, listening for product events")
 		defer nc.Close()
 		defer consumer.Close()
 	}
 
-	productUseCase := usecase.NewProductUseCase(productRepo, messageUseCase)
+	rates, err := exchangerate.NewProviderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err)
+	}
+
+	blobStore, err := blob.NewStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to set up blob storage: %v", err)
+	}
 
-	grpcProductHandler := grpcHandler.NewProductHandler(productUseCase)
+	productUseCase := usecase.NewProductUseCase(productRepo, unitOfWork, messageUseCase, rates)
+	categoryUseCase := usecase.NewCategoryUseCase(categoryRepo)
+	imageUseCase := usecase.NewProductImageUseCase(productRepo, db.NewPostgresProductImageRepository(dbConn), blobStore)
+
+	grpcProductHandler := grpcHandler.NewProductHandler(productUseCase, categoryUseCase)
 
 	grpcPort := os.Getenv("INVENTORY_SERVICE_PORT")
 	if grpcPort == "" {
@@ -268,7 +614,7 @@ func main() {
 		log.Fatalf("Failed to listen on port %s: %v", grpcPort, err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(grpcHandler.ActorInterceptor))
 	pb.RegisterInventoryServiceServer(grpcServer, grpcProductHandler)
 
 	reflection.Register(grpcServer)
@@ -285,7 +631,7 @@ func main() {
 		httpPort = "8082"
 	}
 
-	productHTTPHandler := NewProductHTTPHandler(productUseCase)
+	productHTTPHandler := NewProductHTTPHandler(productUseCase, categoryUseCase, imageUseCase)
 
 	router := mux.NewRouter()
 
@@ -293,7 +639,7 @@ func main() {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-Role")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -305,10 +651,18 @@ func main() {
 	})
 
 	router.HandleFunc("/api/products", productHTTPHandler.GetProducts).Methods("GET")
+	router.HandleFunc("/api/products/search", productHTTPHandler.SearchProducts).Methods("GET")
+	router.HandleFunc("/api/products/suggest", productHTTPHandler.SuggestProducts).Methods("GET")
 	router.HandleFunc("/api/products/{id}", productHTTPHandler.GetProduct).Methods("GET")
 	router.HandleFunc("/api/products", productHTTPHandler.CreateProduct).Methods("POST")
 	router.HandleFunc("/api/products/{id}", productHTTPHandler.UpdateProduct).Methods("PUT")
 	router.HandleFunc("/api/products/{id}", productHTTPHandler.DeleteProduct).Methods("DELETE")
+	router.HandleFunc("/api/products/{id}/images", productHTTPHandler.GetProductImages).Methods("GET")
+	router.HandleFunc("/api/admin/products/{id}/images", productHTTPHandler.UploadProductImage).Methods("POST")
+	router.HandleFunc("/api/admin/products/{id}/images", productHTTPHandler.ReorderProductImages).Methods("PUT")
+	router.HandleFunc("/api/admin/products/{id}/images/{imageId}", productHTTPHandler.DeleteProductImage).Methods("DELETE")
+	router.HandleFunc("/api/categories", productHTTPHandler.GetCategories).Methods("GET")
+	router.HandleFunc("/api/categories/{id}", productHTTPHandler.GetCategory).Methods("GET")
 
 	router.HandleFunc("/api/products", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -316,6 +670,15 @@ func main() {
 	router.HandleFunc("/api/products/{id}", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}).Methods("OPTIONS")
+	router.HandleFunc("/api/admin/products/{id}/images", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("OPTIONS")
+
+	// Files in local storage are served from here; other stores serve
+	// their own.
+	if local, ok := blobStore.(*blob.LocalStore); ok {
+		router.PathPrefix("/media/").Handler(http.StripPrefix("/media", local.Handler()))
+	}
 
 	httpServer := &http.Server{
 		Addr:    ":" + httpPort,
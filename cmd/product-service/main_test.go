@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"AdvProg2/usecase"
+)
+
+// filterRecordingProductRepository remembers the filter of the last search.
+type filterRecordingProductRepository struct {
+	repository.ProductRepository
+	filter repository.ProductFilter
+}
+
+func (r *filterRecordingProductRepository) SearchByFilters(filter repository.ProductFilter, page, limit int32) ([]*domain.Product, int32, error) {
+	r.filter = filter
+	return []*domain.Product{}, 0, nil
+}
+
+func TestGetProducts_PriceFiltersDefaultToCatalogCurrency(t *testing.T) {
+	repo := &filterRecordingProductRepository{}
+	handler := NewProductHTTPHandler(usecase.NewProductUseCase(repo, nil, nil, nil), nil, nil)
+
+	// The storefront sends price bounds and sorts without a currency.
+	request := httptest.NewRequest(http.MethodGet, "/api/products?page=1&min_price=5&max_price=12.5&sort_by=price", nil)
+	recorder := httptest.NewRecorder()
+	handler.GetProducts(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, domain.DefaultCurrency, repo.filter.PriceCurrency)
+	assert.Equal(t, domain.NewMoney(500, domain.DefaultCurrency), repo.filter.MinPrice)
+	assert.Equal(t, domain.NewMoney(1250, domain.DefaultCurrency), repo.filter.MaxPrice)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/products?page=1&min_price=5&price_currency=usd", nil)
+	recorder = httptest.NewRecorder()
+	handler.GetProducts(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, "USD", repo.filter.PriceCurrency)
+	assert.Equal(t, domain.NewMoney(500, "USD"), repo.filter.MinPrice)
+}
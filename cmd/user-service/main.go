@@ -20,10 +20,10 @@ import (
 	grpcHandler "AdvProg2/handler/grpc"
 	httpHandler "AdvProg2/handler/http"
 	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/exchangerate"
 	"AdvProg2/infrastructure/messaging"
 	"AdvProg2/pkg/cache"
 	pb "AdvProg2/proto/user"
-	"AdvProg2/repository"
 	"AdvProg2/usecase"
 )
 
@@ -57,7 +57,7 @@ func main() {
 	if natsURL == "" {
 		natsURL = nats.DefaultURL
 	}
-	var messageProducer repository.MessageProducer
+	var messageProducer *messaging.NatsProducer
 	var messageUseCase *usecase.MessageUseCase
 
 	// Initialize cache
@@ -67,20 +67,33 @@ func main() {
 	if err != nil {
 		log.Printf("Warning: Failed to connect to NATS: %v", err)
 		log.Println("User service will run without messaging capabilities")
+	} else if messageProducer, err = messaging.NewProducerFromEnv(nc); err != nil {
+		log.Printf("Warning: Failed to create NATS producer: %v", err)
+		log.Println("User service will run without messaging capabilities")
+		nc.Close()
 	} else {
-		messageProducer = messaging.NewNatsProducer(nc)
-		messageUseCase = usecase.NewMessageUseCase(messageProducer, productRepo, cacheInstance)
+		messageUseCase = usecase.NewMessageUseCase(messageProducer, productRepo, cacheInstance, nil)
 		log.Println("Connected to NATS messaging system")
 		defer nc.Close()
 		defer messageProducer.Close()
 	}
 
+	rates, err := exchangerate.NewProviderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err)
+	}
+
 	userUseCase := usecase.NewUserUseCase(userRepo)
-	productUseCase := usecase.NewProductUseCase(productRepo, messageUseCase)
+	productUseCase := usecase.NewProductUseCase(productRepo, db.NewPostgresUnitOfWork(dbConn), messageUseCase, rates)
+	categoryUseCase := usecase.NewCategoryUseCase(db.NewPostgresCategoryRepository(dbConn))
+	stockUseCase := usecase.NewStockUseCase(productRepo, db.NewPostgresStockMovementRepository(dbConn))
+	promotionUseCase := usecase.NewPromotionUseCase(db.NewPostgresPromotionRepository(dbConn))
+	addressUseCase := usecase.NewAddressUseCase(db.NewPostgresAddressRepository(dbConn))
+	deliverySlotUseCase := usecase.NewDeliverySlotUseCase(db.NewPostgresDeliverySlotRepository(dbConn))
 	log.Println("Initialized use cases")
 
 	// Setup gRPC handler
-	grpcUserHandler := grpcHandler.NewUserHandler(userUseCase)
+	grpcUserHandler := grpcHandler.NewUserHandler(userUseCase, addressUseCase)
 
 	grpcPort := os.Getenv("USER_SERVICE_PORT")
 	if grpcPort == "" {
@@ -92,7 +105,7 @@ func main() {
 		log.Fatalf("Failed to listen on port %s: %v", grpcPort, err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(grpcHandler.ActorInterceptor))
 	pb.RegisterUserServiceServer(grpcServer, grpcUserHandler)
 
 	reflection.Register(grpcServer)
@@ -111,9 +124,10 @@ func main() {
 	}
 
 	userHTTPHandler := httpHandler.NewUserHTTPHandler(userUseCase)
+	addressHTTPHandler := httpHandler.NewAddressHTTPHandler(addressUseCase)
 
 	// Create admin handler
-	adminHTTPHandler := httpHandler.NewAdminHTTPHandler(productUseCase, messageUseCase)
+	adminHTTPHandler := httpHandler.NewAdminHTTPHandler(productUseCase, categoryUseCase, stockUseCase, promotionUseCase, deliverySlotUseCase)
 	log.Println("Initialized admin HTTP handler")
 
 	router := mux.NewRouter()
@@ -138,6 +152,13 @@ func main() {
 	router.HandleFunc("/api/users/login", userHTTPHandler.Login).Methods("POST")
 	router.HandleFunc("/api/users/profile/{id}", userHTTPHandler.GetProfile).Methods("GET")
 
+	// Addresses of the user the gateway authenticated
+	router.HandleFunc("/api/addresses", addressHTTPHandler.ListAddresses).Methods("GET")
+	router.HandleFunc("/api/addresses", addressHTTPHandler.CreateAddress).Methods("POST")
+	router.HandleFunc("/api/addresses/{id}", addressHTTPHandler.GetAddress).Methods("GET")
+	router.HandleFunc("/api/addresses/{id}", addressHTTPHandler.UpdateAddress).Methods("PUT")
+	router.HandleFunc("/api/addresses/{id}", addressHTTPHandler.DeleteAddress).Methods("DELETE")
+
 	// Add this route handler in your user service
 	router.HandleFunc("/api/users/{id}", func(w http.ResponseWriter, r *http.Request) {
 		userId := mux.Vars(r)["id"]
@@ -167,8 +188,26 @@ func main() {
 
 	// Set up admin routes
 	router.HandleFunc("/api/admin/products", adminHTTPHandler.CreateProduct).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/products/import", adminHTTPHandler.ImportProducts).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/products/export", adminHTTPHandler.ExportProducts).Methods("GET", "OPTIONS")
 	router.HandleFunc("/api/admin/products/{id}", adminHTTPHandler.UpdateProduct).Methods("PUT", "OPTIONS")
 	router.HandleFunc("/api/admin/products/{id}", adminHTTPHandler.DeleteProduct).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/admin/products/{id}/stock-movements", adminHTTPHandler.ListStockMovements).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/products/{id}/reorder-threshold", adminHTTPHandler.SetReorderThreshold).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/stock/low", adminHTTPHandler.GetLowStockProducts).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/stock/discrepancies", adminHTTPHandler.GetStockDiscrepancies).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/stock/reconcile", adminHTTPHandler.ReconcileStock).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/categories", adminHTTPHandler.CreateCategory).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/categories/{id}", adminHTTPHandler.UpdateCategory).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/categories/{id}", adminHTTPHandler.DeleteCategory).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/admin/promotions", adminHTTPHandler.ListPromotions).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/promotions", adminHTTPHandler.CreatePromotion).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/promotions/{id}", adminHTTPHandler.GetPromotion).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/promotions/{id}", adminHTTPHandler.UpdatePromotion).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/admin/promotions/{id}", adminHTTPHandler.DeletePromotion).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/admin/delivery-slots", adminHTTPHandler.ListDeliverySlots).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/admin/delivery-slots", adminHTTPHandler.CreateDeliverySlot).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/admin/delivery-slots/{id}", adminHTTPHandler.DeleteDeliverySlot).Methods("DELETE", "OPTIONS")
 
 	httpServer := &http.Server{
 		Addr:    ":" + httpPort,
@@ -0,0 +1,17 @@
+package domain
+
+const (
+	ActorRoleUser   = "user"
+	ActorRoleAdmin  = "admin"
+	ActorRoleSystem = "system"
+)
+
+// Actor is whoever asked for a change: a signed-in user, an admin, or the
+// system itself for automatic transitions.
+type Actor struct {
+	ID   string `json:"id,omitempty"`
+	Role string `json:"role"`
+}
+
+// SystemActor is the actor of changes made by background jobs.
+var SystemActor = Actor{Role: ActorRoleSystem}
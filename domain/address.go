@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAddress is returned for an address that cannot be saved as
+// given.
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Address is a place a user has saved for deliveries. A user's default
+// address is the one offered first; a user has at most one.
+type Address struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Label      string    `json:"label,omitempty"`
+	Recipient  string    `json:"recipient,omitempty"`
+	Phone      string    `json:"phone,omitempty"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2,omitempty"`
+	City       string    `json:"city"`
+	Region     string    `json:"region,omitempty"`
+	PostalCode string    `json:"postal_code,omitempty"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Validate trims the address's fields and checks that it has a street
+// address and a city.
+func (a *Address) Validate() error {
+	for _, field := range []*string{&a.Label, &a.Recipient, &a.Phone, &a.Line1, &a.Line2, &a.City, &a.Region, &a.PostalCode} {
+		*field = strings.TrimSpace(*field)
+	}
+
+	switch {
+	case a.Line1 == "":
+		return fmt.Errorf("%w: line1 is required", ErrInvalidAddress)
+	case a.City == "":
+		return fmt.Errorf("%w: city is required", ErrInvalidAddress)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidCartQuantity = errors.New("cart item quantity must be positive")
+	ErrEmptyCart           = errors.New("cart is empty")
+	// ErrCartNotOrderable is returned when checking out a cart that has
+	// items which cannot be ordered as they are.
+	ErrCartNotOrderable = errors.New("cart has items that cannot be ordered")
+)
+
+// CartItemIssue is a problem found with a cart item when the cart is read.
+type CartItemIssue string
+
+const (
+	// CartItemUnavailable is an item whose product no longer exists.
+	CartItemUnavailable CartItemIssue = "unavailable"
+	// CartItemInsufficientStock is an item asking for more than is in stock.
+	CartItemInsufficientStock CartItemIssue = "insufficient_stock"
+	// CartItemPriceChanged is an item whose product costs something else
+	// than when it was added. It does not stop the cart being ordered.
+	CartItemPriceChanged CartItemIssue = "price_changed"
+)
+
+// BlocksCheckout reports whether an item with the issue cannot be ordered.
+func (i CartItemIssue) BlocksCheckout() bool {
+	return i == CartItemUnavailable || i == CartItemInsufficientStock
+}
+
+// CartItem is a product in a user's cart. AddedPrice is the product's price
+// when the item was last added or changed. Product and Issue are filled in
+// when the cart is read, from the product as it is then.
+type CartItem struct {
+	ProductID  string        `json:"product_id"`
+	Quantity   int32         `json:"quantity"`
+	AddedPrice Money         `json:"added_price"`
+	AddedAt    time.Time     `json:"added_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Product    *Product      `json:"product,omitempty"`
+	Issue      CartItemIssue `json:"issue,omitempty"`
+}
+
+// Check records product, the item's product as it is now, and the issue it
+// raises, if any. A nil product means it no longer exists.
+func (i *CartItem) Check(product *Product) {
+	i.Product = product
+
+	switch {
+	case product == nil:
+		i.Issue = CartItemUnavailable
+	case i.Quantity > product.Stock:
+		i.Issue = CartItemInsufficientStock
+	case product.Price != i.AddedPrice:
+		i.Issue = CartItemPriceChanged
+	default:
+		i.Issue = ""
+	}
+}
+
+// Cart is a user's cart. Subtotal is the cost of the items at their
+// products' current prices, leaving out items that cannot be ordered.
+type Cart struct {
+	UserID   string      `json:"user_id"`
+	Items    []*CartItem `json:"items"`
+	Subtotal Money       `json:"subtotal"`
+}
+
+// CheckOrderable reports whether the checked cart can be turned into an
+// order.
+func (c *Cart) CheckOrderable() error {
+	if len(c.Items) == 0 {
+		return ErrEmptyCart
+	}
+
+	for _, item := range c.Items {
+		if item.Issue.BlocksCheckout() {
+			return ErrCartNotOrderable
+		}
+	}
+
+	return nil
+}
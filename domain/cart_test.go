@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCartItemCheck(t *testing.T) {
+	item := &CartItem{ProductID: "p1", Quantity: 3, AddedPrice: NewMoney(500, "KZT")}
+
+	item.Check(&Product{ID: "p1", Price: NewMoney(500, "KZT"), Stock: 3})
+	assert.Empty(t, item.Issue)
+
+	item.Check(&Product{ID: "p1", Price: NewMoney(450, "KZT"), Stock: 10})
+	assert.Equal(t, CartItemPriceChanged, item.Issue)
+
+	item.Check(&Product{ID: "p1", Price: NewMoney(450, "KZT"), Stock: 2})
+	assert.Equal(t, CartItemInsufficientStock, item.Issue)
+
+	item.Check(nil)
+	assert.Equal(t, CartItemUnavailable, item.Issue)
+	assert.Nil(t, item.Product)
+}
+
+func TestCartCheckOrderable(t *testing.T) {
+	assert.ErrorIs(t, (&Cart{}).CheckOrderable(), ErrEmptyCart)
+
+	cart := &Cart{Items: []*CartItem{{ProductID: "p1", Issue: CartItemPriceChanged}}}
+	assert.NoError(t, cart.CheckOrderable())
+
+	cart.Items = append(cart.Items, &CartItem{ProductID: "p2", Issue: CartItemInsufficientStock})
+	assert.ErrorIs(t, cart.CheckOrderable(), ErrCartNotOrderable)
+}
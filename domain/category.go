@@ -0,0 +1,15 @@
+package domain
+
+import "errors"
+
+// ErrInvalidCategory is returned for a category that cannot be saved as
+// given, such as one without a name or one placed under itself.
+var ErrInvalidCategory = errors.New("invalid category")
+
+// Category groups products. Categories form a tree through ParentID; a
+// top-level category has an empty ParentID.
+type Category struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	ParentID string `json:"parent_id,omitempty"`
+}
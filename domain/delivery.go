@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidDeliverySlot is returned for a delivery slot that cannot be
+	// saved as given.
+	ErrInvalidDeliverySlot = errors.New("invalid delivery slot")
+	// ErrDeliverySlotClosed is returned when booking a slot that has
+	// already started.
+	ErrDeliverySlotClosed = errors.New("delivery slot is no longer bookable")
+	// ErrNotDeliverable is returned for an address outside every delivery
+	// zone.
+	ErrNotDeliverable = errors.New("address is outside the delivery area")
+	// ErrInvalidDeliveryZone is returned for a delivery zone that cannot be
+	// used as given.
+	ErrInvalidDeliveryZone = errors.New("invalid delivery zone")
+)
+
+// DeliverySlot is a window in which orders are delivered. At most Capacity
+// orders may book it; Booked is how many have.
+type DeliverySlot struct {
+	ID        string    `json:"id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Capacity  int32     `json:"capacity"`
+	Booked    int32     `json:"booked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *DeliverySlot) Validate() error {
+	switch {
+	case s.StartsAt.IsZero() || !s.EndsAt.After(s.StartsAt):
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidDeliverySlot)
+	case s.Capacity <= 0:
+		return fmt.Errorf("%w: capacity must be positive", ErrInvalidDeliverySlot)
+	}
+	return nil
+}
+
+// Remaining is how many more orders may book the slot.
+func (s *DeliverySlot) Remaining() int32 {
+	if s.Booked >= s.Capacity {
+		return 0
+	}
+	return s.Capacity - s.Booked
+}
+
+// IsBookableAt reports whether an order placed at now may book the slot.
+func (s *DeliverySlot) IsBookableAt(now time.Time) bool {
+	return now.Before(s.StartsAt) && s.Remaining() > 0
+}
+
+// DeliveryZone is an area orders are delivered to, matched on the region
+// and city of the address; an empty Region or City matches any. Fee is
+// charged per order, in the catalog currency, unless the order is worth at
+// least FreeFrom. A zero FreeFrom never waives the fee.
+type DeliveryZone struct {
+	Name     string `json:"name"`
+	Region   string `json:"region,omitempty"`
+	City     string `json:"city,omitempty"`
+	Fee      Money  `json:"fee"`
+	FreeFrom Money  `json:"free_from"`
+}
+
+func (z DeliveryZone) Validate() error {
+	switch {
+	case z.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidDeliveryZone)
+	case z.Fee.IsNegative() || z.FreeFrom.IsNegative():
+		return fmt.Errorf("%w: zone %q has a negative amount", ErrInvalidDeliveryZone, z.Name)
+	}
+	return nil
+}
+
+// DeliveryQuote is what delivering an order costs.
+type DeliveryQuote struct {
+	Zone string
+	Fee  Money
+}
+
+// Quote works out the fee for an order worth orderValue, which is priced in
+// the currency rate converts catalog prices into.
+func (z DeliveryZone) Quote(orderValue Money, rate ExchangeRate) (DeliveryQuote, error) {
+	quote := DeliveryQuote{Zone: z.Name, Fee: NewMoney(0, orderValue.Currency)}
+	if z.Fee.IsZero() {
+		return quote, nil
+	}
+
+	if !z.FreeFrom.IsZero() {
+		freeFrom, err := z.FreeFrom.Convert(rate)
+		if err != nil {
+			return DeliveryQuote{}, err
+		}
+		if orderValue.Amount >= freeFrom.Amount {
+			return quote, nil
+		}
+	}
+
+	fee, err := z.Fee.Convert(rate)
+	if err != nil {
+		return DeliveryQuote{}, err
+	}
+	quote.Fee = fee
+	return quote, nil
+}
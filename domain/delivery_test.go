@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryZone_Quote(t *testing.T) {
+	zone := DeliveryZone{Name: "center", Fee: NewMoney(50000, "KZT"), FreeFrom: NewMoney(1000000, "KZT")}
+	kzt := IdentityRate("KZT")
+
+	quote, err := zone.Quote(NewMoney(999999, "KZT"), kzt)
+	assert.NoError(t, err)
+	assert.Equal(t, DeliveryQuote{Zone: "center", Fee: NewMoney(50000, "KZT")}, quote)
+
+	quote, err = zone.Quote(NewMoney(1000000, "KZT"), kzt)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(0, "KZT"), quote.Fee)
+
+	// 10000.00 KZT is 21.00 USD and the 500.00 KZT fee is 1.05 USD.
+	usd, err := NewExchangeRate("KZT", "USD", "0.0021")
+	assert.NoError(t, err)
+	quote, err = zone.Quote(NewMoney(2099, "USD"), usd)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(105, "USD"), quote.Fee)
+	quote, err = zone.Quote(NewMoney(2100, "USD"), usd)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(0, "USD"), quote.Fee)
+
+	// Without a threshold the fee is always charged.
+	zone.FreeFrom = Money{}
+	quote, err = zone.Quote(NewMoney(100000000, "KZT"), kzt)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(50000, "KZT"), quote.Fee)
+
+	free := DeliveryZone{Name: "pickup"}
+	quote, err = free.Quote(NewMoney(100, "USD"), usd)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(0, "USD"), quote.Fee)
+}
+
+func TestDeliverySlot_Bookable(t *testing.T) {
+	now := time.Now()
+	slot := &DeliverySlot{StartsAt: now.Add(time.Hour), EndsAt: now.Add(3 * time.Hour), Capacity: 2, Booked: 1}
+	assert.NoError(t, slot.Validate())
+	assert.Equal(t, int32(1), slot.Remaining())
+	assert.True(t, slot.IsBookableAt(now))
+	assert.False(t, slot.IsBookableAt(now.Add(2*time.Hour)))
+
+	slot.Booked = 2
+	assert.Zero(t, slot.Remaining())
+	assert.False(t, slot.IsBookableAt(now))
+
+	assert.ErrorIs(t, (&DeliverySlot{StartsAt: now, EndsAt: now, Capacity: 1}).Validate(), ErrInvalidDeliverySlot)
+	assert.ErrorIs(t, (&DeliverySlot{StartsAt: now, EndsAt: now.Add(time.Hour)}).Validate(), ErrInvalidDeliverySlot)
+}
+
+func TestAddress_Validate(t *testing.T) {
+	address := &Address{Label: " Home ", Line1: " Abay 10 ", City: "Almaty"}
+	assert.NoError(t, address.Validate())
+	assert.Equal(t, "Home", address.Label)
+	assert.Equal(t, "Abay 10", address.Line1)
+
+	assert.ErrorIs(t, (&Address{Line1: "  ", City: "Almaty"}).Validate(), ErrInvalidAddress)
+	assert.ErrorIs(t, (&Address{Line1: "Abay 10"}).Validate(), ErrInvalidAddress)
+}
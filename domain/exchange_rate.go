@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrUnsupportedCurrency is returned when no exchange rate is known for a
+// currency.
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// ExchangeRate converts amounts in From into To. Rate is the number of To
+// units per From unit, kept as a decimal string such as "0.0021" so that it
+// is stored and applied exactly.
+type ExchangeRate struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Rate string `json:"rate"`
+}
+
+// NewExchangeRate validates rate, which must be a positive decimal.
+func NewExchangeRate(from, to, rate string) (ExchangeRate, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return ExchangeRate{}, fmt.Errorf("invalid exchange rate %q from %s to %s", rate, from, to)
+	}
+	return ExchangeRate{From: normalizeCurrency(from), To: normalizeCurrency(to), Rate: rate}, nil
+}
+
+// IdentityRate is the rate from a currency to itself.
+func IdentityRate(currency string) ExchangeRate {
+	currency = normalizeCurrency(currency)
+	return ExchangeRate{From: currency, To: currency, Rate: "1"}
+}
+
+// IsIdentity reports whether the rate leaves amounts unchanged.
+func (r ExchangeRate) IsIdentity() bool {
+	rate, ok := new(big.Rat).SetString(r.Rate)
+	return r.From == r.To && ok && rate.Cmp(big.NewRat(1, 1)) == 0
+}
+
+// Convert returns m in r.To, rounded half away from zero to the minor unit.
+func (m Money) Convert(r ExchangeRate) (Money, error) {
+	if m.Currency != r.From {
+		return Money{}, fmt.Errorf("%w: cannot convert %s with a rate from %s", ErrCurrencyMismatch, m.Currency, r.From)
+	}
+
+	rate, ok := new(big.Rat).SetString(r.Rate)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid exchange rate %q from %s to %s", r.Rate, r.From, r.To)
+	}
+
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	amount, err := roundHalfAwayFromZero(converted)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: amount, Currency: r.To}, nil
+}
+
+func roundHalfAwayFromZero(r *big.Rat) (int64, error) {
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+
+	// (2|num| + den) / 2den rounds |r| to the nearest integer, halves up.
+	rounded := new(big.Int).Lsh(num, 1)
+	rounded.Add(rounded, den)
+	rounded.Quo(rounded, new(big.Int).Lsh(den, 1))
+	if r.Sign() < 0 {
+		rounded.Neg(rounded)
+	}
+
+	if !rounded.IsInt64() {
+		return 0, fmt.Errorf("%w: %s is out of range", ErrInvalidMoney, r.FloatString(2))
+	}
+	return rounded.Int64(), nil
+}
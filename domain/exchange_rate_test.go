@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoney_Convert(t *testing.T) {
+	usd, err := NewExchangeRate("kzt", "usd", "0.0021")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		in   int64
+		want int64
+	}{
+		{250000, 525},
+		{99900, 210},
+		{238095, 500},
+		{2380, 5},
+		{2381, 5},
+		{-99900, -210},
+		{0, 0},
+	}
+
+	for _, tt := range tests {
+		got, err := NewMoney(tt.in, "KZT").Convert(usd)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, NewMoney(tt.want, "USD"), got, tt.in)
+	}
+
+	_, err = NewMoney(100, "EUR").Convert(usd)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestExchangeRate_Identity(t *testing.T) {
+	rate := IdentityRate("kzt")
+	assert.True(t, rate.IsIdentity())
+
+	got, err := NewMoney(1234, "KZT").Convert(rate)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(1234, "KZT"), got)
+
+	usd, err := NewExchangeRate("KZT", "USD", "1.0")
+	assert.NoError(t, err)
+	assert.False(t, usd.IsIdentity())
+
+	for _, rate := range []string{"", "0", "-1.5", "abc"} {
+		_, err := NewExchangeRate("KZT", "USD", rate)
+		assert.Error(t, err, rate)
+	}
+}
@@ -2,6 +2,26 @@ package domain
 
 import "time"
 
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+	EventOrderCancelled     = "order.cancelled"
+	EventOrderCompleted     = "order.completed"
+	EventProductCreated     = "product.created"
+	EventProductUpdated     = "product.updated"
+	EventProductDeleted     = "product.deleted"
+	EventInventoryLowStock  = "inventory.low_stock"
+
+	EventStockReserved             = "inventory.stock_reserved"
+	EventStockReservationCommitted = "inventory.reservation_committed"
+	EventStockReservationReleased  = "inventory.reservation_released"
+
+	EventPaymentIntentCreated = "payment.intent_created"
+	EventPaymentSucceeded     = "payment.succeeded"
+	EventPaymentFailed        = "payment.failed"
+	EventPaymentRefunded      = "payment.refunded"
+)
+
 type Message struct {
 	ID        string    `json:"id"`
 	Type      string    `json:"type"`
@@ -9,37 +29,123 @@ type Message struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// MessageMetadata identifies the envelope an event was delivered in. The ID
+// stays the same when a message is redelivered.
+type MessageMetadata struct {
+	ID        string
+	CreatedAt time.Time
+}
+
 type OrderCreatedEvent struct {
-	OrderID    string           `json:"order_id"`
-	UserID     string           `json:"user_id"`
-	TotalPrice float64          `json:"total_price"`
-	Items      []OrderItemEvent `json:"items"`
-	CreatedAt  time.Time        `json:"created_at"`
+	OrderID       string           `json:"order_id"`
+	UserID        string           `json:"user_id"`
+	TotalPrice    Money            `json:"total_price"`
+	Subtotal      Money            `json:"subtotal"`
+	DiscountTotal Money            `json:"discount_total"`
+	TaxTotal      Money            `json:"tax_total"`
+	DeliveryFee   Money            `json:"delivery_fee"`
+	Items         []OrderItemEvent `json:"items"`
+	CreatedAt     time.Time        `json:"created_at"`
 }
 
 type OrderItemEvent struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int32   `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID string `json:"product_id"`
+	Quantity  int32  `json:"quantity"`
+	Price     Money  `json:"price"`
 }
 
+// OrderStatusChangedEvent is published on every order status change.
+// RestockedItems lists the stock returned to products by the change, if any.
+type OrderStatusChangedEvent struct {
+	OrderID        string           `json:"order_id"`
+	UserID         string           `json:"user_id"`
+	OldStatus      OrderStatus      `json:"old_status"`
+	NewStatus      OrderStatus      `json:"new_status"`
+	TotalPrice     Money            `json:"total_price"`
+	RestockedItems []OrderItemEvent `json:"restocked_items,omitempty"`
+	Actor          Actor            `json:"actor"`
+	Reason         string           `json:"reason,omitempty"`
+	ChangedAt      time.Time        `json:"changed_at"`
+}
+
+// OrderCancelledEvent is published alongside OrderStatusChangedEvent when an
+// order is cancelled.
+type OrderCancelledEvent OrderStatusChangedEvent
+
+// OrderCompletedEvent is published alongside OrderStatusChangedEvent when an
+// order is delivered.
+type OrderCompletedEvent OrderStatusChangedEvent
+
 type ProductCreatedEvent struct {
-	ProductID string    `json:"product_id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	Stock     int32     `json:"stock"`
-	CreatedAt time.Time `json:"created_at"`
+	ProductID  string    `json:"product_id"`
+	Name       string    `json:"name"`
+	Price      Money     `json:"price"`
+	Stock      int32     `json:"stock"`
+	CategoryID string    `json:"category_id,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type ProductUpdatedEvent struct {
-	ProductID string    `json:"product_id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	Stock     int32     `json:"stock"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ProductID  string    `json:"product_id"`
+	Name       string    `json:"name"`
+	Price      Money     `json:"price"`
+	Stock      int32     `json:"stock"`
+	CategoryID string    `json:"category_id,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type ProductDeletedEvent struct {
 	ProductID string    `json:"product_id"`
 	DeletedAt time.Time `json:"deleted_at"`
 }
+
+// LowStockEvent is published when a stock movement takes a product below its
+// reorder threshold. Reason and ReferenceID are those of the movement, or
+// Reason is LowStockThresholdRaised if the threshold was raised above the
+// product's stock.
+// LowStockThresholdRaised is the Reason of a LowStockEvent raised by setting
+// the reorder threshold rather than by a stock movement.
+const LowStockThresholdRaised StockMovementReason = "threshold_raised"
+
+type LowStockEvent struct {
+	ProductID        string              `json:"product_id"`
+	Name             string              `json:"name"`
+	Stock            int32               `json:"stock"`
+	ReorderThreshold int32               `json:"reorder_threshold"`
+	Reason           StockMovementReason `json:"reason"`
+	ReferenceID      string              `json:"reference_id,omitempty"`
+	DetectedAt       time.Time           `json:"detected_at"`
+}
+
+// StockReservationEvent is published when an order's stock is reserved, and
+// again when the reservation is committed or released. Reason says why a
+// reservation was released: ReservationReleaseCancelled or
+// ReservationReleaseExpired.
+type StockReservationEvent struct {
+	OrderID   string                 `json:"order_id"`
+	Items     []ReservedItemEvent    `json:"items"`
+	Status    StockReservationStatus `json:"status"`
+	ExpiresAt time.Time              `json:"expires_at"`
+	Reason    string                 `json:"reason,omitempty"`
+	ChangedAt time.Time              `json:"changed_at"`
+}
+
+type ReservedItemEvent struct {
+	ProductID string `json:"product_id"`
+	Quantity  int32  `json:"quantity"`
+}
+
+// PaymentEvent is published when a payment intent is created and again when
+// it succeeds, fails or is refunded.
+type PaymentEvent struct {
+	PaymentID     string        `json:"payment_id"`
+	OrderID       string        `json:"order_id"`
+	UserID        string        `json:"user_id"`
+	Amount        Money         `json:"amount"`
+	Status        PaymentStatus `json:"status"`
+	Provider      string        `json:"provider"`
+	FailureReason string        `json:"failure_reason,omitempty"`
+	ChangedAt     time.Time     `json:"changed_at"`
+}
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for amounts given without a currency.
+const DefaultCurrency = "KZT"
+
+// minorUnitsPerMajor is the number of minor units (cents, tiyn) in one unit
+// of every supported currency.
+const minorUnitsPerMajor = 100
+
+var (
+	ErrInvalidMoney     = errors.New("invalid money amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an exact amount in the minor units of a currency, so 12.50 KZT is
+// Money{Amount: 1250, Currency: "KZT"}.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney returns amount minor units of currency, or of DefaultCurrency when
+// currency is empty.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: normalizeCurrency(currency)}
+}
+
+func normalizeCurrency(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+// ParseMoney parses a decimal amount in major units such as "12.5" or
+// "-0.015". Digits past the minor unit are rounded half away from zero.
+func ParseMoney(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	var amount int64
+	if whole != "" {
+		units, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || units > math.MaxInt64/minorUnitsPerMajor-1 {
+			return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, s)
+		}
+		amount = units * minorUnitsPerMajor
+	}
+
+	frac += "000"
+	cents, _ := strconv.ParseInt(frac[:2], 10, 64)
+	amount += cents
+	if frac[2] >= '5' {
+		amount++
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return NewMoney(amount, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MoneyFromFloat converts an amount in major units, rounding half away from
+// zero. The float is read as its shortest decimal form, so 1.005 becomes
+// 1.01 even though it is stored as 1.00499999.
+func MoneyFromFloat(amount float64, currency string) (Money, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return Money{}, fmt.Errorf("%w: %v", ErrInvalidMoney, amount)
+	}
+	return ParseMoney(strconv.FormatFloat(amount, 'f', -1, 64), currency)
+}
+
+// Float64 returns the amount in major units. Use it for display only.
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / minorUnitsPerMajor
+}
+
+// String formats the amount in major units followed by the currency, as in
+// "12.50 KZT".
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/minorUnitsPerMajor, amount%minorUnitsPerMajor, m.Currency)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + o. The zero Money, which has no currency, can be added to
+// any amount; otherwise both must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	switch {
+	case m == Money{}:
+		return o, nil
+	case o == Money{}:
+		return m, nil
+	case m.Currency != o.Currency:
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o, following the same currency rules as Add.
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+// Multiply returns m times quantity.
+func (m Money) Multiply(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// UnmarshalJSON accepts {"amount": 1250, "currency": "KZT"} as well as a
+// plain decimal number or string in major units, such as 12.5 or "12.50",
+// which is read in DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '{':
+		type plain Money
+		var decoded plain
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return err
+		}
+		*m = NewMoney(decoded.Amount, decoded.Currency)
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+
+	parsed, err := ParseMoney(string(data), DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
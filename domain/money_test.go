@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney_RoundsHalfAwayFromZero(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"12", 1200},
+		{"12.5", 1250},
+		{"12.50", 1250},
+		{".99", 99},
+		{"0.004", 0},
+		{"0.005", 1},
+		{"0.0049999", 0},
+		{"1.995", 200},
+		{"2.345", 235},
+		{"-0.005", -1},
+		{"-2.344", -234},
+		{"+3.10", 310},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, "KZT")
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, NewMoney(tt.want, "KZT"), got, tt.in)
+	}
+
+	for _, in := range []string{"", ".", "-", "1.2.3", "1,50", "abc", "99999999999999999999"} {
+		_, err := ParseMoney(in, "KZT")
+		assert.ErrorIs(t, err, ErrInvalidMoney, in)
+	}
+}
+
+func TestMoneyFromFloat_UsesShortestDecimal(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want int64
+	}{
+		{1.005, 101},
+		{0.1 + 0.2, 30},
+		{25.99, 2599},
+		{-1.005, -101},
+	}
+
+	for _, tt := range tests {
+		got, err := MoneyFromFloat(tt.in, "")
+		assert.NoError(t, err)
+		assert.Equal(t, NewMoney(tt.want, DefaultCurrency), got, "%v", tt.in)
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price := NewMoney(2599, "kzt")
+	assert.Equal(t, "KZT", price.Currency)
+
+	total, err := Money{}.Add(price.Multiply(3))
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(7797, "KZT"), total)
+	assert.Equal(t, "77.97 KZT", total.String())
+	assert.Equal(t, "-0.05 USD", NewMoney(-5, "USD").String())
+
+	_, err = total.Add(NewMoney(100, "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	rest, err := total.Sub(NewMoney(797, "KZT"))
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(7000, "KZT"), rest)
+	_, err = total.Sub(NewMoney(100, "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1250, "KZT"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":1250,"currency":"KZT"}`, string(data))
+
+	var product Product
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"Apple","price":{"amount":1250,"currency":"usd"}}`), &product))
+	assert.Equal(t, NewMoney(1250, "USD"), product.Price)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"Apple","price":1.005}`), &product))
+	assert.Equal(t, NewMoney(101, DefaultCurrency), product.Price)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"Apple","price":"12.50"}`), &product))
+	assert.Equal(t, NewMoney(1250, DefaultCurrency), product.Price)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price":"twelve"}`), &product))
+}
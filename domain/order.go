@@ -4,13 +4,32 @@ import (
     "time"
 )
 
+// Order prices are in the currency the customer chose. ExchangeRate is the
+// rate applied to the catalog prices when the order was placed. Subtotal is
+// the price of the items and DiscountTotal the sum of Discounts. TaxTotal is
+// the tax charged for TaxRegion, including the part already in the prices.
+// Delivered orders keep a copy of their DeliveryAddress and are charged
+// DeliveryFee for its DeliveryZone; DeliverySlot is the window they were
+// booked into, if any. TotalPrice, the grand total, is what the customer
+// pays: the subtotal less discounts, plus any tax not included in the
+// prices and the delivery fee.
 type Order struct {
-    ID         string      `json:"id"`
-    UserID     string      `json:"user_id"`
-    Status     string      `json:"status"`
-    TotalPrice float64     `json:"total_price"`
-    CreatedAt  time.Time   `json:"created_at"`
-    Items      []*OrderItem `json:"items,omitempty"`
+    ID              string           `json:"id"`
+    UserID          string           `json:"user_id"`
+    Status          OrderStatus      `json:"status"`
+    TotalPrice      Money            `json:"total_price"`
+    Subtotal        Money            `json:"subtotal"`
+    DiscountTotal   Money            `json:"discount_total"`
+    TaxTotal        Money            `json:"tax_total"`
+    TaxRegion       string           `json:"tax_region,omitempty"`
+    DeliveryFee     Money            `json:"delivery_fee"`
+    DeliveryAddress *Address         `json:"delivery_address,omitempty"`
+    DeliveryZone    string           `json:"delivery_zone,omitempty"`
+    DeliverySlot    *DeliverySlot    `json:"delivery_slot,omitempty"`
+    ExchangeRate    ExchangeRate     `json:"exchange_rate"`
+    CreatedAt       time.Time        `json:"created_at"`
+    Items           []*OrderItem     `json:"items,omitempty"`
+    Discounts       []*OrderDiscount `json:"discounts,omitempty"`
 }
 
 type OrderItem struct {
@@ -18,6 +37,20 @@ type OrderItem struct {
     OrderID   string   `json:"order_id"`
     ProductID string   `json:"product_id"`
     Quantity  int32    `json:"quantity"`
-    Price     float64  `json:"price"`
+    Price     Money    `json:"price"`
     Product   *Product `json:"product,omitempty"`
-}
\ No newline at end of file
+}
+
+// CheckTransition reports whether actor may move the order to status. Users
+// may only change their own orders.
+func (o *Order) CheckTransition(to OrderStatus, actor Actor) error {
+    if err := CanTransition(o.Status, to, actor); err != nil {
+        return err
+    }
+
+    if actor.Role == ActorRoleUser && actor.ID != o.UserID {
+        return &StatusTransitionError{From: o.Status, To: to, Actor: actor, Err: ErrStatusTransitionForbidden}
+    }
+
+    return nil
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type OrderStatus string
+
+const (
+	OrderStatusPending        OrderStatus = "pending"
+	OrderStatusConfirmed      OrderStatus = "confirmed"
+	OrderStatusPreparing      OrderStatus = "preparing"
+	OrderStatusReady          OrderStatus = "ready"
+	OrderStatusOutForDelivery OrderStatus = "out_for_delivery"
+	OrderStatusDelivered      OrderStatus = "delivered"
+	OrderStatusCancelled      OrderStatus = "cancelled"
+	OrderStatusRefunded       OrderStatus = "refunded"
+)
+
+var (
+	ErrInvalidOrderStatus = errors.New("invalid order status")
+	// ErrIllegalStatusTransition means no one may move an order between the
+	// two statuses.
+	ErrIllegalStatusTransition = errors.New("illegal order status transition")
+	// ErrStatusTransitionForbidden means the transition exists but the actor
+	// may not make it.
+	ErrStatusTransitionForbidden = errors.New("order status transition not allowed")
+)
+
+// OrderStatusChange is one entry in an order's status history. FromStatus is
+// empty for the entry recorded when the order is created.
+type OrderStatusChange struct {
+	ID         string      `json:"id"`
+	OrderID    string      `json:"order_id"`
+	FromStatus OrderStatus `json:"from_status,omitempty"`
+	ToStatus   OrderStatus `json:"to_status"`
+	Actor      Actor       `json:"actor"`
+	Reason     string      `json:"reason,omitempty"`
+	ChangedAt  time.Time   `json:"changed_at"`
+}
+
+// StatusTransitionError describes a rejected order status change. It wraps
+// ErrIllegalStatusTransition or ErrStatusTransitionForbidden.
+type StatusTransitionError struct {
+	From  OrderStatus
+	To    OrderStatus
+	Actor Actor
+	Err   error
+}
+
+func (e *StatusTransitionError) Error() string {
+	if errors.Is(e.Err, ErrStatusTransitionForbidden) {
+		return fmt.Sprintf("%s: %s may not change an order from %s to %s", e.Err, e.Actor.Role, e.From, e.To)
+	}
+	return fmt.Sprintf("%s: cannot change an order from %s to %s", e.Err, e.From, e.To)
+}
+
+func (e *StatusTransitionError) Unwrap() error {
+	return e.Err
+}
+
+// orderTransitions lists, for every status, the statuses an order can move to
+// and the actor roles allowed to make each move. Users may only act on their
+// own orders; see Order.CheckTransition.
+var orderTransitions = map[OrderStatus]map[OrderStatus][]string{
+	OrderStatusPending: {
+		OrderStatusConfirmed: {ActorRoleAdmin, ActorRoleSystem},
+		OrderStatusCancelled: {ActorRoleUser, ActorRoleAdmin, ActorRoleSystem},
+	},
+	OrderStatusConfirmed: {
+		OrderStatusPreparing: {ActorRoleAdmin},
+		OrderStatusCancelled: {ActorRoleUser, ActorRoleAdmin, ActorRoleSystem},
+	},
+	OrderStatusPreparing: {
+		OrderStatusReady:     {ActorRoleAdmin},
+		OrderStatusCancelled: {ActorRoleAdmin},
+	},
+	OrderStatusReady: {
+		OrderStatusOutForDelivery: {ActorRoleAdmin},
+		OrderStatusCancelled:      {ActorRoleAdmin},
+	},
+	OrderStatusOutForDelivery: {
+		OrderStatusDelivered: {ActorRoleAdmin, ActorRoleSystem},
+	},
+	OrderStatusDelivered: {
+		OrderStatusRefunded: {ActorRoleAdmin},
+	},
+	OrderStatusCancelled: {
+		OrderStatusRefunded: {ActorRoleAdmin, ActorRoleSystem},
+	},
+	OrderStatusRefunded: {},
+}
+
+// ParseOrderStatus returns the status named s or ErrInvalidOrderStatus.
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	status := OrderStatus(s)
+	if _, ok := orderTransitions[status]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidOrderStatus, s)
+	}
+	return status, nil
+}
+
+// IsFinal reports whether no further transitions are possible.
+func (s OrderStatus) IsFinal() bool {
+	return len(orderTransitions[s]) == 0
+}
+
+// NextStatuses returns the statuses an order in s can move to.
+func (s OrderStatus) NextStatuses() []OrderStatus {
+	next := make([]OrderStatus, 0, len(orderTransitions[s]))
+	for _, status := range OrderStatuses() {
+		if _, ok := orderTransitions[s][status]; ok {
+			next = append(next, status)
+		}
+	}
+	return next
+}
+
+// OrderStatuses returns every status in lifecycle order.
+func OrderStatuses() []OrderStatus {
+	return []OrderStatus{
+		OrderStatusPending,
+		OrderStatusConfirmed,
+		OrderStatusPreparing,
+		OrderStatusReady,
+		OrderStatusOutForDelivery,
+		OrderStatusDelivered,
+		OrderStatusCancelled,
+		OrderStatusRefunded,
+	}
+}
+
+// CanTransition reports whether an actor with role may move an order from
+// one status to another, returning a *StatusTransitionError if not.
+func CanTransition(from, to OrderStatus, actor Actor) error {
+	roles, ok := orderTransitions[from][to]
+	if !ok {
+		return &StatusTransitionError{From: from, To: to, Actor: actor, Err: ErrIllegalStatusTransition}
+	}
+
+	for _, role := range roles {
+		if role == actor.Role {
+			return nil
+		}
+	}
+	return &StatusTransitionError{From: from, To: to, Actor: actor, Err: ErrStatusTransitionForbidden}
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	user := Actor{ID: "u1", Role: ActorRoleUser}
+	admin := Actor{ID: "a1", Role: ActorRoleAdmin}
+
+	tests := []struct {
+		from  OrderStatus
+		to    OrderStatus
+		actor Actor
+		want  error
+	}{
+		{OrderStatusPending, OrderStatusConfirmed, SystemActor, nil},
+		{OrderStatusPending, OrderStatusConfirmed, user, ErrStatusTransitionForbidden},
+		{OrderStatusPending, OrderStatusCancelled, user, nil},
+		{OrderStatusConfirmed, OrderStatusPreparing, admin, nil},
+		{OrderStatusConfirmed, OrderStatusCancelled, user, nil},
+		{OrderStatusPreparing, OrderStatusCancelled, user, ErrStatusTransitionForbidden},
+		{OrderStatusPreparing, OrderStatusCancelled, admin, nil},
+		{OrderStatusReady, OrderStatusOutForDelivery, admin, nil},
+		{OrderStatusOutForDelivery, OrderStatusDelivered, SystemActor, nil},
+		{OrderStatusDelivered, OrderStatusRefunded, admin, nil},
+		{OrderStatusCancelled, OrderStatusRefunded, SystemActor, nil},
+		{OrderStatusPending, OrderStatusDelivered, admin, ErrIllegalStatusTransition},
+		{OrderStatusDelivered, OrderStatusCancelled, admin, ErrIllegalStatusTransition},
+		{OrderStatusRefunded, OrderStatusPending, admin, ErrIllegalStatusTransition},
+		{OrderStatusPending, OrderStatusPending, admin, ErrIllegalStatusTransition},
+	}
+
+	for _, tt := range tests {
+		err := CanTransition(tt.from, tt.to, tt.actor)
+		if tt.want == nil {
+			assert.NoError(t, err, "%s -> %s by %s", tt.from, tt.to, tt.actor.Role)
+		} else {
+			assert.ErrorIs(t, err, tt.want, "%s -> %s by %s", tt.from, tt.to, tt.actor.Role)
+		}
+	}
+}
+
+func TestOrderCheckTransition_UsersOnlyActOnTheirOwnOrders(t *testing.T) {
+	order := &Order{ID: "o1", UserID: "u1", Status: OrderStatusPending}
+
+	assert.NoError(t, order.CheckTransition(OrderStatusCancelled, Actor{ID: "u1", Role: ActorRoleUser}))
+	assert.ErrorIs(t, order.CheckTransition(OrderStatusCancelled, Actor{ID: "u2", Role: ActorRoleUser}), ErrStatusTransitionForbidden)
+	assert.NoError(t, order.CheckTransition(OrderStatusCancelled, Actor{ID: "a1", Role: ActorRoleAdmin}))
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	for _, status := range OrderStatuses() {
+		parsed, err := ParseOrderStatus(string(status))
+		assert.NoError(t, err)
+		assert.Equal(t, status, parsed)
+	}
+
+	_, err := ParseOrderStatus("completed")
+	assert.ErrorIs(t, err, ErrInvalidOrderStatus)
+	assert.True(t, OrderStatusRefunded.IsFinal())
+	assert.False(t, OrderStatusDelivered.IsFinal())
+}
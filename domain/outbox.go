@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// OutboxMessage is an event envelope stored in the same transaction as the
+// state change that produced it, waiting to be relayed to the message broker.
+type OutboxMessage struct {
+	ID            string     `json:"id"`
+	AggregateType string     `json:"aggregate_type"`
+	AggregateID   string     `json:"aggregate_id"`
+	Subject       string     `json:"subject"`
+	Payload       []byte     `json:"payload"`
+	Attempts      int32      `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	DeadAt        *time.Time `json:"dead_at,omitempty"`
+}
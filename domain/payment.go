@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending   PaymentStatus = "pending"
+	PaymentStatusSucceeded PaymentStatus = "succeeded"
+	PaymentStatusFailed    PaymentStatus = "failed"
+	// PaymentStatusRefunding marks an intent whose refund has been claimed
+	// but not yet confirmed by the provider.
+	PaymentStatusRefunding PaymentStatus = "refunding"
+	PaymentStatusRefunded  PaymentStatus = "refunded"
+)
+
+var (
+	// ErrOrderNotPayable is returned when paying for an order that is no
+	// longer pending.
+	ErrOrderNotPayable  = errors.New("order is not awaiting payment")
+	ErrOrderAlreadyPaid = errors.New("order is already paid")
+	// ErrInvalidPaymentWebhook is returned for webhooks that are not signed
+	// by the payment provider or that it could not have sent.
+	ErrInvalidPaymentWebhook = errors.New("invalid payment webhook")
+)
+
+// PaymentIntent is one attempt to pay Amount for an order through Provider.
+// ProviderRef is the provider's own ID for the payment, and ClientSecret is
+// what the customer's client completes the payment with. An order has at
+// most one intent that is pending or succeeded; failed intents are kept and
+// a new one is created for the next attempt.
+type PaymentIntent struct {
+	ID            string        `json:"id"`
+	OrderID       string        `json:"order_id"`
+	UserID        string        `json:"user_id"`
+	Amount        Money         `json:"amount"`
+	Status        PaymentStatus `json:"status"`
+	Provider      string        `json:"provider"`
+	ProviderRef   string        `json:"provider_ref"`
+	ClientSecret  string        `json:"client_secret,omitempty"`
+	FailureReason string        `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// PaymentNotification is what a provider's webhook reports: that the payment
+// with ProviderRef succeeded or failed.
+type PaymentNotification struct {
+	EventID       string
+	ProviderRef   string
+	Status        PaymentStatus
+	FailureReason string
+}
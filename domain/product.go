@@ -1,9 +1,65 @@
 package domain
 
+import (
+    "sort"
+    "strings"
+)
+
 type Product struct {
-    ID    string  `json:"id"`
-    Name  string  `json:"name"`
-    Price float64 `json:"price"`
-    Stock int32   `json:"stock"`
+    ID               string         `json:"id"`
+    Name             string         `json:"name"`
+    Price            Money          `json:"price"`
+    Stock            int32          `json:"stock"`
+    CategoryID       string         `json:"category_id,omitempty"`
+    Tags             []string       `json:"tags,omitempty"`
+    // ReorderThreshold is the stock below which the product needs
+    // reordering. Zero turns low-stock alerts off.
+    ReorderThreshold int32          `json:"reorder_threshold"`
+    // Images is the product's gallery in display order.
+    Images           []ProductImage `json:"images,omitempty"`
+}
+
+// IsLowStock reports whether the product's stock is below its reorder
+// threshold.
+func (p *Product) IsLowStock() bool {
+    return p.Stock < p.ReorderThreshold
+}
+
+// ProductSearchResult is a product matched by a text search.
+type ProductSearchResult struct {
+    Product *Product `json:"product"`
+    // Rank orders results, higher first. It is only comparable between
+    // results of the same search.
+    Rank float64 `json:"rank"`
+    // Highlight is the HTML-escaped product name with matched words wrapped
+    // in <mark> tags.
+    Highlight string `json:"highlight"`
 }
 
+// ProductSuggestion is an autocomplete entry for a partly typed name.
+type ProductSuggestion struct {
+    ID   string `json:"id"`
+    Name string `json:"name"`
+}
+
+// NormalizeTags trims and lower-cases tags, drops empty and duplicate ones
+// and sorts the rest. A nil slice stays nil.
+func NormalizeTags(tags []string) []string {
+    if tags == nil {
+        return nil
+    }
+
+    seen := make(map[string]bool, len(tags))
+    normalized := make([]string, 0, len(tags))
+    for _, tag := range tags {
+        tag = strings.ToLower(strings.TrimSpace(tag))
+        if tag == "" || seen[tag] {
+            continue
+        }
+        seen[tag] = true
+        normalized = append(normalized, tag)
+    }
+
+    sort.Strings(normalized)
+    return normalized
+}
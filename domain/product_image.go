@@ -0,0 +1,25 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrInvalidImage is returned for an upload that is not a JPEG, PNG or
+	// GIF image, or is too large to process.
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrInvalidImageOrder is returned when a new gallery order does not
+	// list each of the product's images exactly once.
+	ErrInvalidImageOrder = errors.New("image order must list every image of the product once")
+)
+
+// ProductImage is one picture in a product's gallery, with a thumbnail made
+// when it was uploaded. Position orders the gallery, starting from 0.
+type ProductImage struct {
+	ID           string `json:"id"`
+	ProductID    string `json:"-"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Position     int32  `json:"position"`
+	// Key and ThumbnailKey locate the files in the blob store.
+	Key          string `json:"-"`
+	ThumbnailKey string `json:"-"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "errors"
+
+// ErrInvalidProductImport is returned for an import file that cannot be read
+// at all, such as an unsupported format or a CSV file with a bad header.
+// Problems with single rows are reported in the ProductImportReport instead.
+var ErrInvalidProductImport = errors.New("invalid product import")
+
+// ProductImportError explains why a row of an import was not applied. Line
+// is the row's line in the file, counting from 1.
+type ProductImportError struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// ProductImportReport counts what an import did with each row. Unchanged
+// rows matched the stored product exactly and published no event. ID is the
+// reference of the stock movements the import recorded.
+type ProductImportReport struct {
+	ID        string               `json:"id"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Failed    int                  `json:"failed"`
+	Errors    []ProductImportError `json:"errors"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"organic", "vegan"}, NormalizeTags([]string{" Vegan", "organic", "", "VEGAN "}))
+	assert.Equal(t, []string{}, NormalizeTags([]string{}))
+	assert.Nil(t, NormalizeTags(nil))
+}
+
+func TestProductIsLowStock(t *testing.T) {
+	assert.True(t, (&Product{Stock: 4, ReorderThreshold: 5}).IsLowStock())
+	assert.False(t, (&Product{Stock: 5, ReorderThreshold: 5}).IsLowStock())
+	assert.False(t, (&Product{Stock: 0}).IsLowStock())
+}
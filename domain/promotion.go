@@ -0,0 +1,203 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPromotion is returned for a promotion that cannot be saved
+	// as given.
+	ErrInvalidPromotion = errors.New("invalid promotion")
+	// ErrCouponNotApplicable is returned when an order gives a coupon code
+	// that is unknown, not running or takes nothing off the order.
+	ErrCouponNotApplicable = errors.New("coupon code does not apply to this order")
+)
+
+// PromotionType says how a promotion works out its discount.
+type PromotionType string
+
+const (
+	// PromotionPercentage takes PercentOff percent off the covered items.
+	PromotionPercentage PromotionType = "percentage"
+	// PromotionFixedAmount takes AmountOff off the order, but never more
+	// than the covered items cost.
+	PromotionFixedAmount PromotionType = "fixed_amount"
+	// PromotionBuyXGetY makes every GetQuantity units of a product free
+	// for each BuyQuantity units paid for.
+	PromotionBuyXGetY PromotionType = "buy_x_get_y"
+)
+
+// Promotion is a discount applied when orders are placed. One with a Code is
+// a coupon, applied only to orders giving that code; one without applies to
+// every order it covers. CategoryID limits it to products in the category
+// and its subcategories. UsageLimit caps the orders that may use it and
+// PerUserLimit the orders of a single user; zero means no limit.
+type Promotion struct {
+	ID           string        `json:"id"`
+	Code         string        `json:"code,omitempty"`
+	Name         string        `json:"name"`
+	Type         PromotionType `json:"type"`
+	PercentOff   int32         `json:"percent_off,omitempty"`
+	AmountOff    Money         `json:"amount_off"`
+	BuyQuantity  int32         `json:"buy_quantity,omitempty"`
+	GetQuantity  int32         `json:"get_quantity,omitempty"`
+	CategoryID   string        `json:"category_id,omitempty"`
+	StartsAt     *time.Time    `json:"starts_at,omitempty"`
+	EndsAt       *time.Time    `json:"ends_at,omitempty"`
+	UsageLimit   int32         `json:"usage_limit"`
+	PerUserLimit int32         `json:"per_user_limit"`
+	TimesUsed    int32         `json:"times_used"`
+	Active       bool          `json:"active"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// NormalizeCouponCode trims and upper-cases code, so that codes match
+// however they are typed.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate normalizes the promotion's code and name and checks that its
+// fields fit its type.
+func (p *Promotion) Validate() error {
+	p.Code = NormalizeCouponCode(p.Code)
+	p.Name = strings.TrimSpace(p.Name)
+
+	if p.Name == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrInvalidPromotion)
+	}
+
+	switch p.Type {
+	case PromotionPercentage:
+		if p.PercentOff < 1 || p.PercentOff > 100 {
+			return fmt.Errorf("%w: percent_off must be between 1 and 100", ErrInvalidPromotion)
+		}
+	case PromotionFixedAmount:
+		if p.AmountOff.Amount <= 0 {
+			return fmt.Errorf("%w: amount_off must be positive", ErrInvalidPromotion)
+		}
+	case PromotionBuyXGetY:
+		if p.BuyQuantity < 1 || p.GetQuantity < 1 {
+			return fmt.Errorf("%w: buy_quantity and get_quantity must be positive", ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidPromotion, p.Type)
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+
+	if p.UsageLimit < 0 || p.PerUserLimit < 0 {
+		return fmt.Errorf("%w: usage limits cannot be negative", ErrInvalidPromotion)
+	}
+
+	return nil
+}
+
+// IsRunningAt reports whether the promotion is active and within its
+// validity window at now. StartsAt is inclusive and EndsAt exclusive.
+func (p *Promotion) IsRunningAt(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || now.Before(*p.EndsAt)
+}
+
+// OrderDiscount is an amount a promotion took off an order. It applies to a
+// single line when OrderItemID is set, and to the whole order otherwise.
+type OrderDiscount struct {
+	ID          string `json:"id"`
+	OrderID     string `json:"order_id"`
+	OrderItemID string `json:"order_item_id,omitempty"`
+	PromotionID string `json:"promotion_id,omitempty"`
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description"`
+	Amount      Money  `json:"amount"`
+}
+
+// Discounts works out what the promotion takes off items, which are priced
+// in the currency rate converts catalog prices into. covers reports whether
+// the promotion covers an item's product. Percentages off a category and
+// buy-X-get-Y give one discount per line; the rest give a single
+// order-level discount. It returns none when no covered item is discounted,
+// or for a fixed amount in a currency other than the one the order's
+// catalog prices were in, rate.From.
+func (p *Promotion) Discounts(items []*OrderItem, covers func(productID string) bool, rate ExchangeRate) ([]*OrderDiscount, error) {
+	var covered Money
+	var discounts []*OrderDiscount
+
+	for _, item := range items {
+		if !covers(item.ProductID) {
+			continue
+		}
+
+		line := item.Price.Multiply(int64(item.Quantity))
+		var err error
+		if covered, err = covered.Add(line); err != nil {
+			return nil, err
+		}
+
+		var amount Money
+		switch {
+		case p.Type == PromotionBuyXGetY:
+			free := item.Quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+			amount = item.Price.Multiply(int64(free))
+		case p.Type == PromotionPercentage && p.CategoryID != "":
+			amount = percentOf(line, p.PercentOff)
+		default:
+			continue
+		}
+
+		if amount.Amount > 0 {
+			discounts = append(discounts, p.discount(item.ID, amount))
+		}
+	}
+
+	if covered.Amount <= 0 {
+		return nil, nil
+	}
+
+	switch {
+	case p.Type == PromotionPercentage && p.CategoryID == "":
+		if amount := percentOf(covered, p.PercentOff); amount.Amount > 0 {
+			discounts = append(discounts, p.discount("", amount))
+		}
+	case p.Type == PromotionFixedAmount:
+		if p.AmountOff.Currency != rate.From {
+			return nil, nil
+		}
+		amount, err := p.AmountOff.Convert(rate)
+		if err != nil {
+			return nil, err
+		}
+		if amount.Amount > covered.Amount {
+			amount = covered
+		}
+		discounts = append(discounts, p.discount("", amount))
+	}
+
+	return discounts, nil
+}
+
+func (p *Promotion) discount(orderItemID string, amount Money) *OrderDiscount {
+	return &OrderDiscount{
+		OrderItemID: orderItemID,
+		PromotionID: p.ID,
+		Code:        p.Code,
+		Description: p.Name,
+		Amount:      amount,
+	}
+}
+
+// percentOf returns percent percent of m, rounded half up to the minor
+// unit.
+func percentOf(m Money, percent int32) Money {
+	return Money{Amount: (m.Amount*int64(percent) + 50) / 100, Currency: m.Currency}
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func coversAll(string) bool { return true }
+
+func TestPromotionValidate(t *testing.T) {
+	p := &Promotion{Code: " spring10 ", Name: "Spring", Type: PromotionPercentage, PercentOff: 10}
+	assert.NoError(t, p.Validate())
+	assert.Equal(t, "SPRING10", p.Code)
+
+	for _, invalid := range []*Promotion{
+		{Type: PromotionPercentage, PercentOff: 10},
+		{Name: "Too much", Type: PromotionPercentage, PercentOff: 101},
+		{Name: "Nothing off", Type: PromotionFixedAmount},
+		{Name: "Free", Type: PromotionBuyXGetY, BuyQuantity: 2},
+		{Name: "Unknown", Type: "bogo"},
+		{Name: "Limits", Type: PromotionPercentage, PercentOff: 5, UsageLimit: -1},
+	} {
+		assert.ErrorIs(t, invalid.Validate(), ErrInvalidPromotion, invalid.Name)
+	}
+
+	start := time.Now()
+	p.StartsAt, p.EndsAt = &start, &start
+	assert.ErrorIs(t, p.Validate(), ErrInvalidPromotion)
+}
+
+func TestPromotionIsRunningAt(t *testing.T) {
+	now := time.Now()
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	p := &Promotion{Active: true, StartsAt: &start, EndsAt: &end}
+
+	assert.True(t, p.IsRunningAt(now))
+	assert.True(t, p.IsRunningAt(start))
+	assert.False(t, p.IsRunningAt(end))
+	assert.False(t, p.IsRunningAt(start.Add(-time.Second)))
+
+	p.Active = false
+	assert.False(t, p.IsRunningAt(now))
+}
+
+func TestPromotionDiscounts(t *testing.T) {
+	items := []*OrderItem{
+		{ID: "i1", ProductID: "apple", Quantity: 5, Price: NewMoney(333, "KZT")},
+		{ID: "i2", ProductID: "pear", Quantity: 1, Price: NewMoney(1000, "KZT")},
+	}
+	rate := IdentityRate("KZT")
+	applesOnly := func(productID string) bool { return productID == "apple" }
+
+	// 10% of 26.65 is 2.665, rounded half up.
+	percentage := &Promotion{ID: "p1", Code: "TEN", Name: "10% off", Type: PromotionPercentage, PercentOff: 10}
+	discounts, err := percentage.Discounts(items, coversAll, rate)
+	assert.NoError(t, err)
+	assert.Equal(t, []*OrderDiscount{{PromotionID: "p1", Code: "TEN", Description: "10% off", Amount: NewMoney(267, "KZT")}}, discounts)
+
+	percentage.CategoryID = "fruit"
+	discounts, err = percentage.Discounts(items, applesOnly, rate)
+	assert.NoError(t, err)
+	assert.Len(t, discounts, 1)
+	assert.Equal(t, "i1", discounts[0].OrderItemID)
+	assert.Equal(t, NewMoney(167, "KZT"), discounts[0].Amount)
+
+	fixed := &Promotion{Name: "5000 off", Type: PromotionFixedAmount, AmountOff: NewMoney(500000, "KZT")}
+	discounts, err = fixed.Discounts(items, applesOnly, rate)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(1665, "KZT"), discounts[0].Amount)
+
+	usd, err := NewExchangeRate("KZT", "USD", "0.002")
+	assert.NoError(t, err)
+	fixed.AmountOff = NewMoney(1000, "KZT")
+	discounts, err = fixed.Discounts([]*OrderItem{{ProductID: "pear", Quantity: 1, Price: NewMoney(500, "USD")}}, coversAll, usd)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(2, "USD"), discounts[0].Amount)
+
+	// An amount in another currency than the catalog prices does not apply.
+	fixed.AmountOff = NewMoney(1000, "EUR")
+	discounts, err = fixed.Discounts(items, coversAll, rate)
+	assert.NoError(t, err)
+	assert.Empty(t, discounts)
+
+	// Buy 2 get 1: five apples are one group of three, so one is free.
+	bogo := &Promotion{Name: "3 for 2", Type: PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}
+	discounts, err = bogo.Discounts(items, coversAll, rate)
+	assert.NoError(t, err)
+	assert.Len(t, discounts, 1)
+	assert.Equal(t, "i1", discounts[0].OrderItemID)
+	assert.Equal(t, NewMoney(333, "KZT"), discounts[0].Amount)
+
+	discounts, err = percentage.Discounts(items, func(string) bool { return false }, rate)
+	assert.NoError(t, err)
+	assert.Empty(t, discounts)
+}
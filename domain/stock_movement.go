@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// StockMovementReason says why a product's stock changed.
+type StockMovementReason string
+
+const (
+	// StockMovementOrder takes the ordered quantity for an order.
+	StockMovementOrder StockMovementReason = "order"
+	// StockMovementCancellation returns a cancelled order's quantity.
+	StockMovementCancellation StockMovementReason = "cancellation"
+	// StockMovementRestock is stock received, including a new product's
+	// initial stock.
+	StockMovementRestock StockMovementReason = "restock"
+	// StockMovementAdjustment is an admin setting the stock by hand.
+	StockMovementAdjustment StockMovementReason = "adjustment"
+	// StockMovementImport is stock set by a bulk product import.
+	StockMovementImport StockMovementReason = "import"
+	// StockMovementReconciliation brings the ledger back in line with the
+	// products' stock without changing it.
+	StockMovementReconciliation StockMovementReason = "reconciliation"
+)
+
+// StockMovement is an entry in the append-only stock ledger. ReferenceID
+// points at what caused it, such as the order, and StockAfter is the
+// product's stock once it was applied.
+type StockMovement struct {
+	ID          string              `json:"id"`
+	ProductID   string              `json:"product_id"`
+	Delta       int32               `json:"delta"`
+	Reason      StockMovementReason `json:"reason"`
+	ReferenceID string              `json:"reference_id,omitempty"`
+	StockAfter  int32               `json:"stock_after"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// DropsBelow reports whether the movement took stock from at or above
+// threshold to below it.
+func (m *StockMovement) DropsBelow(threshold int32) bool {
+	before := m.StockAfter - m.Delta
+	return before >= threshold && m.StockAfter < threshold
+}
+
+// StockDiscrepancy is a product whose stock differs from the sum of its
+// stock movements.
+type StockDiscrepancy struct {
+	ProductID   string `json:"product_id"`
+	Name        string `json:"name"`
+	Stock       int32  `json:"stock"`
+	LedgerStock int32  `json:"ledger_stock"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockMovementDropsBelow(t *testing.T) {
+	movement := func(delta, stockAfter int32) *StockMovement {
+		return &StockMovement{Delta: delta, StockAfter: stockAfter}
+	}
+
+	assert.True(t, movement(-3, 9).DropsBelow(10))
+	assert.True(t, movement(-3, 7).DropsBelow(10))
+	assert.False(t, movement(-3, 6).DropsBelow(10), "already below before the movement")
+	assert.False(t, movement(-3, 10).DropsBelow(10))
+	assert.False(t, movement(5, 8).DropsBelow(10))
+	assert.False(t, movement(-4, 0).DropsBelow(0), "a zero threshold never alerts")
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// StockReservationStatus is where a reservation is in its life.
+type StockReservationStatus string
+
+const (
+	// StockReservationActive holds stock until ExpiresAt.
+	StockReservationActive StockReservationStatus = "active"
+	// StockReservationCommitted is a reservation whose order was confirmed,
+	// so the stock is sold and no longer expires.
+	StockReservationCommitted StockReservationStatus = "committed"
+	// StockReservationReleased is a reservation whose stock went back on
+	// sale, because its order was cancelled or it expired.
+	StockReservationReleased StockReservationStatus = "released"
+)
+
+// Reasons given when reservations are released.
+const (
+	ReservationReleaseCancelled = "cancelled"
+	ReservationReleaseExpired   = "expired"
+)
+
+// StockReservation holds Quantity of a product for a pending order. The
+// stock is taken from the product when the reservation is made; releasing
+// the reservation returns it. ResolvedAt is set once it is committed or
+// released.
+type StockReservation struct {
+	ID         string                 `json:"id"`
+	OrderID    string                 `json:"order_id"`
+	ProductID  string                 `json:"product_id"`
+	Quantity   int32                  `json:"quantity"`
+	Status     StockReservationStatus `json:"status"`
+	ExpiresAt  time.Time              `json:"expires_at"`
+	CreatedAt  time.Time              `json:"created_at"`
+	ResolvedAt *time.Time             `json:"resolved_at,omitempty"`
+}
+
+// IsExpired reports whether an active reservation has run out at now.
+func (r *StockReservation) IsExpired(now time.Time) bool {
+	return r.Status == StockReservationActive && !now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrInvalidTaxRule = errors.New("invalid tax rule")
+
+// TaxRule is the tax charged on products in CategoryID sold in Region. An
+// empty CategoryID or Region matches any. Rate is a decimal fraction, so
+// "0.12" is 12%. Inclusive rates are already part of the catalog prices;
+// exclusive ones are added on top.
+type TaxRule struct {
+	CategoryID string `json:"category_id,omitempty"`
+	Region     string `json:"region,omitempty"`
+	Rate       string `json:"rate"`
+	Inclusive  bool   `json:"inclusive"`
+}
+
+func (r TaxRule) rate() (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(r.Rate)
+	if !ok || rate.Sign() < 0 {
+		return nil, fmt.Errorf("%w: rate %q", ErrInvalidTaxRule, r.Rate)
+	}
+	return rate, nil
+}
+
+func (r TaxRule) Validate() error {
+	_, err := r.rate()
+	return err
+}
+
+// Tax returns the tax on amount, rounded half away from zero to the minor
+// unit. For inclusive rules the tax is the part of amount above its net
+// price amount / (1 + rate); for exclusive rules it is amount * rate.
+func (r TaxRule) Tax(amount Money) (Money, error) {
+	rate, err := r.rate()
+	if err != nil {
+		return Money{}, err
+	}
+
+	value := new(big.Rat).SetInt64(amount.Amount)
+	if !r.Inclusive {
+		tax, err := roundHalfAwayFromZero(value.Mul(value, rate))
+		if err != nil {
+			return Money{}, err
+		}
+		return Money{Amount: tax, Currency: amount.Currency}, nil
+	}
+
+	net, err := roundHalfAwayFromZero(value.Quo(value, rate.Add(rate, big.NewRat(1, 1))))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount.Amount - net, Currency: amount.Currency}, nil
+}
+
+// TaxableLine is what the customer pays for one order line, after the
+// discounts on it and its share of the order-level discounts.
+// CategoryAncestors are the ancestors of CategoryID, nearest first.
+type TaxableLine struct {
+	OrderItemID       string
+	CategoryID        string
+	CategoryAncestors []string
+	Amount            Money
+}
+
+// TaxBreakdown is the tax on an order. Tax is all of it; Added is the part
+// charged by exclusive rules, which is added to the order's total.
+type TaxBreakdown struct {
+	Tax   Money
+	Added Money
+}
+
+// TaxableLines returns the taxable amount of each item. Order-level
+// discounts are shared between the lines in proportion to what is left of
+// them, with the remainder of the split going to the last lines that have
+// room for it. No line's share takes it below zero.
+func TaxableLines(items []*OrderItem, discounts []*OrderDiscount) ([]TaxableLine, error) {
+	lines := make([]TaxableLine, len(items))
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		line := TaxableLine{OrderItemID: item.ID, Amount: item.Price.Multiply(int64(item.Quantity))}
+		if item.Product != nil {
+			line.CategoryID = item.Product.CategoryID
+		}
+		lines[i] = line
+		index[item.ID] = i
+	}
+
+	var orderLevel Money
+	for _, discount := range discounts {
+		var err error
+		i, ok := index[discount.OrderItemID]
+		if !ok {
+			if orderLevel, err = orderLevel.Add(discount.Amount); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if lines[i].Amount, err = lines[i].Amount.Sub(discount.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	if orderLevel.Amount == 0 || len(lines) == 0 {
+		return lines, nil
+	}
+
+	var total int64
+	for _, line := range lines {
+		if line.Amount.Amount > 0 {
+			total += line.Amount.Amount
+		}
+	}
+	if total <= 0 {
+		return lines, nil
+	}
+
+	// A discount larger than the order leaves nothing to tax.
+	remaining := orderLevel.Amount
+	if remaining > total {
+		remaining = total
+	}
+	split := remaining
+	for i := range lines {
+		if lines[i].Amount.Amount <= 0 {
+			continue
+		}
+		share := new(big.Int).Quo(
+			new(big.Int).Mul(big.NewInt(split), big.NewInt(lines[i].Amount.Amount)),
+			big.NewInt(total),
+		).Int64()
+		lines[i].Amount.Amount -= share
+		remaining -= share
+	}
+	for i := len(lines) - 1; i >= 0 && remaining > 0; i-- {
+		if lines[i].Amount.Amount <= 0 {
+			continue
+		}
+		share := lines[i].Amount.Amount
+		if share > remaining {
+			share = remaining
+		}
+		lines[i].Amount.Amount -= share
+		remaining -= share
+	}
+
+	return lines, nil
+}
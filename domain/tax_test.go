@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxRule_TaxRounding(t *testing.T) {
+	tests := []struct {
+		rate      string
+		inclusive bool
+		amount    int64
+		want      int64
+	}{
+		{"0.12", false, 1000, 120},
+		{"0.12", false, 1004, 120},
+		{"0.12", false, 1005, 121},
+		{"0.1", false, 5, 1},
+		{"0.1", false, -5, -1},
+		{"0", false, 1000, 0},
+		{"0.12", true, 1120, 120},
+		{"0.12", true, 1000, 107},
+		{"0.25", true, 3, 1},
+		{"1", true, 3, 1},
+		{"1", true, -3, -1},
+		{"0.12", true, 0, 0},
+	}
+
+	for _, tt := range tests {
+		rule := TaxRule{Rate: tt.rate, Inclusive: tt.inclusive}
+		got, err := rule.Tax(NewMoney(tt.amount, "KZT"))
+		assert.NoError(t, err, tt)
+		assert.Equal(t, NewMoney(tt.want, "KZT"), got, tt)
+	}
+}
+
+func TestTaxRule_RejectsInvalidRates(t *testing.T) {
+	for _, rate := range []string{"", "twelve", "-0.1"} {
+		rule := TaxRule{Rate: rate}
+		assert.ErrorIs(t, rule.Validate(), ErrInvalidTaxRule, rate)
+
+		_, err := rule.Tax(NewMoney(100, "KZT"))
+		assert.ErrorIs(t, err, ErrInvalidTaxRule, rate)
+	}
+}
+
+func TestTaxableLines_SharesOrderDiscounts(t *testing.T) {
+	items := []*OrderItem{
+		{ID: "a", Quantity: 2, Price: NewMoney(1000, "KZT"), Product: &Product{CategoryID: "fruit"}},
+		{ID: "b", Quantity: 1, Price: NewMoney(500, "KZT")},
+		{ID: "c", Quantity: 1, Price: NewMoney(300, "KZT"), Product: &Product{CategoryID: "bakery"}},
+	}
+	discounts := []*OrderDiscount{
+		{OrderItemID: "b", Amount: NewMoney(100, "KZT")},
+		{Amount: NewMoney(100, "KZT")},
+	}
+
+	lines, err := TaxableLines(items, discounts)
+	assert.NoError(t, err)
+	assert.Equal(t, []TaxableLine{
+		{OrderItemID: "a", CategoryID: "fruit", Amount: NewMoney(1926, "KZT")},
+		{OrderItemID: "b", Amount: NewMoney(386, "KZT")},
+		{OrderItemID: "c", CategoryID: "bakery", Amount: NewMoney(288, "KZT")},
+	}, lines)
+
+	lines, err = TaxableLines(items, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(2000, "KZT"), lines[0].Amount)
+	assert.Equal(t, NewMoney(500, "KZT"), lines[1].Amount)
+}
+
+func TestTaxableLines_NeverGoesBelowZero(t *testing.T) {
+	items := []*OrderItem{
+		{ID: "a", Quantity: 1, Price: NewMoney(1000, "KZT")},
+		{ID: "b", Quantity: 1, Price: NewMoney(1000, "KZT")},
+		{ID: "c", Quantity: 1, Price: NewMoney(1, "KZT")},
+	}
+
+	// The remainder of the split does not fit in the last line.
+	lines, err := TaxableLines(items, []*OrderDiscount{{Amount: NewMoney(1000, "KZT")}})
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(501, "KZT"), lines[0].Amount)
+	assert.Equal(t, NewMoney(500, "KZT"), lines[1].Amount)
+	assert.Equal(t, NewMoney(0, "KZT"), lines[2].Amount)
+
+	lines, err = TaxableLines(items, []*OrderDiscount{{Amount: NewMoney(5000, "KZT")}})
+	assert.NoError(t, err)
+	for _, line := range lines {
+		assert.Equal(t, NewMoney(0, "KZT"), line.Amount)
+	}
+}
@@ -0,0 +1,53 @@
+package grpc
+
+import (
+    "context"
+    "strings"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+
+    "AdvProg2/domain"
+    "AdvProg2/pkg/auth"
+)
+
+// authorizationMetadataKey carries the caller's JWT as "Bearer <token>", the
+// same token the API gateway accepts.
+const authorizationMetadataKey = "authorization"
+
+type actorContextKey struct{}
+
+// ActorInterceptor identifies the caller of each call from the JWT in its
+// authorization metadata. Calls without a token are made by an anonymous
+// user, and calls with an invalid one are rejected. The caller's ID and
+// role are only ever taken from a verified token, never from metadata the
+// client sets itself.
+func ActorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+    actor := domain.Actor{Role: domain.ActorRoleUser}
+
+    if md, ok := metadata.FromIncomingContext(ctx); ok {
+        if values := md.Get(authorizationMetadataKey); len(values) > 0 {
+            claims, err := auth.ValidateToken(strings.TrimPrefix(values[0], "Bearer "))
+            if err != nil {
+                return nil, status.Error(codes.Unauthenticated, "invalid token")
+            }
+            actor.ID = claims.UserID
+            if claims.Role != "" {
+                actor.Role = claims.Role
+            }
+        }
+    }
+
+    return handler(context.WithValue(ctx, actorContextKey{}, actor), req)
+}
+
+// actorFromContext returns the caller identified by ActorInterceptor, or an
+// anonymous user when the call did not pass through it.
+func actorFromContext(ctx context.Context) domain.Actor {
+    if actor, ok := ctx.Value(actorContextKey{}).(domain.Actor); ok {
+        return actor
+    }
+    return domain.Actor{Role: domain.ActorRoleUser}
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"AdvProg2/domain"
+	"AdvProg2/pkg/auth"
+)
+
+// interceptedActor runs a call with md through ActorInterceptor and returns
+// the actor its handler saw.
+func interceptedActor(md metadata.MD) (domain.Actor, error) {
+	var actor domain.Actor
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	_, err := ActorInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		actor = actorFromContext(ctx)
+		return nil, nil
+	})
+	return actor, err
+}
+
+func TestActorInterceptor_IgnoresClientSetIdentity(t *testing.T) {
+	actor, err := interceptedActor(metadata.Pairs("x-user-id", "u1", "x-user-role", domain.ActorRoleAdmin))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Actor{Role: domain.ActorRoleUser}, actor)
+
+	token, err := auth.GenerateToken("u2", "bob", domain.ActorRoleUser)
+	assert.NoError(t, err)
+	actor, err = interceptedActor(metadata.Pairs("authorization", "Bearer "+token, "x-user-role", domain.ActorRoleAdmin))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Actor{ID: "u2", Role: domain.ActorRoleUser}, actor)
+}
+
+func TestActorInterceptor_TakesActorFromVerifiedToken(t *testing.T) {
+	token, err := auth.GenerateToken("a1", "alice", domain.ActorRoleAdmin)
+	assert.NoError(t, err)
+	actor, err := interceptedActor(metadata.Pairs("authorization", "Bearer "+token))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Actor{ID: "a1", Role: domain.ActorRoleAdmin}, actor)
+
+	_, err = interceptedActor(metadata.Pairs("authorization", "Bearer "+token+"x"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,201 @@
+package grpc
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+
+    "AdvProg2/domain"
+    pb "AdvProg2/proto/cart"
+    "AdvProg2/repository"
+    "AdvProg2/usecase"
+)
+
+type CartHandler struct {
+    pb.UnimplementedCartServiceServer
+    cartUseCase *usecase.CartUseCase
+}
+
+func NewCartHandler(cartUseCase *usecase.CartUseCase) *CartHandler {
+    return &CartHandler{
+        cartUseCase: cartUseCase,
+    }
+}
+
+// cartError converts cart use case errors to gRPC status errors.
+func cartError(err error) error {
+    switch {
+    case errors.Is(err, domain.ErrInvalidCartQuantity),
+        errors.Is(err, domain.ErrCouponNotApplicable):
+        return status.Error(codes.InvalidArgument, err.Error())
+    case errors.Is(err, repository.ErrProductNotFound),
+        errors.Is(err, repository.ErrCartItemNotFound):
+        return status.Error(codes.NotFound, err.Error())
+    case errors.Is(err, domain.ErrEmptyCart),
+        errors.Is(err, domain.ErrCartNotOrderable),
+        errors.Is(err, repository.ErrInsufficientStock):
+        return status.Error(codes.FailedPrecondition, err.Error())
+    default:
+        return orderError(err)
+    }
+}
+
+// cartUserID returns the caller whose cart a request is for.
+func cartUserID(ctx context.Context) (string, error) {
+    userID := actorFromContext(ctx).ID
+    if userID == "" {
+        return "", status.Error(codes.Unauthenticated, "user ID is required")
+    }
+    return userID, nil
+}
+
+func cartMoneyToProto(m domain.Money) *pb.Money {
+    return &pb.Money{
+        Amount:   m.Amount,
+        Currency: m.Currency,
+    }
+}
+
+func domainCartToProto(cart *domain.Cart) *pb.Cart {
+    protoCart := &pb.Cart{
+        UserId:   cart.UserID,
+        Items:    make([]*pb.CartItem, 0, len(cart.Items)),
+        Subtotal: cartMoneyToProto(cart.Subtotal),
+    }
+
+    for _, item := range cart.Items {
+        protoItem := &pb.CartItem{
+            ProductId:  item.ProductID,
+            Quantity:   item.Quantity,
+            AddedPrice: cartMoneyToProto(item.AddedPrice),
+            AddedAt:    item.AddedAt.Format(time.RFC3339),
+            UpdatedAt:  item.UpdatedAt.Format(time.RFC3339),
+            Issue:      string(item.Issue),
+        }
+
+        if item.Product != nil {
+            protoItem.Product = &pb.Product{
+                Id:    item.Product.ID,
+                Name:  item.Product.Name,
+                Price: cartMoneyToProto(item.Product.Price),
+                Stock: item.Product.Stock,
+            }
+        }
+
+        protoCart.Items = append(protoCart.Items, protoItem)
+    }
+
+    return protoCart
+}
+
+func (h *CartHandler) GetCart(ctx context.Context, req *pb.GetCartRequest) (*pb.Cart, error) {
+    userID, err := cartUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    cart, err := h.cartUseCase.GetCart(userID)
+    if err != nil {
+        return nil, cartError(err)
+    }
+
+    return domainCartToProto(cart), nil
+}
+
+func (h *CartHandler) AddItem(ctx context.Context, req *pb.AddItemRequest) (*pb.Cart, error) {
+    userID, err := cartUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.ProductId == "" {
+        return nil, status.Error(codes.InvalidArgument, "product ID is required")
+    }
+
+    cart, err := h.cartUseCase.AddItem(userID, req.ProductId, req.Quantity)
+    if err != nil {
+        return nil, cartError(err)
+    }
+
+    return domainCartToProto(cart), nil
+}
+
+func (h *CartHandler) UpdateItem(ctx context.Context, req *pb.UpdateItemRequest) (*pb.Cart, error) {
+    userID, err := cartUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.ProductId == "" {
+        return nil, status.Error(codes.InvalidArgument, "product ID is required")
+    }
+
+    cart, err := h.cartUseCase.UpdateItem(userID, req.ProductId, req.Quantity)
+    if err != nil {
+        return nil, cartError(err)
+    }
+
+    return domainCartToProto(cart), nil
+}
+
+func (h *CartHandler) RemoveItem(ctx context.Context, req *pb.RemoveItemRequest) (*pb.Cart, error) {
+    userID, err := cartUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.ProductId == "" {
+        return nil, status.Error(codes.InvalidArgument, "product ID is required")
+    }
+
+    cart, err := h.cartUseCase.RemoveItem(userID, req.ProductId)
+    if err != nil {
+        return nil, cartError(err)
+    }
+
+    return domainCartToProto(cart), nil
+}
+
+func (h *CartHandler) ClearCart(ctx context.Context, req *pb.ClearCartRequest) (*pb.ClearCartResponse, error) {
+    userID, err := cartUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := h.cartUseCase.ClearCart(userID); err != nil {
+        return nil, cartError(err)
+    }
+
+    return &pb.ClearCartResponse{Success: true}, nil
+}
+
+func (h *CartHandler) Checkout(ctx context.Context, req *pb.CheckoutRequest) (*pb.CheckoutResponse, error) {
+    userID, err := cartUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    order, err := h.cartUseCase.Checkout(userID, usecase.OrderOptions{
+        Currency:       req.Currency,
+        CouponCode:     req.CouponCode,
+        Region:         req.Region,
+        AddressID:      req.AddressId,
+        DeliverySlotID: req.DeliverySlotId,
+    })
+    if err != nil {
+        return nil, cartError(err)
+    }
+
+    return &pb.CheckoutResponse{
+        OrderId:       order.ID,
+        Status:        string(order.Status),
+        TotalPrice:    cartMoneyToProto(order.TotalPrice),
+        DiscountTotal: cartMoneyToProto(order.DiscountTotal),
+        Subtotal:      cartMoneyToProto(order.Subtotal),
+        TaxTotal:      cartMoneyToProto(order.TaxTotal),
+        DeliveryFee:   cartMoneyToProto(order.DeliveryFee),
+    }, nil
+}
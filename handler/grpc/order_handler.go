@@ -2,23 +2,112 @@ package grpc
 
 import (
     "context"
+    "errors"
     "time"
     
     pb "AdvProg2/proto/order"
     "AdvProg2/usecase"
     "AdvProg2/domain"
+    "AdvProg2/repository"
     "google.golang.org/grpc/codes"
     "google.golang.org/grpc/status"
 )
 
 type OrderHandler struct {
     pb.UnimplementedOrderServiceServer
-    orderUseCase *usecase.OrderUseCase
+    orderUseCase        *usecase.OrderUseCase
+    deliverySlotUseCase *usecase.DeliverySlotUseCase
 }
 
-func NewOrderHandler(orderUseCase *usecase.OrderUseCase) *OrderHandler {
+func NewOrderHandler(orderUseCase *usecase.OrderUseCase, deliverySlotUseCase *usecase.DeliverySlotUseCase) *OrderHandler {
     return &OrderHandler{
-        orderUseCase: orderUseCase,
+        orderUseCase:        orderUseCase,
+        deliverySlotUseCase: deliverySlotUseCase,
+    }
+}
+
+var orderStatusToProto = map[domain.OrderStatus]pb.OrderStatus{
+    domain.OrderStatusPending:        pb.OrderStatus_ORDER_STATUS_PENDING,
+    domain.OrderStatusConfirmed:      pb.OrderStatus_ORDER_STATUS_CONFIRMED,
+    domain.OrderStatusPreparing:      pb.OrderStatus_ORDER_STATUS_PREPARING,
+    domain.OrderStatusReady:          pb.OrderStatus_ORDER_STATUS_READY,
+    domain.OrderStatusOutForDelivery: pb.OrderStatus_ORDER_STATUS_OUT_FOR_DELIVERY,
+    domain.OrderStatusDelivered:      pb.OrderStatus_ORDER_STATUS_DELIVERED,
+    domain.OrderStatusCancelled:      pb.OrderStatus_ORDER_STATUS_CANCELLED,
+    domain.OrderStatusRefunded:       pb.OrderStatus_ORDER_STATUS_REFUNDED,
+}
+
+func orderStatusFromProto(s pb.OrderStatus) (domain.OrderStatus, bool) {
+    for domainStatus, protoStatus := range orderStatusToProto {
+        if protoStatus == s {
+            return domainStatus, true
+        }
+    }
+    return "", false
+}
+
+// orderError converts order use case errors to gRPC status errors.
+func orderError(err error) error {
+    switch {
+    case errors.Is(err, domain.ErrInvalidOrderStatus),
+        errors.Is(err, domain.ErrUnsupportedCurrency),
+        errors.Is(err, domain.ErrCouponNotApplicable),
+        errors.Is(err, domain.ErrInvalidDeliverySlot),
+        errors.Is(err, repository.ErrInvalidPageToken):
+        return status.Error(codes.InvalidArgument, err.Error())
+    case errors.Is(err, repository.ErrAddressNotFound),
+        errors.Is(err, repository.ErrDeliverySlotNotFound):
+        return status.Error(codes.NotFound, err.Error())
+    case errors.Is(err, domain.ErrNotDeliverable),
+        errors.Is(err, domain.ErrDeliverySlotClosed),
+        errors.Is(err, repository.ErrDeliverySlotFull):
+        return status.Error(codes.FailedPrecondition, err.Error())
+    case errors.Is(err, domain.ErrStatusTransitionForbidden):
+        return status.Error(codes.PermissionDenied, err.Error())
+    case errors.Is(err, domain.ErrIllegalStatusTransition):
+        return status.Error(codes.FailedPrecondition, err.Error())
+    case errors.Is(err, repository.ErrOrderStatusChanged):
+        return status.Error(codes.Aborted, err.Error())
+    default:
+        return status.Error(codes.Internal, err.Error())
+    }
+}
+
+func orderMoneyToProto(m domain.Money) *pb.Money {
+    return &pb.Money{
+        Amount:   m.Amount,
+        Currency: m.Currency,
+    }
+}
+
+func deliverySlotToProto(slot *domain.DeliverySlot) *pb.DeliverySlot {
+    if slot == nil {
+        return nil
+    }
+
+    return &pb.DeliverySlot{
+        Id:              slot.ID,
+        StartsAt:  slot.StartsAt.Format(time.RFC3339),
+        EndsAt:    slot.EndsAt.Format(time.RFC3339),
+        Remaining: slot.Remaining(),
+    }
+}
+
+func orderAddressToProto(address *domain.Address) *pb.Address {
+    if address == nil {
+        return nil
+    }
+
+    return &pb.Address{
+        Id:         address.ID,
+        Label:      address.Label,
+        Recipient:  address.Recipient,
+        Phone:      address.Phone,
+        Line1:      address.Line1,
+        Line2:      address.Line2,
+        City:       address.City,
+        Region:     address.Region,
+        PostalCode: address.PostalCode,
     }
 }
 
@@ -29,12 +118,26 @@ func domainOrderToProto(order *domain.Order) *pb.Order {
     }
     
     protoOrder := &pb.Order{
-        Id:         order.ID,
-        UserId:     order.UserID,
-        Status:     order.Status,
-        TotalPrice: order.TotalPrice,
-        CreatedAt:  order.CreatedAt.Format(time.RFC3339),
-        Items:      make([]*pb.OrderItem, 0, len(order.Items)),
+        Id:              order.ID,
+        UserId:          order.UserID,
+        Status:          orderStatusToProto[order.Status],
+        TotalPrice:      orderMoneyToProto(order.TotalPrice),
+        Subtotal:        orderMoneyToProto(order.Subtotal),
+        DiscountTotal:   orderMoneyToProto(order.DiscountTotal),
+        TaxTotal:        orderMoneyToProto(order.TaxTotal),
+        TaxRegion:       order.TaxRegion,
+        DeliveryFee:     orderMoneyToProto(order.DeliveryFee),
+        DeliveryAddress: orderAddressToProto(order.DeliveryAddress),
+        DeliveryZone:    order.DeliveryZone,
+        DeliverySlot:    deliverySlotToProto(order.DeliverySlot),
+        ExchangeRate: &pb.ExchangeRate{
+            FromCurrency: order.ExchangeRate.From,
+            ToCurrency:   order.ExchangeRate.To,
+            Rate:         order.ExchangeRate.Rate,
+        },
+        CreatedAt: order.CreatedAt.Format(time.RFC3339),
+        Items:     make([]*pb.OrderItem, 0, len(order.Items)),
+        Discounts: make([]*pb.OrderDiscount, 0, len(order.Discounts)),
     }
     
     for _, item := range order.Items {
@@ -43,14 +146,14 @@ func domainOrderToProto(order *domain.Order) *pb.Order {
             OrderId:   item.OrderID,
             ProductId: item.ProductID,
             Quantity:  item.Quantity,
-            Price:     item.Price,
+            Price:     orderMoneyToProto(item.Price),
         }
         
         if item.Product != nil {
             protoItem.Product = &pb.Product{
                 Id:    item.Product.ID,
                 Name:  item.Product.Name,
-                Price: item.Product.Price,
+                Price: orderMoneyToProto(item.Product.Price),
                 Stock: item.Product.Stock,
             }
         }
@@ -58,12 +161,44 @@ func domainOrderToProto(order *domain.Order) *pb.Order {
         protoOrder.Items = append(protoOrder.Items, protoItem)
     }
     
+    for _, discount := range order.Discounts {
+        protoOrder.Discounts = append(protoOrder.Discounts, &pb.OrderDiscount{
+            Id:          discount.ID,
+            OrderItemId: discount.OrderItemID,
+            PromotionId: discount.PromotionID,
+            Code:        discount.Code,
+            Description: discount.Description,
+            Amount:      orderMoneyToProto(discount.Amount),
+        })
+    }
+    
     return protoOrder
 }
 
+// orderUserID returns the user a request is for: the caller, who may leave
+// requested empty. Only admins may name another user, and only when
+// allowOthers is set.
+func orderUserID(ctx context.Context, requested string, allowOthers bool) (string, error) {
+    actor := actorFromContext(ctx)
+    if actor.ID == "" {
+        return "", status.Error(codes.Unauthenticated, "user ID is required")
+    }
+    
+    if requested == "" || requested == actor.ID {
+        return actor.ID, nil
+    }
+    if allowOthers && actor.Role == domain.ActorRoleAdmin {
+        return requested, nil
+    }
+    return "", status.Error(codes.PermissionDenied, "user ID does not match the caller")
+}
+
+// CreateOrder places an order for the caller, so saved addresses and
+// per-user coupon limits are checked against the authenticated user.
 func (h *OrderHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderRequest) (*pb.Order, error) {
-    if req.UserId == "" {
-        return nil, status.Error(codes.InvalidArgument, "user ID is required")
+    userID, err := orderUserID(ctx, req.UserId, false)
+    if err != nil {
+        return nil, err
     }
     
     if len(req.Items) == 0 {
@@ -79,9 +214,15 @@ func (h *OrderHandler) CreateOrder(ctx context.Context, req *pb.CreateOrderReque
         })
     }
     
-    order, err := h.orderUseCase.CreateOrder(req.UserId, orderItems)
+    order, err := h.orderUseCase.CreateOrder(userID, orderItems, usecase.OrderOptions{
+        Currency:       req.Currency,
+        CouponCode:     req.CouponCode,
+        Region:         req.Region,
+        AddressID:      req.AddressId,
+        DeliverySlotID: req.DeliverySlotId,
+    })
     if err != nil {
-        return nil, status.Error(codes.Internal, err.Error())
+        return nil, orderError(err)
     }
     
     return domainOrderToProto(order), nil
@@ -100,25 +241,54 @@ func (h *OrderHandler) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*
     return domainOrderToProto(order), nil
 }
 
+// GetUserOrders lists the caller's orders, or for admins those of the user
+// they name.
 func (h *OrderHandler) GetUserOrders(ctx context.Context, req *pb.GetUserOrdersRequest) (*pb.ListOrdersResponse, error) {
-    if req.UserId == "" {
-        return nil, status.Error(codes.InvalidArgument, "user ID is required")
+    userID, err := orderUserID(ctx, req.UserId, true)
+    if err != nil {
+        return nil, err
     }
     
-    orders, total, err := h.orderUseCase.GetUserOrders(req.UserId, req.Page, req.Limit)
+    // Clients that ask for a page number keep getting numbered pages with
+    // a total; everyone else pages by position.
+    if req.Page > 0 && req.PageToken == "" {
+        orders, total, err := h.orderUseCase.GetUserOrders(userID, req.Page, req.Limit)
+        if err != nil {
+            return nil, status.Error(codes.Internal, err.Error())
+        }
+        
+        return &pb.ListOrdersResponse{
+            Orders: domainOrdersToProto(orders),
+            Total:  total,
+        }, nil
+    }
+    
+    page, err := h.orderUseCase.GetUserOrdersPage(userID, repository.PageRequest{
+        Token:     req.PageToken,
+        Limit:     req.Limit,
+        WithTotal: req.IncludeTotal,
+    })
     if err != nil {
-        return nil, status.Error(codes.Internal, err.Error())
+        return nil, orderError(err)
+    }
+    
+    response := &pb.ListOrdersResponse{
+        Orders:        domainOrdersToProto(page.Items),
+        NextPageToken: page.NextPageToken,
+    }
+    if page.Total != nil {
+        response.Total = *page.Total
     }
     
+    return response, nil
+}
+
+func domainOrdersToProto(orders []*domain.Order) []*pb.Order {
     protoOrders := make([]*pb.Order, 0, len(orders))
     for _, order := range orders {
         protoOrders = append(protoOrders, domainOrderToProto(order))
     }
-    
-    return &pb.ListOrdersResponse{
-        Orders: protoOrders,
-        Total:  total,
-    }, nil
+    return protoOrders
 }
 
 func (h *OrderHandler) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.Order, error) {
@@ -126,13 +296,18 @@ func (h *OrderHandler) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrde
         return nil, status.Error(codes.InvalidArgument, "order ID is required")
     }
     
-    if req.Status == "" {
+    if req.Status == pb.OrderStatus_ORDER_STATUS_UNSPECIFIED {
         return nil, status.Error(codes.InvalidArgument, "status is required")
     }
     
-    order, err := h.orderUseCase.UpdateOrderStatus(req.Id, req.Status)
+    orderStatus, ok := orderStatusFromProto(req.Status)
+    if !ok {
+        return nil, status.Errorf(codes.InvalidArgument, "unknown status %v", req.Status)
+    }
+    
+    order, err := h.orderUseCase.UpdateOrderStatus(req.Id, orderStatus, actorFromContext(ctx), req.Reason)
     if err != nil {
-        return nil, status.Error(codes.Internal, err.Error())
+        return nil, orderError(err)
     }
     
     return domainOrderToProto(order), nil
@@ -143,12 +318,55 @@ func (h *OrderHandler) CancelOrder(ctx context.Context, req *pb.CancelOrderReque
         return nil, status.Error(codes.InvalidArgument, "order ID is required")
     }
     
-    err := h.orderUseCase.CancelOrder(req.Id)
+    err := h.orderUseCase.CancelOrder(req.Id, actorFromContext(ctx), req.Reason)
     if err != nil {
-        return nil, status.Error(codes.Internal, err.Error())
+        return nil, orderError(err)
     }
     
     return &pb.CancelOrderResponse{
         Success: true,
     }, nil
-}
\ No newline at end of file
+}
+
+func (h *OrderHandler) GetOrderHistory(ctx context.Context, req *pb.GetOrderHistoryRequest) (*pb.GetOrderHistoryResponse, error) {
+    if req.Id == "" {
+        return nil, status.Error(codes.InvalidArgument, "order ID is required")
+    }
+    
+    history, err := h.orderUseCase.GetOrderHistory(actorFromContext(ctx), req.Id)
+    if err != nil {
+        return nil, status.Error(codes.NotFound, err.Error())
+    }
+    
+    changes := make([]*pb.OrderStatusChange, 0, len(history))
+    for _, change := range history {
+        changes = append(changes, &pb.OrderStatusChange{
+            Id:         change.ID,
+            OrderId:    change.OrderID,
+            FromStatus: orderStatusToProto[change.FromStatus],
+            ToStatus:   orderStatusToProto[change.ToStatus],
+            ActorId:    change.Actor.ID,
+            ActorRole:  change.Actor.Role,
+            Reason:     change.Reason,
+            ChangedAt:  change.ChangedAt.Format(time.RFC3339),
+        })
+    }
+    
+    return &pb.GetOrderHistoryResponse{
+        Changes: changes,
+    }, nil
+}
+
+func (h *OrderHandler) ListDeliverySlots(ctx context.Context, req *pb.ListDeliverySlotsRequest) (*pb.ListDeliverySlotsResponse, error) {
+    slots, err := h.deliverySlotUseCase.ListAvailableSlots()
+    if err != nil {
+        return nil, status.Error(codes.Internal, err.Error())
+    }
+
+    response := &pb.ListDeliverySlotsResponse{Slots: make([]*pb.DeliverySlot, 0, len(slots))}
+    for _, slot := range slots {
+        response.Slots = append(response.Slots, deliverySlotToProto(slot))
+    }
+
+    return response, nil
+}
@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"AdvProg2/domain"
+	pb "AdvProg2/proto/order"
+)
+
+func actorContext(actor domain.Actor) context.Context {
+	return context.WithValue(context.Background(), actorContextKey{}, actor)
+}
+
+func TestOrderHandler_OrdersOnlyForTheCaller(t *testing.T) {
+	handler := NewOrderHandler(nil, nil)
+	user := actorContext(domain.Actor{ID: "u1", Role: domain.ActorRoleUser})
+	items := []*pb.OrderItemRequest{{ProductId: "p1", Quantity: 1}}
+
+	_, err := handler.CreateOrder(user, &pb.CreateOrderRequest{UserId: "u2", Items: items})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = handler.CreateOrder(context.Background(), &pb.CreateOrderRequest{Items: items})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = handler.GetUserOrders(user, &pb.GetUserOrdersRequest{UserId: "u2"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestOrderUserID_LetsAdminsListOtherUsers(t *testing.T) {
+	admin := actorContext(domain.Actor{ID: "a1", Role: domain.ActorRoleAdmin})
+
+	userID, err := orderUserID(admin, "u2", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "u2", userID)
+
+	_, err = orderUserID(admin, "u2", false)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	userID, err = orderUserID(admin, "", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "a1", userID)
+}
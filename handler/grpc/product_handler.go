@@ -2,62 +2,153 @@ package grpc
 
 import (
     "context"
+    "errors"
     
+    "AdvProg2/domain"
     pb "AdvProg2/proto/product"
+    "AdvProg2/repository"
     "AdvProg2/usecase"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
 )
 
 type ProductHandler struct {
     pb.UnimplementedInventoryServiceServer
-    productUseCase *usecase.ProductUseCase
+    productUseCase  *usecase.ProductUseCase
+    categoryUseCase *usecase.CategoryUseCase
 }
 
-func NewProductHandler(productUseCase *usecase.ProductUseCase) *ProductHandler {
+func NewProductHandler(productUseCase *usecase.ProductUseCase, categoryUseCase *usecase.CategoryUseCase) *ProductHandler {
     return &ProductHandler{
-        productUseCase: productUseCase,
+        productUseCase:  productUseCase,
+        categoryUseCase: categoryUseCase,
+    }
+}
+
+func productMoneyToProto(m domain.Money) *pb.Money {
+    return &pb.Money{
+        Amount:   m.Amount,
+        Currency: m.Currency,
+    }
+}
+
+// productMoneyFromProto treats a missing price as zero in the default currency.
+func productMoneyFromProto(m *pb.Money) domain.Money {
+    return domain.NewMoney(m.GetAmount(), m.GetCurrency())
+}
+
+// priceCurrencyFromProto returns the currency of the price bounds that are
+// set, or "" when neither is.
+func priceCurrencyFromProto(minPrice, maxPrice *pb.Money) string {
+    switch {
+    case minPrice != nil:
+        return productMoneyFromProto(minPrice).Currency
+    case maxPrice != nil:
+        return productMoneyFromProto(maxPrice).Currency
+    default:
+        return ""
+    }
+}
+
+var productSortFieldFromProto = map[pb.ProductSortField]repository.ProductSortField{
+    pb.ProductSortField_PRODUCT_SORT_FIELD_UNSPECIFIED: repository.ProductSortByName,
+    pb.ProductSortField_PRODUCT_SORT_FIELD_NAME:        repository.ProductSortByName,
+    pb.ProductSortField_PRODUCT_SORT_FIELD_PRICE:       repository.ProductSortByPrice,
+    pb.ProductSortField_PRODUCT_SORT_FIELD_STOCK:       repository.ProductSortByStock,
+}
+
+func domainProductToProto(product *domain.Product) *pb.Product {
+    return &pb.Product{
+        Id:               product.ID,
+        Name:             product.Name,
+        Price:            productMoneyToProto(product.Price),
+        Stock:            product.Stock,
+        CategoryId:       product.CategoryID,
+        Tags:             product.Tags,
+        ReorderThreshold: product.ReorderThreshold,
+        Images:           productImagesToProto(product.Images),
+    }
+}
+
+func productImagesToProto(images []domain.ProductImage) []*pb.ProductImage {
+    if len(images) == 0 {
+        return nil
+    }
+    
+    pbImages := make([]*pb.ProductImage, len(images))
+    for i, image := range images {
+        pbImages[i] = &pb.ProductImage{
+            Id:           image.ID,
+            Url:          image.URL,
+            ThumbnailUrl: image.ThumbnailURL,
+            Position:     image.Position,
+        }
+    }
+    return pbImages
+}
+
+func domainCategoryToProto(category *domain.Category) *pb.Category {
+    return &pb.Category{
+        Id:       category.ID,
+        Name:     category.Name,
+        ParentId: category.ParentID,
+    }
+}
+
+// productError reports bad input as an invalid argument and missing or
+// still used categories with their own codes. Other errors pass through.
+func productError(err error) error {
+    switch {
+    case errors.Is(err, domain.ErrUnsupportedCurrency),
+        errors.Is(err, domain.ErrInvalidCategory),
+        errors.Is(err, repository.ErrInvalidProductFilter),
+        errors.Is(err, repository.ErrInvalidPageToken):
+        return status.Error(codes.InvalidArgument, err.Error())
+    case errors.Is(err, repository.ErrCategoryNotFound):
+        return status.Error(codes.NotFound, err.Error())
+    case errors.Is(err, repository.ErrCategoryInUse):
+        return status.Error(codes.FailedPrecondition, err.Error())
+    default:
+        return err
     }
 }
 
 func (h *ProductHandler) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
-    product, err := h.productUseCase.CreateProduct(req.Name, req.Price, req.Stock)
+    product, err := h.productUseCase.CreateProduct(req.Name, productMoneyFromProto(req.Price), req.Stock, req.CategoryId, req.Tags)
     if err != nil {
-        return nil, err
+        return nil, productError(err)
     }
     
-    return &pb.Product{
-        Id:    product.ID,
-        Name:  product.Name,
-        Price: product.Price,
-        Stock: product.Stock,
-    }, nil
+    return domainProductToProto(product), nil
 }
 
 func (h *ProductHandler) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.Product, error) {
-    product, err := h.productUseCase.GetProduct(req.Id)
+    product, err := h.productUseCase.GetProduct(req.Id, req.Currency)
     if err != nil {
-        return nil, err
+        return nil, productError(err)
     }
     
-    return &pb.Product{
-        Id:    product.ID,
-        Name:  product.Name,
-        Price: product.Price,
-        Stock: product.Stock,
-    }, nil
+    return domainProductToProto(product), nil
 }
 
 func (h *ProductHandler) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
-    product, err := h.productUseCase.UpdateProduct(req.Id, req.Name, req.Price, req.Stock)
+    var price *domain.Money
+    if req.Price != nil {
+        money := productMoneyFromProto(req.Price)
+        price = &money
+    }
+    
+    var tags []string
+    if req.Tags != nil {
+        tags = append([]string{}, req.Tags.Tags...)
+    }
+    
+    product, err := h.productUseCase.UpdateProduct(req.Id, req.Name, price, req.Stock, req.CategoryId, tags)
     if err != nil {
-        return nil, err
+        return nil, productError(err)
     }
     
-    return &pb.Product{
-        Id:    product.ID,
-        Name:  product.Name,
-        Price: product.Price,
-        Stock: product.Stock,
-    }, nil
+    return domainProductToProto(product), nil
 }
 
 func (h *ProductHandler) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
@@ -70,23 +161,183 @@ func (h *ProductHandler) DeleteProduct(ctx context.Context, req *pb.DeleteProduc
 }
 
 func (h *ProductHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
-    products, total, err := h.productUseCase.ListProducts(req.Page, req.Limit)
+    filter := repository.ProductFilter{
+        CategoryID: req.CategoryId,
+        Tags:       req.Tags,
+    }
+    
+    // Clients that ask for a page number keep getting numbered pages with
+    // a total; everyone else pages by position.
+    if req.Page > 0 && req.PageToken == "" {
+        products, total, err := h.productUseCase.ListProducts(filter, req.Page, req.Limit, req.Currency)
+        if err != nil {
+            return nil, productError(err)
+        }
+        
+        return &pb.ListProductsResponse{
+            Products: domainProductsToProto(products),
+            Total:    total,
+        }, nil
+    }
+    
+    page, err := h.productUseCase.ListProductsPage(filter, repository.PageRequest{
+        Token:     req.PageToken,
+        Limit:     req.Limit,
+        WithTotal: req.IncludeTotal,
+    }, req.Currency)
     if err != nil {
-        return nil, err
+        return nil, productError(err)
     }
     
+    response := &pb.ListProductsResponse{
+        Products:      domainProductsToProto(page.Items),
+        NextPageToken: page.NextPageToken,
+    }
+    if page.Total != nil {
+        response.Total = *page.Total
+    }
+    
+    return response, nil
+}
+
+func domainProductsToProto(products []*domain.Product) []*pb.Product {
     pbProducts := make([]*pb.Product, len(products))
     for i, product := range products {
-        pbProducts[i] = &pb.Product{
-            Id:    product.ID,
-            Name:  product.Name,
-            Price: product.Price,
-            Stock: product.Stock,
-        }
+        pbProducts[i] = domainProductToProto(product)
+    }
+    return pbProducts
+}
+
+func (h *ProductHandler) SearchProducts(ctx context.Context, req *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
+    sortBy, ok := productSortFieldFromProto[req.SortBy]
+    if !ok {
+        return nil, status.Errorf(codes.InvalidArgument, "unknown sort field %v", req.SortBy)
+    }
+    
+    filter := repository.ProductFilter{
+        Name:       req.Name,
+        MinPrice:      productMoneyFromProto(req.MinPrice),
+        MaxPrice:      productMoneyFromProto(req.MaxPrice),
+        PriceCurrency: priceCurrencyFromProto(req.MinPrice, req.MaxPrice),
+        CategoryID:    req.CategoryId,
+        Tags:          req.Tags,
+        InStock:       req.InStock,
+        SortBy:        sortBy,
+        Descending:    req.SortDirection == pb.SortDirection_SORT_DIRECTION_DESC,
     }
     
-    return &pb.ListProductsResponse{
+    products, total, err := h.productUseCase.SearchProducts(filter, req.Page, req.Limit, req.Currency)
+    if err != nil {
+        return nil, productError(err)
+    }
+    
+    pbProducts := make([]*pb.Product, len(products))
+    for i, product := range products {
+        pbProducts[i] = domainProductToProto(product)
+    }
+    
+    return &pb.SearchProductsResponse{
         Products: pbProducts,
         Total:    total,
     }, nil
-}
\ No newline at end of file
+}
+
+func (h *ProductHandler) FullTextSearch(ctx context.Context, req *pb.FullTextSearchRequest) (*pb.FullTextSearchResponse, error) {
+    filter := repository.ProductFilter{
+        MinPrice:      productMoneyFromProto(req.MinPrice),
+        MaxPrice:      productMoneyFromProto(req.MaxPrice),
+        PriceCurrency: priceCurrencyFromProto(req.MinPrice, req.MaxPrice),
+        CategoryID:    req.CategoryId,
+        Tags:          req.Tags,
+        InStock:       req.InStock,
+    }
+    
+    results, total, err := h.productUseCase.SearchText(req.Query, filter, req.Page, req.Limit, req.Currency)
+    if err != nil {
+        return nil, productError(err)
+    }
+    
+    matches := make([]*pb.ProductMatch, len(results))
+    for i, result := range results {
+        matches[i] = &pb.ProductMatch{
+            Product:   domainProductToProto(result.Product),
+            Rank:      result.Rank,
+            Highlight: result.Highlight,
+        }
+    }
+    
+    return &pb.FullTextSearchResponse{
+        Matches: matches,
+        Total:   total,
+    }, nil
+}
+
+func (h *ProductHandler) SuggestProducts(ctx context.Context, req *pb.SuggestProductsRequest) (*pb.SuggestProductsResponse, error) {
+    suggestions, err := h.productUseCase.SuggestProducts(req.Prefix, req.Limit)
+    if err != nil {
+        return nil, productError(err)
+    }
+    
+    pbSuggestions := make([]*pb.ProductSuggestion, len(suggestions))
+    for i, suggestion := range suggestions {
+        pbSuggestions[i] = &pb.ProductSuggestion{
+            Id:   suggestion.ID,
+            Name: suggestion.Name,
+        }
+    }
+    
+    return &pb.SuggestProductsResponse{
+        Suggestions: pbSuggestions,
+    }, nil
+}
+
+func (h *ProductHandler) CreateCategory(ctx context.Context, req *pb.CreateCategoryRequest) (*pb.Category, error) {
+    category, err := h.categoryUseCase.CreateCategory(req.Name, req.ParentId)
+    if err != nil {
+        return nil, productError(err)
+    }
+    
+    return domainCategoryToProto(category), nil
+}
+
+func (h *ProductHandler) GetCategory(ctx context.Context, req *pb.GetCategoryRequest) (*pb.Category, error) {
+    category, err := h.categoryUseCase.GetCategory(req.Id)
+    if err != nil {
+        return nil, productError(err)
+    }
+    
+    return domainCategoryToProto(category), nil
+}
+
+func (h *ProductHandler) UpdateCategory(ctx context.Context, req *pb.UpdateCategoryRequest) (*pb.Category, error) {
+    category, err := h.categoryUseCase.UpdateCategory(req.Id, req.Name, req.ParentId)
+    if err != nil {
+        return nil, productError(err)
+    }
+    
+    return domainCategoryToProto(category), nil
+}
+
+func (h *ProductHandler) DeleteCategory(ctx context.Context, req *pb.DeleteCategoryRequest) (*pb.DeleteCategoryResponse, error) {
+    if err := h.categoryUseCase.DeleteCategory(req.Id); err != nil {
+        return &pb.DeleteCategoryResponse{Success: false}, productError(err)
+    }
+    
+    return &pb.DeleteCategoryResponse{Success: true}, nil
+}
+
+func (h *ProductHandler) ListCategories(ctx context.Context, req *pb.ListCategoriesRequest) (*pb.ListCategoriesResponse, error) {
+    categories, err := h.categoryUseCase.ListCategories()
+    if err != nil {
+        return nil, productError(err)
+    }
+    
+    pbCategories := make([]*pb.Category, len(categories))
+    for i, category := range categories {
+        pbCategories[i] = domainCategoryToProto(category)
+    }
+    
+    return &pb.ListCategoriesResponse{
+        Categories: pbCategories,
+    }, nil
+}
@@ -2,10 +2,13 @@ package grpc
 
 import (
     "context"
+    "errors"
+    "time"
 
     "google.golang.org/grpc/codes"
     "google.golang.org/grpc/status"
     
+    "AdvProg2/domain"
     "AdvProg2/repository"
     pb "AdvProg2/proto/user"
     "AdvProg2/usecase"
@@ -13,12 +16,14 @@ import (
 
 type UserHandler struct {
     pb.UnimplementedUserServiceServer
-    userUseCase *usecase.UserUseCase
+    userUseCase    *usecase.UserUseCase
+    addressUseCase *usecase.AddressUseCase
 }
 
-func NewUserHandler(userUseCase *usecase.UserUseCase) *UserHandler {
+func NewUserHandler(userUseCase *usecase.UserUseCase, addressUseCase *usecase.AddressUseCase) *UserHandler {
     return &UserHandler{
-        userUseCase: userUseCase,
+        userUseCase:    userUseCase,
+        addressUseCase: addressUseCase,
     }
 }
 
@@ -82,4 +87,137 @@ func (h *UserHandler) GetProfile(ctx context.Context, req *pb.GetProfileRequest)
         Username: user.Username,
         Role:     user.Role,
     }, nil
-}
\ No newline at end of file
+}
+
+// addressError converts address use case errors to gRPC status errors.
+func addressError(err error) error {
+    switch {
+    case errors.Is(err, domain.ErrInvalidAddress):
+        return status.Error(codes.InvalidArgument, err.Error())
+    case errors.Is(err, repository.ErrAddressNotFound):
+        return status.Error(codes.NotFound, err.Error())
+    default:
+        return status.Error(codes.Internal, err.Error())
+    }
+}
+
+// addressUserID returns the caller whose addresses a request is for.
+func addressUserID(ctx context.Context) (string, error) {
+    userID := actorFromContext(ctx).ID
+    if userID == "" {
+        return "", status.Error(codes.Unauthenticated, "user ID is required")
+    }
+    return userID, nil
+}
+
+func addressFromProto(address *pb.Address) *domain.Address {
+    if address == nil {
+        return &domain.Address{}
+    }
+
+    return &domain.Address{
+        Label:      address.Label,
+        Recipient:  address.Recipient,
+        Phone:      address.Phone,
+        Line1:      address.Line1,
+        Line2:      address.Line2,
+        City:       address.City,
+        Region:     address.Region,
+        PostalCode: address.PostalCode,
+        IsDefault:  address.IsDefault,
+    }
+}
+
+func addressToProto(address *domain.Address) *pb.Address {
+    return &pb.Address{
+        Id:         address.ID,
+        Label:      address.Label,
+        Recipient:  address.Recipient,
+        Phone:      address.Phone,
+        Line1:      address.Line1,
+        Line2:      address.Line2,
+        City:       address.City,
+        Region:     address.Region,
+        PostalCode: address.PostalCode,
+        IsDefault:  address.IsDefault,
+        CreatedAt:  address.CreatedAt.Format(time.RFC3339),
+    }
+}
+
+func (h *UserHandler) CreateAddress(ctx context.Context, req *pb.CreateAddressRequest) (*pb.Address, error) {
+    userID, err := addressUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    address, err := h.addressUseCase.CreateAddress(userID, addressFromProto(req.Address))
+    if err != nil {
+        return nil, addressError(err)
+    }
+
+    return addressToProto(address), nil
+}
+
+func (h *UserHandler) GetAddress(ctx context.Context, req *pb.GetAddressRequest) (*pb.Address, error) {
+    userID, err := addressUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    address, err := h.addressUseCase.GetAddress(userID, req.Id)
+    if err != nil {
+        return nil, addressError(err)
+    }
+
+    return addressToProto(address), nil
+}
+
+func (h *UserHandler) UpdateAddress(ctx context.Context, req *pb.UpdateAddressRequest) (*pb.Address, error) {
+    userID, err := addressUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if req.Address == nil || req.Address.Id == "" {
+        return nil, status.Error(codes.InvalidArgument, "address ID is required")
+    }
+
+    address, err := h.addressUseCase.UpdateAddress(userID, req.Address.Id, addressFromProto(req.Address))
+    if err != nil {
+        return nil, addressError(err)
+    }
+
+    return addressToProto(address), nil
+}
+
+func (h *UserHandler) DeleteAddress(ctx context.Context, req *pb.DeleteAddressRequest) (*pb.DeleteAddressResponse, error) {
+    userID, err := addressUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := h.addressUseCase.DeleteAddress(userID, req.Id); err != nil {
+        return nil, addressError(err)
+    }
+
+    return &pb.DeleteAddressResponse{Success: true}, nil
+}
+
+func (h *UserHandler) ListAddresses(ctx context.Context, req *pb.ListAddressesRequest) (*pb.ListAddressesResponse, error) {
+    userID, err := addressUserID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    addresses, err := h.addressUseCase.ListAddresses(userID)
+    if err != nil {
+        return nil, addressError(err)
+    }
+
+    response := &pb.ListAddressesResponse{Addresses: make([]*pb.Address, 0, len(addresses))}
+    for _, address := range addresses {
+        response.Addresses = append(response.Addresses, addressToProto(address))
+    }
+
+    return response, nil
+}
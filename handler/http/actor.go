@@ -0,0 +1,25 @@
+package grpc
+
+import (
+    "net/http"
+
+    "AdvProg2/domain"
+)
+
+// Headers set by the API gateway from the validated JWT. The gateway drops
+// any values sent by the client, so services can trust them.
+const (
+    userIDHeader   = "X-User-ID"
+    userRoleHeader = "X-User-Role"
+)
+
+func actorFromRequest(r *http.Request) domain.Actor {
+    actor := domain.Actor{
+        ID:   r.Header.Get(userIDHeader),
+        Role: r.Header.Get(userRoleHeader),
+    }
+    if actor.Role == "" {
+        actor.Role = domain.ActorRoleUser
+    }
+    return actor
+}
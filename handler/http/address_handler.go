@@ -0,0 +1,144 @@
+package grpc
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "AdvProg2/usecase"
+)
+
+// AddressHTTPHandler serves the caller's saved addresses. The caller is the
+// user the API gateway authenticated.
+type AddressHTTPHandler struct {
+    addressUseCase *usecase.AddressUseCase
+}
+
+func NewAddressHTTPHandler(addressUseCase *usecase.AddressUseCase) *AddressHTTPHandler {
+    return &AddressHTTPHandler{
+        addressUseCase: addressUseCase,
+    }
+}
+
+// addressUserID returns the caller's ID, or writes a 401 and returns ""
+// when the request has none.
+func addressUserID(w http.ResponseWriter, r *http.Request) string {
+    userID := actorFromRequest(r).ID
+    if userID == "" {
+        http.Error(w, "User ID is required", http.StatusUnauthorized)
+    }
+    return userID
+}
+
+func addressErrorStatusCode(err error) int {
+    switch {
+    case errors.Is(err, domain.ErrInvalidAddress):
+        return http.StatusBadRequest
+    case errors.Is(err, repository.ErrAddressNotFound):
+        return http.StatusNotFound
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
+func (h *AddressHTTPHandler) ListAddresses(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := addressUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    addresses, err := h.addressUseCase.ListAddresses(userID)
+    if err != nil {
+        http.Error(w, err.Error(), addressErrorStatusCode(err))
+        return
+    }
+
+    json.NewEncoder(w).Encode(map[string]interface{}{"addresses": addresses})
+}
+
+func (h *AddressHTTPHandler) GetAddress(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := addressUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    address, err := h.addressUseCase.GetAddress(userID, mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, err.Error(), addressErrorStatusCode(err))
+        return
+    }
+
+    json.NewEncoder(w).Encode(address)
+}
+
+func (h *AddressHTTPHandler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := addressUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    var address domain.Address
+    if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    created, err := h.addressUseCase.CreateAddress(userID, &address)
+    if err != nil {
+        http.Error(w, err.Error(), addressErrorStatusCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(created)
+}
+
+// UpdateAddress replaces the address with the body, so fields left out are
+// cleared.
+func (h *AddressHTTPHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := addressUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    var address domain.Address
+    if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    updated, err := h.addressUseCase.UpdateAddress(userID, mux.Vars(r)["id"], &address)
+    if err != nil {
+        http.Error(w, err.Error(), addressErrorStatusCode(err))
+        return
+    }
+
+    json.NewEncoder(w).Encode(updated)
+}
+
+func (h *AddressHTTPHandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := addressUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    if err := h.addressUseCase.DeleteAddress(userID, mux.Vars(r)["id"]); err != nil {
+        http.Error(w, err.Error(), addressErrorStatusCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
@@ -2,24 +2,83 @@ package grpc
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
 	"AdvProg2/domain"
+	"AdvProg2/repository"
 	"AdvProg2/usecase"
 )
 
 type AdminHTTPHandler struct {
-	productUseCase *usecase.ProductUseCase
-	messageUseCase *usecase.MessageUseCase
+	productUseCase      *usecase.ProductUseCase
+	categoryUseCase     *usecase.CategoryUseCase
+	stockUseCase        *usecase.StockUseCase
+	promotionUseCase    *usecase.PromotionUseCase
+	deliverySlotUseCase *usecase.DeliverySlotUseCase
 }
 
-func NewAdminHTTPHandler(productUseCase *usecase.ProductUseCase, messageUseCase *usecase.MessageUseCase) *AdminHTTPHandler {
+func NewAdminHTTPHandler(productUseCase *usecase.ProductUseCase, categoryUseCase *usecase.CategoryUseCase, stockUseCase *usecase.StockUseCase, promotionUseCase *usecase.PromotionUseCase, deliverySlotUseCase *usecase.DeliverySlotUseCase) *AdminHTTPHandler {
 	return &AdminHTTPHandler{
-		productUseCase: productUseCase,
-		messageUseCase: messageUseCase,
+		productUseCase:      productUseCase,
+		categoryUseCase:     categoryUseCase,
+		stockUseCase:        stockUseCase,
+		promotionUseCase:    promotionUseCase,
+		deliverySlotUseCase: deliverySlotUseCase,
+	}
+}
+
+// productErrorStatusCode reports a product placed in an unknown category as
+// a bad request.
+func productErrorStatusCode(err error) int {
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func categoryErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCategory):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrCategoryInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func promotionErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPromotion),
+		errors.Is(err, repository.ErrCategoryNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrPromotionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrPromotionCodeTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func deliverySlotErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidDeliverySlot):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrDeliverySlotNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrDeliverySlotBooked):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
 	}
 }
 
@@ -39,30 +98,18 @@ func (h *AdminHTTPHandler) CreateProduct(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	log.Printf("Admin creating product: %s with price $%.2f and stock %d",
+	log.Printf("Admin creating product: %s with price %s and stock %d",
 		product.Name, product.Price, product.Stock)
 
-	createdProduct, err := h.productUseCase.CreateProduct(product.Name, product.Price, product.Stock)
+	createdProduct, err := h.productUseCase.CreateProduct(product.Name, product.Price, product.Stock, product.CategoryID, product.Tags)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), productErrorStatusCode(err))
 		return
 	}
 
+	// The product.created event is queued in the outbox with the insert
 	log.Printf("Product created successfully with ID: %s", createdProduct.ID)
 
-	// Publish product created event
-	if h.messageUseCase == nil {
-		log.Printf("WARNING: messageUseCase is nil, cannot publish event!")
-	} else {
-		log.Printf("Publishing product.created event for product %s", createdProduct.ID)
-		if err := h.messageUseCase.PublishProductCreatedEvent(createdProduct); err != nil {
-			log.Printf("Warning: Failed to publish product created event: %v", err)
-			// Continue even if publishing fails
-		} else {
-			log.Printf("Successfully published product.created event for product %s", createdProduct.ID)
-		}
-	}
-
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(createdProduct)
 }
@@ -81,37 +128,30 @@ func (h *AdminHTTPHandler) UpdateProduct(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var product domain.Product
+	// A missing category_id or tags leaves them unchanged, while "" and []
+	// clear them.
+	var product struct {
+		domain.Product
+		CategoryID *string `json:"category_id"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
 		log.Printf("Error decoding product update request body: %v", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("Admin updating product ID %s: %s with price $%.2f and stock %d",
+	log.Printf("Admin updating product ID %s: %s with price %s and stock %d",
 		id, product.Name, product.Price, product.Stock)
 
-	updatedProduct, err := h.productUseCase.UpdateProduct(id, product.Name, product.Price, product.Stock)
+	updatedProduct, err := h.productUseCase.UpdateProduct(id, product.Name, &product.Price, product.Stock, product.CategoryID, product.Tags)
 	if err != nil {
 		log.Printf("Failed to update product %s: %v", id, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), productErrorStatusCode(err))
 		return
 	}
 
 	log.Printf("Product %s updated successfully in database", id)
 
-	// Publish product updated event
-	if h.messageUseCase == nil {
-		log.Printf("WARNING: messageUseCase is nil, cannot publish event for product update!")
-	} else {
-		log.Printf("Preparing to publish product.updated event for product %s", updatedProduct.ID)
-		if err := h.messageUseCase.PublishProductUpdatedEvent(updatedProduct); err != nil {
-			log.Printf("Warning: Failed to publish product updated event: %v", err)
-		} else {
-			log.Printf("Successfully published product.updated event for product %s", updatedProduct.ID)
-		}
-	}
-
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updatedProduct)
 }
@@ -132,15 +172,14 @@ func (h *AdminHTTPHandler) DeleteProduct(w http.ResponseWriter, r *http.Request)
 
 	log.Printf("Admin requesting deletion of product ID: %s", id)
 
-	// Get product before deletion for event publishing
-	product, err := h.productUseCase.GetProduct(id)
+	product, err := h.productUseCase.GetProduct(id, "")
 	if err != nil {
 		log.Printf("Failed to retrieve product %s for deletion: %v", id, err)
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	log.Printf("Found product to delete: %s (%s) with price $%.2f and stock %d",
+	log.Printf("Found product to delete: %s (%s) with price %s and stock %d",
 		id, product.Name, product.Price, product.Stock)
 
 	err = h.productUseCase.DeleteProduct(id)
@@ -152,17 +191,523 @@ func (h *AdminHTTPHandler) DeleteProduct(w http.ResponseWriter, r *http.Request)
 
 	log.Printf("Product %s deleted successfully from database", id)
 
-	// Publish product deleted event
-	if h.messageUseCase == nil {
-		log.Printf("WARNING: messageUseCase is nil, cannot publish event for product deletion!")
-	} else {
-		log.Printf("Preparing to publish product.deleted event for product %s", id)
-		if err := h.messageUseCase.PublishProductDeletedEvent(id); err != nil {
-			log.Printf("Warning: Failed to publish product deleted event: %v", err)
-		} else {
-			log.Printf("Successfully published product.deleted event for product %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxProductImportSize caps the body of a catalog import.
+const maxProductImportSize = 50 << 20
+
+// productImportFormat takes the format from the format query parameter, or
+// else from the Content-Type of the upload.
+func productImportFormat(r *http.Request) usecase.ProductFormat {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return usecase.ProductFormat(format)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return usecase.ProductFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return usecase.ProductFormatJSONLines
+	}
+	return ""
+}
+
+// ImportProducts upserts the products in a CSV or JSON Lines body and
+// responds with a report of what happened to each row.
+func (h *AdminHTTPHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	format := productImportFormat(r)
+	log.Printf("Admin importing products as %q", format)
+
+	report, err := h.productUseCase.ImportProducts(http.MaxBytesReader(w, r.Body, maxProductImportSize), format)
+	if err != nil {
+		log.Printf("Failed to import products: %v", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidProductImport) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	log.Printf("Product import finished: %d created, %d updated, %d unchanged, %d failed",
+		report.Created, report.Updated, report.Unchanged, report.Failed)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// ExportProducts streams the whole catalog as CSV, or as JSON Lines with
+// format=jsonl.
+func (h *AdminHTTPHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	format := usecase.ProductFormat(r.URL.Query().Get("format"))
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case "", usecase.ProductFormatCSV:
+		format = usecase.ProductFormatCSV
+	case usecase.ProductFormatJSONLines:
+		contentType = "application/x-ndjson"
+	default:
+		http.Error(w, "format must be csv or jsonl", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="products.`+string(format)+`"`)
+
+	// The status is sent with the first page, so a later failure can only
+	// cut the download short.
+	if err := h.productUseCase.ExportProducts(w, format); err != nil {
+		log.Printf("Product export failed: %v", err)
+	}
+}
+
+// ListStockMovements returns a page of the product's stock movements,
+// newest first. Pass the returned next_page_token as page_token for the
+// next page.
+func (h *AdminHTTPHandler) ListStockMovements(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	includeTotal, _ := strconv.ParseBool(r.URL.Query().Get("include_total"))
+
+	result, err := h.stockUseCase.ListMovements(id, repository.PageRequest{
+		Token:     r.URL.Query().Get("page_token"),
+		Limit:     int32(limit),
+		WithTotal: includeTotal,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, repository.ErrInvalidPageToken):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	response := map[string]interface{}{
+		"movements":       result.Items,
+		"next_page_token": result.NextPageToken,
+	}
+	if result.Total != nil {
+		response["total"] = *result.Total
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetReorderThreshold sets the stock below which the product is reported
+// as low on stock. A threshold of 0 turns the alerts off.
+func (h *AdminHTTPHandler) SetReorderThreshold(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	var req struct {
+		ReorderThreshold *int32 `json:"reorder_threshold"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ReorderThreshold == nil {
+		http.Error(w, "Invalid request body: reorder_threshold is required", http.StatusBadRequest)
+		return
+	}
+
+	if *req.ReorderThreshold < 0 {
+		http.Error(w, "reorder_threshold cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.productUseCase.SetReorderThreshold(id, *req.ReorderThreshold)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrProductNotFound) {
+			status = http.StatusNotFound
 		}
+		http.Error(w, err.Error(), status)
+		return
 	}
 
+	log.Printf("Reorder threshold of product %s set to %d", id, product.ReorderThreshold)
+
+	json.NewEncoder(w).Encode(product)
+}
+
+// GetLowStockProducts lists the products whose stock is below their reorder
+// threshold, those furthest below it first.
+func (h *AdminHTTPHandler) GetLowStockProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	products, err := h.stockUseCase.ListLowStock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"products": products})
+}
+
+// GetStockDiscrepancies lists the products whose stock does not match the
+// sum of their stock movements.
+func (h *AdminHTTPHandler) GetStockDiscrepancies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	discrepancies, err := h.stockUseCase.FindDiscrepancies()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"discrepancies": discrepancies})
+}
+
+// ReconcileStock records movements that bring the ledger back in line with
+// the products' stock and lists the products it corrected.
+func (h *AdminHTTPHandler) ReconcileStock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	discrepancies, err := h.stockUseCase.Reconcile()
+	if err != nil {
+		log.Printf("Failed to reconcile stock: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Stock reconciled for %d products", len(discrepancies))
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"reconciled": discrepancies})
+}
+
+func (h *AdminHTTPHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	var category domain.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	createdCategory, err := h.categoryUseCase.CreateCategory(category.Name, category.ParentID)
+	if err != nil {
+		log.Printf("Failed to create category %s: %v", category.Name, err)
+		http.Error(w, err.Error(), categoryErrorStatusCode(err))
+		return
+	}
+
+	log.Printf("Category created successfully with ID: %s", createdCategory.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdCategory)
+}
+
+func (h *AdminHTTPHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	// A missing parent_id leaves the parent unchanged, while "" moves the
+	// category to the top level.
+	var category struct {
+		Name     string  `json:"name"`
+		ParentID *string `json:"parent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updatedCategory, err := h.categoryUseCase.UpdateCategory(id, category.Name, category.ParentID)
+	if err != nil {
+		log.Printf("Failed to update category %s: %v", id, err)
+		http.Error(w, err.Error(), categoryErrorStatusCode(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updatedCategory)
+}
+
+func (h *AdminHTTPHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	if err := h.categoryUseCase.DeleteCategory(id); err != nil {
+		log.Printf("Failed to delete category %s: %v", id, err)
+		http.Error(w, err.Error(), categoryErrorStatusCode(err))
+		return
+	}
+
+	log.Printf("Category %s deleted successfully", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHTTPHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	promotions, err := h.promotionUseCase.ListPromotions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"promotions": promotions})
+}
+
+func (h *AdminHTTPHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	promotion, err := h.promotionUseCase.GetPromotion(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), promotionErrorStatusCode(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(promotion)
+}
+
+func (h *AdminHTTPHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	var promotion domain.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	createdPromotion, err := h.promotionUseCase.CreatePromotion(&promotion)
+	if err != nil {
+		log.Printf("Failed to create promotion %s: %v", promotion.Name, err)
+		http.Error(w, err.Error(), promotionErrorStatusCode(err))
+		return
+	}
+
+	log.Printf("Promotion created successfully with ID: %s", createdPromotion.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdPromotion)
+}
+
+// UpdatePromotion replaces every setting of the promotion with the body, so
+// fields left out are cleared.
+func (h *AdminHTTPHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	var promotion domain.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updatedPromotion, err := h.promotionUseCase.UpdatePromotion(id, &promotion)
+	if err != nil {
+		log.Printf("Failed to update promotion %s: %v", id, err)
+		http.Error(w, err.Error(), promotionErrorStatusCode(err))
+		return
+	}
+
+	json.NewEncoder(w).Encode(updatedPromotion)
+}
+
+func (h *AdminHTTPHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	if err := h.promotionUseCase.DeletePromotion(id); err != nil {
+		log.Printf("Failed to delete promotion %s: %v", id, err)
+		http.Error(w, err.Error(), promotionErrorStatusCode(err))
+		return
+	}
+
+	log.Printf("Promotion %s deleted successfully", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliverySlots returns every delivery slot that has not started yet,
+// with how many orders booked each.
+func (h *AdminHTTPHandler) ListDeliverySlots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	slots, err := h.deliverySlotUseCase.ListSlots()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"slots": slots})
+}
+
+func (h *AdminHTTPHandler) CreateDeliverySlot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	var slot domain.DeliverySlot
+	if err := json.NewDecoder(r.Body).Decode(&slot); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	createdSlot, err := h.deliverySlotUseCase.CreateSlot(&slot)
+	if err != nil {
+		log.Printf("Failed to create delivery slot: %v", err)
+		http.Error(w, err.Error(), deliverySlotErrorStatusCode(err))
+		return
+	}
+
+	log.Printf("Delivery slot created successfully with ID: %s", createdSlot.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdSlot)
+}
+
+// DeleteDeliverySlot removes a delivery slot no order has booked.
+func (h *AdminHTTPHandler) DeleteDeliverySlot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Check for admin role
+	userRole := r.Header.Get("X-User-Role")
+	if userRole != "admin" {
+		http.Error(w, "Unauthorized: admin role required", http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	if err := h.deliverySlotUseCase.DeleteSlot(id); err != nil {
+		log.Printf("Failed to delete delivery slot %s: %v", id, err)
+		http.Error(w, err.Error(), deliverySlotErrorStatusCode(err))
+		return
+	}
+
+	log.Printf("Delivery slot %s deleted successfully", id)
+
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,194 @@
+package grpc
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "AdvProg2/usecase"
+)
+
+// CartHTTPHandler serves the caller's cart. The caller is the user the API
+// gateway authenticated.
+type CartHTTPHandler struct {
+    cartUseCase *usecase.CartUseCase
+}
+
+func NewCartHTTPHandler(cartUseCase *usecase.CartUseCase) *CartHTTPHandler {
+    return &CartHTTPHandler{
+        cartUseCase: cartUseCase,
+    }
+}
+
+// cartUserID returns the caller's ID, or writes a 401 and returns "" when
+// the request has none.
+func cartUserID(w http.ResponseWriter, r *http.Request) string {
+    userID := actorFromRequest(r).ID
+    if userID == "" {
+        http.Error(w, "User ID is required", http.StatusUnauthorized)
+    }
+    return userID
+}
+
+func writeCart(w http.ResponseWriter, cart *domain.Cart, err error) {
+    if err != nil {
+        http.Error(w, err.Error(), cartErrorStatusCode(err))
+        return
+    }
+
+    json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHTTPHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := cartUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    cart, err := h.cartUseCase.GetCart(userID)
+    writeCart(w, cart, err)
+}
+
+func (h *CartHTTPHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := cartUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    var req struct {
+        ProductID string `json:"product_id"`
+        Quantity  int32  `json:"quantity"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if req.ProductID == "" {
+        http.Error(w, "Product ID is required", http.StatusBadRequest)
+        return
+    }
+
+    cart, err := h.cartUseCase.AddItem(userID, req.ProductID, req.Quantity)
+    writeCart(w, cart, err)
+}
+
+func (h *CartHTTPHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := cartUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    var req struct {
+        Quantity int32 `json:"quantity"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    cart, err := h.cartUseCase.UpdateItem(userID, mux.Vars(r)["productId"], req.Quantity)
+    writeCart(w, cart, err)
+}
+
+func (h *CartHTTPHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := cartUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    cart, err := h.cartUseCase.RemoveItem(userID, mux.Vars(r)["productId"])
+    writeCart(w, cart, err)
+}
+
+func (h *CartHTTPHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
+    userID := cartUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    if err := h.cartUseCase.ClearCart(userID); err != nil {
+        http.Error(w, err.Error(), cartErrorStatusCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHTTPHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := cartUserID(w, r)
+    if userID == "" {
+        return
+    }
+
+    // The body is optional and only chooses the currency, coupon, tax
+    // region and delivery.
+    var req struct {
+        Currency       string `json:"currency"`
+        CouponCode     string `json:"coupon_code"`
+        Region         string `json:"region"`
+        AddressID      string `json:"address_id"`
+        DeliverySlotID string `json:"delivery_slot_id"`
+    }
+    if r.ContentLength != 0 {
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            http.Error(w, "Invalid request body", http.StatusBadRequest)
+            return
+        }
+    }
+
+    order, err := h.cartUseCase.Checkout(userID, usecase.OrderOptions{
+        Currency:       req.Currency,
+        CouponCode:     req.CouponCode,
+        Region:         req.Region,
+        AddressID:      req.AddressID,
+        DeliverySlotID: req.DeliverySlotID,
+    })
+    if err != nil {
+        http.Error(w, err.Error(), cartErrorStatusCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "order_id":       order.ID,
+        "status":         order.Status,
+        "total_price":    order.TotalPrice,
+        "subtotal":       order.Subtotal,
+        "discount_total": order.DiscountTotal,
+        "tax_total":      order.TaxTotal,
+        "delivery_fee":   order.DeliveryFee,
+        "message":        "Order created successfully",
+    })
+}
+
+// cartErrorStatusCode maps cart use case errors to HTTP status codes.
+func cartErrorStatusCode(err error) int {
+    switch {
+    case errors.Is(err, domain.ErrInvalidCartQuantity),
+        errors.Is(err, domain.ErrCouponNotApplicable):
+        return http.StatusBadRequest
+    case errors.Is(err, repository.ErrProductNotFound),
+        errors.Is(err, repository.ErrCartItemNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, domain.ErrEmptyCart),
+        errors.Is(err, domain.ErrCartNotOrderable),
+        errors.Is(err, repository.ErrInsufficientStock):
+        return http.StatusConflict
+    default:
+        return orderErrorStatusCode(err)
+    }
+}
@@ -2,20 +2,25 @@ package grpc
 
 import (
     "encoding/json"
+    "errors"
     "net/http"
     "strconv"
     
     "github.com/gorilla/mux"
+    "AdvProg2/domain"
+    "AdvProg2/repository"
     "AdvProg2/usecase"
 )
 
 type OrderHTTPHandler struct {
-    orderUseCase *usecase.OrderUseCase
+    orderUseCase        *usecase.OrderUseCase
+    deliverySlotUseCase *usecase.DeliverySlotUseCase
 }
 
-func NewOrderHTTPHandler(orderUseCase *usecase.OrderUseCase) *OrderHTTPHandler {
+func NewOrderHTTPHandler(orderUseCase *usecase.OrderUseCase, deliverySlotUseCase *usecase.DeliverySlotUseCase) *OrderHTTPHandler {
     return &OrderHTTPHandler{
-        orderUseCase: orderUseCase,
+        orderUseCase:        orderUseCase,
+        deliverySlotUseCase: deliverySlotUseCase,
     }
 }
 
@@ -28,8 +33,12 @@ func (h *OrderHTTPHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
     }
     
     type CreateOrderRequest struct {
-        UserID string            `json:"user_id"`
-        Items  []OrderItemRequest `json:"items"`
+        Items          []OrderItemRequest `json:"items"`
+        Currency       string             `json:"currency"`
+        CouponCode     string             `json:"coupon_code"`
+        Region         string             `json:"region"`
+        AddressID      string             `json:"address_id"`
+        DeliverySlotID string             `json:"delivery_slot_id"`
     }
     
     var req CreateOrderRequest
@@ -38,8 +47,11 @@ func (h *OrderHTTPHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    if req.UserID == "" {
-        http.Error(w, "User ID is required", http.StatusBadRequest)
+    // Orders are always placed for the caller, so saved addresses and
+    // per-user coupon limits are checked against the signed-in user.
+    userID := actorFromRequest(r).ID
+    if userID == "" {
+        http.Error(w, "User ID is required", http.StatusUnauthorized)
         return
     }
     
@@ -56,17 +68,28 @@ func (h *OrderHTTPHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
         })
     }
     
-    order, err := h.orderUseCase.CreateOrder(req.UserID, orderItems)
+    order, err := h.orderUseCase.CreateOrder(userID, orderItems, usecase.OrderOptions{
+        Currency:       req.Currency,
+        CouponCode:     req.CouponCode,
+        Region:         req.Region,
+        AddressID:      req.AddressID,
+        DeliverySlotID: req.DeliverySlotID,
+    })
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), orderErrorStatusCode(err))
         return
     }
     
     w.WriteHeader(http.StatusCreated)
     json.NewEncoder(w).Encode(map[string]interface{}{
-        "order_id": order.ID,
-        "status":   order.Status,
-        "message":  "Order created successfully",
+        "order_id":       order.ID,
+        "status":         order.Status,
+        "total_price":    order.TotalPrice,
+        "subtotal":       order.Subtotal,
+        "discount_total": order.DiscountTotal,
+        "tax_total":      order.TaxTotal,
+        "delivery_fee":   order.DeliveryFee,
+        "message":        "Order created successfully",
     })
 }
 
@@ -109,17 +132,50 @@ func (h *OrderHTTPHandler) GetUserOrders(w http.ResponseWriter, r *http.Request)
         }
     }
     
-    orders, total, err := h.orderUseCase.GetUserOrders(userID, page, limit)
+    // Requests with a page number keep getting numbered pages with a total,
+    // the rest are paged by position with page_token.
+    pageToken := r.URL.Query().Get("page_token")
+    if pageToken == "" && r.URL.Query().Has("page") {
+        orders, total, err := h.orderUseCase.GetUserOrders(userID, page, limit)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        
+        response := map[string]interface{}{
+            "orders": orders,
+            "total":  total,
+            "page":   page,
+            "limit":  limit,
+        }
+        
+        json.NewEncoder(w).Encode(response)
+        return
+    }
+    
+    includeTotal, _ := strconv.ParseBool(r.URL.Query().Get("include_total"))
+    
+    result, err := h.orderUseCase.GetUserOrdersPage(userID, repository.PageRequest{
+        Token:     pageToken,
+        Limit:     limit,
+        WithTotal: includeTotal,
+    })
+    if errors.Is(err, repository.ErrInvalidPageToken) {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
     if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
     
     response := map[string]interface{}{
-        "orders": orders,
-        "total":  total,
-        "page":   page,
-        "limit":  limit,
+        "orders":          result.Items,
+        "next_page_token": result.NextPageToken,
+        "limit":           limit,
+    }
+    if result.Total != nil {
+        response["total"] = *result.Total
     }
     
     json.NewEncoder(w).Encode(response)
@@ -133,6 +189,7 @@ func (h *OrderHTTPHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Requ
     
     type UpdateStatusRequest struct {
         Status string `json:"status"`
+        Reason string `json:"reason"`
     }
     
     var req UpdateStatusRequest
@@ -146,9 +203,9 @@ func (h *OrderHTTPHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Requ
         return
     }
     
-    order, err := h.orderUseCase.UpdateOrderStatus(id, req.Status)
+    order, err := h.orderUseCase.UpdateOrderStatus(id, domain.OrderStatus(req.Status), actorFromRequest(r), req.Reason)
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), orderErrorStatusCode(err))
         return
     }
     
@@ -159,9 +216,9 @@ func (h *OrderHTTPHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id := vars["id"]
     
-    err := h.orderUseCase.CancelOrder(id)
+    err := h.orderUseCase.CancelOrder(id, actorFromRequest(r), r.URL.Query().Get("reason"))
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), orderErrorStatusCode(err))
         return
     }
     
@@ -170,4 +227,61 @@ func (h *OrderHTTPHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
         "success": true,
         "message": "Order cancelled successfully",
     })
-}
\ No newline at end of file
+}
+
+func (h *OrderHTTPHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    
+    vars := mux.Vars(r)
+    id := vars["id"]
+    
+    history, err := h.orderUseCase.GetOrderHistory(actorFromRequest(r), id)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "order_id": id,
+        "history":  history,
+    })
+}
+
+// ListDeliverySlots returns the delivery slots new orders can book.
+func (h *OrderHTTPHandler) ListDeliverySlots(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    slots, err := h.deliverySlotUseCase.ListAvailableSlots()
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "slots": slots,
+    })
+}
+
+// orderErrorStatusCode maps order use case errors to HTTP status codes.
+func orderErrorStatusCode(err error) int {
+    switch {
+    case errors.Is(err, domain.ErrInvalidOrderStatus),
+        errors.Is(err, domain.ErrUnsupportedCurrency),
+        errors.Is(err, domain.ErrCouponNotApplicable),
+        errors.Is(err, domain.ErrInvalidDeliverySlot):
+        return http.StatusBadRequest
+    case errors.Is(err, domain.ErrStatusTransitionForbidden):
+        return http.StatusForbidden
+    case errors.Is(err, repository.ErrAddressNotFound),
+        errors.Is(err, repository.ErrDeliverySlotNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, domain.ErrIllegalStatusTransition),
+        errors.Is(err, repository.ErrOrderStatusChanged),
+        errors.Is(err, domain.ErrNotDeliverable),
+        errors.Is(err, domain.ErrDeliverySlotClosed),
+        errors.Is(err, repository.ErrDeliverySlotFull):
+        return http.StatusConflict
+    default:
+        return http.StatusInternalServerError
+    }
+}
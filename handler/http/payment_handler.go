@@ -0,0 +1,164 @@
+package grpc
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "AdvProg2/usecase"
+)
+
+// paymentSignatureHeader carries the provider's signature of a webhook body.
+const paymentSignatureHeader = "X-Payment-Signature"
+
+// maxWebhookSize bounds the webhook bodies read into memory.
+const maxWebhookSize = 1 << 20
+
+// WebhookSimulator builds the webhook calls a payment provider would make.
+// Only providers for development have one.
+type WebhookSimulator interface {
+    Webhook(ref string, status domain.PaymentStatus, failureReason string) ([]byte, string, error)
+}
+
+type PaymentHTTPHandler struct {
+    paymentUseCase *usecase.PaymentUseCase
+    simulator      WebhookSimulator
+}
+
+// NewPaymentHTTPHandler creates the payment handler. simulator may be nil,
+// in which case SimulatePayment is not available.
+func NewPaymentHTTPHandler(paymentUseCase *usecase.PaymentUseCase, simulator WebhookSimulator) *PaymentHTTPHandler {
+    return &PaymentHTTPHandler{
+        paymentUseCase: paymentUseCase,
+        simulator:      simulator,
+    }
+}
+
+func paymentErrorStatusCode(err error) int {
+    switch {
+    case errors.Is(err, domain.ErrInvalidPaymentWebhook):
+        return http.StatusBadRequest
+    case errors.Is(err, repository.ErrOrderNotFound),
+        errors.Is(err, repository.ErrPaymentNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, domain.ErrOrderNotPayable),
+        errors.Is(err, domain.ErrOrderAlreadyPaid):
+        return http.StatusConflict
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
+// CreatePayment starts paying for one of the caller's orders and returns the
+// payment intent, whose client secret the client completes the payment with.
+func (h *PaymentHTTPHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    userID := actorFromRequest(r).ID
+    if userID == "" {
+        http.Error(w, "User ID is required", http.StatusUnauthorized)
+        return
+    }
+
+    var req struct {
+        OrderID string `json:"order_id"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.OrderID == "" {
+        http.Error(w, "Order ID is required", http.StatusBadRequest)
+        return
+    }
+
+    intent, err := h.paymentUseCase.CreatePayment(userID, req.OrderID)
+    if err != nil {
+        http.Error(w, err.Error(), paymentErrorStatusCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(intent)
+}
+
+func (h *PaymentHTTPHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    intent, err := h.paymentUseCase.GetPayment(actorFromRequest(r), mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, err.Error(), paymentErrorStatusCode(err))
+        return
+    }
+
+    json.NewEncoder(w).Encode(intent)
+}
+
+// Webhook receives the payment provider's calls. They are not made on behalf
+// of a user; the provider's signature of the body is checked instead.
+func (h *PaymentHTTPHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+    payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookSize))
+    if err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if err := h.paymentUseCase.HandleWebhook(payload, r.Header.Get(paymentSignatureHeader)); err != nil {
+        http.Error(w, err.Error(), paymentErrorStatusCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// SimulatePayment completes one of the caller's payments the way the
+// provider would, by sending the webhook for the requested outcome:
+// "succeeded", the default, or "failed".
+func (h *PaymentHTTPHandler) SimulatePayment(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+
+    if h.simulator == nil {
+        http.Error(w, "Payment simulation is not available", http.StatusNotFound)
+        return
+    }
+
+    var req struct {
+        Outcome       domain.PaymentStatus `json:"outcome"`
+        FailureReason string               `json:"failure_reason"`
+    }
+    if r.ContentLength != 0 {
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            http.Error(w, "Invalid request body", http.StatusBadRequest)
+            return
+        }
+    }
+    if req.Outcome == "" {
+        req.Outcome = domain.PaymentStatusSucceeded
+    }
+
+    actor := actorFromRequest(r)
+    intent, err := h.paymentUseCase.GetPayment(actor, mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, err.Error(), paymentErrorStatusCode(err))
+        return
+    }
+
+    payload, signature, err := h.simulator.Webhook(intent.ProviderRef, req.Outcome, req.FailureReason)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    if err := h.paymentUseCase.HandleWebhook(payload, signature); err != nil {
+        http.Error(w, err.Error(), paymentErrorStatusCode(err))
+        return
+    }
+
+    intent, err = h.paymentUseCase.GetPayment(actor, intent.ID)
+    if err != nil {
+        http.Error(w, err.Error(), paymentErrorStatusCode(err))
+        return
+    }
+
+    json.NewEncoder(w).Encode(intent)
+}
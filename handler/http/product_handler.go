@@ -2,8 +2,11 @@ package grpc
 
 import (
     "context"
+    "errors"
     
+    "AdvProg2/domain"
     pb "AdvProg2/proto/product"
+    "AdvProg2/repository"
     "AdvProg2/usecase"
     "google.golang.org/grpc/codes"
     "google.golang.org/grpc/status"
@@ -20,27 +23,60 @@ func NewProductHandler(productUseCase *usecase.ProductUseCase) *ProductHandler {
     }
 }
 
+func productMoneyToProto(m domain.Money) *pb.Money {
+    return &pb.Money{
+        Amount:   m.Amount,
+        Currency: m.Currency,
+    }
+}
+
+func productImagesToProto(images []domain.ProductImage) []*pb.ProductImage {
+    if len(images) == 0 {
+        return nil
+    }
+    
+    pbImages := make([]*pb.ProductImage, len(images))
+    for i, image := range images {
+        pbImages[i] = &pb.ProductImage{
+            Id:           image.ID,
+            Url:          image.URL,
+            ThumbnailUrl: image.ThumbnailURL,
+            Position:     image.Position,
+        }
+    }
+    return pbImages
+}
+
+// productMoneyFromProto treats a missing price as zero in the default currency.
+func productMoneyFromProto(m *pb.Money) domain.Money {
+    return domain.NewMoney(m.GetAmount(), m.GetCurrency())
+}
+
 func (h *ProductHandler) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
     if req.Name == "" {
         return nil, status.Error(codes.InvalidArgument, "product name is required")
     }
-    if req.Price < 0 {
+    if req.Price.GetAmount() < 0 {
         return nil, status.Error(codes.InvalidArgument, "product price cannot be negative")
     }
     if req.Stock < 0 {
         return nil, status.Error(codes.InvalidArgument, "product stock cannot be negative")
     }
     
-    product, err := h.productUseCase.CreateProduct(req.Name, req.Price, req.Stock)
+    product, err := h.productUseCase.CreateProduct(req.Name, productMoneyFromProto(req.Price), req.Stock, req.CategoryId, req.Tags)
     if err != nil {
         return nil, status.Error(codes.Internal, err.Error())
     }
     
     return &pb.Product{
-        Id:    product.ID,
-        Name:  product.Name,
-        Price: product.Price,
-        Stock: product.Stock,
+        Id:               product.ID,
+        Name:             product.Name,
+        Price:            productMoneyToProto(product.Price),
+        Stock:            product.Stock,
+        CategoryId:       product.CategoryID,
+        Tags:             product.Tags,
+        ReorderThreshold: product.ReorderThreshold,
+        Images:           productImagesToProto(product.Images),
     }, nil
 }
 
@@ -49,16 +85,23 @@ func (h *ProductHandler) GetProduct(ctx context.Context, req *pb.GetProductReque
         return nil, status.Error(codes.InvalidArgument, "product ID is required")
     }
     
-    product, err := h.productUseCase.GetProduct(req.Id)
+    product, err := h.productUseCase.GetProduct(req.Id, req.Currency)
+    if errors.Is(err, domain.ErrUnsupportedCurrency) {
+        return nil, status.Error(codes.InvalidArgument, err.Error())
+    }
     if err != nil {
         return nil, status.Error(codes.NotFound, err.Error())
     }
     
     return &pb.Product{
-        Id:    product.ID,
-        Name:  product.Name,
-        Price: product.Price,
-        Stock: product.Stock,
+        Id:               product.ID,
+        Name:             product.Name,
+        Price:            productMoneyToProto(product.Price),
+        Stock:            product.Stock,
+        CategoryId:       product.CategoryID,
+        Tags:             product.Tags,
+        ReorderThreshold: product.ReorderThreshold,
+        Images:           productImagesToProto(product.Images),
     }, nil
 }
 
@@ -67,16 +110,31 @@ func (h *ProductHandler) UpdateProduct(ctx context.Context, req *pb.UpdateProduc
         return nil, status.Error(codes.InvalidArgument, "product ID is required")
     }
     
-    product, err := h.productUseCase.UpdateProduct(req.Id, req.Name, req.Price, req.Stock)
+    var price *domain.Money
+    if req.Price != nil {
+        money := productMoneyFromProto(req.Price)
+        price = &money
+    }
+    
+    var tags []string
+    if req.Tags != nil {
+        tags = append([]string{}, req.Tags.Tags...)
+    }
+    
+    product, err := h.productUseCase.UpdateProduct(req.Id, req.Name, price, req.Stock, req.CategoryId, tags)
     if err != nil {
         return nil, status.Error(codes.Internal, err.Error())
     }
     
     return &pb.Product{
-        Id:    product.ID,
-        Name:  product.Name,
-        Price: product.Price,
-        Stock: product.Stock,
+        Id:               product.ID,
+        Name:             product.Name,
+        Price:            productMoneyToProto(product.Price),
+        Stock:            product.Stock,
+        CategoryId:       product.CategoryID,
+        Tags:             product.Tags,
+        ReorderThreshold: product.ReorderThreshold,
+        Images:           productImagesToProto(product.Images),
     }, nil
 }
 
@@ -94,7 +152,15 @@ func (h *ProductHandler) DeleteProduct(ctx context.Context, req *pb.DeleteProduc
 }
 
 func (h *ProductHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
-    products, total, err := h.productUseCase.ListProducts(req.Page, req.Limit)
+    filter := repository.ProductFilter{
+        CategoryID: req.CategoryId,
+        Tags:       req.Tags,
+    }
+    
+    products, total, err := h.productUseCase.ListProducts(filter, req.Page, req.Limit, req.Currency)
+    if errors.Is(err, domain.ErrUnsupportedCurrency) {
+        return nil, status.Error(codes.InvalidArgument, err.Error())
+    }
     if err != nil {
         return nil, status.Error(codes.Internal, err.Error())
     }
@@ -102,10 +168,14 @@ func (h *ProductHandler) ListProducts(ctx context.Context, req *pb.ListProductsR
     pbProducts := make([]*pb.Product, len(products))
     for i, product := range products {
         pbProducts[i] = &pb.Product{
-            Id:    product.ID,
-            Name:  product.Name,
-            Price: product.Price,
-            Stock: product.Stock,
+            Id:               product.ID,
+            Name:             product.Name,
+            Price:            productMoneyToProto(product.Price),
+            Stock:            product.Stock,
+            CategoryId:       product.CategoryID,
+            Tags:             product.Tags,
+            ReorderThreshold: product.ReorderThreshold,
+            Images:           productImagesToProto(product.Images),
         }
     }
     
@@ -0,0 +1,44 @@
+// Package blob stores uploaded files on the local filesystem or in an
+// S3-compatible bucket.
+package blob
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"AdvProg2/repository"
+)
+
+// NewStoreFromEnv picks the store named by BLOB_STORE. "local", the default,
+// writes to BLOB_LOCAL_DIR and serves files under BLOB_BASE_URL; "s3" uses
+// the bucket described by the S3_* variables.
+func NewStoreFromEnv() (repository.BlobStore, error) {
+	switch kind := os.Getenv("BLOB_STORE"); kind {
+	case "", "local":
+		dir := getEnv("BLOB_LOCAL_DIR", "data/media")
+		baseURL := getEnv("BLOB_BASE_URL", "/media")
+		log.Printf("Storing uploads in %s, served from %s", dir, baseURL)
+		return NewLocalStore(dir, baseURL)
+	case "s3":
+		config := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		}
+		log.Printf("Storing uploads in bucket %s at %s", config.Bucket, config.Endpoint)
+		return NewS3Store(config)
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", kind)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,38 @@
+package blob
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore_PutServeAndDelete(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/media/")
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Put("products/p1/a.png", "image/png", []byte("png")))
+	assert.Equal(t, "/media/products/p1/a.png", store.URL("products/p1/a.png"))
+
+	server := httptest.NewServer(http.StripPrefix("/media", store.Handler()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/media/products/p1/a.png")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "png", string(body))
+
+	resp, err = http.Get(server.URL + "/media/products/p1/")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	assert.NoError(t, store.Delete("products/p1/a.png"))
+	assert.NoError(t, store.Delete("products/p1/a.png"))
+
+	assert.Error(t, store.Put("../outside.png", "image/png", []byte("png")))
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+    "encoding/base64"
+    "encoding/json"
+
+    "AdvProg2/repository"
+)
+
+// pageCursor is the position after the last row of a page. Clients get it
+// as an opaque, URL-safe page token.
+type pageCursor struct {
+    // Sort names the order of the listing the token was issued for, since
+    // the position means nothing in any other order.
+    Sort  string          `json:"s"`
+    Value json.RawMessage `json:"v"`
+    ID    string          `json:"id"`
+}
+
+// encodePageToken returns the token for the page following the row with
+// the given sort value and id.
+func encodePageToken(sort string, value interface{}, id string) (string, error) {
+    rawValue, err := json.Marshal(value)
+    if err != nil {
+        return "", err
+    }
+
+    data, err := json.Marshal(pageCursor{Sort: sort, Value: rawValue, ID: id})
+    if err != nil {
+        return "", err
+    }
+
+    return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePageToken reads a token made by encodePageToken for the same sort,
+// storing the sort value in value and returning the id.
+func decodePageToken(token, sort string, value interface{}) (string, error) {
+    data, err := base64.RawURLEncoding.DecodeString(token)
+    if err != nil {
+        return "", repository.ErrInvalidPageToken
+    }
+
+    var cursor pageCursor
+    if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort || cursor.ID == "" {
+        return "", repository.ErrInvalidPageToken
+    }
+
+    if err := json.Unmarshal(cursor.Value, value); err != nil {
+        return "", repository.ErrInvalidPageToken
+    }
+
+    return cursor.ID, nil
+}
+
+// newPage builds a page from rows fetched with a LIMIT of limit+1. The
+// extra row only shows that another page follows; it is dropped and the
+// token for the next page is made from the last row kept.
+func newPage[T any](rows []T, limit int32, nextToken func(last T) (string, error)) (*repository.Page[T], error) {
+    page := &repository.Page[T]{Items: rows}
+
+    if int32(len(rows)) > limit {
+        page.Items = rows[:limit]
+
+        token, err := nextToken(page.Items[limit-1])
+        if err != nil {
+            return nil, err
+        }
+        page.NextPageToken = token
+    }
+
+    return page, nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+    "database/sql"
+    "time"
+
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "github.com/google/uuid"
+)
+
+// addressColumns is the column list scanned by scanAddress.
+const addressColumns = `id, user_id, label, recipient, phone, line1, line2, city, region, postal_code, is_default, created_at`
+
+type PostgresAddressRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresAddressRepository(db *sql.DB) *PostgresAddressRepository {
+    return &PostgresAddressRepository{
+        db: db,
+    }
+}
+
+func scanAddress(row interface{ Scan(dest ...interface{}) error }) (*domain.Address, error) {
+    var address domain.Address
+    err := row.Scan(&address.ID, &address.UserID, &address.Label, &address.Recipient, &address.Phone, &address.Line1,
+        &address.Line2, &address.City, &address.Region, &address.PostalCode, &address.IsDefault, &address.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &address, nil
+}
+
+// clearDefault unsets the default flag on the user's other addresses, so
+// that address can take it.
+func clearDefault(tx dbExecutor, address *domain.Address) error {
+    if !address.IsDefault {
+        return nil
+    }
+    _, err := tx.Exec(`UPDATE addresses SET is_default = FALSE WHERE user_id = $1 AND id <> $2 AND is_default`,
+        address.UserID, address.ID)
+    return err
+}
+
+func (r *PostgresAddressRepository) Create(address *domain.Address) error {
+    if address.ID == "" {
+        address.ID = uuid.New().String()
+    }
+    if address.CreatedAt.IsZero() {
+        address.CreatedAt = time.Now().UTC()
+    }
+
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        if err := clearDefault(tx, address); err != nil {
+            return err
+        }
+
+        _, err := tx.Exec(`
+            INSERT INTO addresses (id, user_id, label, recipient, phone, line1, line2, city, region, postal_code, is_default, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        `, address.ID, address.UserID, address.Label, address.Recipient, address.Phone, address.Line1, address.Line2,
+            address.City, address.Region, address.PostalCode, address.IsDefault, address.CreatedAt)
+        return err
+    })
+}
+
+func (r *PostgresAddressRepository) GetByID(id string) (*domain.Address, error) {
+    address, err := scanAddress(r.db.QueryRow(`SELECT `+addressColumns+` FROM addresses WHERE id = $1`, id))
+    if err == sql.ErrNoRows {
+        return nil, repository.ErrAddressNotFound
+    }
+    return address, err
+}
+
+func (r *PostgresAddressRepository) Update(address *domain.Address) error {
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        if err := clearDefault(tx, address); err != nil {
+            return err
+        }
+
+        res, err := tx.Exec(`
+            UPDATE addresses SET label = $2, recipient = $3, phone = $4, line1 = $5, line2 = $6, city = $7,
+                region = $8, postal_code = $9, is_default = $10
+            WHERE id = $1
+        `, address.ID, address.Label, address.Recipient, address.Phone, address.Line1, address.Line2,
+            address.City, address.Region, address.PostalCode, address.IsDefault)
+        if err != nil {
+            return err
+        }
+
+        return addressAffected(res)
+    })
+}
+
+func (r *PostgresAddressRepository) Delete(id string) error {
+    res, err := r.db.Exec(`DELETE FROM addresses WHERE id = $1`, id)
+    if err != nil {
+        return err
+    }
+
+    return addressAffected(res)
+}
+
+// addressAffected fails with repository.ErrAddressNotFound if res changed
+// no rows.
+func addressAffected(res sql.Result) error {
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return repository.ErrAddressNotFound
+    }
+    return nil
+}
+
+func (r *PostgresAddressRepository) ListByUserID(userID string) ([]*domain.Address, error) {
+    rows, err := r.db.Query(`
+        SELECT `+addressColumns+` FROM addresses
+        WHERE user_id = $1
+        ORDER BY is_default DESC, created_at, id
+    `, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    addresses := []*domain.Address{}
+    for rows.Next() {
+        address, err := scanAddress(rows)
+        if err != nil {
+            return nil, err
+        }
+        addresses = append(addresses, address)
+    }
+
+    return addresses, rows.Err()
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresAddressRepository_CreateMovesTheDefault(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresAddressRepository(db)
+	address := &domain.Address{
+		ID:        "a2",
+		UserID:    "u1",
+		Line1:     "Abay 10",
+		City:      "Almaty",
+		IsDefault: true,
+		CreatedAt: time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE addresses SET is_default = FALSE WHERE user_id = \$1 AND id <> \$2 AND is_default`).
+		WithArgs("u1", "a2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO addresses`).
+		WithArgs("a2", "u1", "", "", "", "Abay 10", "", "Almaty", "", "", true, address.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Create(address))
+
+	// Other addresses leave the default alone.
+	address = &domain.Address{ID: "a3", UserID: "u1", Line1: "Dostyk 5", City: "Almaty", CreatedAt: address.CreatedAt}
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO addresses`).
+		WithArgs("a3", "u1", "", "", "", "Dostyk 5", "", "Almaty", "", "", false, address.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Create(address))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+    "database/sql"
+    "time"
+
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+)
+
+type PostgresCartRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresCartRepository(db *sql.DB) *PostgresCartRepository {
+    return &PostgresCartRepository{
+        db: db,
+    }
+}
+
+func (r *PostgresCartRepository) GetItems(userID string) ([]*domain.CartItem, error) {
+    query := `
+        SELECT product_id, quantity, added_price_cents, currency, added_at, updated_at
+        FROM cart_items
+        WHERE user_id = $1
+        ORDER BY added_at, product_id
+    `
+
+    rows, err := r.db.Query(query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    items := []*domain.CartItem{}
+    for rows.Next() {
+        var item domain.CartItem
+        err := rows.Scan(&item.ProductID, &item.Quantity, &item.AddedPrice.Amount, &item.AddedPrice.Currency,
+            &item.AddedAt, &item.UpdatedAt)
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, &item)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    return items, nil
+}
+
+func (r *PostgresCartRepository) AddItem(userID string, item *domain.CartItem) error {
+    now := time.Now().UTC()
+    query := `
+        INSERT INTO cart_items (user_id, product_id, quantity, added_price_cents, currency, added_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)
+        ON CONFLICT (user_id, product_id) DO UPDATE SET
+            quantity = cart_items.quantity + EXCLUDED.quantity,
+            added_price_cents = EXCLUDED.added_price_cents,
+            currency = EXCLUDED.currency,
+            updated_at = EXCLUDED.updated_at
+        RETURNING quantity, added_at, updated_at
+    `
+
+    return r.db.QueryRow(query, userID, item.ProductID, item.Quantity, item.AddedPrice.Amount, item.AddedPrice.Currency, now).
+        Scan(&item.Quantity, &item.AddedAt, &item.UpdatedAt)
+}
+
+func (r *PostgresCartRepository) UpdateItem(userID string, item *domain.CartItem) error {
+    query := `
+        UPDATE cart_items SET quantity = $3, added_price_cents = $4, currency = $5, updated_at = $6
+        WHERE user_id = $1 AND product_id = $2
+        RETURNING added_at, updated_at
+    `
+
+    err := r.db.QueryRow(query, userID, item.ProductID, item.Quantity, item.AddedPrice.Amount, item.AddedPrice.Currency, time.Now().UTC()).
+        Scan(&item.AddedAt, &item.UpdatedAt)
+    if err == sql.ErrNoRows {
+        return repository.ErrCartItemNotFound
+    }
+    return err
+}
+
+func (r *PostgresCartRepository) RemoveItem(userID, productID string) error {
+    result, err := r.db.Exec(`DELETE FROM cart_items WHERE user_id = $1 AND product_id = $2`, userID, productID)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return repository.ErrCartItemNotFound
+    }
+
+    return nil
+}
+
+func (r *PostgresCartRepository) Clear(userID string) error {
+    _, err := r.db.Exec(`DELETE FROM cart_items WHERE user_id = $1`, userID)
+    return err
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresCartRepository_AddItemAddsToExistingQuantity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresCartRepository{db: db}
+
+	addedAt := time.Now().Add(-time.Hour)
+	mock.ExpectQuery(`INSERT INTO cart_items .+ON CONFLICT \(user_id, product_id\) DO UPDATE SET\s+quantity = cart_items.quantity \+ EXCLUDED.quantity`).
+		WithArgs("u1", "apple", int32(2), int64(150), "KZT", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "added_at", "updated_at"}).AddRow(5, addedAt, time.Now()))
+
+	item := &domain.CartItem{ProductID: "apple", Quantity: 2, AddedPrice: domain.NewMoney(150, "KZT")}
+	assert.NoError(t, repo.AddItem("u1", item))
+	assert.Equal(t, int32(5), item.Quantity)
+	assert.True(t, item.AddedAt.Equal(addedAt))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCartRepository_UpdateAndRemoveMissingItem(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresCartRepository{db: db}
+
+	mock.ExpectQuery(`UPDATE cart_items SET quantity = \$3`).
+		WithArgs("u1", "pear", int32(1), int64(90), "KZT", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"added_at", "updated_at"}))
+
+	err = repo.UpdateItem("u1", &domain.CartItem{ProductID: "pear", Quantity: 1, AddedPrice: domain.NewMoney(90, "KZT")})
+	assert.ErrorIs(t, err, repository.ErrCartItemNotFound)
+
+	mock.ExpectExec(`DELETE FROM cart_items WHERE user_id = \$1 AND product_id = \$2`).
+		WithArgs("u1", "pear").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(t, repo.RemoveItem("u1", "pear"), repository.ErrCartItemNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCartRepository_GetItemsOldestFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresCartRepository{db: db}
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT product_id, quantity, added_price_cents, currency, added_at, updated_at\s+FROM cart_items\s+WHERE user_id = \$1\s+ORDER BY added_at, product_id`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "quantity", "added_price_cents", "currency", "added_at", "updated_at"}).
+			AddRow("apple", 2, 150, "KZT", now, now))
+
+	items, err := repo.GetItems("u1")
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, domain.NewMoney(150, "KZT"), items[0].AddedPrice)
+
+	mock.ExpectQuery(`SELECT product_id`).
+		WithArgs("u2").
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "quantity", "added_price_cents", "currency", "added_at", "updated_at"}))
+
+	items, err = repo.GetItems("u2")
+	assert.NoError(t, err)
+	assert.NotNil(t, items)
+	assert.Empty(t, items)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
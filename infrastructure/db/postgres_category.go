@@ -0,0 +1,121 @@
+package db
+
+import (
+    "database/sql"
+    "fmt"
+    
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+)
+
+type PostgresCategoryRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresCategoryRepository(db *sql.DB) *PostgresCategoryRepository {
+    return &PostgresCategoryRepository{
+        db: db,
+    }
+}
+
+func categoryWriteError(err error, category *domain.Category) error {
+    if isForeignKeyViolation(err, "categories_parent_id_fkey") {
+        return fmt.Errorf("%w: parent %s", repository.ErrCategoryNotFound, category.ParentID)
+    }
+    return err
+}
+
+func (r *PostgresCategoryRepository) Create(category *domain.Category) error {
+    query := `INSERT INTO categories (id, name, parent_id) VALUES ($1, $2, $3)`
+    
+    _, err := r.db.Exec(query, category.ID, category.Name, nullableID(category.ParentID))
+    return categoryWriteError(err, category)
+}
+
+func (r *PostgresCategoryRepository) GetByID(id string) (*domain.Category, error) {
+    query := `SELECT id, name, parent_id FROM categories WHERE id = $1`
+    
+    var category domain.Category
+    var parentID sql.NullString
+    err := r.db.QueryRow(query, id).Scan(&category.ID, &category.Name, &parentID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, repository.ErrCategoryNotFound
+        }
+        return nil, err
+    }
+    
+    category.ParentID = parentID.String
+    return &category, nil
+}
+
+func (r *PostgresCategoryRepository) Update(category *domain.Category) error {
+    query := `UPDATE categories SET name = $2, parent_id = $3 WHERE id = $1`
+    
+    res, err := r.db.Exec(query, category.ID, category.Name, nullableID(category.ParentID))
+    if err != nil {
+        return categoryWriteError(err, category)
+    }
+    
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+    
+    if rowsAffected == 0 {
+        return repository.ErrCategoryNotFound
+    }
+    
+    return nil
+}
+
+func (r *PostgresCategoryRepository) Delete(id string) error {
+    query := `DELETE FROM categories WHERE id = $1`
+    
+    res, err := r.db.Exec(query, id)
+    if err != nil {
+        if isForeignKeyViolation(err, "categories_parent_id_fkey") {
+            return repository.ErrCategoryInUse
+        }
+        return err
+    }
+    
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+    
+    if rowsAffected == 0 {
+        return repository.ErrCategoryNotFound
+    }
+    
+    return nil
+}
+
+func (r *PostgresCategoryRepository) List() ([]*domain.Category, error) {
+    query := `SELECT id, name, parent_id FROM categories ORDER BY name`
+    
+    rows, err := r.db.Query(query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    var categories []*domain.Category
+    
+    for rows.Next() {
+        var category domain.Category
+        var parentID sql.NullString
+        if err := rows.Scan(&category.ID, &category.Name, &parentID); err != nil {
+            return nil, err
+        }
+        category.ParentID = parentID.String
+        categories = append(categories, &category)
+    }
+    
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+    
+    return categories, nil
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+    "database/sql"
+    "fmt"
+    "time"
+
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "github.com/google/uuid"
+)
+
+// deliverySlotColumns is the column list scanned by scanDeliverySlot.
+const deliverySlotColumns = `id, starts_at, ends_at, capacity, booked, created_at`
+
+type PostgresDeliverySlotRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresDeliverySlotRepository(db *sql.DB) *PostgresDeliverySlotRepository {
+    return &PostgresDeliverySlotRepository{
+        db: db,
+    }
+}
+
+func scanDeliverySlot(row interface{ Scan(dest ...interface{}) error }) (*domain.DeliverySlot, error) {
+    var slot domain.DeliverySlot
+    err := row.Scan(&slot.ID, &slot.StartsAt, &slot.EndsAt, &slot.Capacity, &slot.Booked, &slot.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &slot, nil
+}
+
+func (r *PostgresDeliverySlotRepository) Create(slot *domain.DeliverySlot) error {
+    if slot.ID == "" {
+        slot.ID = uuid.New().String()
+    }
+    if slot.CreatedAt.IsZero() {
+        slot.CreatedAt = time.Now().UTC()
+    }
+
+    _, err := r.db.Exec(`
+        INSERT INTO delivery_slots (id, starts_at, ends_at, capacity, created_at) VALUES ($1, $2, $3, $4, $5)
+    `, slot.ID, slot.StartsAt, slot.EndsAt, slot.Capacity, slot.CreatedAt)
+    return err
+}
+
+func (r *PostgresDeliverySlotRepository) GetByID(id string) (*domain.DeliverySlot, error) {
+    slot, err := scanDeliverySlot(r.db.QueryRow(`SELECT `+deliverySlotColumns+` FROM delivery_slots WHERE id = $1`, id))
+    if err == sql.ErrNoRows {
+        return nil, repository.ErrDeliverySlotNotFound
+    }
+    return slot, err
+}
+
+func (r *PostgresDeliverySlotRepository) Delete(id string) error {
+    res, err := r.db.Exec(`DELETE FROM delivery_slots WHERE id = $1 AND booked = 0`, id)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil || rowsAffected > 0 {
+        return err
+    }
+
+    if _, err := r.GetByID(id); err != nil {
+        return err
+    }
+    return repository.ErrDeliverySlotBooked
+}
+
+func (r *PostgresDeliverySlotRepository) ListFrom(from time.Time) ([]*domain.DeliverySlot, error) {
+    rows, err := r.db.Query(`
+        SELECT `+deliverySlotColumns+` FROM delivery_slots
+        WHERE starts_at > $1
+        ORDER BY starts_at, id
+    `, from)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    slots := []*domain.DeliverySlot{}
+    for rows.Next() {
+        slot, err := scanDeliverySlot(rows)
+        if err != nil {
+            return nil, err
+        }
+        slots = append(slots, slot)
+    }
+
+    return slots, rows.Err()
+}
+
+// Book checks the capacity in the same statement that takes the place, so
+// the row lock serializes concurrent bookings.
+func (r *PostgresDeliverySlotRepository) Book(id string, now time.Time) (*domain.DeliverySlot, error) {
+    slot, err := scanDeliverySlot(r.db.QueryRow(`
+        UPDATE delivery_slots SET booked = booked + 1
+        WHERE id = $1 AND booked < capacity AND starts_at > $2
+        RETURNING `+deliverySlotColumns, id, now))
+    if err != sql.ErrNoRows {
+        return slot, err
+    }
+
+    slot, err = r.GetByID(id)
+    if err != nil {
+        return nil, err
+    }
+    if !now.Before(slot.StartsAt) {
+        return nil, fmt.Errorf("%w: it started at %s", domain.ErrDeliverySlotClosed, slot.StartsAt.Format(time.RFC3339))
+    }
+    return nil, repository.ErrDeliverySlotFull
+}
+
+func (r *PostgresDeliverySlotRepository) Release(id string) error {
+    _, err := r.db.Exec(`UPDATE delivery_slots SET booked = GREATEST(booked - 1, 0) WHERE id = $1`, id)
+    return err
+}
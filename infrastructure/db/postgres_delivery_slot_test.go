@@ -0,0 +1,86 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresDeliverySlotRepository_BookReportsWhyItFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresDeliverySlotRepository{db: db}
+	columns := []string{"id", "starts_at", "ends_at", "capacity", "booked", "created_at"}
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	startsAt := now.Add(time.Hour)
+
+	mock.ExpectQuery(`UPDATE delivery_slots SET booked = booked \+ 1\s+WHERE id = \$1 AND booked < capacity AND starts_at > \$2`).
+		WithArgs("slot-1", now).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("slot-1", startsAt, startsAt.Add(2*time.Hour), 10, 4, now))
+
+	slot, err := repo.Book("slot-1", now)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), slot.Booked)
+
+	mock.ExpectQuery(`UPDATE delivery_slots`).
+		WithArgs("slot-1", now).
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(`SELECT (.+) FROM delivery_slots WHERE id = \$1`).
+		WithArgs("slot-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("slot-1", startsAt, startsAt.Add(2*time.Hour), 10, 10, now))
+
+	_, err = repo.Book("slot-1", now)
+	assert.ErrorIs(t, err, repository.ErrDeliverySlotFull)
+
+	mock.ExpectQuery(`UPDATE delivery_slots`).
+		WithArgs("slot-1", startsAt).
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(`SELECT (.+) FROM delivery_slots WHERE id = \$1`).
+		WithArgs("slot-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("slot-1", startsAt, startsAt.Add(2*time.Hour), 10, 3, now))
+
+	_, err = repo.Book("slot-1", startsAt)
+	assert.ErrorIs(t, err, domain.ErrDeliverySlotClosed)
+
+	mock.ExpectQuery(`UPDATE delivery_slots`).
+		WithArgs("missing", now).
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(`SELECT (.+) FROM delivery_slots WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	_, err = repo.Book("missing", now)
+	assert.ErrorIs(t, err, repository.ErrDeliverySlotNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresDeliverySlotRepository_DeleteKeepsBookedSlots(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresDeliverySlotRepository{db: db}
+	now := time.Now()
+
+	mock.ExpectExec(`DELETE FROM delivery_slots WHERE id = \$1 AND booked = 0`).
+		WithArgs("slot-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FROM delivery_slots WHERE id = \$1`).
+		WithArgs("slot-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "starts_at", "ends_at", "capacity", "booked", "created_at"}).
+			AddRow("slot-1", now, now.Add(time.Hour), 5, 1, now))
+
+	assert.ErrorIs(t, repo.Delete("slot-1"), repository.ErrDeliverySlotBooked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,149 +2,158 @@ package db
 
 import (
     "database/sql"
-    "errors"
+    "encoding/json"
     "fmt"
+    "strings"
     "time"
     
     "github.com/google/uuid"
+    "github.com/lib/pq"
     "AdvProg2/domain"
+    "AdvProg2/repository"
 )
 
-func createOrderTablesIfNotExist(db *sql.DB) error {
-    createOrdersTable := `
-    CREATE TABLE IF NOT EXISTS orders (
-        id VARCHAR(36) PRIMARY KEY,
-        user_id VARCHAR(255) NOT NULL,
-        status VARCHAR(50) NOT NULL DEFAULT 'pending',
-        total_price DECIMAL(10, 2) NOT NULL DEFAULT 0,
-        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-    );
-    `
-
-    createOrderItemsTable := `
-    CREATE TABLE IF NOT EXISTS order_items (
-        id VARCHAR(36) PRIMARY KEY,
-        order_id VARCHAR(36) NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
-        product_id VARCHAR(36) NOT NULL REFERENCES products(id),
-        quantity INT NOT NULL,
-        price DECIMAL(10, 2) NOT NULL,
-        CONSTRAINT unique_order_product UNIQUE (order_id, product_id)
-    );
-    `
-
-    _, err := db.Exec(createOrdersTable)
-    if err != nil {
-        return err
-    }
-
-    _, err = db.Exec(createOrderItemsTable)
-    if err != nil {
-        return err
-    }
-
-    return nil
-}
-
 type PostgresOrderRepository struct {
-    db *sql.DB
+    db dbExecutor
 }
 
 func NewPostgresOrderRepository(db *sql.DB) *PostgresOrderRepository {
-    err := createOrderTablesIfNotExist(db)
-    if err != nil {
-        panic(fmt.Sprintf("Failed to create order tables: %v", err))
-    }
-    
     return &PostgresOrderRepository{
         db: db,
     }
 }
 
 func (r *PostgresOrderRepository) Create(order *domain.Order) error {
-    tx, err := r.db.Begin()
-    if err != nil {
-        return err
-    }
-    defer func() {
-        if err != nil {
-            tx.Rollback()
-            return
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        query := `
+            INSERT INTO orders (id, user_id, status, total_price_cents, subtotal_cents, discount_cents, tax_cents, tax_region,
+                delivery_fee_cents, delivery_address, delivery_zone, delivery_slot_id, delivery_starts_at, delivery_ends_at,
+                currency, base_currency, exchange_rate, created_at) 
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+        `
+        
+        if order.ID == "" {
+            order.ID = uuid.New().String()
         }
-        err = tx.Commit()
-    }()
-
-    query := `
-        INSERT INTO orders (id, user_id, status, total_price, created_at) 
-        VALUES ($1, $2, $3, $4, $5)
-    `
-    
-    if order.ID == "" {
-        order.ID = uuid.New().String()
-    }
-    
-    if order.CreatedAt.IsZero() {
-        order.CreatedAt = time.Now()
-    }
-    
-    _, err = tx.Exec(query, order.ID, order.UserID, order.Status, order.TotalPrice, order.CreatedAt)
-    if err != nil {
-        return err
-    }
-    
-    for _, item := range order.Items {
-        if item.ID == "" {
-            item.ID = uuid.New().String()
+        
+        if order.CreatedAt.IsZero() {
+            order.CreatedAt = time.Now()
         }
         
-        item.OrderID = order.ID
+        if order.ExchangeRate.Rate == "" {
+            order.ExchangeRate = domain.IdentityRate(order.TotalPrice.Currency)
+        }
         
-        query := `
-            INSERT INTO order_items (id, order_id, product_id, quantity, price) 
-            VALUES ($1, $2, $3, $4, $5)
-        `
-        _, err = tx.Exec(query, item.ID, item.OrderID, item.ProductID, item.Quantity, item.Price)
+        // Orders that are not delivered store NULL.
+        var deliveryAddress interface{}
+        if order.DeliveryAddress != nil {
+            data, err := json.Marshal(order.DeliveryAddress)
+            if err != nil {
+                return err
+            }
+            deliveryAddress = data
+        }
+        
+        var slotID sql.NullString
+        var slotStartsAt, slotEndsAt sql.NullTime
+        if slot := order.DeliverySlot; slot != nil {
+            slotID = nullableID(slot.ID)
+            slotStartsAt = sql.NullTime{Time: slot.StartsAt, Valid: true}
+            slotEndsAt = sql.NullTime{Time: slot.EndsAt, Valid: true}
+        }
+        
+        _, err := tx.Exec(query, order.ID, order.UserID, order.Status, order.TotalPrice.Amount, order.Subtotal.Amount, order.DiscountTotal.Amount,
+            order.TaxTotal.Amount, order.TaxRegion, order.DeliveryFee.Amount, deliveryAddress, order.DeliveryZone,
+            slotID, slotStartsAt, slotEndsAt, order.TotalPrice.Currency,
+            order.ExchangeRate.From, order.ExchangeRate.Rate, order.CreatedAt)
         if err != nil {
             return err
         }
-    }
-    
-    return nil
+        
+        for _, item := range order.Items {
+            if item.ID == "" {
+                item.ID = uuid.New().String()
+            }
+            
+            item.OrderID = order.ID
+            
+            query := `
+                INSERT INTO order_items (id, order_id, product_id, quantity, price_cents) 
+                VALUES ($1, $2, $3, $4, $5)
+            `
+            _, err = tx.Exec(query, item.ID, item.OrderID, item.ProductID, item.Quantity, item.Price.Amount)
+            if err != nil {
+                return err
+            }
+        }
+        
+        for _, discount := range order.Discounts {
+            if discount.ID == "" {
+                discount.ID = uuid.New().String()
+            }
+            
+            discount.OrderID = order.ID
+            
+            query := `
+                INSERT INTO order_discounts (id, order_id, order_item_id, promotion_id, code, description, amount_cents) 
+                VALUES ($1, $2, $3, $4, $5, $6, $7)
+            `
+            _, err = tx.Exec(query, discount.ID, discount.OrderID, nullableID(discount.OrderItemID),
+                nullableID(discount.PromotionID), discount.Code, discount.Description, discount.Amount.Amount)
+            if err != nil {
+                return err
+            }
+        }
+        
+        return nil
+    })
 }
 
 func (r *PostgresOrderRepository) GetByID(id string) (*domain.Order, error) {
-    orderQuery := `
-        SELECT id, user_id, status, total_price, created_at 
-        FROM orders 
-        WHERE id = $1
-    `
-    
-    var order domain.Order
-    err := r.db.QueryRow(orderQuery, id).Scan(
-        &order.ID, 
-        &order.UserID, 
-        &order.Status, 
-        &order.TotalPrice, 
-        &order.CreatedAt,
-    )
-    
+    order, err := scanOrder(r.db.QueryRow(`SELECT `+orderColumns+` FROM orders WHERE id = $1`, id))
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, errors.New("order not found")
+            return nil, repository.ErrOrderNotFound
         }
         return nil, err
     }
     
+    if err := r.loadItems([]*domain.Order{order}); err != nil {
+        return nil, err
+    }
+    
+    if err := r.loadDiscounts([]*domain.Order{order}); err != nil {
+        return nil, err
+    }
+    
+    return order, nil
+}
+
+// loadItems fills in the items of orders, with their products, in a single
+// query.
+func (r *PostgresOrderRepository) loadItems(orders []*domain.Order) error {
+    if len(orders) == 0 {
+        return nil
+    }
+    
+    ids := make([]string, len(orders))
+    byID := make(map[string]*domain.Order, len(orders))
+    for i, order := range orders {
+        ids[i] = order.ID
+        byID[order.ID] = order
+    }
+    
     itemsQuery := `
-        SELECT oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price,
-               p.id, p.name, p.price, p.stock
+        SELECT oi.id, oi.order_id, oi.product_id, oi.quantity, oi.price_cents,
+               p.id, p.name, p.price_cents, p.currency, p.stock
         FROM order_items oi
         LEFT JOIN products p ON oi.product_id = p.id
-        WHERE oi.order_id = $1
+        WHERE oi.order_id = ANY($1)
     `
     
-    rows, err := r.db.Query(itemsQuery, id)
+    rows, err := r.db.Query(itemsQuery, pq.Array(ids))
     if err != nil {
-        return nil, err
+        return err
     }
     defer rows.Close()
     
@@ -157,26 +166,76 @@ func (r *PostgresOrderRepository) GetByID(id string) (*domain.Order, error) {
             &item.OrderID,
             &item.ProductID,
             &item.Quantity,
-            &item.Price,
+            &item.Price.Amount,
             &product.ID,
             &product.Name,
-            &product.Price,
+            &product.Price.Amount,
+            &product.Price.Currency,
             &product.Stock,
         )
         
         if err != nil {
-            return nil, err
+            return err
         }
         
+        order := byID[item.OrderID]
+        item.Price.Currency = order.TotalPrice.Currency
         item.Product = &product
         order.Items = append(order.Items, &item)
     }
     
-    if err = rows.Err(); err != nil {
-        return nil, err
+    return rows.Err()
+}
+
+// loadDiscounts fills in the discounts of orders in a single query.
+func (r *PostgresOrderRepository) loadDiscounts(orders []*domain.Order) error {
+    if len(orders) == 0 {
+        return nil
     }
     
-    return &order, nil
+    ids := make([]string, len(orders))
+    byID := make(map[string]*domain.Order, len(orders))
+    for i, order := range orders {
+        ids[i] = order.ID
+        byID[order.ID] = order
+    }
+    
+    discountsQuery := `
+        SELECT id, order_id, COALESCE(order_item_id, ''), COALESCE(promotion_id, ''), code, description, amount_cents
+        FROM order_discounts
+        WHERE order_id = ANY($1)
+        ORDER BY order_id, id
+    `
+    
+    rows, err := r.db.Query(discountsQuery, pq.Array(ids))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+    
+    for rows.Next() {
+        var discount domain.OrderDiscount
+        
+        err := rows.Scan(
+            &discount.ID,
+            &discount.OrderID,
+            &discount.OrderItemID,
+            &discount.PromotionID,
+            &discount.Code,
+            &discount.Description,
+            &discount.Amount.Amount,
+        )
+        
+        if err != nil {
+            return err
+        }
+        
+        order := byID[discount.OrderID]
+        discount.Amount.Currency = order.TotalPrice.Currency
+        order.Discounts = append(order.Discounts, &discount)
+    }
+    
+    return rows.Err()
 }
 
 func (r *PostgresOrderRepository) GetByUserID(userID string, page, limit int32) ([]*domain.Order, int32, error) {
@@ -190,55 +249,163 @@ func (r *PostgresOrderRepository) GetByUserID(userID string, page, limit int32)
     }
     
     ordersQuery := `
-        SELECT id, user_id, status, total_price, created_at 
+        SELECT ` + orderColumns + `
         FROM orders 
         WHERE user_id = $1
         ORDER BY created_at DESC
         LIMIT $2 OFFSET $3
     `
     
-    rows, err := r.db.Query(ordersQuery, userID, limit, offset)
+    orders, err := r.queryOrders(ordersQuery, userID, limit, offset)
     if err != nil {
         return nil, 0, err
     }
-    defer rows.Close()
     
-    var orders []*domain.Order
+    return orders, total, nil
+}
+
+// GetPageByUserID pages by keyset on (created_at, id), newest first.
+func (r *PostgresOrderRepository) GetPageByUserID(userID string, page repository.PageRequest) (*repository.Page[*domain.Order], error) {
+    const sort = "created_at desc"
     
-    for rows.Next() {
-        var order domain.Order
-        err := rows.Scan(
-            &order.ID,
-            &order.UserID,
-            &order.Status,
-            &order.TotalPrice,
-            &order.CreatedAt,
-        )
-        
+    var total *int32
+    if page.WithTotal {
+        total = new(int32)
+        err := r.db.QueryRow("SELECT COUNT(*) FROM orders WHERE user_id = $1", userID).Scan(total)
         if err != nil {
-            return nil, 0, err
+            return nil, err
+        }
+    }
+    
+    args := []interface{}{userID}
+    keyset := ""
+    if page.Token != "" {
+        var lastCreatedAt time.Time
+        lastID, err := decodePageToken(page.Token, sort, &lastCreatedAt)
+        if err != nil {
+            return nil, err
         }
         
-        orderDetails, err := r.GetByID(order.ID)
+        args = append(args, lastCreatedAt, lastID)
+        keyset = "AND (created_at, id) < ($2, $3)"
+    }
+    args = append(args, page.Limit+1)
+    
+    ordersQuery := fmt.Sprintf(`
+        SELECT `+orderColumns+`
+        FROM orders 
+        WHERE user_id = $1 %s
+        ORDER BY created_at DESC, id DESC
+        LIMIT $%d
+    `, keyset, len(args))
+    
+    orders, err := r.queryOrders(ordersQuery, args...)
+    if err != nil {
+        return nil, err
+    }
+    
+    result, err := newPage(orders, page.Limit, func(last *domain.Order) (string, error) {
+        return encodePageToken(sort, last.CreatedAt, last.ID)
+    })
+    if err != nil {
+        return nil, err
+    }
+    result.Total = total
+    
+    return result, nil
+}
+
+// queryOrders runs a query selecting orders rows and loads their items.
+func (r *PostgresOrderRepository) queryOrders(query string, args ...interface{}) ([]*domain.Order, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    var orders []*domain.Order
+    
+    for rows.Next() {
+        order, err := scanOrder(rows)
         if err != nil {
-            return nil, 0, err
+            return nil, err
         }
         
-        order.Items = orderDetails.Items
-        orders = append(orders, &order)
+        orders = append(orders, order)
     }
     
     if err = rows.Err(); err != nil {
-        return nil, 0, err
+        return nil, err
     }
+    // Free the connection first, in case r.db is a transaction.
+    rows.Close()
     
-    return orders, total, nil
+    if err := r.loadItems(orders); err != nil {
+        return nil, err
+    }
+    
+    if err := r.loadDiscounts(orders); err != nil {
+        return nil, err
+    }
+    
+    return orders, nil
+}
+
+// orderColumns is the column list scanned by scanOrder.
+const orderColumns = `id, user_id, status, total_price_cents, subtotal_cents, discount_cents, tax_cents, tax_region,
+        delivery_fee_cents, delivery_address, delivery_zone, delivery_slot_id, delivery_starts_at, delivery_ends_at,
+        currency, base_currency, exchange_rate, created_at`
+
+// scanOrder reads an orders row without its items and discounts.
+func scanOrder(row interface{ Scan(dest ...interface{}) error }) (*domain.Order, error) {
+    var order domain.Order
+    var deliveryAddress []byte
+    var slotID sql.NullString
+    var slotStartsAt, slotEndsAt sql.NullTime
+    
+    err := row.Scan(&order.ID, &order.UserID, &order.Status, &order.TotalPrice.Amount, &order.Subtotal.Amount,
+        &order.DiscountTotal.Amount, &order.TaxTotal.Amount, &order.TaxRegion, &order.DeliveryFee.Amount,
+        &deliveryAddress, &order.DeliveryZone, &slotID, &slotStartsAt, &slotEndsAt, &order.TotalPrice.Currency,
+        &order.ExchangeRate.From, &order.ExchangeRate.Rate, &order.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    
+    if deliveryAddress != nil {
+        order.DeliveryAddress = &domain.Address{}
+        if err := json.Unmarshal(deliveryAddress, order.DeliveryAddress); err != nil {
+            return nil, err
+        }
+    }
+    
+    // The slot's times are kept on the order, so they survive the slot
+    // being deleted.
+    if slotStartsAt.Valid {
+        order.DeliverySlot = &domain.DeliverySlot{ID: slotID.String, StartsAt: slotStartsAt.Time, EndsAt: slotEndsAt.Time}
+    }
+    
+    setOrderRateCurrency(&order)
+    return &order, nil
+}
+
+// setOrderRateCurrency completes the amounts and exchange rate read from an
+// orders row, which stores the order currency once, and only the base
+// currency and the rate to it.
+func setOrderRateCurrency(order *domain.Order) {
+    order.Subtotal.Currency = order.TotalPrice.Currency
+    order.DiscountTotal.Currency = order.TotalPrice.Currency
+    order.TaxTotal.Currency = order.TotalPrice.Currency
+    order.DeliveryFee.Currency = order.TotalPrice.Currency
+    order.ExchangeRate.To = order.TotalPrice.Currency
+    if strings.Contains(order.ExchangeRate.Rate, ".") {
+        order.ExchangeRate.Rate = strings.TrimSuffix(strings.TrimRight(order.ExchangeRate.Rate, "0"), ".")
+    }
 }
 
-func (r *PostgresOrderRepository) UpdateStatus(id, status string) error {
-    query := "UPDATE orders SET status = $1 WHERE id = $2"
+func (r *PostgresOrderRepository) UpdateStatus(id string, from, to domain.OrderStatus) error {
+    query := "UPDATE orders SET status = $1 WHERE id = $2 AND status = $3"
     
-    res, err := r.db.Exec(query, status, id)
+    res, err := r.db.Exec(query, to, id, from)
     if err != nil {
         return err
     }
@@ -249,40 +416,108 @@ func (r *PostgresOrderRepository) UpdateStatus(id, status string) error {
     }
     
     if rowsAffected == 0 {
-        return errors.New("order not found")
+        var exists bool
+        err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1)", id).Scan(&exists)
+        if err != nil {
+            return err
+        }
+        if !exists {
+            return repository.ErrOrderNotFound
+        }
+        return repository.ErrOrderStatusChanged
     }
     
     return nil
 }
 
-func (r *PostgresOrderRepository) Delete(id string) error {
-    tx, err := r.db.Begin()
-    if err != nil {
-        return err
+func (r *PostgresOrderRepository) AddStatusChange(change *domain.OrderStatusChange) error {
+    query := `
+        INSERT INTO order_status_history (id, order_id, from_status, to_status, actor_id, actor_role, reason, changed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+    
+    if change.ID == "" {
+        change.ID = uuid.New().String()
     }
-    defer func() {
-        if err != nil {
-            tx.Rollback()
-            return
-        }
-        err = tx.Commit()
-    }()
     
-    query := "DELETE FROM orders WHERE id = $1"
+    if change.ChangedAt.IsZero() {
+        change.ChangedAt = time.Now()
+    }
     
-    res, err := tx.Exec(query, id)
-    if err != nil {
-        return err
+    var fromStatus sql.NullString
+    if change.FromStatus != "" {
+        fromStatus = sql.NullString{String: string(change.FromStatus), Valid: true}
     }
     
-    rowsAffected, err := res.RowsAffected()
+    _, err := r.db.Exec(query, change.ID, change.OrderID, fromStatus, change.ToStatus,
+        change.Actor.ID, change.Actor.Role, change.Reason, change.ChangedAt)
+    return err
+}
+
+func (r *PostgresOrderRepository) GetStatusHistory(orderID string) ([]*domain.OrderStatusChange, error) {
+    query := `
+        SELECT id, order_id, from_status, to_status, actor_id, actor_role, reason, changed_at
+        FROM order_status_history
+        WHERE order_id = $1
+        ORDER BY changed_at, id
+    `
+    
+    rows, err := r.db.Query(query, orderID)
     if err != nil {
-        return err
+        return nil, err
     }
+    defer rows.Close()
     
-    if rowsAffected == 0 {
-        return errors.New("order not found")
+    history := []*domain.OrderStatusChange{}
+    
+    for rows.Next() {
+        var change domain.OrderStatusChange
+        var fromStatus sql.NullString
+        
+        err := rows.Scan(
+            &change.ID,
+            &change.OrderID,
+            &fromStatus,
+            &change.ToStatus,
+            &change.Actor.ID,
+            &change.Actor.Role,
+            &change.Reason,
+            &change.ChangedAt,
+        )
+        
+        if err != nil {
+            return nil, err
+        }
+        
+        change.FromStatus = domain.OrderStatus(fromStatus.String)
+        history = append(history, &change)
     }
     
-    return nil
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+    
+    return history, nil
+}
+
+func (r *PostgresOrderRepository) Delete(id string) error {
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        query := "DELETE FROM orders WHERE id = $1"
+        
+        res, err := tx.Exec(query, id)
+        if err != nil {
+            return err
+        }
+        
+        rowsAffected, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        
+        if rowsAffected == 0 {
+            return repository.ErrOrderNotFound
+        }
+        
+        return nil
+    })
 }
\ No newline at end of file
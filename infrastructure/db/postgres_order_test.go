@@ -2,10 +2,14 @@ package db
 
 import (
 	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"database/sql"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,27 +27,39 @@ func TestPostgresOrderRepository_Create(t *testing.T) {
 		ID:         "test-order-id",
 		UserID:     "test-user-id",
 		Status:     "pending",
-		TotalPrice: 100.0,
-		CreatedAt:  now,
+		TotalPrice:    domain.NewMoney(10080, "KZT"),
+		Subtotal:      domain.NewMoney(10000, "KZT"),
+		DiscountTotal: domain.NewMoney(1000, "KZT"),
+		TaxTotal:      domain.NewMoney(1080, "KZT"),
+		TaxRegion:     "KZ",
+		CreatedAt:     now,
 		Items: []*domain.OrderItem{
 			{
 				ID:        "test-item-id",
 				OrderID:   "test-order-id",
 				ProductID: "test-product-id",
 				Quantity:  2,
-				Price:     50.0,
+				Price:     domain.NewMoney(5000, "KZT"),
 			},
 		},
+		Discounts: []*domain.OrderDiscount{
+			{ID: "test-discount-id", PromotionID: "test-promotion-id", Code: "SAVE10", Description: "10% off", Amount: domain.NewMoney(1000, "KZT")},
+		},
 	}
 
 	mock.ExpectBegin()
 
 	mock.ExpectExec("INSERT INTO orders").
-		WithArgs(order.ID, order.UserID, order.Status, order.TotalPrice, order.CreatedAt).
+		WithArgs(order.ID, order.UserID, order.Status, int64(10080), int64(10000), int64(1000), int64(1080), "KZ",
+			int64(0), nil, "", sql.NullString{}, sql.NullTime{}, sql.NullTime{}, "KZT", "KZT", "1", order.CreatedAt).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	mock.ExpectExec("INSERT INTO order_items").
-		WithArgs(order.Items[0].ID, order.Items[0].OrderID, order.Items[0].ProductID, order.Items[0].Quantity, order.Items[0].Price).
+		WithArgs(order.Items[0].ID, order.Items[0].OrderID, order.Items[0].ProductID, order.Items[0].Quantity, int64(5000)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("INSERT INTO order_discounts").
+		WithArgs("test-discount-id", order.ID, sql.NullString{}, sql.NullString{String: "test-promotion-id", Valid: true}, "SAVE10", "10% off", int64(1000)).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	mock.ExpectCommit()
@@ -55,3 +71,239 @@ func TestPostgresOrderRepository_Create(t *testing.T) {
 	err = mock.ExpectationsWereMet()
 	assert.NoError(t, err)
 }
+
+func TestPostgresOrderRepository_UpdateStatusDetectsConcurrentChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresOrderRepository{db: db}
+
+	mock.ExpectExec("UPDATE orders SET status").
+		WithArgs("cancelled", "test-order-id", "pending").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("test-order-id").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	err = repo.UpdateStatus("test-order-id", domain.OrderStatusPending, domain.OrderStatusCancelled)
+
+	assert.ErrorIs(t, err, repository.ErrOrderStatusChanged)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestPostgresOrderRepository_StatusHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresOrderRepository{db: db}
+
+	created := time.Now().Add(-time.Hour)
+	confirmed := time.Now()
+
+	mock.ExpectExec("INSERT INTO order_status_history").
+		WithArgs("change-1", "test-order-id", nil, domain.OrderStatusPending, "test-user-id", domain.ActorRoleUser, "", created).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO order_status_history").
+		WithArgs("change-2", "test-order-id", "pending", domain.OrderStatusConfirmed, "", domain.ActorRoleSystem, "payment received", confirmed).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.AddStatusChange(&domain.OrderStatusChange{
+		ID:        "change-1",
+		OrderID:   "test-order-id",
+		ToStatus:  domain.OrderStatusPending,
+		Actor:     domain.Actor{ID: "test-user-id", Role: domain.ActorRoleUser},
+		ChangedAt: created,
+	})
+	assert.NoError(t, err)
+
+	err = repo.AddStatusChange(&domain.OrderStatusChange{
+		ID:         "change-2",
+		OrderID:    "test-order-id",
+		FromStatus: domain.OrderStatusPending,
+		ToStatus:   domain.OrderStatusConfirmed,
+		Actor:      domain.SystemActor,
+		Reason:     "payment received",
+		ChangedAt:  confirmed,
+	})
+	assert.NoError(t, err)
+
+	mock.ExpectQuery("SELECT (.+) FROM order_status_history").
+		WithArgs("test-order-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "from_status", "to_status", "actor_id", "actor_role", "reason", "changed_at"}).
+			AddRow("change-1", "test-order-id", nil, "pending", "test-user-id", "user", "", created).
+			AddRow("change-2", "test-order-id", "pending", "confirmed", "", "system", "payment received", confirmed))
+
+	history, err := repo.GetStatusHistory("test-order-id")
+
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, domain.OrderStatus(""), history[0].FromStatus)
+	assert.Equal(t, domain.OrderStatusPending, history[0].ToStatus)
+	assert.Equal(t, domain.OrderStatusPending, history[1].FromStatus)
+	assert.Equal(t, domain.SystemActor, history[1].Actor)
+	assert.Equal(t, "payment received", history[1].Reason)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestPostgresOrderRepository_GetPageByUserIDUsesKeyset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresOrderRepository{db: db}
+
+	lastCreatedAt := time.Date(2026, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	token, err := encodePageToken("created_at desc", lastCreatedAt, "o2")
+	assert.NoError(t, err)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders WHERE user_id = \$1`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	mock.ExpectQuery(`WHERE user_id = \$1 AND \(created_at, id\) < \(\$2, \$3\)\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$4`).
+		WithArgs("u1", lastCreatedAt, "o2", int32(6)).
+		WillReturnRows(sqlmock.NewRows(orderColumnNames))
+
+	page, err := repo.GetPageByUserID("u1", repository.PageRequest{Token: token, Limit: 5, WithTotal: true})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Items)
+	assert.Empty(t, page.NextPageToken)
+	assert.Equal(t, int32(7), *page.Total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+var orderColumnNames = []string{"id", "user_id", "status", "total_price_cents", "subtotal_cents", "discount_cents", "tax_cents",
+	"tax_region", "delivery_fee_cents", "delivery_address", "delivery_zone", "delivery_slot_id", "delivery_starts_at",
+	"delivery_ends_at", "currency", "base_currency", "exchange_rate", "created_at"}
+
+// expectUserOrdersPage returns orders of which the first is delivered to a
+// saved address in a booked slot.
+func expectUserOrdersPage(mock sqlmock.Sqlmock, orders int) {
+	orderRows := sqlmock.NewRows(orderColumnNames)
+	itemRows := sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "price_cents", "id", "name", "price_cents", "currency", "stock"})
+	discountRows := sqlmock.NewRows([]string{"id", "order_id", "order_item_id", "promotion_id", "code", "description", "amount_cents"})
+	slotStartsAt := time.Date(2026, 5, 2, 10, 0, 0, 0, time.UTC)
+	ids := make([]string, orders)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("o%d", i)
+		if i == 0 {
+			orderRows.AddRow(ids[i], "u1", "pending", 950, 500, 50, 0, "", 500, []byte(`{"id":"a1","line1":"Abay 10","city":"Almaty"}`),
+				"center", "slot-1", slotStartsAt, slotStartsAt.Add(2*time.Hour), "KZT", "KZT", "1", time.Now())
+		} else {
+			orderRows.AddRow(ids[i], "u1", "pending", 450, 500, 50, 0, "", 0, nil, "", nil, nil, nil, "KZT", "KZT", "1", time.Now())
+		}
+		itemRows.AddRow("i"+ids[i], ids[i], "p1", 2, 250, "p1", "Apple", 250, "KZT", 10)
+		discountRows.AddRow("d"+ids[i], ids[i], "i"+ids[i], "promo-1", "", "10% off fruit", 50)
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders WHERE user_id = \$1`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(orders))
+	mock.ExpectQuery(`FROM orders\s+WHERE user_id = \$1`).
+		WithArgs("u1", int32(orders), int32(0)).
+		WillReturnRows(orderRows)
+	mock.ExpectQuery(`FROM order_items oi\s+LEFT JOIN products p ON oi.product_id = p.id\s+WHERE oi.order_id = ANY\(\$1\)`).
+		WithArgs(pq.Array(ids)).
+		WillReturnRows(itemRows)
+	mock.ExpectQuery(`FROM order_discounts\s+WHERE order_id = ANY\(\$1\)`).
+		WithArgs(pq.Array(ids)).
+		WillReturnRows(discountRows)
+}
+
+func TestPostgresOrderRepository_GetByUserIDLoadsItemsInOneQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresOrderRepository{db: db}
+
+	expectUserOrdersPage(mock, 3)
+
+	orders, total, err := repo.GetByUserID("u1", 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), total)
+	assert.Len(t, orders, 3)
+	for _, order := range orders {
+		assert.Len(t, order.Items, 1)
+		assert.Equal(t, order.ID, order.Items[0].OrderID)
+		assert.Equal(t, domain.NewMoney(250, "KZT"), order.Items[0].Price)
+		assert.Equal(t, "Apple", order.Items[0].Product.Name)
+		assert.Equal(t, domain.NewMoney(50, "KZT"), order.DiscountTotal)
+		assert.Len(t, order.Discounts, 1)
+		assert.Equal(t, order.Items[0].ID, order.Discounts[0].OrderItemID)
+		assert.Equal(t, domain.NewMoney(50, "KZT"), order.Discounts[0].Amount)
+	}
+
+	assert.Equal(t, domain.NewMoney(500, "KZT"), orders[0].DeliveryFee)
+	assert.Equal(t, "center", orders[0].DeliveryZone)
+	assert.Equal(t, &domain.Address{ID: "a1", Line1: "Abay 10", City: "Almaty"}, orders[0].DeliveryAddress)
+	assert.Equal(t, "slot-1", orders[0].DeliverySlot.ID)
+	assert.Equal(t, 2*time.Hour, orders[0].DeliverySlot.EndsAt.Sub(orders[0].DeliverySlot.StartsAt))
+	assert.Nil(t, orders[1].DeliveryAddress)
+	assert.Nil(t, orders[1].DeliverySlot)
+	assert.Equal(t, domain.NewMoney(0, "KZT"), orders[1].DeliveryFee)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// countingExecutor counts the statements sent through it.
+type countingExecutor struct {
+	dbExecutor
+	statements int
+}
+
+func (e *countingExecutor) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	e.statements++
+	return e.dbExecutor.Query(query, args...)
+}
+
+func (e *countingExecutor) QueryRow(query string, args ...interface{}) *sql.Row {
+	e.statements++
+	return e.dbExecutor.QueryRow(query, args...)
+}
+
+// BenchmarkPostgresOrderRepository_GetByUserID reports the statements needed
+// for a page of orders, which stays at four whatever the page size.
+func BenchmarkPostgresOrderRepository_GetByUserID(b *testing.B) {
+	for _, pageSize := range []int{10, 50} {
+		b.Run(fmt.Sprintf("page=%d", pageSize), func(b *testing.B) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				b.Fatalf("an error '%s' ", err)
+			}
+			defer db.Close()
+
+			exec := &countingExecutor{dbExecutor: db}
+			repo := &PostgresOrderRepository{db: exec}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				expectUserOrdersPage(mock, pageSize)
+				b.StartTimer()
+
+				if _, _, err := repo.GetByUserID("u1", 1, int32(pageSize)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(exec.statements)/float64(b.N), "queries/op")
+			if err := mock.ExpectationsWereMet(); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "AdvProg2/domain"
+)
+
+// outboxRelayLockKey identifies the advisory lock held by the relay draining
+// the outbox table.
+const outboxRelayLockKey = 7305001
+
+type PostgresOutboxRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+    return &PostgresOutboxRepository{
+        db: db,
+    }
+}
+
+func (r *PostgresOutboxRepository) Add(message *domain.OutboxMessage) error {
+    if message.CreatedAt.IsZero() {
+        message.CreatedAt = time.Now()
+    }
+
+    if message.NextAttemptAt.IsZero() {
+        message.NextAttemptAt = message.CreatedAt
+    }
+
+    query := `
+        INSERT INTO outbox (id, aggregate_type, aggregate_id, subject, payload, next_attempt_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+
+    _, err := r.db.Exec(query, message.ID, message.AggregateType, message.AggregateID,
+        message.Subject, message.Payload, message.NextAttemptAt, message.CreatedAt)
+    return err
+}
+
+// ListPending picks the oldest live message of every aggregate and keeps the
+// ones that are due, so messages waiting behind a backing-off head never fill
+// the batch.
+func (r *PostgresOutboxRepository) ListPending(limit int32) ([]*domain.OutboxMessage, error) {
+    query := `
+        SELECT id, aggregate_type, aggregate_id, subject, payload, attempts, last_error, next_attempt_at, created_at
+        FROM (
+            SELECT DISTINCT ON (aggregate_type, aggregate_id) seq, id, aggregate_type, aggregate_id, subject,
+                payload, attempts, last_error, next_attempt_at, created_at
+            FROM outbox
+            WHERE delivered_at IS NULL AND dead_at IS NULL
+            ORDER BY aggregate_type, aggregate_id, seq
+        ) heads
+        WHERE next_attempt_at <= NOW()
+        ORDER BY seq
+        LIMIT $1
+    `
+
+    rows, err := r.db.Query(query, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var messages []*domain.OutboxMessage
+
+    for rows.Next() {
+        var message domain.OutboxMessage
+        err := rows.Scan(
+            &message.ID,
+            &message.AggregateType,
+            &message.AggregateID,
+            &message.Subject,
+            &message.Payload,
+            &message.Attempts,
+            &message.LastError,
+            &message.NextAttemptAt,
+            &message.CreatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        messages = append(messages, &message)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    return messages, nil
+}
+
+func (r *PostgresOutboxRepository) MarkDelivered(id string) error {
+    query := `UPDATE outbox SET delivered_at = NOW(), attempts = attempts + 1, last_error = '' WHERE id = $1`
+
+    return r.execAffectingOne(query, id)
+}
+
+func (r *PostgresOutboxRepository) MarkFailed(id string, lastError string, nextAttemptAt time.Time) error {
+    query := `UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`
+
+    return r.execAffectingOne(query, id, lastError, nextAttemptAt)
+}
+
+func (r *PostgresOutboxRepository) MarkDead(id string, lastError string) error {
+    query := `UPDATE outbox SET dead_at = NOW(), attempts = attempts + 1, last_error = $2 WHERE id = $1`
+
+    return r.execAffectingOne(query, id, lastError)
+}
+
+func (r *PostgresOutboxRepository) DeleteDeliveredBefore(before time.Time) (int64, error) {
+    query := `DELETE FROM outbox WHERE delivered_at IS NOT NULL AND delivered_at < $1`
+
+    res, err := r.db.Exec(query, before)
+    if err != nil {
+        return 0, err
+    }
+
+    return res.RowsAffected()
+}
+
+func (r *PostgresOutboxRepository) TryLock() (bool, error) {
+    var locked bool
+    err := r.db.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLockKey).Scan(&locked)
+    return locked, err
+}
+
+func (r *PostgresOutboxRepository) execAffectingOne(query string, args ...interface{}) error {
+    res, err := r.db.Exec(query, args...)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return errors.New("outbox message not found")
+    }
+
+    return nil
+}
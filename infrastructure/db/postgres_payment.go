@@ -0,0 +1,145 @@
+package db
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "github.com/google/uuid"
+    "github.com/lib/pq"
+)
+
+// paymentColumns is the column list scanned by scanPayment.
+const paymentColumns = `id, order_id, user_id, amount_cents, currency, status, provider, provider_ref, client_secret,
+    failure_reason, created_at, updated_at`
+
+type PostgresPaymentRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresPaymentRepository(db *sql.DB) *PostgresPaymentRepository {
+    return &PostgresPaymentRepository{
+        db: db,
+    }
+}
+
+func scanPayment(row interface{ Scan(dest ...interface{}) error }) (*domain.PaymentIntent, error) {
+    var intent domain.PaymentIntent
+    err := row.Scan(&intent.ID, &intent.OrderID, &intent.UserID, &intent.Amount.Amount, &intent.Amount.Currency,
+        &intent.Status, &intent.Provider, &intent.ProviderRef, &intent.ClientSecret, &intent.FailureReason,
+        &intent.CreatedAt, &intent.UpdatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &intent, nil
+}
+
+func (r *PostgresPaymentRepository) Create(intent *domain.PaymentIntent) error {
+    if intent.ID == "" {
+        intent.ID = uuid.New().String()
+    }
+    if intent.Status == "" {
+        intent.Status = domain.PaymentStatusPending
+    }
+    if intent.CreatedAt.IsZero() {
+        intent.CreatedAt = time.Now().UTC()
+    }
+    if intent.UpdatedAt.IsZero() {
+        intent.UpdatedAt = intent.CreatedAt
+    }
+
+    _, err := r.db.Exec(`
+        INSERT INTO payment_intents (id, order_id, user_id, amount_cents, currency, status, provider, provider_ref,
+            client_secret, failure_reason, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+    `, intent.ID, intent.OrderID, intent.UserID, intent.Amount.Amount, intent.Amount.Currency, intent.Status,
+        intent.Provider, intent.ProviderRef, intent.ClientSecret, intent.FailureReason, intent.CreatedAt, intent.UpdatedAt)
+
+    if isOpenPaymentViolation(err) {
+        return fmt.Errorf("%w: %s", repository.ErrOrderHasOpenPayment, intent.OrderID)
+    }
+    return err
+}
+
+// isOpenPaymentViolation reports whether err is a write giving an order a
+// second pending or succeeded intent.
+func isOpenPaymentViolation(err error) bool {
+    var pqErr *pq.Error
+    return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_payment_intents_open"
+}
+
+func (r *PostgresPaymentRepository) GetByID(id string) (*domain.PaymentIntent, error) {
+    intent, err := scanPayment(r.db.QueryRow(`SELECT `+paymentColumns+` FROM payment_intents WHERE id = $1`, id))
+    if err == sql.ErrNoRows {
+        return nil, repository.ErrPaymentNotFound
+    }
+    return intent, err
+}
+
+func (r *PostgresPaymentRepository) GetByProviderRef(provider, ref string) (*domain.PaymentIntent, error) {
+    intent, err := scanPayment(r.db.QueryRow(`
+        SELECT `+paymentColumns+` FROM payment_intents WHERE provider = $1 AND provider_ref = $2
+    `, provider, ref))
+    if err == sql.ErrNoRows {
+        return nil, repository.ErrPaymentNotFound
+    }
+    return intent, err
+}
+
+func (r *PostgresPaymentRepository) ListByOrderID(orderID string) ([]*domain.PaymentIntent, error) {
+    rows, err := r.db.Query(`
+        SELECT `+paymentColumns+` FROM payment_intents
+        WHERE order_id = $1
+        ORDER BY created_at DESC, id DESC
+    `, orderID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    intents := []*domain.PaymentIntent{}
+    for rows.Next() {
+        intent, err := scanPayment(rows)
+        if err != nil {
+            return nil, err
+        }
+        intents = append(intents, intent)
+    }
+
+    return intents, rows.Err()
+}
+
+func (r *PostgresPaymentRepository) UpdateStatus(id string, from, to domain.PaymentStatus, failureReason string, at time.Time) error {
+    res, err := r.db.Exec(`
+        UPDATE payment_intents SET status = $3, failure_reason = $4, updated_at = $5
+        WHERE id = $1 AND status = $2
+    `, id, from, to, failureReason, at)
+    if isOpenPaymentViolation(err) {
+        return fmt.Errorf("%w: payment %s cannot become %s", repository.ErrOrderHasOpenPayment, id, to)
+    }
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        var exists bool
+        err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM payment_intents WHERE id = $1)", id).Scan(&exists)
+        if err != nil {
+            return err
+        }
+        if !exists {
+            return repository.ErrPaymentNotFound
+        }
+        return repository.ErrPaymentStatusChanged
+    }
+
+    return nil
+}
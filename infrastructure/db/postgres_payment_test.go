@@ -0,0 +1,98 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresPaymentRepository_CreateRejectsASecondOpenIntent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresPaymentRepository{db: db}
+	createdAt := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	intent := &domain.PaymentIntent{
+		ID:          "pay-1",
+		OrderID:     "order-1",
+		UserID:      "u1",
+		Amount:      domain.NewMoney(125000, "KZT"),
+		Provider:    "fake",
+		ProviderRef: "fake_pi_pay-1",
+		CreatedAt:   createdAt,
+	}
+
+	mock.ExpectExec(`INSERT INTO payment_intents`).
+		WithArgs("pay-1", "order-1", "u1", int64(125000), "KZT", domain.PaymentStatusPending, "fake", "fake_pi_pay-1",
+			"", "", createdAt, createdAt).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_payment_intents_open"})
+
+	assert.ErrorIs(t, repo.Create(intent), repository.ErrOrderHasOpenPayment)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresPaymentRepository_UpdateStatusReportsWhyItFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresPaymentRepository{db: db}
+	now := time.Now()
+
+	mock.ExpectExec(`UPDATE payment_intents SET status = \$3, failure_reason = \$4, updated_at = \$5\s+WHERE id = \$1 AND status = \$2`).
+		WithArgs("pay-1", domain.PaymentStatusPending, domain.PaymentStatusSucceeded, "", now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.UpdateStatus("pay-1", domain.PaymentStatusPending, domain.PaymentStatusSucceeded, "", now))
+
+	mock.ExpectExec(`UPDATE payment_intents`).
+		WithArgs("pay-1", domain.PaymentStatusPending, domain.PaymentStatusFailed, "card declined", now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM payment_intents WHERE id = \$1\)`).
+		WithArgs("pay-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	err = repo.UpdateStatus("pay-1", domain.PaymentStatusPending, domain.PaymentStatusFailed, "card declined", now)
+	assert.ErrorIs(t, err, repository.ErrPaymentStatusChanged)
+
+	mock.ExpectExec(`UPDATE payment_intents`).
+		WithArgs("missing", domain.PaymentStatusPending, domain.PaymentStatusFailed, "", now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	err = repo.UpdateStatus("missing", domain.PaymentStatusPending, domain.PaymentStatusFailed, "", now)
+	assert.ErrorIs(t, err, repository.ErrPaymentNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresPaymentRepository_UpdateStatusRejectsASecondOpenIntent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresPaymentRepository{db: db}
+	now := time.Now()
+
+	mock.ExpectExec(`UPDATE payment_intents`).
+		WithArgs("pay-1", domain.PaymentStatusFailed, domain.PaymentStatusSucceeded, "", now).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_payment_intents_open"})
+
+	err = repo.UpdateStatus("pay-1", domain.PaymentStatusFailed, domain.PaymentStatusSucceeded, "", now)
+	assert.ErrorIs(t, err, repository.ErrOrderHasOpenPayment)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+    "database/sql"
+    "time"
+
+    "AdvProg2/repository"
+)
+
+// PostgresProcessedMessageRepository records the message IDs handled by one
+// consumer. Every service passes its own consumer name, so each of them still
+// handles every event once.
+type PostgresProcessedMessageRepository struct {
+    db       dbExecutor
+    consumer string
+}
+
+func NewPostgresProcessedMessageRepository(db *sql.DB, consumer string) *PostgresProcessedMessageRepository {
+    return &PostgresProcessedMessageRepository{
+        db:       db,
+        consumer: consumer,
+    }
+}
+
+func (r *PostgresProcessedMessageRepository) Process(messageID string, ttl time.Duration, handle func(tx repository.Transaction) error) (bool, error) {
+    // A claim that has expired is taken over; a live one leaves the insert
+    // with no rows. The row lock makes a concurrent claim of the same
+    // message wait until this transaction ends.
+    query := `
+        INSERT INTO processed_messages (consumer, message_id, processed_at, expires_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (consumer, message_id)
+        DO UPDATE SET processed_at = EXCLUDED.processed_at, expires_at = EXCLUDED.expires_at
+        WHERE processed_messages.expires_at <= EXCLUDED.processed_at
+    `
+
+    processed := false
+    err := inTransaction(r.db, func(tx dbExecutor) error {
+        now := time.Now()
+        res, err := tx.Exec(query, r.consumer, messageID, now, now.Add(ttl))
+        if err != nil {
+            return err
+        }
+
+        claimed, err := res.RowsAffected()
+        if err != nil || claimed == 0 {
+            return err
+        }
+
+        processed = true
+        return handle(&postgresTransaction{tx: tx})
+    })
+    if err != nil {
+        return false, err
+    }
+
+    return processed, nil
+}
+
+func (r *PostgresProcessedMessageRepository) DeleteExpired() (int64, error) {
+    query := `DELETE FROM processed_messages WHERE expires_at <= NOW()`
+
+    res, err := r.db.Exec(query)
+    if err != nil {
+        return 0, err
+    }
+
+    return res.RowsAffected()
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresProcessedMessageRepository_ProcessClaimsBeforeHandling(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresProcessedMessageRepository(db, "test-service")
+	claim := `INSERT INTO processed_messages \(consumer, message_id, processed_at, expires_at\)\s+VALUES \(\$1, \$2, \$3, \$4\)\s+ON CONFLICT \(consumer, message_id\)`
+
+	mock.ExpectBegin()
+	mock.ExpectExec(claim).
+		WithArgs("test-service", "message-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	processed, err := repo.Process("message-1", time.Hour, func(tx repository.Transaction) error {
+		return tx.Outbox().Add(&domain.OutboxMessage{ID: "out-1"})
+	})
+	assert.NoError(t, err)
+	assert.True(t, processed)
+
+	// Already claimed: the handler is not run.
+	mock.ExpectBegin()
+	mock.ExpectExec(claim).
+		WithArgs("test-service", "message-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	processed, err = repo.Process("message-1", time.Hour, func(tx repository.Transaction) error {
+		t.Fatal("handler ran for a processed message")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, processed)
+
+	// A failing handler rolls the claim back, so the message is retried.
+	mock.ExpectBegin()
+	mock.ExpectExec(claim).
+		WithArgs("test-service", "message-2", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	processed, err = repo.Process("message-2", time.Hour, func(tx repository.Transaction) error {
+		return errors.New("smtp unavailable")
+	})
+	assert.EqualError(t, err, "smtp unavailable")
+	assert.False(t, processed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
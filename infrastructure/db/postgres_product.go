@@ -4,14 +4,19 @@ import (
     "database/sql"
     "errors"
     "fmt"
+    "html"
     "strings"
     
     "AdvProg2/domain"
-    _ "github.com/lib/pq"
+    "AdvProg2/repository"
+    "github.com/lib/pq"
 )
 
+// productColumns is the column list scanned by scanProduct.
+const productColumns = `id, name, price_cents, currency, stock, category_id, reorder_threshold`
+
 type PostgresProductRepository struct {
-    db *sql.DB
+    db dbExecutor
 }
 
 func NewPostgresProductRepository(db *sql.DB) *PostgresProductRepository {
@@ -20,32 +25,94 @@ func NewPostgresProductRepository(db *sql.DB) *PostgresProductRepository {
     }
 }
 
-func (r *PostgresProductRepository) Create(product *domain.Product) error {
-    query := `INSERT INTO products (id, name, price, stock) VALUES ($1, $2, $3, $4)`
+func scanProduct(row interface{ Scan(dest ...interface{}) error }) (*domain.Product, error) {
+    var product domain.Product
+    var categoryID sql.NullString
+    
+    err := row.Scan(&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.Stock, &categoryID,
+        &product.ReorderThreshold)
+    if err != nil {
+        return nil, err
+    }
     
-    _, err := r.db.Exec(query, product.ID, product.Name, product.Price, product.Stock)
+    product.CategoryID = categoryID.String
+    return &product, nil
+}
+
+// nullableID stores an empty ID as NULL so that it satisfies foreign keys.
+func nullableID(id string) sql.NullString {
+    return sql.NullString{String: id, Valid: id != ""}
+}
+
+// isForeignKeyViolation reports whether err was raised by the named foreign
+// key constraint.
+func isForeignKeyViolation(err error, constraint string) bool {
+    var pqErr *pq.Error
+    return errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == constraint
+}
+
+func productWriteError(err error, product *domain.Product) error {
+    if isForeignKeyViolation(err, "products_category_id_fkey") {
+        return fmt.Errorf("%w: %s", repository.ErrCategoryNotFound, product.CategoryID)
+    }
     return err
 }
 
+func (r *PostgresProductRepository) Create(product *domain.Product) error {
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        query := `INSERT INTO products (id, name, price_cents, currency, stock, category_id) VALUES ($1, $2, $3, $4, 0, $5)`
+        
+        _, err := tx.Exec(query, product.ID, product.Name, product.Price.Amount, product.Price.Currency, nullableID(product.CategoryID))
+        if err != nil {
+            return productWriteError(err, product)
+        }
+        
+        return setProductTags(tx, product.ID, product.Tags)
+    })
+}
+
 func (r *PostgresProductRepository) GetByID(id string) (*domain.Product, error) {
-    query := `SELECT id, name, price, stock FROM products WHERE id = $1`
+    query := `SELECT ` + productColumns + ` FROM products WHERE id = $1`
     
-    var product domain.Product
-    err := r.db.QueryRow(query, id).Scan(&product.ID, &product.Name, &product.Price, &product.Stock)
+    product, err := scanProduct(r.db.QueryRow(query, id))
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, errors.New("product not found")
+            return nil, repository.ErrProductNotFound
         }
         return nil, err
     }
     
-    return &product, nil
+    if err := r.loadDetails([]*domain.Product{product}); err != nil {
+        return nil, err
+    }
+    
+    return product, nil
 }
 
 func (r *PostgresProductRepository) Update(product *domain.Product) error {
-    query := `UPDATE products SET name = $2, price = $3, stock = $4 WHERE id = $1`
-    
-    res, err := r.db.Exec(query, product.ID, product.Name, product.Price, product.Stock)
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        query := `UPDATE products SET name = $2, price_cents = $3, currency = $4, category_id = $5 WHERE id = $1`
+        
+        res, err := tx.Exec(query, product.ID, product.Name, product.Price.Amount, product.Price.Currency, nullableID(product.CategoryID))
+        if err != nil {
+            return productWriteError(err, product)
+        }
+        
+        rowsAffected, err := res.RowsAffected()
+        if err != nil {
+            return err
+        }
+        
+        if rowsAffected == 0 {
+            return repository.ErrProductNotFound
+        }
+        
+        return setProductTags(tx, product.ID, product.Tags)
+    })
+}
+
+func (r *PostgresProductRepository) SetReorderThreshold(id string, threshold int32) error {
+    res, err := r.db.Exec(`UPDATE products SET reorder_threshold = $2 WHERE id = $1`, id, threshold)
     if err != nil {
         return err
     }
@@ -56,12 +123,201 @@ func (r *PostgresProductRepository) Update(product *domain.Product) error {
     }
     
     if rowsAffected == 0 {
-        return errors.New("product not found")
+        return repository.ErrProductNotFound
     }
     
     return nil
 }
 
+// ListLowStock returns the products below their reorder threshold, those
+// furthest below it first.
+func (r *PostgresProductRepository) ListLowStock() ([]*domain.Product, error) {
+    query := `SELECT ` + productColumns + ` FROM products
+        WHERE stock < reorder_threshold
+        ORDER BY stock - reorder_threshold, name, id`
+    
+    products, err := r.queryProducts(query)
+    if err != nil {
+        return nil, err
+    }
+    
+    if products == nil {
+        products = []*domain.Product{}
+    }
+    
+    return products, nil
+}
+
+func (r *PostgresProductRepository) ListIDsInCategory(categoryID string, productIDs []string) ([]string, error) {
+    conditions, args := productConditions(repository.ProductFilter{CategoryID: categoryID}, []interface{}{pq.Array(productIDs)})
+    query := `SELECT id FROM products WHERE id = ANY($1) AND ` + conditions[0] + ` ORDER BY id`
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    ids := []string{}
+    for rows.Next() {
+        var id string
+        if err := rows.Scan(&id); err != nil {
+            return nil, err
+        }
+        ids = append(ids, id)
+    }
+
+    return ids, rows.Err()
+}
+
+func (r *PostgresProductRepository) ListCategoryAncestors(categoryIDs []string) (map[string][]string, error) {
+    query := `
+        WITH RECURSIVE ancestors(category_id, id, depth) AS (
+            SELECT id, parent_id, 1 FROM categories
+            WHERE id = ANY($1) AND parent_id IS NOT NULL
+            UNION ALL
+            SELECT a.category_id, c.parent_id, a.depth + 1 FROM ancestors a
+            JOIN categories c ON c.id = a.id
+            WHERE c.parent_id IS NOT NULL
+        )
+        SELECT category_id, id FROM ancestors ORDER BY category_id, depth`
+
+    rows, err := r.db.Query(query, pq.Array(categoryIDs))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    ancestors := make(map[string][]string)
+    for rows.Next() {
+        var categoryID, id string
+        if err := rows.Scan(&categoryID, &id); err != nil {
+            return nil, err
+        }
+        ancestors[categoryID] = append(ancestors[categoryID], id)
+    }
+
+    return ancestors, rows.Err()
+}
+
+// setProductTags replaces the product's tags.
+func setProductTags(tx dbExecutor, productID string, tags []string) error {
+    if _, err := tx.Exec(`DELETE FROM product_tags WHERE product_id = $1`, productID); err != nil {
+        return err
+    }
+    
+    tags = domain.NormalizeTags(tags)
+    if len(tags) == 0 {
+        return nil
+    }
+    
+    _, err := tx.Exec(`INSERT INTO product_tags (product_id, tag) SELECT $1, unnest($2::text[])`, productID, pq.Array(tags))
+    return err
+}
+
+// loadTags fills in the tags of products with a single query.
+func (r *PostgresProductRepository) loadTags(products []*domain.Product) error {
+    if len(products) == 0 {
+        return nil
+    }
+    
+    ids := make([]string, len(products))
+    byID := make(map[string]*domain.Product, len(products))
+    for i, product := range products {
+        ids[i] = product.ID
+        byID[product.ID] = product
+    }
+    
+    rows, err := r.db.Query(`SELECT product_id, tag FROM product_tags WHERE product_id = ANY($1) ORDER BY tag`, pq.Array(ids))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+    
+    for rows.Next() {
+        var productID, tag string
+        if err := rows.Scan(&productID, &tag); err != nil {
+            return err
+        }
+        if product, ok := byID[productID]; ok {
+            product.Tags = append(product.Tags, tag)
+        }
+    }
+    
+    return rows.Err()
+}
+
+// loadImages fills in the galleries of products with a single query.
+func (r *PostgresProductRepository) loadImages(products []*domain.Product) error {
+    if len(products) == 0 {
+        return nil
+    }
+    
+    ids := make([]string, len(products))
+    byID := make(map[string]*domain.Product, len(products))
+    for i, product := range products {
+        ids[i] = product.ID
+        byID[product.ID] = product
+    }
+    
+    query := `SELECT ` + productImageColumns + ` FROM product_images WHERE product_id = ANY($1) ORDER BY product_id, position`
+    rows, err := r.db.Query(query, pq.Array(ids))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+    
+    for rows.Next() {
+        image, err := scanProductImage(rows)
+        if err != nil {
+            return err
+        }
+        if product, ok := byID[image.ProductID]; ok {
+            product.Images = append(product.Images, image)
+        }
+    }
+    
+    return rows.Err()
+}
+
+// loadDetails fills in the tags and images of products.
+func (r *PostgresProductRepository) loadDetails(products []*domain.Product) error {
+    if err := r.loadTags(products); err != nil {
+        return err
+    }
+    return r.loadImages(products)
+}
+
+// queryProducts runs a query selecting productColumns and loads the tags and
+// images of the products it returns.
+func (r *PostgresProductRepository) queryProducts(query string, args ...interface{}) ([]*domain.Product, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    var products []*domain.Product
+    
+    for rows.Next() {
+        product, err := scanProduct(rows)
+        if err != nil {
+            return nil, err
+        }
+        products = append(products, product)
+    }
+    
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+    
+    if err := r.loadDetails(products); err != nil {
+        return nil, err
+    }
+    
+    return products, nil
+}
+
 func (r *PostgresProductRepository) Delete(id string) error {
     query := `DELETE FROM products WHERE id = $1`
     
@@ -76,7 +332,7 @@ func (r *PostgresProductRepository) Delete(id string) error {
     }
     
     if rowsAffected == 0 {
-        return errors.New("product not found")
+        return repository.ErrProductNotFound
     }
     
     return nil
@@ -92,158 +348,174 @@ func (r *PostgresProductRepository) List(page, limit int32) ([]*domain.Product,
         return nil, 0, err
     }
     
-    query := `SELECT id, name, price, stock FROM products ORDER BY name LIMIT $1 OFFSET $2`
+    query := `SELECT ` + productColumns + ` FROM products ORDER BY name LIMIT $1 OFFSET $2`
     
-    rows, err := r.db.Query(query, limit, offset)
+    products, err := r.queryProducts(query, limit, offset)
     if err != nil {
         return nil, 0, err
     }
-    defer rows.Close()
-    
-    var products []*domain.Product
     
-    for rows.Next() {
-        var product domain.Product
-        if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock); err != nil {
-            return nil, 0, err
-        }
-        products = append(products, &product)
+    return products, total, nil
+}
+
+func (r *PostgresProductRepository) SearchByName(name string, page, limit int32) ([]*domain.Product, int32, error) {
+    return r.SearchByFilters(repository.ProductFilter{Name: name}, page, limit)
+}
+
+func (r *PostgresProductRepository) SearchByPriceRange(minPrice, maxPrice domain.Money, page, limit int32) ([]*domain.Product, int32, error) {
+    priceCurrency := minPrice.Currency
+    if minPrice.Amount <= 0 {
+        priceCurrency = maxPrice.Currency
+    }
+    return r.SearchByFilters(repository.ProductFilter{MinPrice: minPrice, MaxPrice: maxPrice, PriceCurrency: priceCurrency}, page, limit)
+}
+
+var productSortColumns = map[repository.ProductSortField]string{
+    repository.ProductSortByName:  "name",
+    repository.ProductSortByPrice: "price_cents",
+    repository.ProductSortByStock: "stock",
+}
+
+// productOrderBy builds the ORDER BY clause for filter. Ties are broken by
+// id so that pages do not overlap.
+func productOrderBy(filter repository.ProductFilter) string {
+    column, ok := productSortColumns[filter.SortBy]
+    if !ok {
+        column = "name"
     }
     
-    if err = rows.Err(); err != nil {
-        return nil, 0, err
+    direction := "ASC"
+    if filter.Descending {
+        direction = "DESC"
     }
     
-    return products, total, nil
+    return fmt.Sprintf("%s %s, id %s", column, direction, direction)
 }
 
-func (r *PostgresProductRepository) SearchByName(name string, page, limit int32) ([]*domain.Product, int32, error) {
-    offset := (page - 1) * limit
+// escapeLike makes LIKE wildcards in text match literally.
+func escapeLike(text string) string {
+    return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+// productConditions turns filter into WHERE conditions. Their placeholders
+// are numbered after the arguments already in args, and the returned
+// arguments include those.
+func productConditions(filter repository.ProductFilter, args []interface{}) ([]string, []interface{}) {
+    var conditions []string
     
-    searchPattern := "%" + strings.ToLower(name) + "%"
+    if filter.Name != "" {
+        args = append(args, "%"+escapeLike(filter.Name)+"%")
+        conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(args)))
+    }
     
-    countQuery := `SELECT COUNT(*) FROM products WHERE LOWER(name) LIKE $1`
-    var total int32
-    err := r.db.QueryRow(countQuery, searchPattern).Scan(&total)
-    if err != nil {
-        return nil, 0, err
+    if filter.PriceCurrency != "" {
+        args = append(args, filter.PriceCurrency)
+        conditions = append(conditions, fmt.Sprintf("currency = $%d", len(args)))
     }
     
-    query := `SELECT id, name, price, stock FROM products WHERE LOWER(name) LIKE $1 ORDER BY name LIMIT $2 OFFSET $3`
+    if filter.MinPrice.Amount > 0 {
+        args = append(args, filter.MinPrice.Amount)
+        conditions = append(conditions, fmt.Sprintf("price_cents >= $%d", len(args)))
+    }
     
-    rows, err := r.db.Query(query, searchPattern, limit, offset)
-    if err != nil {
-        return nil, 0, err
+    if filter.MaxPrice.Amount > 0 {
+        args = append(args, filter.MaxPrice.Amount)
+        conditions = append(conditions, fmt.Sprintf("price_cents <= $%d", len(args)))
     }
-    defer rows.Close()
     
-    var products []*domain.Product
+    if filter.CategoryID != "" {
+        args = append(args, filter.CategoryID)
+        conditions = append(conditions, fmt.Sprintf(`category_id IN (
+            WITH RECURSIVE subtree(id) AS (
+                SELECT id FROM categories WHERE id = $%d
+                UNION
+                SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+            )
+            SELECT id FROM subtree
+        )`, len(args)))
+    }
     
-    for rows.Next() {
-        var product domain.Product
-        if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock); err != nil {
-            return nil, 0, err
-        }
-        products = append(products, &product)
+    if tags := domain.NormalizeTags(filter.Tags); len(tags) > 0 {
+        args = append(args, pq.Array(tags), len(tags))
+        conditions = append(conditions, fmt.Sprintf(`id IN (
+            SELECT product_id FROM product_tags WHERE tag = ANY($%d)
+            GROUP BY product_id HAVING COUNT(*) = $%d
+        )`, len(args)-1, len(args)))
     }
     
-    if err = rows.Err(); err != nil {
-        return nil, 0, err
+    if filter.InStock {
+        conditions = append(conditions, "stock > 0")
     }
     
-    return products, total, nil
+    return conditions, args
 }
 
-func (r *PostgresProductRepository) SearchByPriceRange(minPrice, maxPrice float64, page, limit int32) ([]*domain.Product, int32, error) {
+func (r *PostgresProductRepository) SearchByFilters(filter repository.ProductFilter, page, limit int32) ([]*domain.Product, int32, error) {
     offset := (page - 1) * limit
     
-    var countQuery string
-    var countArgs []interface{}
+    conditions, args := productConditions(filter, nil)
     
-    if minPrice > 0 && maxPrice > 0 {
-        countQuery = `SELECT COUNT(*) FROM products WHERE price >= $1 AND price <= $2`
-        countArgs = []interface{}{minPrice, maxPrice}
-    } else if minPrice > 0 {
-        countQuery = `SELECT COUNT(*) FROM products WHERE price >= $1`
-        countArgs = []interface{}{minPrice}
-    } else if maxPrice > 0 {
-        countQuery = `SELECT COUNT(*) FROM products WHERE price <= $1`
-        countArgs = []interface{}{maxPrice}
-    } else {
-        countQuery = `SELECT COUNT(*) FROM products`
+    whereClause := ""
+    if len(conditions) > 0 {
+        whereClause = "WHERE " + strings.Join(conditions, " AND ")
     }
     
+    countQuery := fmt.Sprintf("SELECT COUNT(*) FROM products %s", whereClause)
     var total int32
-    err := r.db.QueryRow(countQuery, countArgs...).Scan(&total)
+    err := r.db.QueryRow(countQuery, args...).Scan(&total)
     if err != nil {
         return nil, 0, err
     }
     
-    var query string
-    var queryArgs []interface{}
-    
-    if minPrice > 0 && maxPrice > 0 {
-        query = `SELECT id, name, price, stock FROM products WHERE price >= $1 AND price <= $2 ORDER BY name LIMIT $3 OFFSET $4`
-        queryArgs = []interface{}{minPrice, maxPrice, limit, offset}
-    } else if minPrice > 0 {
-        query = `SELECT id, name, price, stock FROM products WHERE price >= $1 ORDER BY name LIMIT $2 OFFSET $3`
-        queryArgs = []interface{}{minPrice, limit, offset}
-    } else if maxPrice > 0 {
-        query = `SELECT id, name, price, stock FROM products WHERE price <= $1 ORDER BY name LIMIT $2 OFFSET $3`
-        queryArgs = []interface{}{maxPrice, limit, offset}
-    } else {
-        query = `SELECT id, name, price, stock FROM products ORDER BY name LIMIT $1 OFFSET $2`
-        queryArgs = []interface{}{limit, offset}
-    }
+    query := fmt.Sprintf("SELECT %s FROM products %s ORDER BY %s LIMIT $%d OFFSET $%d", 
+                         productColumns, whereClause, productOrderBy(filter), len(args)+1, len(args)+2)
+    args = append(args, limit, offset)
     
-    rows, err := r.db.Query(query, queryArgs...)
+    products, err := r.queryProducts(query, args...)
     if err != nil {
         return nil, 0, err
     }
-    defer rows.Close()
-    
-    var products []*domain.Product
-    
-    for rows.Next() {
-        var product domain.Product
-        if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock); err != nil {
-            return nil, 0, err
-        }
-        products = append(products, &product)
-    }
-    
-    if err = rows.Err(); err != nil {
-        return nil, 0, err
-    }
     
     return products, total, nil
 }
 
-func (r *PostgresProductRepository) SearchByFilters(name string, minPrice, maxPrice float64, page, limit int32) ([]*domain.Product, int32, error) {
-    offset := (page - 1) * limit
-    
-    searchPattern := "%" + strings.ToLower(name) + "%"
-    
-    var conditions []string
-    var args []interface{}
-    var argIndex int = 1
+// ListPage pages by keyset on the sort column and id, the order used by
+// productOrderBy, so a page costs the same however deep it is.
+func (r *PostgresProductRepository) ListPage(filter repository.ProductFilter, page repository.PageRequest) (*repository.Page[*domain.Product], error) {
+    column, ok := productSortColumns[filter.SortBy]
+    if !ok {
+        column = "name"
+    }
     
-    if name != "" {
-        conditions = append(conditions, fmt.Sprintf("LOWER(name) LIKE $%d", argIndex))
-        args = append(args, searchPattern)
-        argIndex++
+    comparison, sort := ">", column+" asc"
+    if filter.Descending {
+        comparison, sort = "<", column+" desc"
     }
     
-    if minPrice > 0 {
-        conditions = append(conditions, fmt.Sprintf("price >= $%d", argIndex))
-        args = append(args, minPrice)
-        argIndex++
+    conditions, args := productConditions(filter, nil)
+    
+    var total *int32
+    if page.WithTotal {
+        countQuery := "SELECT COUNT(*) FROM products"
+        if len(conditions) > 0 {
+            countQuery += " WHERE " + strings.Join(conditions, " AND ")
+        }
+        
+        total = new(int32)
+        if err := r.db.QueryRow(countQuery, args...).Scan(total); err != nil {
+            return nil, err
+        }
     }
     
-    if maxPrice > 0 {
-        conditions = append(conditions, fmt.Sprintf("price <= $%d", argIndex))
-        args = append(args, maxPrice)
-        argIndex++
+    if page.Token != "" {
+        last := productSortValue(&domain.Product{}, column)
+        id, err := decodePageToken(page.Token, sort, last)
+        if err != nil {
+            return nil, err
+        }
+        
+        args = append(args, last, id)
+        conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args)))
     }
     
     whereClause := ""
@@ -251,15 +523,79 @@ func (r *PostgresProductRepository) SearchByFilters(name string, minPrice, maxPr
         whereClause = "WHERE " + strings.Join(conditions, " AND ")
     }
     
-    countQuery := fmt.Sprintf("SELECT COUNT(*) FROM products %s", whereClause)
+    query := fmt.Sprintf("SELECT %s FROM products %s ORDER BY %s LIMIT $%d",
+                         productColumns, whereClause, productOrderBy(filter), len(args)+1)
+    args = append(args, page.Limit+1)
+    
+    products, err := r.queryProducts(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    
+    result, err := newPage(products, page.Limit, func(last *domain.Product) (string, error) {
+        return encodePageToken(sort, productSortValue(last, column), last.ID)
+    })
+    if err != nil {
+        return nil, err
+    }
+    result.Total = total
+    
+    return result, nil
+}
+
+// productSortValue points to the product's field for the sort column.
+func productSortValue(product *domain.Product, column string) interface{} {
+    switch column {
+    case "price_cents":
+        return &product.Price.Amount
+    case "stock":
+        return &product.Stock
+    }
+    return &product.Name
+}
+
+// ts_headline marks matched words with control characters, which product
+// names do not use, so that the name can be HTML-escaped before they are
+// turned into <mark> tags.
+const (
+    highlightStart = "\x02"
+    highlightStop  = "\x03"
+)
+
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// highlightHTML escapes the headline returned by ts_headline and wraps its
+// matched words in <mark> tags.
+func highlightHTML(headline string) string {
+    return highlightMarks.Replace(html.EscapeString(headline))
+}
+
+// SearchText matches text against the full-text index of product names and,
+// to tolerate typos, against their trigrams. Results are ranked by both.
+func (r *PostgresProductRepository) SearchText(text string, filter repository.ProductFilter, page, limit int32) ([]*domain.ProductSearchResult, int32, error) {
+    offset := (page - 1) * limit
+    
+    filter.Name = ""
+    conditions, args := productConditions(filter, []interface{}{text})
+    conditions = append([]string{"(search_vector @@ tsq OR $1 <% name)"}, conditions...)
+    whereClause := "WHERE " + strings.Join(conditions, " AND ")
+    
+    countQuery := fmt.Sprintf("SELECT COUNT(*) FROM products, websearch_to_tsquery('simple', $1) AS tsq %s", whereClause)
     var total int32
     err := r.db.QueryRow(countQuery, args...).Scan(&total)
     if err != nil {
         return nil, 0, err
     }
     
-    query := fmt.Sprintf("SELECT id, name, price, stock FROM products %s ORDER BY name LIMIT $%d OFFSET $%d", 
-                         whereClause, argIndex, argIndex+1)
+    query := fmt.Sprintf(`
+        SELECT %s,
+            ts_rank(search_vector, tsq) + word_similarity($1, name) AS rank,
+            ts_headline('simple', name, tsq, 'StartSel=%s, StopSel=%s, HighlightAll=true') AS highlight
+        FROM products, websearch_to_tsquery('simple', $1) AS tsq
+        %s
+        ORDER BY rank DESC, name, id
+        LIMIT $%d OFFSET $%d
+    `, productColumns, highlightStart, highlightStop, whereClause, len(args)+1, len(args)+2)
     args = append(args, limit, offset)
     
     rows, err := r.db.Query(query, args...)
@@ -268,13 +604,24 @@ func (r *PostgresProductRepository) SearchByFilters(name string, minPrice, maxPr
     }
     defer rows.Close()
     
+    var results []*domain.ProductSearchResult
     var products []*domain.Product
     
     for rows.Next() {
         var product domain.Product
-        if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock); err != nil {
+        var categoryID sql.NullString
+        var result domain.ProductSearchResult
+        
+        err := rows.Scan(&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.Stock, &categoryID,
+            &product.ReorderThreshold, &result.Rank, &result.Highlight)
+        if err != nil {
             return nil, 0, err
         }
+        
+        product.CategoryID = categoryID.String
+        result.Product = &product
+        result.Highlight = highlightHTML(result.Highlight)
+        results = append(results, &result)
         products = append(products, &product)
     }
     
@@ -282,5 +629,42 @@ func (r *PostgresProductRepository) SearchByFilters(name string, minPrice, maxPr
         return nil, 0, err
     }
     
-    return products, total, nil
-}
\ No newline at end of file
+    if err := r.loadDetails(products); err != nil {
+        return nil, 0, err
+    }
+    
+    return results, total, nil
+}
+
+// Suggest returns products whose names start with prefix, followed by the
+// closest fuzzy matches.
+func (r *PostgresProductRepository) Suggest(prefix string, limit int32) ([]*domain.ProductSuggestion, error) {
+    query := `
+        SELECT id, name FROM products
+        WHERE name ILIKE $1 OR $2 <% name
+        ORDER BY name ILIKE $1 DESC, word_similarity($2, name) DESC, name
+        LIMIT $3
+    `
+    
+    rows, err := r.db.Query(query, escapeLike(prefix)+"%", prefix, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    var suggestions []*domain.ProductSuggestion
+    
+    for rows.Next() {
+        var suggestion domain.ProductSuggestion
+        if err := rows.Scan(&suggestion.ID, &suggestion.Name); err != nil {
+            return nil, err
+        }
+        suggestions = append(suggestions, &suggestion)
+    }
+    
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+    
+    return suggestions, nil
+}
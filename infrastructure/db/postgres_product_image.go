@@ -0,0 +1,121 @@
+package db
+
+import (
+    "database/sql"
+
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "github.com/lib/pq"
+)
+
+// productImageColumns is the column list scanned by scanProductImage.
+const productImageColumns = `id, product_id, position, url, thumbnail_url, image_key, thumbnail_key`
+
+type PostgresProductImageRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresProductImageRepository(db *sql.DB) *PostgresProductImageRepository {
+    return &PostgresProductImageRepository{
+        db: db,
+    }
+}
+
+func scanProductImage(row interface{ Scan(dest ...interface{}) error }) (domain.ProductImage, error) {
+    var image domain.ProductImage
+    err := row.Scan(&image.ID, &image.ProductID, &image.Position, &image.URL, &image.ThumbnailURL, &image.Key, &image.ThumbnailKey)
+    return image, err
+}
+
+func (r *PostgresProductImageRepository) Add(image *domain.ProductImage) error {
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        // Locking the product serializes uploads to its gallery, so two
+        // images cannot take the same position.
+        var productID string
+        err := tx.QueryRow(`SELECT id FROM products WHERE id = $1 FOR UPDATE`, image.ProductID).Scan(&productID)
+        if err != nil {
+            if err == sql.ErrNoRows {
+                return repository.ErrProductNotFound
+            }
+            return err
+        }
+
+        query := `
+            INSERT INTO product_images (id, product_id, position, url, thumbnail_url, image_key, thumbnail_key)
+            SELECT $1, $2, COALESCE(MAX(position) + 1, 0), $3, $4, $5, $6
+            FROM product_images WHERE product_id = $2
+            RETURNING position
+        `
+
+        return tx.QueryRow(query, image.ID, image.ProductID, image.URL, image.ThumbnailURL, image.Key, image.ThumbnailKey).
+            Scan(&image.Position)
+    })
+}
+
+func (r *PostgresProductImageRepository) GetByID(id string) (*domain.ProductImage, error) {
+    query := `SELECT ` + productImageColumns + ` FROM product_images WHERE id = $1`
+
+    image, err := scanProductImage(r.db.QueryRow(query, id))
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, repository.ErrProductImageNotFound
+        }
+        return nil, err
+    }
+
+    return &image, nil
+}
+
+func (r *PostgresProductImageRepository) ListByProduct(productID string) ([]domain.ProductImage, error) {
+    query := `SELECT ` + productImageColumns + ` FROM product_images WHERE product_id = $1 ORDER BY position`
+
+    rows, err := r.db.Query(query, productID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    images := []domain.ProductImage{}
+
+    for rows.Next() {
+        image, err := scanProductImage(rows)
+        if err != nil {
+            return nil, err
+        }
+        images = append(images, image)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    return images, nil
+}
+
+func (r *PostgresProductImageRepository) Reorder(productID string, imageIDs []string) error {
+    query := `
+        UPDATE product_images AS pi SET position = o.position - 1
+        FROM unnest($2::text[]) WITH ORDINALITY AS o(id, position)
+        WHERE pi.id = o.id AND pi.product_id = $1
+    `
+
+    _, err := r.db.Exec(query, productID, pq.Array(imageIDs))
+    return err
+}
+
+func (r *PostgresProductImageRepository) Delete(id string) error {
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        var productID string
+        var position int32
+        err := tx.QueryRow(`DELETE FROM product_images WHERE id = $1 RETURNING product_id, position`, id).Scan(&productID, &position)
+        if err != nil {
+            if err == sql.ErrNoRows {
+                return repository.ErrProductImageNotFound
+            }
+            return err
+        }
+
+        _, err = tx.Exec(`UPDATE product_images SET position = position - 1 WHERE product_id = $1 AND position > $2`, productID, position)
+        return err
+    })
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresProductImageRepository_AddAppendsToTheGallery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductImageRepository{db: db}
+
+	image := &domain.ProductImage{ID: "img-2", ProductID: "apple", URL: "/media/b.png", ThumbnailURL: "/media/b_thumb.png", Key: "b.png", ThumbnailKey: "b_thumb.png"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM products WHERE id = \$1 FOR UPDATE`).
+		WithArgs("apple").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("apple"))
+	mock.ExpectQuery(`INSERT INTO product_images .+ COALESCE\(MAX\(position\) \+ 1, 0\)`).
+		WithArgs("img-2", "apple", "/media/b.png", "/media/b_thumb.png", "b.png", "b_thumb.png").
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Add(image))
+	assert.Equal(t, int32(1), image.Position)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM products`).
+		WithArgs("pear").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.Add(&domain.ProductImage{ProductID: "pear"}), repository.ErrProductNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductImageRepository_DeleteClosesTheGap(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductImageRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM product_images WHERE id = \$1 RETURNING product_id, position`).
+		WithArgs("img-1").
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "position"}).AddRow("apple", 0))
+	mock.ExpectExec(`UPDATE product_images SET position = position - 1 WHERE product_id = \$1 AND position > \$2`).
+		WithArgs("apple", 0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Delete("img-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductImageRepository_Reorder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductImageRepository{db: db}
+
+	mock.ExpectExec(`UPDATE product_images AS pi SET position = o.position - 1\s+FROM unnest\(\$2::text\[\]\) WITH ORDINALITY`).
+		WithArgs("apple", pq.Array([]string{"img-2", "img-1"})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, repo.Reorder("apple", []string{"img-2", "img-1"}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
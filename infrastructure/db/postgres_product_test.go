@@ -0,0 +1,303 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func productImageRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "product_id", "position", "url", "thumbnail_url", "image_key", "thumbnail_key"})
+}
+
+func TestPostgresProductRepository_SearchByFiltersMatchesSubcategoriesAndAllTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductRepository{db: db}
+
+	filter := repository.ProductFilter{
+		CategoryID: "fruit",
+		Tags:       []string{"Organic", "vegan", "organic"},
+	}
+	tags := pq.Array([]string{"organic", "vegan"})
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM products WHERE category_id IN \(\s+WITH RECURSIVE subtree`).
+		WithArgs("fruit", tags, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT id, name, price_cents, currency, stock, category_id, reorder_threshold FROM products WHERE .+ LIMIT \$4 OFFSET \$5`).
+		WithArgs("fruit", tags, 2, int32(10), int32(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold"}).
+			AddRow("apple", "Apple", 150, "KZT", 9, "green-fruit", 0))
+
+	mock.ExpectQuery(`SELECT product_id, tag FROM product_tags WHERE product_id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"apple"})).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "tag"}).
+			AddRow("apple", "organic").
+			AddRow("apple", "vegan"))
+
+	mock.ExpectQuery(`FROM product_images WHERE product_id = ANY\(\$1\) ORDER BY product_id, position`).
+		WithArgs(pq.Array([]string{"apple"})).
+		WillReturnRows(productImageRows().
+			AddRow("img-1", "apple", 0, "/media/a.jpg", "/media/a_thumb.jpg", "a.jpg", "a_thumb.jpg"))
+
+	products, total, err := repo.SearchByFilters(filter, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	assert.Equal(t, []*domain.Product{{
+		ID:         "apple",
+		Name:       "Apple",
+		Price:      domain.NewMoney(150, "KZT"),
+		Stock:      9,
+		CategoryID: "green-fruit",
+		Tags:       []string{"organic", "vegan"},
+		Images: []domain.ProductImage{{
+			ID:           "img-1",
+			ProductID:    "apple",
+			URL:          "/media/a.jpg",
+			ThumbnailURL: "/media/a_thumb.jpg",
+			Key:          "a.jpg",
+			ThumbnailKey: "a_thumb.jpg",
+		}},
+	}}, products)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductRepository_UpdateReplacesTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductRepository{db: db}
+
+	product := &domain.Product{ID: "apple", Name: "Apple", Price: domain.NewMoney(150, "KZT"), Stock: 9, Tags: []string{"vegan"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE products SET name = \\$2, price_cents = \\$3, currency = \\$4, category_id = \\$5 WHERE").
+		WithArgs("apple", "Apple", int64(150), "KZT", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM product_tags").
+		WithArgs("apple").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO product_tags").
+		WithArgs("apple", pq.Array([]string{"vegan"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Update(product))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductRepository_SearchByFiltersInStockSortedByPrice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductRepository{db: db}
+
+	filter := repository.ProductFilter{
+		Name:          "100% Apple",
+		PriceCurrency: "KZT",
+		MaxPrice:      domain.NewMoney(5000, "KZT"),
+		InStock:       true,
+		SortBy:        repository.ProductSortByPrice,
+		Descending:    true,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM products WHERE name ILIKE \$1 AND currency = \$2 AND price_cents <= \$3 AND stock > 0`).
+		WithArgs(`%100\% Apple%`, "KZT", int64(5000)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectQuery(`FROM products WHERE name ILIKE \$1 AND currency = \$2 AND price_cents <= \$3 AND stock > 0 ORDER BY price_cents DESC, id DESC LIMIT \$4 OFFSET \$5`).
+		WithArgs(`%100\% Apple%`, "KZT", int64(5000), int32(20), int32(20)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold"}))
+
+	products, total, err := repo.SearchByFilters(filter, 2, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), total)
+	assert.Empty(t, products)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductRepository_SearchTextRanksFuzzyMatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductRepository{db: db}
+
+	filter := repository.ProductFilter{Name: "ignored", InStock: true, SortBy: repository.ProductSortByPrice}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM products, websearch_to_tsquery\('simple', \$1\) AS tsq WHERE \(search_vector @@ tsq OR \$1 <% name\) AND stock > 0`).
+		WithArgs("aple").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`ts_headline\(.+\) AS highlight\s+FROM products, websearch_to_tsquery\('simple', \$1\) AS tsq\s+WHERE .+ AND stock > 0\s+ORDER BY rank DESC, name, id\s+LIMIT \$2 OFFSET \$3`).
+		WithArgs("aple", int32(10), int32(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold", "rank", "highlight"}).
+			AddRow("apple", "Green <b>apple</b>", 150, "KZT", 9, nil, 0, 0.57, "Green <b>\x02apple\x03</b>"))
+
+	mock.ExpectQuery(`SELECT product_id, tag FROM product_tags`).
+		WithArgs(pq.Array([]string{"apple"})).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "tag"}))
+	mock.ExpectQuery(`FROM product_images`).
+		WillReturnRows(productImageRows())
+
+	results, total, err := repo.SearchText("aple", filter, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "apple", results[0].Product.ID)
+	assert.Equal(t, "", results[0].Product.CategoryID)
+	assert.Equal(t, 0.57, results[0].Rank)
+	assert.Equal(t, "Green &lt;b&gt;<mark>apple</mark>&lt;/b&gt;", results[0].Highlight)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductRepository_SuggestEscapesPrefix(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductRepository{db: db}
+
+	mock.ExpectQuery(`SELECT id, name FROM products\s+WHERE name ILIKE \$1 OR \$2 <% name`).
+		WithArgs(`50\_`+"%", "50_", int32(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("p1", "50_50 juice"))
+
+	suggestions, err := repo.Suggest("50_", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []*domain.ProductSuggestion{{ID: "p1", Name: "50_50 juice"}}, suggestions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductRepository_ListPageContinuesAfterTheLastRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductRepository{db: db}
+
+	filter := repository.ProductFilter{
+		InStock:    true,
+		SortBy:     repository.ProductSortByPrice,
+		Descending: true,
+	}
+	columns := []string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold"}
+
+	mock.ExpectQuery(`FROM products WHERE stock > 0 ORDER BY price_cents DESC, id DESC LIMIT \$1$`).
+		WithArgs(int32(3)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("c", "Cherry", 900, "KZT", 1, nil, 0).
+			AddRow("b", "Banana", 200, "KZT", 4, nil, 0).
+			AddRow("a", "Apple", 200, "KZT", 2, nil, 0))
+	mock.ExpectQuery(`SELECT product_id, tag FROM product_tags`).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "tag"}))
+	mock.ExpectQuery(`FROM product_images`).
+		WillReturnRows(productImageRows())
+
+	first, err := repo.ListPage(filter, repository.PageRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, first.Items, 2)
+	assert.Equal(t, "b", first.Items[1].ID)
+	assert.NotEmpty(t, first.NextPageToken)
+	assert.Nil(t, first.Total)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM products WHERE stock > 0$`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`FROM products WHERE stock > 0 AND \(price_cents, id\) < \(\$1, \$2\) ORDER BY price_cents DESC, id DESC LIMIT \$3`).
+		WithArgs(int64(200), "b", int32(3)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("a", "Apple", 200, "KZT", 2, nil, 0))
+	mock.ExpectQuery(`SELECT product_id, tag FROM product_tags`).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "tag"}))
+	mock.ExpectQuery(`FROM product_images`).
+		WillReturnRows(productImageRows())
+
+	second, err := repo.ListPage(filter, repository.PageRequest{Token: first.NextPageToken, Limit: 2, WithTotal: true})
+	assert.NoError(t, err)
+	assert.Len(t, second.Items, 1)
+	assert.Empty(t, second.NextPageToken)
+	assert.Equal(t, int32(3), *second.Total)
+
+	// The position means nothing once the sort order changes.
+	filter.Descending = false
+	_, err = repo.ListPage(filter, repository.PageRequest{Token: first.NextPageToken, Limit: 2})
+	assert.ErrorIs(t, err, repository.ErrInvalidPageToken)
+
+	_, err = repo.ListPage(filter, repository.PageRequest{Token: "not a token", Limit: 2})
+	assert.ErrorIs(t, err, repository.ErrInvalidPageToken)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductRepository_ListLowStockOrdersByShortfall(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductRepository{db: db}
+
+	mock.ExpectQuery(`FROM products\s+WHERE stock < reorder_threshold\s+ORDER BY stock - reorder_threshold, name, id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold"}).
+			AddRow("milk", "Milk", 450, "KZT", 0, nil, 10).
+			AddRow("bread", "Bread", 200, "KZT", 3, nil, 5))
+	mock.ExpectQuery(`SELECT product_id, tag FROM product_tags`).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "tag"}))
+	mock.ExpectQuery(`FROM product_images`).
+		WillReturnRows(productImageRows())
+
+	products, err := repo.ListLowStock()
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Equal(t, "milk", products[0].ID)
+	assert.Equal(t, int32(10), products[0].ReorderThreshold)
+
+	mock.ExpectExec(`UPDATE products SET reorder_threshold = \$2 WHERE id = \$1`).
+		WithArgs("pear", int32(5)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(t, repo.SetReorderThreshold("pear", 5), repository.ErrProductNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresProductRepository_ListCategoryAncestorsNearestFirst(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresProductRepository{db: db}
+
+	mock.ExpectQuery(`WITH RECURSIVE ancestors.*ORDER BY category_id, depth`).
+		WithArgs(pq.Array([]string{"apples", "food"})).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "id"}).
+			AddRow("apples", "fruit").
+			AddRow("apples", "food"))
+
+	ancestors, err := repo.ListCategoryAncestors([]string{"apples", "food"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"apples": {"fruit", "food"}}, ancestors)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,241 @@
+package db
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "github.com/google/uuid"
+    "github.com/lib/pq"
+)
+
+// promotionColumns is the column list scanned by scanPromotion.
+const promotionColumns = `id, code, name, type, percent_off, amount_off_cents, currency, buy_quantity, get_quantity,
+    category_id, starts_at, ends_at, usage_limit, per_user_limit, times_used, active, created_at`
+
+type PostgresPromotionRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresPromotionRepository(db *sql.DB) *PostgresPromotionRepository {
+    return &PostgresPromotionRepository{
+        db: db,
+    }
+}
+
+func scanPromotion(row interface{ Scan(dest ...interface{}) error }) (*domain.Promotion, error) {
+    var promotion domain.Promotion
+    var categoryID sql.NullString
+    var startsAt, endsAt sql.NullTime
+
+    err := row.Scan(&promotion.ID, &promotion.Code, &promotion.Name, &promotion.Type, &promotion.PercentOff,
+        &promotion.AmountOff.Amount, &promotion.AmountOff.Currency, &promotion.BuyQuantity, &promotion.GetQuantity,
+        &categoryID, &startsAt, &endsAt, &promotion.UsageLimit, &promotion.PerUserLimit, &promotion.TimesUsed,
+        &promotion.Active, &promotion.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+
+    promotion.CategoryID = categoryID.String
+    if startsAt.Valid {
+        promotion.StartsAt = &startsAt.Time
+    }
+    if endsAt.Valid {
+        promotion.EndsAt = &endsAt.Time
+    }
+    return &promotion, nil
+}
+
+// nullableTime stores a nil time as NULL.
+func nullableTime(t *time.Time) sql.NullTime {
+    if t == nil {
+        return sql.NullTime{}
+    }
+    return sql.NullTime{Time: *t, Valid: true}
+}
+
+func promotionWriteError(err error, promotion *domain.Promotion) error {
+    var pqErr *pq.Error
+    if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_promotions_code" {
+        return fmt.Errorf("%w: %s", repository.ErrPromotionCodeTaken, promotion.Code)
+    }
+    if isForeignKeyViolation(err, "promotions_category_id_fkey") {
+        return fmt.Errorf("%w: %s", repository.ErrCategoryNotFound, promotion.CategoryID)
+    }
+    return err
+}
+
+func (r *PostgresPromotionRepository) Create(promotion *domain.Promotion) error {
+    if promotion.ID == "" {
+        promotion.ID = uuid.New().String()
+    }
+    if promotion.CreatedAt.IsZero() {
+        promotion.CreatedAt = time.Now().UTC()
+    }
+    if promotion.AmountOff.Currency == "" {
+        promotion.AmountOff.Currency = domain.DefaultCurrency
+    }
+
+    _, err := r.db.Exec(`
+        INSERT INTO promotions (id, code, name, type, percent_off, amount_off_cents, currency, buy_quantity, get_quantity,
+            category_id, starts_at, ends_at, usage_limit, per_user_limit, active, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+    `, promotion.ID, promotion.Code, promotion.Name, promotion.Type, promotion.PercentOff,
+        promotion.AmountOff.Amount, promotion.AmountOff.Currency, promotion.BuyQuantity, promotion.GetQuantity,
+        nullableID(promotion.CategoryID), nullableTime(promotion.StartsAt), nullableTime(promotion.EndsAt),
+        promotion.UsageLimit, promotion.PerUserLimit, promotion.Active, promotion.CreatedAt)
+    return promotionWriteError(err, promotion)
+}
+
+func (r *PostgresPromotionRepository) GetByID(id string) (*domain.Promotion, error) {
+    return r.getOne(`SELECT `+promotionColumns+` FROM promotions WHERE id = $1`, id)
+}
+
+func (r *PostgresPromotionRepository) GetByCode(code string) (*domain.Promotion, error) {
+    if code == "" {
+        return nil, repository.ErrPromotionNotFound
+    }
+    return r.getOne(`SELECT `+promotionColumns+` FROM promotions WHERE code = $1`, code)
+}
+
+func (r *PostgresPromotionRepository) getOne(query string, arg interface{}) (*domain.Promotion, error) {
+    promotion, err := scanPromotion(r.db.QueryRow(query, arg))
+    if err == sql.ErrNoRows {
+        return nil, repository.ErrPromotionNotFound
+    }
+    return promotion, err
+}
+
+func (r *PostgresPromotionRepository) Update(promotion *domain.Promotion) error {
+    if promotion.AmountOff.Currency == "" {
+        promotion.AmountOff.Currency = domain.DefaultCurrency
+    }
+
+    res, err := r.db.Exec(`
+        UPDATE promotions SET code = $2, name = $3, type = $4, percent_off = $5, amount_off_cents = $6, currency = $7,
+            buy_quantity = $8, get_quantity = $9, category_id = $10, starts_at = $11, ends_at = $12,
+            usage_limit = $13, per_user_limit = $14, active = $15
+        WHERE id = $1
+    `, promotion.ID, promotion.Code, promotion.Name, promotion.Type, promotion.PercentOff,
+        promotion.AmountOff.Amount, promotion.AmountOff.Currency, promotion.BuyQuantity, promotion.GetQuantity,
+        nullableID(promotion.CategoryID), nullableTime(promotion.StartsAt), nullableTime(promotion.EndsAt),
+        promotion.UsageLimit, promotion.PerUserLimit, promotion.Active)
+    if err != nil {
+        return promotionWriteError(err, promotion)
+    }
+
+    return promotionAffected(res)
+}
+
+func (r *PostgresPromotionRepository) Delete(id string) error {
+    res, err := r.db.Exec(`DELETE FROM promotions WHERE id = $1`, id)
+    if err != nil {
+        return err
+    }
+
+    return promotionAffected(res)
+}
+
+// promotionAffected fails with repository.ErrPromotionNotFound if res
+// changed no rows.
+func promotionAffected(res sql.Result) error {
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return repository.ErrPromotionNotFound
+    }
+    return nil
+}
+
+func (r *PostgresPromotionRepository) List() ([]*domain.Promotion, error) {
+    return r.queryPromotions(`SELECT ` + promotionColumns + ` FROM promotions ORDER BY created_at DESC, id`)
+}
+
+func (r *PostgresPromotionRepository) ListAutomatic(now time.Time) ([]*domain.Promotion, error) {
+    query := `
+        SELECT ` + promotionColumns + ` FROM promotions
+        WHERE code = '' AND active
+            AND (starts_at IS NULL OR starts_at <= $1)
+            AND (ends_at IS NULL OR ends_at > $1)
+        ORDER BY created_at, id`
+    return r.queryPromotions(query, now)
+}
+
+func (r *PostgresPromotionRepository) queryPromotions(query string, args ...interface{}) ([]*domain.Promotion, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    promotions := []*domain.Promotion{}
+    for rows.Next() {
+        promotion, err := scanPromotion(rows)
+        if err != nil {
+            return nil, err
+        }
+        promotions = append(promotions, promotion)
+    }
+
+    return promotions, rows.Err()
+}
+
+// Redeem locks the promotion's row, so concurrent orders are counted against
+// its limits one at a time.
+func (r *PostgresPromotionRepository) Redeem(promotionID, orderID, userID string) error {
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        var usageLimit, perUserLimit, timesUsed int32
+        err := tx.QueryRow(`
+            SELECT usage_limit, per_user_limit, times_used FROM promotions WHERE id = $1 FOR UPDATE
+        `, promotionID).Scan(&usageLimit, &perUserLimit, &timesUsed)
+        if err == sql.ErrNoRows {
+            return repository.ErrPromotionNotFound
+        }
+        if err != nil {
+            return err
+        }
+
+        if usageLimit > 0 && timesUsed >= usageLimit {
+            return repository.ErrPromotionUsageLimitReached
+        }
+
+        if perUserLimit > 0 {
+            var userUses int32
+            err := tx.QueryRow(`
+                SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2
+            `, promotionID, userID).Scan(&userUses)
+            if err != nil {
+                return err
+            }
+            if userUses >= perUserLimit {
+                return repository.ErrPromotionUserLimitReached
+            }
+        }
+
+        _, err = tx.Exec(`
+            INSERT INTO promotion_redemptions (promotion_id, order_id, user_id) VALUES ($1, $2, $3)
+        `, promotionID, orderID, userID)
+        if err != nil {
+            return err
+        }
+
+        _, err = tx.Exec(`UPDATE promotions SET times_used = times_used + 1 WHERE id = $1`, promotionID)
+        return err
+    })
+}
+
+func (r *PostgresPromotionRepository) ReleaseRedemptions(orderID string) error {
+    _, err := r.db.Exec(`
+        WITH released AS (
+            DELETE FROM promotion_redemptions WHERE order_id = $1 RETURNING promotion_id
+        )
+        UPDATE promotions SET times_used = GREATEST(times_used - 1, 0)
+        WHERE id IN (SELECT promotion_id FROM released)
+    `, orderID)
+    return err
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresPromotionRepository_RedeemEnforcesLimits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresPromotionRepository{db: db}
+	limits := []string{"usage_limit", "per_user_limit", "times_used"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT usage_limit, per_user_limit, times_used FROM promotions WHERE id = \$1 FOR UPDATE`).
+		WithArgs("promo-1").
+		WillReturnRows(sqlmock.NewRows(limits).AddRow(100, 0, 100))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.Redeem("promo-1", "o1", "u1"), repository.ErrPromotionUsageLimitReached)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs("promo-1").
+		WillReturnRows(sqlmock.NewRows(limits).AddRow(100, 1, 5))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM promotion_redemptions WHERE promotion_id = \$1 AND user_id = \$2`).
+		WithArgs("promo-1", "u1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.Redeem("promo-1", "o1", "u1"), repository.ErrPromotionUserLimitReached)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs("promo-1").
+		WillReturnRows(sqlmock.NewRows(limits).AddRow(100, 1, 5))
+	mock.ExpectQuery(`FROM promotion_redemptions`).
+		WithArgs("promo-1", "u2").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`INSERT INTO promotion_redemptions`).
+		WithArgs("promo-1", "o2", "u2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE promotions SET times_used = times_used \+ 1 WHERE id = \$1`).
+		WithArgs("promo-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Redeem("promo-1", "o2", "u2"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresPromotionRepository_CreateReportsTakenCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresPromotionRepository{db: db}
+	endsAt := time.Now().Add(24 * time.Hour)
+	promotion := &domain.Promotion{
+		ID:         "promo-1",
+		Code:       "SPRING10",
+		Name:       "Spring sale",
+		Type:       domain.PromotionPercentage,
+		PercentOff: 10,
+		EndsAt:     &endsAt,
+		Active:     true,
+	}
+
+	mock.ExpectExec(`INSERT INTO promotions`).
+		WithArgs("promo-1", "SPRING10", "Spring sale", domain.PromotionPercentage, int32(10), int64(0), "KZT",
+			int32(0), int32(0), nil, nil, endsAt, int32(0), int32(0), true, sqlmock.AnyArg()).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_promotions_code"})
+
+	assert.ErrorIs(t, repo.Create(promotion), repository.ErrPromotionCodeTaken)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresPromotionRepository_ListAutomaticOnlyReturnsRunningPromotions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresPromotionRepository{db: db}
+	now := time.Now()
+
+	mock.ExpectQuery(`WHERE code = '' AND active\s+AND \(starts_at IS NULL OR starts_at <= \$1\)\s+AND \(ends_at IS NULL OR ends_at > \$1\)`).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code", "name", "type", "percent_off", "amount_off_cents", "currency",
+			"buy_quantity", "get_quantity", "category_id", "starts_at", "ends_at", "usage_limit", "per_user_limit",
+			"times_used", "active", "created_at"}).
+			AddRow("promo-1", "", "3 for 2 on fruit", "buy_x_get_y", 0, 0, "KZT", 2, 1, "fruit", nil, nil, 0, 1, 4, true, now))
+
+	promotions, err := repo.ListAutomatic(now)
+	assert.NoError(t, err)
+	assert.Len(t, promotions, 1)
+	assert.Equal(t, "fruit", promotions[0].CategoryID)
+	assert.Nil(t, promotions[0].StartsAt)
+	assert.Equal(t, int32(1), promotions[0].PerUserLimit)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
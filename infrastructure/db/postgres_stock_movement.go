@@ -0,0 +1,242 @@
+package db
+
+import (
+    "database/sql"
+    "fmt"
+    "time"
+
+    "AdvProg2/domain"
+    "AdvProg2/repository"
+    "github.com/google/uuid"
+)
+
+// stockMovementColumns is the column list scanned by scanStockMovement.
+const stockMovementColumns = `id, product_id, delta, reason, reference_id, stock_after, created_at`
+
+type PostgresStockMovementRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresStockMovementRepository(db *sql.DB) *PostgresStockMovementRepository {
+    return &PostgresStockMovementRepository{
+        db: db,
+    }
+}
+
+func scanStockMovement(row interface{ Scan(dest ...interface{}) error }) (*domain.StockMovement, error) {
+    var movement domain.StockMovement
+    err := row.Scan(&movement.ID, &movement.ProductID, &movement.Delta, &movement.Reason,
+        &movement.ReferenceID, &movement.StockAfter, &movement.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &movement, nil
+}
+
+func (r *PostgresStockMovementRepository) Record(movement *domain.StockMovement) (*domain.Product, error) {
+    if movement.CreatedAt.IsZero() {
+        movement.CreatedAt = time.Now().UTC()
+    }
+
+    // The stock and the ledger change in one statement, so neither is ever
+    // written without the other.
+    query := `
+        WITH updated AS (
+            UPDATE products SET stock = stock + $2
+            WHERE id = $1 AND stock + $2 >= 0
+            RETURNING ` + productColumns + `
+        ), movement AS (
+            INSERT INTO stock_movements (id, product_id, delta, reason, reference_id, stock_after, created_at)
+            SELECT $3, id, $2, $4, $5, stock, $6 FROM updated
+        )
+        SELECT ` + productColumns + ` FROM updated
+    `
+
+    product, err := scanProduct(r.db.QueryRow(query, movement.ProductID, movement.Delta, movement.ID, movement.Reason, movement.ReferenceID, movement.CreatedAt))
+    if err == nil {
+        movement.StockAfter = product.Stock
+        return product, nil
+    }
+    if err != sql.ErrNoRows {
+        return nil, err
+    }
+
+    var name string
+    err = r.db.QueryRow(`SELECT name FROM products WHERE id = $1`, movement.ProductID).Scan(&name)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, repository.ErrProductNotFound
+        }
+        return nil, err
+    }
+
+    return nil, fmt.Errorf("%w for product: %s", repository.ErrInsufficientStock, name)
+}
+
+func (r *PostgresStockMovementRepository) SetStock(movement *domain.StockMovement, stock int32) error {
+    if movement.CreatedAt.IsZero() {
+        movement.CreatedAt = time.Now().UTC()
+    }
+
+    // Locking the row first makes the difference exact even while orders
+    // take stock concurrently.
+    query := `
+        WITH previous AS (
+            SELECT id, stock FROM products WHERE id = $1 FOR UPDATE
+        ), updated AS (
+            UPDATE products p SET stock = $2 FROM previous
+            WHERE p.id = previous.id
+            RETURNING previous.stock AS previous_stock
+        ), movement AS (
+            INSERT INTO stock_movements (id, product_id, delta, reason, reference_id, stock_after, created_at)
+            SELECT $3, $1, $2 - previous_stock, $4, $5, $2, $6 FROM updated WHERE previous_stock <> $2
+        )
+        SELECT previous_stock FROM updated
+    `
+
+    var previous int32
+    err := r.db.QueryRow(query, movement.ProductID, stock, movement.ID, movement.Reason, movement.ReferenceID, movement.CreatedAt).
+        Scan(&previous)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return repository.ErrProductNotFound
+        }
+        return err
+    }
+
+    movement.Delta = stock - previous
+    movement.StockAfter = stock
+    return nil
+}
+
+func (r *PostgresStockMovementRepository) ListByProduct(productID string, page repository.PageRequest) (*repository.Page[*domain.StockMovement], error) {
+    const sort = "created_at desc"
+
+    var total *int32
+    if page.WithTotal {
+        total = new(int32)
+        err := r.db.QueryRow("SELECT COUNT(*) FROM stock_movements WHERE product_id = $1", productID).Scan(total)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    args := []interface{}{productID}
+    keyset := ""
+    if page.Token != "" {
+        var lastCreatedAt time.Time
+        lastID, err := decodePageToken(page.Token, sort, &lastCreatedAt)
+        if err != nil {
+            return nil, err
+        }
+
+        args = append(args, lastCreatedAt, lastID)
+        keyset = "AND (created_at, id) < ($2, $3)"
+    }
+    args = append(args, page.Limit+1)
+
+    query := fmt.Sprintf(`
+        SELECT %s FROM stock_movements
+        WHERE product_id = $1 %s
+        ORDER BY created_at DESC, id DESC
+        LIMIT $%d
+    `, stockMovementColumns, keyset, len(args))
+
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    movements := []*domain.StockMovement{}
+    for rows.Next() {
+        movement, err := scanStockMovement(rows)
+        if err != nil {
+            return nil, err
+        }
+        movements = append(movements, movement)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    result, err := newPage(movements, page.Limit, func(last *domain.StockMovement) (string, error) {
+        return encodePageToken(sort, last.CreatedAt, last.ID)
+    })
+    if err != nil {
+        return nil, err
+    }
+    result.Total = total
+
+    return result, nil
+}
+
+// discrepanciesQuery selects the products whose stock differs from their
+// ledger. Callers may append FOR UPDATE OF p.
+const discrepanciesQuery = `
+    SELECT p.id, p.name, p.stock, COALESCE(m.total, 0)
+    FROM products p
+    LEFT JOIN (
+        SELECT product_id, SUM(delta) AS total FROM stock_movements GROUP BY product_id
+    ) m ON m.product_id = p.id
+    WHERE p.stock <> COALESCE(m.total, 0)
+`
+
+func (r *PostgresStockMovementRepository) FindDiscrepancies() ([]*domain.StockDiscrepancy, error) {
+    return queryDiscrepancies(r.db, discrepanciesQuery+` ORDER BY p.name, p.id`)
+}
+
+func (r *PostgresStockMovementRepository) Reconcile() ([]*domain.StockDiscrepancy, error) {
+    var discrepancies []*domain.StockDiscrepancy
+
+    err := inTransaction(r.db, func(tx dbExecutor) error {
+        // Locking the products keeps their stock still until the
+        // correcting movements are in.
+        var err error
+        discrepancies, err = queryDiscrepancies(tx, discrepanciesQuery+` ORDER BY p.id FOR UPDATE OF p`)
+        if err != nil {
+            return err
+        }
+
+        now := time.Now().UTC()
+        for _, d := range discrepancies {
+            _, err := tx.Exec(`
+                INSERT INTO stock_movements (id, product_id, delta, reason, reference_id, stock_after, created_at)
+                VALUES ($1, $2, $3, $4, '', $5, $6)
+            `, uuid.New().String(), d.ProductID, d.Stock-d.LedgerStock, domain.StockMovementReconciliation, d.Stock, now)
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return discrepancies, nil
+}
+
+func queryDiscrepancies(db dbExecutor, query string) ([]*domain.StockDiscrepancy, error) {
+    rows, err := db.Query(query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    discrepancies := []*domain.StockDiscrepancy{}
+    for rows.Next() {
+        var d domain.StockDiscrepancy
+        if err := rows.Scan(&d.ProductID, &d.Name, &d.Stock, &d.LedgerStock); err != nil {
+            return nil, err
+        }
+        discrepancies = append(discrepancies, &d)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    return discrepancies, nil
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStockMovementRepository_RecordAppliesDeltaWithMovement(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresStockMovementRepository{db: db}
+
+	movement := &domain.StockMovement{ID: "m1", ProductID: "apple", Delta: 5, Reason: domain.StockMovementRestock}
+
+	mock.ExpectQuery(`UPDATE products SET stock = stock \+ \$2\s+WHERE id = \$1 AND stock \+ \$2 >= 0.+INSERT INTO stock_movements`).
+		WithArgs("apple", int32(5), "m1", domain.StockMovementRestock, "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold"}).
+			AddRow("apple", "Apple", 150, "KZT", 12, "fruit", 0))
+
+	product, err := repo.Record(movement)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(12), product.Stock)
+	assert.Equal(t, "fruit", product.CategoryID)
+	assert.Equal(t, int32(12), movement.StockAfter)
+	assert.False(t, movement.CreatedAt.IsZero())
+
+	mock.ExpectQuery(`UPDATE products SET stock`).
+		WithArgs("pear", int32(5), "m2", domain.StockMovementRestock, "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold"}))
+	mock.ExpectQuery(`SELECT name FROM products`).
+		WithArgs("pear").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	_, err = repo.Record(&domain.StockMovement{ID: "m2", ProductID: "pear", Delta: 5, Reason: domain.StockMovementRestock})
+	assert.ErrorIs(t, err, repository.ErrProductNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStockMovementRepository_SetStockRecordsDifference(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresStockMovementRepository{db: db}
+
+	movement := &domain.StockMovement{ID: "m1", ProductID: "apple", Reason: domain.StockMovementAdjustment, ReferenceID: "admin"}
+
+	mock.ExpectQuery(`SELECT id, stock FROM products WHERE id = \$1 FOR UPDATE.+UPDATE products p SET stock = \$2.+WHERE previous_stock <> \$2`).
+		WithArgs("apple", int32(4), "m1", domain.StockMovementAdjustment, "admin", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"previous_stock"}).AddRow(10))
+
+	assert.NoError(t, repo.SetStock(movement, 4))
+	assert.Equal(t, int32(-6), movement.Delta)
+	assert.Equal(t, int32(4), movement.StockAfter)
+
+	mock.ExpectQuery(`SELECT id, stock FROM products`).
+		WithArgs("pear", int32(4), "m2", domain.StockMovementAdjustment, "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"previous_stock"}))
+
+	err = repo.SetStock(&domain.StockMovement{ID: "m2", ProductID: "pear", Reason: domain.StockMovementAdjustment}, 4)
+	assert.ErrorIs(t, err, repository.ErrProductNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStockMovementRepository_ListByProductUsesKeyset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresStockMovementRepository{db: db}
+
+	newer := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	older := newer.Add(-time.Hour)
+	columns := []string{"id", "product_id", "delta", "reason", "reference_id", "stock_after", "created_at"}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM stock_movements WHERE product_id = \$1`).
+		WithArgs("apple").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`FROM stock_movements\s+WHERE product_id = \$1\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$2`).
+		WithArgs("apple", int32(3)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("m3", "apple", -2, "order", "order-1", 8, newer).
+			AddRow("m2", "apple", 10, "restock", "", 10, older).
+			AddRow("m1", "apple", 0, "restock", "", 0, older))
+
+	page, err := repo.ListByProduct("apple", repository.PageRequest{Limit: 2, WithTotal: true})
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, domain.StockMovementOrder, page.Items[0].Reason)
+	assert.Equal(t, int32(-2), page.Items[0].Delta)
+	assert.Equal(t, int32(3), *page.Total)
+
+	mock.ExpectQuery(`WHERE product_id = \$1 AND \(created_at, id\) < \(\$2, \$3\)`).
+		WithArgs("apple", older, "m2", int32(3)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("m1", "apple", 0, "restock", "", 0, older))
+
+	page, err = repo.ListByProduct("apple", repository.PageRequest{Token: page.NextPageToken, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextPageToken)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStockMovementRepository_ReconcileAppendsCorrections(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := &PostgresStockMovementRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WHERE p.stock <> COALESCE\(m.total, 0\)\s+ORDER BY p.id FOR UPDATE OF p`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "stock", "total"}).
+			AddRow("apple", "Apple", 7, 10).
+			AddRow("pear", "Pear", 3, 0))
+	mock.ExpectExec(`INSERT INTO stock_movements`).
+		WithArgs(sqlmock.AnyArg(), "apple", int32(-3), domain.StockMovementReconciliation, int32(7), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO stock_movements`).
+		WithArgs(sqlmock.AnyArg(), "pear", int32(3), domain.StockMovementReconciliation, int32(3), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	discrepancies, err := repo.Reconcile()
+	assert.NoError(t, err)
+	assert.Equal(t, []*domain.StockDiscrepancy{
+		{ProductID: "apple", Name: "Apple", Stock: 7, LedgerStock: 10},
+		{ProductID: "pear", Name: "Pear", Stock: 3, LedgerStock: 0},
+	}, discrepancies)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
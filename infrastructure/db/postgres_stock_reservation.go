@@ -0,0 +1,134 @@
+package db
+
+import (
+    "database/sql"
+    "time"
+
+    "AdvProg2/domain"
+    "github.com/google/uuid"
+)
+
+// stockReservationColumns is the column list scanned by scanStockReservation.
+const stockReservationColumns = `id, order_id, product_id, quantity, status, expires_at, created_at, resolved_at`
+
+type PostgresStockReservationRepository struct {
+    db dbExecutor
+}
+
+func NewPostgresStockReservationRepository(db *sql.DB) *PostgresStockReservationRepository {
+    return &PostgresStockReservationRepository{
+        db: db,
+    }
+}
+
+func scanStockReservation(row interface{ Scan(dest ...interface{}) error }) (*domain.StockReservation, error) {
+    var reservation domain.StockReservation
+    var resolvedAt sql.NullTime
+
+    err := row.Scan(&reservation.ID, &reservation.OrderID, &reservation.ProductID, &reservation.Quantity,
+        &reservation.Status, &reservation.ExpiresAt, &reservation.CreatedAt, &resolvedAt)
+    if err != nil {
+        return nil, err
+    }
+
+    if resolvedAt.Valid {
+        reservation.ResolvedAt = &resolvedAt.Time
+    }
+    return &reservation, nil
+}
+
+func (r *PostgresStockReservationRepository) Create(reservations []*domain.StockReservation) error {
+    return inTransaction(r.db, func(tx dbExecutor) error {
+        for _, reservation := range reservations {
+            if reservation.ID == "" {
+                reservation.ID = uuid.New().String()
+            }
+            if reservation.Status == "" {
+                reservation.Status = domain.StockReservationActive
+            }
+            if reservation.CreatedAt.IsZero() {
+                reservation.CreatedAt = time.Now().UTC()
+            }
+
+            _, err := tx.Exec(`
+                INSERT INTO stock_reservations (id, order_id, product_id, quantity, status, expires_at, created_at)
+                VALUES ($1, $2, $3, $4, $5, $6, $7)
+            `, reservation.ID, reservation.OrderID, reservation.ProductID, reservation.Quantity,
+                reservation.Status, reservation.ExpiresAt, reservation.CreatedAt)
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+func (r *PostgresStockReservationRepository) ListByOrder(orderID string) ([]*domain.StockReservation, error) {
+    query := `SELECT ` + stockReservationColumns + ` FROM stock_reservations WHERE order_id = $1 ORDER BY product_id`
+    return r.queryReservations(query, orderID)
+}
+
+func (r *PostgresStockReservationRepository) Resolve(orderID string, status domain.StockReservationStatus, at time.Time) ([]*domain.StockReservation, error) {
+    query := `
+        UPDATE stock_reservations SET status = $2, resolved_at = $3
+        WHERE order_id = $1 AND status = 'active'
+        RETURNING ` + stockReservationColumns
+    return r.queryReservations(query, orderID, status, at)
+}
+
+func (r *PostgresStockReservationRepository) ListExpiredOrderIDs(now time.Time, limit int32) ([]string, error) {
+    query := `
+        SELECT order_id FROM stock_reservations
+        WHERE status = 'active' AND expires_at <= $1
+            AND (release_retry_at IS NULL OR release_retry_at <= $1)
+        GROUP BY order_id
+        ORDER BY MIN(expires_at), order_id
+        LIMIT $2
+    `
+
+    rows, err := r.db.Query(query, now, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var orderIDs []string
+    for rows.Next() {
+        var orderID string
+        if err := rows.Scan(&orderID); err != nil {
+            return nil, err
+        }
+        orderIDs = append(orderIDs, orderID)
+    }
+
+    return orderIDs, rows.Err()
+}
+
+func (r *PostgresStockReservationRepository) DeferRelease(orderID string, retryAt time.Time) error {
+    query := `UPDATE stock_reservations SET release_retry_at = $2 WHERE order_id = $1 AND status = 'active'`
+    _, err := r.db.Exec(query, orderID, retryAt)
+    return err
+}
+
+func (r *PostgresStockReservationRepository) queryReservations(query string, args ...interface{}) ([]*domain.StockReservation, error) {
+    rows, err := r.db.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    reservations := []*domain.StockReservation{}
+    for rows.Next() {
+        reservation, err := scanStockReservation(rows)
+        if err != nil {
+            return nil, err
+        }
+        reservations = append(reservations, reservation)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    return reservations, nil
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStockReservationRepository_CreateInsertsEveryItem(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStockReservationRepository(db)
+
+	expiresAt := time.Now().Add(15 * time.Minute)
+	reservations := []*domain.StockReservation{
+		{ID: "r1", OrderID: "o1", ProductID: "apple", Quantity: 2, ExpiresAt: expiresAt},
+		{ID: "r2", OrderID: "o1", ProductID: "pear", Quantity: 1, ExpiresAt: expiresAt},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO stock_reservations`).
+		WithArgs("r1", "o1", "apple", int32(2), domain.StockReservationActive, expiresAt, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO stock_reservations`).
+		WithArgs("r2", "o1", "pear", int32(1), domain.StockReservationActive, expiresAt, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Create(reservations))
+	assert.False(t, reservations[0].CreatedAt.IsZero())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStockReservationRepository_ResolveOnlyTouchesActiveReservations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStockReservationRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery(`UPDATE stock_reservations SET status = \$2, resolved_at = \$3\s+WHERE order_id = \$1 AND status = 'active'`).
+		WithArgs("o1", domain.StockReservationReleased, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "status", "expires_at", "created_at", "resolved_at"}).
+			AddRow("r1", "o1", "apple", 2, "released", now, now, now))
+
+	reservations, err := repo.Resolve("o1", domain.StockReservationReleased, now)
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+	assert.Equal(t, domain.StockReservationReleased, reservations[0].Status)
+	assert.NotNil(t, reservations[0].ResolvedAt)
+
+	mock.ExpectQuery(`UPDATE stock_reservations`).
+		WithArgs("o2", domain.StockReservationCommitted, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "status", "expires_at", "created_at", "resolved_at"}))
+
+	reservations, err = repo.Resolve("o2", domain.StockReservationCommitted, now)
+	assert.NoError(t, err)
+	assert.Empty(t, reservations)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStockReservationRepository_ListExpiredOrderIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStockReservationRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT order_id FROM stock_reservations\s+WHERE status = 'active' AND expires_at <= \$1\s+AND \(release_retry_at IS NULL OR release_retry_at <= \$1\)\s+GROUP BY order_id`).
+		WithArgs(now, int32(100)).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow("o1").AddRow("o2"))
+
+	orderIDs, err := repo.ListExpiredOrderIDs(now, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"o1", "o2"}, orderIDs)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStockReservationRepository_DeferRelease(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresStockReservationRepository(db)
+
+	retryAt := time.Now().Add(10 * time.Minute)
+	mock.ExpectExec(`UPDATE stock_reservations SET release_retry_at = \$2 WHERE order_id = \$1 AND status = 'active'`).
+		WithArgs("o1", retryAt).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, repo.DeferRelease("o1", retryAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,29 +9,11 @@ import (
     "AdvProg2/repository"
 )
 
-func createUserTableIfNotExist(db *sql.DB) error {
-    createUsersTable := `
-    CREATE TABLE IF NOT EXISTS users (
-        id VARCHAR(36) PRIMARY KEY,
-        username VARCHAR(255) NOT NULL UNIQUE,
-        password VARCHAR(255) NOT NULL,
-        role VARCHAR(50) NOT NULL DEFAULT 'user'
-    );
-    `
-
-    _, err := db.Exec(createUsersTable)
-    return err
-}
-
 type PostgresUserRepository struct {
     db *sql.DB
 }
 
 func NewPostgresUserRepository(db *sql.DB) (*PostgresUserRepository, error) {
-    if err := createUserTableIfNotExist(db); err != nil {
-        return nil, err
-    }
-
     return &PostgresUserRepository{
         db: db,
     }, nil
@@ -0,0 +1,98 @@
+package db
+
+import (
+    "database/sql"
+
+    "AdvProg2/repository"
+)
+
+// dbExecutor is satisfied by both *sql.DB and *sql.Tx, so the same repository
+// code can run standalone or as part of a unit of work.
+type dbExecutor interface {
+    Exec(query string, args ...interface{}) (sql.Result, error)
+    Query(query string, args ...interface{}) (*sql.Rows, error)
+    QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// inTransaction runs fn in a new transaction when exec is a plain connection
+// and reuses the caller's transaction otherwise.
+func inTransaction(exec dbExecutor, fn func(tx dbExecutor) error) (err error) {
+    conn, ok := exec.(*sql.DB)
+    if !ok {
+        return fn(exec)
+    }
+
+    tx, err := conn.Begin()
+    if err != nil {
+        return err
+    }
+    defer func() {
+        if p := recover(); p != nil {
+            tx.Rollback()
+            panic(p)
+        }
+        if err != nil {
+            tx.Rollback()
+            return
+        }
+        err = tx.Commit()
+    }()
+
+    return fn(tx)
+}
+
+type PostgresUnitOfWork struct {
+    db *sql.DB
+}
+
+func NewPostgresUnitOfWork(db *sql.DB) *PostgresUnitOfWork {
+    return &PostgresUnitOfWork{
+        db: db,
+    }
+}
+
+func (u *PostgresUnitOfWork) Do(fn func(tx repository.Transaction) error) error {
+    return inTransaction(u.db, func(tx dbExecutor) error {
+        return fn(&postgresTransaction{tx: tx})
+    })
+}
+
+type postgresTransaction struct {
+    tx dbExecutor
+}
+
+func (t *postgresTransaction) Orders() repository.OrderRepository {
+    return &PostgresOrderRepository{db: t.tx}
+}
+
+func (t *postgresTransaction) Products() repository.ProductRepository {
+    return &PostgresProductRepository{db: t.tx}
+}
+
+func (t *postgresTransaction) StockMovements() repository.StockMovementRepository {
+    return &PostgresStockMovementRepository{db: t.tx}
+}
+
+func (t *postgresTransaction) StockReservations() repository.StockReservationRepository {
+    return &PostgresStockReservationRepository{db: t.tx}
+}
+
+func (t *postgresTransaction) Outbox() repository.OutboxRepository {
+    return &PostgresOutboxRepository{db: t.tx}
+}
+
+func (t *postgresTransaction) Promotions() repository.PromotionRepository {
+    return &PostgresPromotionRepository{db: t.tx}
+}
+
+func (t *postgresTransaction) Addresses() repository.AddressRepository {
+    return &PostgresAddressRepository{db: t.tx}
+}
+
+func (t *postgresTransaction) DeliverySlots() repository.DeliverySlotRepository {
+    return &PostgresDeliverySlotRepository{db: t.tx}
+}
+
+func (t *postgresTransaction) Payments() repository.PaymentRepository {
+    return &PostgresPaymentRepository{db: t.tx}
+}
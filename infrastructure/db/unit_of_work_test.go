@@ -0,0 +1,95 @@
+package db
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func orderMovement(id, productID string, quantity int32) *domain.StockMovement {
+	return &domain.StockMovement{
+		ID:          id,
+		ProductID:   productID,
+		Delta:       -quantity,
+		Reason:      domain.StockMovementOrder,
+		ReferenceID: "order-1",
+	}
+}
+
+func TestPostgresUnitOfWork_RollsBackOnInsufficientStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	uow := NewPostgresUnitOfWork(db)
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery("UPDATE products SET stock = stock \\+ \\$2\\s+WHERE id = \\$1 AND stock \\+ \\$2 >= 0").
+		WithArgs("product-a", int32(-1), "m1", domain.StockMovementOrder, "order-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold"}).
+			AddRow("product-a", "Apple", 150, "KZT", 9, nil, 0))
+
+	mock.ExpectQuery("UPDATE products SET stock = stock \\+ \\$2\\s+WHERE id = \\$1 AND stock \\+ \\$2 >= 0").
+		WithArgs("product-b", int32(-5), "m2", domain.StockMovementOrder, "order-1", sqlmock.AnyArg()).
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectQuery("SELECT name FROM products").
+		WithArgs("product-b").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Bread"))
+
+	mock.ExpectRollback()
+
+	err = uow.Do(func(tx repository.Transaction) error {
+		if _, err := tx.StockMovements().Record(orderMovement("m1", "product-a", 1)); err != nil {
+			return err
+		}
+		_, err := tx.StockMovements().Record(orderMovement("m2", "product-b", 5))
+		return err
+	})
+
+	assert.True(t, errors.Is(err, repository.ErrInsufficientStock))
+	assert.EqualError(t, err, "not enough stock for product: Bread")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+func TestPostgresUnitOfWork_CommitsOrderWithStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' ", err)
+	}
+	defer db.Close()
+
+	uow := NewPostgresUnitOfWork(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE products SET stock = stock \\+ \\$2").
+		WithArgs("product-a", int32(-2), "m1", domain.StockMovementOrder, "order-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price_cents", "currency", "stock", "category_id", "reorder_threshold"}).
+			AddRow("product-a", "Apple", 150, "KZT", 8, nil, 0))
+	mock.ExpectExec("UPDATE orders SET status").
+		WithArgs("confirmed", "order-1", "pending").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = uow.Do(func(tx repository.Transaction) error {
+		if _, err := tx.StockMovements().Record(orderMovement("m1", "product-a", 2)); err != nil {
+			return err
+		}
+		return tx.Orders().UpdateStatus("order-1", domain.OrderStatusPending, domain.OrderStatusConfirmed)
+	})
+
+	assert.NoError(t, err)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
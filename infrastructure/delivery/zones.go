@@ -0,0 +1,120 @@
+// Package delivery provides repository.DeliveryFeeCalculator implementations.
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"AdvProg2/domain"
+)
+
+// ZonesFile is the format read by LoadZoneTable:
+//
+//	{"zones": [
+//	    {"name": "almaty-center", "region": "KZ", "city": "Almaty", "fee": 800, "free_from": 20000},
+//	    {"name": "kazakhstan", "region": "KZ", "fee": 2500}
+//	]}
+type ZonesFile struct {
+	Zones []domain.DeliveryZone `json:"zones"`
+}
+
+// ZoneTable quotes each address the fee of the most specific zone that
+// covers it: one for its region and city, then one for its region, then one
+// for anywhere. Addresses no zone covers are not delivered to. Regions and
+// cities are compared ignoring case.
+type ZoneTable struct {
+	zones map[zoneKey]domain.DeliveryZone
+	// open is set when the table has no zones at all, in which case every
+	// address is delivered to for free.
+	open bool
+}
+
+type zoneKey struct {
+	region string
+	city   string
+}
+
+func newZoneKey(region, city string) zoneKey {
+	return zoneKey{region: strings.ToUpper(strings.TrimSpace(region)), city: strings.ToUpper(strings.TrimSpace(city))}
+}
+
+func NewZoneTable(zones []domain.DeliveryZone) (*ZoneTable, error) {
+	t := &ZoneTable{zones: make(map[zoneKey]domain.DeliveryZone, len(zones)), open: len(zones) == 0}
+
+	for _, zone := range zones {
+		if err := zone.Validate(); err != nil {
+			return nil, fmt.Errorf("delivery: %w", err)
+		}
+		if zone.Region == "" && zone.City != "" {
+			return nil, fmt.Errorf("delivery: %w: zone %q has a city but no region",
+				domain.ErrInvalidDeliveryZone, zone.Name)
+		}
+
+		key := newZoneKey(zone.Region, zone.City)
+		if other, ok := t.zones[key]; ok {
+			return nil, fmt.Errorf("delivery: %w: zones %q and %q cover the same area",
+				domain.ErrInvalidDeliveryZone, other.Name, zone.Name)
+		}
+		t.zones[key] = zone
+	}
+
+	return t, nil
+}
+
+// LoadZoneTable reads a ZonesFile from path.
+func LoadZoneTable(path string) (*ZoneTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("delivery: reading %s: %w", path, err)
+	}
+
+	var file ZonesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("delivery: parsing %s: %w", path, err)
+	}
+
+	return NewZoneTable(file.Zones)
+}
+
+// NewFeeCalculatorFromEnv loads the file named by DELIVERY_ZONES_FILE.
+// Without it delivery is free everywhere.
+func NewFeeCalculatorFromEnv() (*ZoneTable, error) {
+	path := os.Getenv("DELIVERY_ZONES_FILE")
+	if path == "" {
+		log.Println("DELIVERY_ZONES_FILE not set, delivery is free everywhere")
+		return NewZoneTable(nil)
+	}
+
+	table, err := LoadZoneTable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Loaded %d delivery zones from %s", len(table.zones), path)
+	return table, nil
+}
+
+func (t *ZoneTable) zone(region, city string) (domain.DeliveryZone, bool) {
+	key := newZoneKey(region, city)
+	for _, key := range []zoneKey{key, {region: key.region}, {}} {
+		if zone, ok := t.zones[key]; ok {
+			return zone, true
+		}
+	}
+	return domain.DeliveryZone{}, false
+}
+
+func (t *ZoneTable) Quote(address *domain.Address, orderValue domain.Money, rate domain.ExchangeRate) (domain.DeliveryQuote, error) {
+	if address == nil || t.open {
+		return domain.DeliveryQuote{Fee: domain.NewMoney(0, orderValue.Currency)}, nil
+	}
+
+	zone, ok := t.zone(address.Region, address.City)
+	if !ok {
+		return domain.DeliveryQuote{}, fmt.Errorf("%w: %s, %s", domain.ErrNotDeliverable, address.City, address.Region)
+	}
+	return zone.Quote(orderValue, rate)
+}
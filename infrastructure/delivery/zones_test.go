@@ -0,0 +1,76 @@
+package delivery
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+)
+
+func TestZoneTable_PicksMostSpecificZone(t *testing.T) {
+	table, err := NewZoneTable([]domain.DeliveryZone{
+		{Name: "almaty", Region: "kz", City: "almaty", Fee: domain.NewMoney(80000, "KZT"), FreeFrom: domain.NewMoney(2000000, "KZT")},
+		{Name: "kazakhstan", Region: "KZ", Fee: domain.NewMoney(250000, "KZT")},
+	})
+	assert.NoError(t, err)
+	kzt := domain.IdentityRate("KZT")
+
+	quote, err := table.Quote(&domain.Address{City: "Almaty", Region: "KZ"}, domain.NewMoney(500000, "KZT"), kzt)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DeliveryQuote{Zone: "almaty", Fee: domain.NewMoney(80000, "KZT")}, quote)
+
+	quote, err = table.Quote(&domain.Address{City: " ALMATY ", Region: "kz"}, domain.NewMoney(2000000, "KZT"), kzt)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DeliveryQuote{Zone: "almaty", Fee: domain.NewMoney(0, "KZT")}, quote)
+
+	quote, err = table.Quote(&domain.Address{City: "Astana", Region: "KZ"}, domain.NewMoney(2000000, "KZT"), kzt)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DeliveryQuote{Zone: "kazakhstan", Fee: domain.NewMoney(250000, "KZT")}, quote)
+
+	_, err = table.Quote(&domain.Address{City: "Bishkek", Region: "KG"}, domain.NewMoney(100, "KZT"), kzt)
+	assert.ErrorIs(t, err, domain.ErrNotDeliverable)
+
+	// Orders without an address are not delivered, so cost nothing.
+	quote, err = table.Quote(nil, domain.NewMoney(100, "KZT"), kzt)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DeliveryQuote{Fee: domain.NewMoney(0, "KZT")}, quote)
+}
+
+func TestZoneTable_WithoutZonesDeliversEverywhereForFree(t *testing.T) {
+	table, err := NewZoneTable(nil)
+	assert.NoError(t, err)
+
+	quote, err := table.Quote(&domain.Address{City: "Bishkek", Region: "KG"}, domain.NewMoney(100, "USD"), domain.IdentityRate("USD"))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(0, "USD"), quote.Fee)
+}
+
+func TestNewZoneTable_RejectsBadZones(t *testing.T) {
+	for _, zones := range [][]domain.DeliveryZone{
+		{{Region: "KZ"}},
+		{{Name: "refund", Fee: domain.NewMoney(-100, "KZT")}},
+		{{Name: "nowhere", City: "Almaty"}},
+		{{Name: "a", Region: "kz"}, {Name: "b", Region: "KZ"}},
+	} {
+		_, err := NewZoneTable(zones)
+		assert.ErrorIs(t, err, domain.ErrInvalidDeliveryZone, zones)
+	}
+}
+
+func TestLoadZoneTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zones.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"zones": [{"name": "kz", "region": "KZ", "fee": 1500, "free_from": 20000}]}`), 0o644))
+
+	table, err := LoadZoneTable(path)
+	assert.NoError(t, err)
+
+	quote, err := table.Quote(&domain.Address{City: "Almaty", Region: "KZ"}, domain.NewMoney(1999999, "KZT"), domain.IdentityRate("KZT"))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(150000, "KZT"), quote.Fee)
+
+	_, err = LoadZoneTable(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+// Package email provides repository.EmailSender implementations.
+package email
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPSender sends emails through the email sender service.
+type HTTPSender struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHTTPSender(baseURL string) *HTTPSender {
+	return &HTTPSender{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type sendRequest struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+func (s *HTTPSender) Send(to, subject, body string) error {
+	payload, err := json.Marshal(sendRequest{To: to, Subject: subject, Body: body})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.baseURL+"/api/email/send", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("sending email to %s: %w", to, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&failure) != nil || failure.Error == "" {
+			failure.Error = resp.Status
+		}
+		return fmt.Errorf("sending email to %s: %s", to, failure.Error)
+	}
+
+	return nil
+}
+
+// ParseAddresses splits a comma-separated list of addresses, dropping empty
+// entries.
+func ParseAddresses(list string) []string {
+	var addresses []string
+	for _, address := range strings.Split(list, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
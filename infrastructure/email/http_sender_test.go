@@ -0,0 +1,39 @@
+package email
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPSender_Send(t *testing.T) {
+	var received sendRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/email/send", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		if received.To == "bounce@example.com" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "Failed to send email: mailbox unavailable"}`))
+			return
+		}
+		w.Write([]byte(`{"message": "Email sent successfully"}`))
+	}))
+	defer server.Close()
+
+	sender := NewHTTPSender(server.URL + "/")
+
+	assert.NoError(t, sender.Send("admin@example.com", "Low stock", "Milk is running out"))
+	assert.Equal(t, sendRequest{To: "admin@example.com", Subject: "Low stock", Body: "Milk is running out"}, received)
+
+	err := sender.Send("bounce@example.com", "Low stock", "Milk is running out")
+	assert.EqualError(t, err, "sending email to bounce@example.com: Failed to send email: mailbox unavailable")
+}
+
+func TestParseAddresses(t *testing.T) {
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, ParseAddresses(" a@example.com,, b@example.com "))
+	assert.Nil(t, ParseAddresses(""))
+}
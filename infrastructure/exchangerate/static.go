@@ -0,0 +1,114 @@
+// Package exchangerate provides repository.ExchangeRateProvider
+// implementations.
+package exchangerate
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"AdvProg2/domain"
+)
+
+// RatesFile is the format read by LoadStaticProvider. Rates gives the value
+// of one unit of Base in every other currency:
+//
+//	{"base": "KZT", "rates": {"USD": "0.0021", "EUR": "0.0019"}}
+type RatesFile struct {
+	Base  string            `json:"base"`
+	Rates map[string]string `json:"rates"`
+}
+
+// StaticProvider serves a fixed table of rates against one base currency.
+// Rates between two non-base currencies are derived through the base.
+type StaticProvider struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+func NewStaticProvider(base string, rates map[string]string) (*StaticProvider, error) {
+	base = strings.ToUpper(base)
+	if base == "" {
+		base = domain.DefaultCurrency
+	}
+
+	p := &StaticProvider{
+		base:  base,
+		rates: map[string]*big.Rat{base: big.NewRat(1, 1)},
+	}
+
+	for currency, rate := range rates {
+		r, ok := new(big.Rat).SetString(rate)
+		if !ok || r.Sign() <= 0 {
+			return nil, fmt.Errorf("exchangerate: invalid rate %q for %s", rate, currency)
+		}
+		p.rates[strings.ToUpper(currency)] = r
+	}
+
+	return p, nil
+}
+
+// LoadStaticProvider reads a RatesFile from path.
+func LoadStaticProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("exchangerate: reading %s: %w", path, err)
+	}
+
+	var file RatesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("exchangerate: parsing %s: %w", path, err)
+	}
+
+	return NewStaticProvider(file.Base, file.Rates)
+}
+
+// NewProviderFromEnv loads the file named by EXCHANGE_RATES_FILE. Without it
+// only the default currency is supported.
+func NewProviderFromEnv() (*StaticProvider, error) {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		log.Printf("EXCHANGE_RATES_FILE not set, only %s prices are available", domain.DefaultCurrency)
+		return NewStaticProvider(domain.DefaultCurrency, nil)
+	}
+
+	provider, err := LoadStaticProvider(path)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Loaded exchange rates for %d currencies from %s", len(provider.rates), path)
+	return provider, nil
+}
+
+func (p *StaticProvider) Rate(from, to string) (domain.ExchangeRate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	if from == to {
+		return domain.IdentityRate(from), nil
+	}
+
+	fromRate, ok := p.rates[from]
+	if !ok {
+		return domain.ExchangeRate{}, fmt.Errorf("%w: %s", domain.ErrUnsupportedCurrency, from)
+	}
+
+	toRate, ok := p.rates[to]
+	if !ok {
+		return domain.ExchangeRate{}, fmt.Errorf("%w: %s", domain.ErrUnsupportedCurrency, to)
+	}
+
+	rate := new(big.Rat).Quo(toRate, fromRate)
+	return domain.NewExchangeRate(from, to, rateString(rate))
+}
+
+// rateString formats rate with up to ten decimal places, the precision
+// orders store it with.
+func rateString(rate *big.Rat) string {
+	s := rate.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,53 @@
+package exchangerate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+)
+
+func TestStaticProvider_Rate(t *testing.T) {
+	provider, err := NewStaticProvider("kzt", map[string]string{"usd": "0.002", "EUR": "0.0016"})
+	assert.NoError(t, err)
+
+	rate, err := provider.Rate("KZT", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ExchangeRate{From: "KZT", To: "USD", Rate: "0.002"}, rate)
+
+	rate, err = provider.Rate("usd", "kzt")
+	assert.NoError(t, err)
+	assert.Equal(t, "500", rate.Rate)
+
+	rate, err = provider.Rate("USD", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ExchangeRate{From: "USD", To: "EUR", Rate: "0.8"}, rate)
+
+	rate, err = provider.Rate("EUR", "eur")
+	assert.NoError(t, err)
+	assert.True(t, rate.IsIdentity())
+
+	_, err = provider.Rate("KZT", "GBP")
+	assert.ErrorIs(t, err, domain.ErrUnsupportedCurrency)
+
+	_, err = NewStaticProvider("KZT", map[string]string{"USD": "0"})
+	assert.Error(t, err)
+}
+
+func TestLoadStaticProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"base": "KZT", "rates": {"RUB": "0.18"}}`), 0o644))
+
+	provider, err := LoadStaticProvider(path)
+	assert.NoError(t, err)
+
+	rate, err := provider.Rate("RUB", "KZT")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.5555555556", rate.Rate)
+
+	_, err = LoadStaticProvider(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
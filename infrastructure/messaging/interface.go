@@ -1,11 +0,0 @@
-package messaging
-
-import (
-    "AdvProg2/domain"
-)
-
-type MessageProducer interface {
-    PublishOrderCreated(event domain.OrderCreatedEvent) error
-    PublishProductCreated(event domain.ProductCreatedEvent) error
-    Close() error
-}
\ No newline at end of file
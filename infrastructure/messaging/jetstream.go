@@ -0,0 +1,187 @@
+package messaging
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	dlqSuffix = ".dlq"
+
+	jetStreamFetchBatch = 10
+)
+
+// JetStreamConfig configures the durable mode of NatsProducer and NatsConsumer.
+type JetStreamConfig struct {
+	// Stream stores every event subject, including the dead-letter subjects.
+	Stream   string
+	Subjects []string
+	MaxAge   time.Duration
+
+	// DurablePrefix names this service's consumers. Services with different
+	// prefixes each receive every event; instances sharing a prefix resume
+	// from the same position.
+	DurablePrefix string
+
+	// MaxDeliver is how many times a message is attempted before it is moved
+	// to the <subject>.dlq dead-letter subject. The consumer enforces it;
+	// the server keeps redelivering until the message is dead-lettered.
+	MaxDeliver int
+	// BackOff is the redelivery delay after each failed attempt. The last
+	// value is reused once the list is exhausted.
+	BackOff []time.Duration
+	AckWait time.Duration
+	// FetchWait bounds how long a pull request waits for new messages.
+	FetchWait time.Duration
+}
+
+func DefaultJetStreamConfig(durablePrefix string) JetStreamConfig {
+	return JetStreamConfig{
+		Stream:        "FOODSTORE_EVENTS",
+		Subjects:      []string{"order.>", "product.>", "inventory.>", "payment.>"},
+		MaxAge:        7 * 24 * time.Hour,
+		DurablePrefix: durablePrefix,
+		MaxDeliver:    5,
+		BackOff:       []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 2 * time.Minute},
+		AckWait:       30 * time.Second,
+		FetchWait:     5 * time.Second,
+	}
+}
+
+// JetStreamEnabled reports whether NATS_JETSTREAM asks for durable messaging.
+func JetStreamEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("NATS_JETSTREAM"))
+	return enabled
+}
+
+// JetStreamConfigFromEnv applies NATS_MAX_DELIVER and NATS_BACKOFF (a comma
+// separated list of durations such as "1s,5s,30s") to the defaults.
+func JetStreamConfigFromEnv(durablePrefix string) JetStreamConfig {
+	cfg := DefaultJetStreamConfig(durablePrefix)
+
+	if maxDeliver := os.Getenv("NATS_MAX_DELIVER"); maxDeliver != "" {
+		if n, err := strconv.Atoi(maxDeliver); err == nil && n > 0 {
+			cfg.MaxDeliver = n
+		} else {
+			log.Printf("Warning: ignoring invalid NATS_MAX_DELIVER %q", maxDeliver)
+		}
+	}
+
+	if backOff := os.Getenv("NATS_BACKOFF"); backOff != "" {
+		var delays []time.Duration
+		for _, part := range strings.Split(backOff, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(part))
+			if err != nil {
+				log.Printf("Warning: ignoring invalid NATS_BACKOFF %q: %v", backOff, err)
+				delays = nil
+				break
+			}
+			delays = append(delays, d)
+		}
+		if len(delays) > 0 {
+			cfg.BackOff = delays
+		}
+	}
+
+	return cfg
+}
+
+// NewProducerFromEnv returns a JetStream producer when NATS_JETSTREAM is set
+// and a core NATS producer otherwise.
+func NewProducerFromEnv(nc *nats.Conn) (*NatsProducer, error) {
+	if !JetStreamEnabled() {
+		return NewNatsProducer(nc), nil
+	}
+	return NewJetStreamProducer(nc, JetStreamConfigFromEnv(""))
+}
+
+// NewConsumerFromEnv returns a durable JetStream consumer named after
+// durablePrefix when NATS_JETSTREAM is set and a core NATS consumer otherwise.
+func NewConsumerFromEnv(nc *nats.Conn, durablePrefix string) (*NatsConsumer, error) {
+	if !JetStreamEnabled() {
+		return NewNatsConsumer(nc), nil
+	}
+	return NewJetStreamConsumer(nc, JetStreamConfigFromEnv(durablePrefix))
+}
+
+// ensureStream creates the event stream or updates it to the configured
+// subjects, so subjects added by newer services are picked up on startup.
+func ensureStream(js nats.JetStreamContext, cfg JetStreamConfig) error {
+	streamCfg := &nats.StreamConfig{
+		Name:      cfg.Stream,
+		Subjects:  cfg.Subjects,
+		Storage:   nats.FileStorage,
+		Retention: nats.LimitsPolicy,
+		MaxAge:    cfg.MaxAge,
+	}
+
+	_, err := js.StreamInfo(cfg.Stream)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		log.Printf("Creating JetStream stream %s for %v", cfg.Stream, cfg.Subjects)
+		_, err = js.AddStream(streamCfg)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = js.UpdateStream(streamCfg)
+	return err
+}
+
+// ensureConsumer creates or updates the durable pull consumer for subject.
+// Creating it here rather than through js.PullSubscribe keeps it alive when
+// the subscription is closed, so messages published while the service is
+// down are delivered once it is back. The server's delivery limit is left
+// off: a message is only given up on once a copy is in the dead-letter
+// subject, which may take more than cfg.MaxDeliver attempts.
+func ensureConsumer(js nats.JetStreamContext, cfg JetStreamConfig, subject, durable string) error {
+	consumerCfg := &nats.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: subject,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       cfg.AckWait,
+		MaxDeliver:    -1,
+		DeliverPolicy: nats.DeliverAllPolicy,
+	}
+
+	_, err := js.ConsumerInfo(cfg.Stream, durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = js.AddConsumer(cfg.Stream, consumerCfg)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = js.UpdateConsumer(cfg.Stream, consumerCfg)
+	return err
+}
+
+func durableName(prefix, subject string) string {
+	name := strings.NewReplacer(".", "_", "*", "any", ">", "all").Replace(subject)
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+func (cfg JetStreamConfig) backOffFor(delivered uint64) time.Duration {
+	if len(cfg.BackOff) == 0 {
+		return 0
+	}
+	i := int(delivered) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(cfg.BackOff) {
+		i = len(cfg.BackOff) - 1
+	}
+	return cfg.BackOff[i]
+}
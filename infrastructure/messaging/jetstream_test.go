@@ -0,0 +1,235 @@
+package messaging
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/pkg/eventbus"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func runJetStreamServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create nats server: %v", err)
+	}
+
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	return srv
+}
+
+func connect(t *testing.T, srv *server.Server) *nats.Conn {
+	t.Helper()
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("failed to connect to nats server: %v", err)
+	}
+	t.Cleanup(nc.Close)
+
+	return nc
+}
+
+func testJetStreamConfig(durablePrefix string) JetStreamConfig {
+	cfg := DefaultJetStreamConfig(durablePrefix)
+	cfg.MaxDeliver = 3
+	cfg.BackOff = []time.Duration{10 * time.Millisecond}
+	cfg.AckWait = time.Second
+	cfg.FetchWait = 100 * time.Millisecond
+	return cfg
+}
+
+func TestJetStream_DeliversMessagesPublishedWhileConsumerWasDown(t *testing.T) {
+	srv := runJetStreamServer(t)
+	cfg := testJetStreamConfig("test-service")
+
+	producer, err := NewJetStreamProducer(connect(t, srv), cfg)
+	assert.NoError(t, err)
+
+	// Register the durable consumer, then shut the service down.
+	consumer, err := NewJetStreamConsumer(connect(t, srv), cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, eventbus.Subscribe(consumer, domain.EventProductDeleted, func(meta domain.MessageMetadata, event domain.ProductDeletedEvent) error { return nil }))
+	assert.NoError(t, consumer.Close())
+
+	assert.NoError(t, eventbus.Publish(producer, domain.EventProductDeleted, domain.ProductDeletedEvent{ProductID: "p-1", DeletedAt: time.Now()}))
+
+	received := make(chan domain.MessageMetadata, 1)
+	consumer, err = NewJetStreamConsumer(connect(t, srv), cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, eventbus.Subscribe(consumer, domain.EventProductDeleted, func(meta domain.MessageMetadata, event domain.ProductDeletedEvent) error {
+		assert.Equal(t, "p-1", event.ProductID)
+		received <- meta
+		return nil
+	}))
+
+	select {
+	case meta := <-received:
+		assert.NotEmpty(t, meta.ID)
+		assert.False(t, meta.CreatedAt.IsZero())
+	case <-time.After(5 * time.Second):
+		t.Fatal("message published while the consumer was down was not delivered")
+	}
+}
+
+func TestJetStream_RedeliversAndDeadLettersFailingMessages(t *testing.T) {
+	nc := connect(t, runJetStreamServer(t))
+	cfg := testJetStreamConfig("test-service")
+
+	producer, err := NewJetStreamProducer(nc, cfg)
+	assert.NoError(t, err)
+
+	dlq := make(chan *nats.Msg, 1)
+	_, err = nc.Subscribe(domain.EventOrderCreated+dlqSuffix, func(m *nats.Msg) { dlq <- m })
+	assert.NoError(t, err)
+
+	var attempts int32
+	consumer, err := NewJetStreamConsumer(nc, cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, eventbus.Subscribe(consumer, domain.EventOrderCreated, func(meta domain.MessageMetadata, event domain.OrderCreatedEvent) error {
+		atomic.AddInt32(&attempts, 1)
+		return errors.New("database unavailable")
+	}))
+
+	assert.NoError(t, eventbus.Publish(producer, domain.EventOrderCreated, domain.OrderCreatedEvent{OrderID: "o-1", CreatedAt: time.Now()}))
+
+	select {
+	case m := <-dlq:
+		assert.Equal(t, "order.created", m.Header.Get("Dlq-Original-Subject"))
+		assert.Equal(t, "database unavailable", m.Header.Get("Dlq-Error"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("failing message was not dead-lettered")
+	}
+
+	assert.Equal(t, int32(cfg.MaxDeliver), atomic.LoadInt32(&attempts))
+}
+
+func TestJetStream_RetriesDeadLetteringThatFails(t *testing.T) {
+	nc := connect(t, runJetStreamServer(t))
+	cfg := testJetStreamConfig("test-service")
+
+	producer, err := NewJetStreamProducer(nc, cfg)
+	assert.NoError(t, err)
+	js, err := nc.JetStream()
+	assert.NoError(t, err)
+
+	// Only copies stored in the stream count, so read them through it.
+	dlq := make(chan *nats.Msg, 1)
+	_, err = js.Subscribe(domain.EventOrderCreated+dlqSuffix, func(m *nats.Msg) { dlq <- m }, nats.BindStream(cfg.Stream))
+	assert.NoError(t, err)
+
+	// limitStream makes the stream refuse new messages, so the dead-letter
+	// copy cannot be stored.
+	limitStream := func(maxMsgs int64) {
+		info, err := js.StreamInfo(cfg.Stream)
+		assert.NoError(t, err)
+		info.Config.MaxMsgs = maxMsgs
+		info.Config.Discard = nats.DiscardNew
+		_, err = js.UpdateStream(&info.Config)
+		assert.NoError(t, err)
+	}
+
+	var attempts int32
+	consumer, err := NewJetStreamConsumer(nc, cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, eventbus.Subscribe(consumer, domain.EventOrderCreated, func(meta domain.MessageMetadata, event domain.OrderCreatedEvent) error {
+		switch atomic.AddInt32(&attempts, 1) {
+		case int32(cfg.MaxDeliver):
+			limitStream(1)
+		case int32(cfg.MaxDeliver) + 1:
+			limitStream(-1)
+		}
+		return errors.New("database unavailable")
+	}))
+
+	assert.NoError(t, eventbus.Publish(producer, domain.EventOrderCreated, domain.OrderCreatedEvent{OrderID: "o-1", CreatedAt: time.Now()}))
+
+	select {
+	case m := <-dlq:
+		assert.Equal(t, "order.created", m.Header.Get("Dlq-Original-Subject"))
+		assert.Equal(t, "4", m.Header.Get("Dlq-Deliveries"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was lost when dead-lettering it failed")
+	}
+
+	assert.Equal(t, int32(cfg.MaxDeliver)+1, atomic.LoadInt32(&attempts))
+}
+
+func TestJetStream_DeadLettersUndecodableMessagesImmediately(t *testing.T) {
+	nc := connect(t, runJetStreamServer(t))
+	cfg := testJetStreamConfig("test-service")
+
+	producer, err := NewJetStreamProducer(nc, cfg)
+	assert.NoError(t, err)
+
+	dlq := make(chan *nats.Msg, 1)
+	_, err = nc.Subscribe(domain.EventProductCreated+dlqSuffix, func(m *nats.Msg) { dlq <- m })
+	assert.NoError(t, err)
+
+	consumer, err := NewJetStreamConsumer(nc, cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, eventbus.Subscribe(consumer, domain.EventProductCreated, func(meta domain.MessageMetadata, event domain.ProductCreatedEvent) error {
+		t.Error("handler must not be called for an undecodable message")
+		return nil
+	}))
+
+	assert.NoError(t, producer.PublishEnvelope(domain.EventProductCreated, []byte("not json")))
+
+	select {
+	case m := <-dlq:
+		assert.Equal(t, "1", m.Header.Get("Dlq-Deliveries"))
+		assert.Equal(t, []byte("not json"), m.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("undecodable message was not dead-lettered")
+	}
+}
+
+func TestJetStream_StreamCoversPaymentEvents(t *testing.T) {
+	nc := connect(t, runJetStreamServer(t))
+	cfg := testJetStreamConfig("test-service")
+
+	producer, err := NewJetStreamProducer(nc, cfg)
+	assert.NoError(t, err)
+
+	received := make(chan domain.PaymentEvent, 1)
+	consumer, err := NewJetStreamConsumer(nc, cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, eventbus.Subscribe(consumer, domain.EventPaymentSucceeded, func(meta domain.MessageMetadata, event domain.PaymentEvent) error {
+		received <- event
+		return nil
+	}))
+
+	// Publishing fails if no stream stores the subject.
+	assert.NoError(t, eventbus.Publish(producer, domain.EventPaymentSucceeded, domain.PaymentEvent{
+		PaymentID: "pay-1",
+		OrderID:   "o-1",
+		Status:    domain.PaymentStatusSucceeded,
+		ChangedAt: time.Now(),
+	}))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "pay-1", event.PaymentID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("payment event was not delivered")
+	}
+}
@@ -1,17 +1,20 @@
 package messaging
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/nats-io/nats.go"
 
-	"AdvProg2/domain"
+	"AdvProg2/repository"
 )
 
 type NatsConsumer struct {
 	nc            *nats.Conn
+	js            nats.JetStreamContext
+	jsConfig      JetStreamConfig
 	subscriptions []*nats.Subscription
 }
 
@@ -22,167 +25,141 @@ func NewNatsConsumer(nc *nats.Conn) *NatsConsumer {
 	}
 }
 
-func (c *NatsConsumer) SubscribeToOrderCreated(handler func(event domain.OrderCreatedEvent) error) error {
-	subject := "order.created"
-
-	log.Printf("Subscribing to %s", subject)
-
-	subscription, err := c.nc.Subscribe(subject, func(m *nats.Msg) {
-		var message domain.Message
-		if err := json.Unmarshal(m.Data, &message); err != nil {
-			log.Printf("Error unmarshalling message: %v", err)
-			return
-		}
-
-		var event domain.OrderCreatedEvent
-		if err := json.Unmarshal(message.Data, &event); err != nil {
-			log.Printf("Error unmarshalling order created event: %v", err)
-			return
-		}
-
-		log.Printf("Received order.created event for order %s", event.OrderID)
-
-		if err := handler(event); err != nil {
-			log.Printf("Error handling order created event: %v", err)
-		}
-	})
-
+// NewJetStreamConsumer subscribes through durable JetStream consumers named
+// after cfg.DurablePrefix. Messages are acknowledged only after the handler
+// succeeds; failures are redelivered with cfg.BackOff and moved to the
+// <subject>.dlq subject after cfg.MaxDeliver attempts.
+func NewJetStreamConsumer(nc *nats.Conn, cfg JetStreamConfig) (*NatsConsumer, error) {
+	js, err := nc.JetStream()
 	if err != nil {
-		log.Printf("Error subscribing to %s: %v", subject, err)
-		return err
+		return nil, err
 	}
 
-	c.subscriptions = append(c.subscriptions, subscription)
+	if err := ensureStream(js, cfg); err != nil {
+		log.Printf("Error ensuring JetStream stream %s: %v", cfg.Stream, err)
+		return nil, err
+	}
 
-	log.Printf("Successfully subscribed to %s", subject)
-	return nil
+	return &NatsConsumer{
+		nc:            nc,
+		js:            js,
+		jsConfig:      cfg,
+		subscriptions: make([]*nats.Subscription, 0),
+	}, nil
 }
 
-func (c *NatsConsumer) SubscribeToProductCreated(handler func(event domain.ProductCreatedEvent) error) error {
-	subject := "product.created"
-
-	log.Printf("Subscribing to %s", subject)
-
-	subscription, err := c.nc.Subscribe(subject, func(m *nats.Msg) {
-
-		var message domain.Message
-		if err := json.Unmarshal(m.Data, &message); err != nil {
-			return
-		}
-
-		var event domain.ProductCreatedEvent
-		if err := json.Unmarshal(message.Data, &event); err != nil {
-			log.Printf("Event data content: %s", string(message.Data))
-			return
-		}
-
-		log.Printf("Successfully parsed product.created event for product %s (%s)",
-			event.ProductID, event.Name)
+func (c *NatsConsumer) subscribe(subject string, process func(m *nats.Msg) error) (*nats.Subscription, error) {
+	if c.js == nil {
+		return c.nc.Subscribe(subject, func(m *nats.Msg) {
+			process(m)
+		})
+	}
 
-		if err := handler(event); err != nil {
-			log.Printf("Error handling product created event: %v", err)
-		}
-	})
+	durable := durableName(c.jsConfig.DurablePrefix, subject)
+	if err := ensureConsumer(c.js, c.jsConfig, subject, durable); err != nil {
+		return nil, err
+	}
 
+	sub, err := c.js.PullSubscribe(subject, durable, nats.Bind(c.jsConfig.Stream, durable), nats.ManualAck())
 	if err != nil {
-		log.Printf("Error subscribing to %s: %v", subject, err)
-		return err
+		return nil, err
 	}
 
-	c.subscriptions = append(c.subscriptions, subscription)
-
-	log.Printf("Successfully subscribed to %s", subject)
-	return nil
+	go c.fetchLoop(sub, subject, process)
+	return sub, nil
 }
 
-func (c *NatsConsumer) SubscribeToProductUpdated(handler func(event domain.ProductUpdatedEvent) error) error {
-	subject := "product.updated"
-
-	log.Printf("NATS Consumer: Subscribing to %s", subject)
-
-	subscription, err := c.nc.Subscribe(subject, func(m *nats.Msg) {
-		log.Printf("NATS Consumer: Received raw message on %s: %s", subject, string(m.Data))
-
-		var message domain.Message
-		if err := json.Unmarshal(m.Data, &message); err != nil {
-			log.Printf("Error unmarshalling message: %v", err)
-			log.Printf("Raw message content: %s", string(m.Data))
-			return
+// fetchLoop pulls messages for a durable consumer until the subscription is
+// closed.
+func (c *NatsConsumer) fetchLoop(sub *nats.Subscription, subject string, process func(m *nats.Msg) error) {
+	for sub.IsValid() {
+		msgs, err := sub.Fetch(jetStreamFetchBatch, nats.MaxWait(c.jsConfig.FetchWait))
+		if err != nil {
+			if errors.Is(err, nats.ErrTimeout) {
+				continue
+			}
+			if !sub.IsValid() || errors.Is(err, nats.ErrConnectionClosed) {
+				return
+			}
+			log.Printf("Error fetching %s messages: %v", subject, err)
+			time.Sleep(time.Second)
+			continue
 		}
 
-		log.Printf("NATS Consumer: Message envelope parsed: ID=%s, Type=%s",
-			message.ID, message.Type)
-
-		// Use message.Data directly as it's already []byte
-		eventData := message.Data
-		log.Printf("NATS Consumer: Using message data as bytes")
-
-		var event domain.ProductUpdatedEvent
-		if err := json.Unmarshal(eventData, &event); err != nil {
-			log.Printf("Error unmarshalling product updated event: %v", err)
-			log.Printf("Event data content: %s", string(eventData))
-			return
+		for _, m := range msgs {
+			c.settle(m, subject, process(m))
 		}
+	}
+}
 
-		log.Printf("NATS Consumer: Successfully parsed product.updated event for product %s (%s)",
-			event.ProductID, event.Name)
-		log.Printf("NATS Consumer: Product details: Price=%.2f, Stock=%d, Updated at %s",
-			event.Price, event.Stock, event.UpdatedAt.Format(time.RFC3339))
-
-		if err := handler(event); err != nil {
-			log.Printf("Error handling product updated event: %v", err)
+// settle acknowledges a processed message, or schedules its redelivery with
+// backoff, or dead-letters it once it is malformed or out of attempts.
+func (c *NatsConsumer) settle(m *nats.Msg, subject string, err error) {
+	if err == nil {
+		if err := m.Ack(); err != nil {
+			log.Printf("Error acknowledging %s message: %v", subject, err)
 		}
-	})
+		return
+	}
 
-	if err != nil {
-		log.Printf("Error subscribing to %s: %v", subject, err)
-		return err
+	var delivered uint64 = 1
+	if meta, metaErr := m.Metadata(); metaErr == nil {
+		delivered = meta.NumDelivered
 	}
 
-	c.subscriptions = append(c.subscriptions, subscription)
+	if errors.Is(err, repository.ErrMalformedMessage) || int(delivered) >= c.jsConfig.MaxDeliver {
+		c.deadLetter(m, err, delivered)
+		return
+	}
 
-	log.Printf("NATS Consumer: Successfully subscribed to %s", subject)
-	return nil
+	delay := c.jsConfig.backOffFor(delivered)
+	log.Printf("Redelivering %s message in %s (attempt %d of %d): %v",
+		subject, delay, delivered, c.jsConfig.MaxDeliver, err)
+	if err := m.NakWithDelay(delay); err != nil {
+		log.Printf("Error requesting redelivery of %s message: %v", subject, err)
+	}
 }
 
-func (c *NatsConsumer) SubscribeToProductDeleted(handler func(event domain.ProductDeletedEvent) error) error {
-	subject := "product.deleted"
-
-	log.Printf("NATS Consumer: Subscribing to %s", subject)
-
-	subscription, err := c.nc.Subscribe(subject, func(m *nats.Msg) {
-		log.Printf("NATS Consumer: Received raw message on %s: %s", subject, string(m.Data))
-
-		var message domain.Message
-		if err := json.Unmarshal(m.Data, &message); err != nil {
-			log.Printf("Error unmarshalling message: %v", err)
-			log.Printf("Raw message content: %s", string(m.Data))
-			return
+// deadLetter copies the message to <subject>.dlq with the failure details in
+// headers and terminates it so it is not redelivered. If the copy cannot be
+// published the message is redelivered instead, and dead-lettered again on
+// its next failure.
+func (c *NatsConsumer) deadLetter(m *nats.Msg, cause error, delivered uint64) {
+	dlqSubject := m.Subject + dlqSuffix
+	log.Printf("Moving %s message to %s after %d attempts: %v", m.Subject, dlqSubject, delivered, cause)
+
+	dlqMsg := nats.NewMsg(dlqSubject)
+	dlqMsg.Data = m.Data
+	dlqMsg.Header.Set("Dlq-Original-Subject", m.Subject)
+	dlqMsg.Header.Set("Dlq-Error", cause.Error())
+	dlqMsg.Header.Set("Dlq-Deliveries", fmt.Sprint(delivered))
+
+	if _, err := c.js.PublishMsg(dlqMsg); err != nil {
+		// The consumer has no server-side delivery limit, so the message
+		// is not dropped while it is kept out of the dead-letter subject.
+		delay := c.jsConfig.backOffFor(delivered)
+		log.Printf("Error publishing to %s, redelivering %s message in %s: %v", dlqSubject, m.Subject, delay, err)
+		if err := m.NakWithDelay(delay); err != nil {
+			log.Printf("Error requesting redelivery of %s message: %v", m.Subject, err)
 		}
+		return
+	}
 
-		log.Printf("NATS Consumer: Message envelope parsed: ID=%s, Type=%s",
-			message.ID, message.Type)
-
-		eventData := message.Data
-		log.Printf("NATS Consumer: Using message data as bytes")
-
-		var event domain.ProductDeletedEvent
-		if err := json.Unmarshal(eventData, &event); err != nil {
-			log.Printf("Error unmarshalling product deleted event: %v", err)
-			log.Printf("Event data content: %s", string(eventData))
-			return
-		}
+	if err := m.Term(); err != nil {
+		log.Printf("Error terminating %s message: %v", m.Subject, err)
+	}
+}
 
-		log.Printf("NATS Consumer: Successfully parsed product.deleted event for product %s",
-			event.ProductID)
-		log.Printf("NATS Consumer: Product was deleted at %s",
-			event.DeletedAt.Format(time.RFC3339))
+// SubscribeEnvelope passes every message on subject to handler. In JetStream
+// mode a message is acknowledged when handler succeeds and redelivered when
+// it fails; repository.ErrMalformedMessage sends it straight to the
+// dead-letter subject.
+func (c *NatsConsumer) SubscribeEnvelope(subject string, handler func(envelope []byte) error) error {
+	log.Printf("Subscribing to %s", subject)
 
-		if err := handler(event); err != nil {
-			log.Printf("Error handling product deleted event: %v", err)
-		}
+	subscription, err := c.subscribe(subject, func(m *nats.Msg) error {
+		return handler(m.Data)
 	})
-
 	if err != nil {
 		log.Printf("Error subscribing to %s: %v", subject, err)
 		return err
@@ -190,7 +167,7 @@ func (c *NatsConsumer) SubscribeToProductDeleted(handler func(event domain.Produ
 
 	c.subscriptions = append(c.subscriptions, subscription)
 
-	log.Printf("NATS Consumer: Successfully subscribed to %s", subject)
+	log.Printf("Successfully subscribed to %s", subject)
 	return nil
 }
 
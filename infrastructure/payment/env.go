@@ -0,0 +1,30 @@
+package payment
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"AdvProg2/repository"
+)
+
+// devWebhookSecret signs fake webhooks when PAYMENT_WEBHOOK_SECRET is unset.
+const devWebhookSecret = "dev-payment-webhook-secret"
+
+// NewProviderFromEnv picks the provider named by PAYMENT_PROVIDER. Only
+// "fake", the default, exists so far; it signs its webhooks with
+// PAYMENT_WEBHOOK_SECRET.
+func NewProviderFromEnv() (repository.PaymentProvider, error) {
+	switch kind := os.Getenv("PAYMENT_PROVIDER"); kind {
+	case "", FakeProviderName:
+		secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+		if secret == "" {
+			log.Println("PAYMENT_WEBHOOK_SECRET not set, signing payment webhooks with the development secret")
+			secret = devWebhookSecret
+		}
+		log.Println("Taking payments through the fake payment provider")
+		return NewFakeProvider(secret)
+	default:
+		return nil, fmt.Errorf("payment: unknown PAYMENT_PROVIDER %q", kind)
+	}
+}
@@ -0,0 +1,152 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"AdvProg2/domain"
+)
+
+// FakeProviderName is the name payment intents taken by FakeProvider are
+// stored under.
+const FakeProviderName = "fake"
+
+// fakeWebhook is the body of the webhooks FakeProvider sends.
+type fakeWebhook struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	Reference     string `json:"reference"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+const (
+	fakeWebhookSucceeded = "payment.succeeded"
+	fakeWebhookFailed    = "payment.failed"
+)
+
+// FakeProvider is a payment gateway for development and tests that never
+// moves money. It is deterministic: an intent's reference and client secret
+// depend only on the intent's ID, and nothing happens to a payment until
+// Webhook is used to build the call the gateway would make. Webhooks are
+// signed with a hex HMAC-SHA256 of the body under the webhook secret.
+type FakeProvider struct {
+	secret []byte
+
+	mu      sync.Mutex
+	refunds map[string]string
+}
+
+func NewFakeProvider(webhookSecret string) (*FakeProvider, error) {
+	if webhookSecret == "" {
+		return nil, errors.New("payment: webhook secret is required")
+	}
+	return &FakeProvider{secret: []byte(webhookSecret), refunds: map[string]string{}}, nil
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) CreatePayment(intent *domain.PaymentIntent) (string, string, error) {
+	if intent.ID == "" {
+		return "", "", errors.New("payment: intent ID is required")
+	}
+	if intent.Amount.Amount <= 0 {
+		return "", "", fmt.Errorf("payment: %w: amount must be positive", domain.ErrInvalidMoney)
+	}
+
+	ref := "fake_pi_" + intent.ID
+	return ref, ref + "_secret_" + p.sign([]byte(ref))[:16], nil
+}
+
+func (p *FakeProvider) Refund(intent *domain.PaymentIntent, idempotencyKey string) error {
+	if intent.ProviderRef == "" {
+		return errors.New("payment: intent has no provider reference")
+	}
+	if idempotencyKey == "" {
+		return errors.New("payment: idempotency key is required")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.refunds[idempotencyKey]; !ok {
+		p.refunds[idempotencyKey] = intent.ProviderRef
+	}
+	return nil
+}
+
+// Refunds returns how many refunds were made for the payment with ref.
+func (p *FakeProvider) Refunds(ref string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	count := 0
+	for _, refunded := range p.refunds {
+		if refunded == ref {
+			count++
+		}
+	}
+	return count
+}
+
+// Webhook returns the body and signature of the webhook the gateway sends
+// when the payment with ref ends in status, which is succeeded or failed.
+// failureReason is only sent for failed payments.
+func (p *FakeProvider) Webhook(ref string, status domain.PaymentStatus, failureReason string) ([]byte, string, error) {
+	webhook := fakeWebhook{ID: "evt_" + ref + "_" + string(status), Reference: ref}
+	switch status {
+	case domain.PaymentStatusSucceeded:
+		webhook.Type = fakeWebhookSucceeded
+	case domain.PaymentStatusFailed:
+		webhook.Type = fakeWebhookFailed
+		webhook.FailureReason = failureReason
+		if webhook.FailureReason == "" {
+			webhook.FailureReason = "card declined"
+		}
+	default:
+		return nil, "", fmt.Errorf("payment: no webhook is sent for %s payments", status)
+	}
+
+	payload, err := json.Marshal(webhook)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, p.sign(payload), nil
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, signature string) (*domain.PaymentNotification, error) {
+	if !hmac.Equal([]byte(p.sign(payload)), []byte(signature)) {
+		return nil, fmt.Errorf("%w: bad signature", domain.ErrInvalidPaymentWebhook)
+	}
+
+	var webhook fakeWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPaymentWebhook, err)
+	}
+	if webhook.Reference == "" {
+		return nil, fmt.Errorf("%w: missing payment reference", domain.ErrInvalidPaymentWebhook)
+	}
+
+	notification := &domain.PaymentNotification{EventID: webhook.ID, ProviderRef: webhook.Reference}
+	switch webhook.Type {
+	case fakeWebhookSucceeded:
+		notification.Status = domain.PaymentStatusSucceeded
+	case fakeWebhookFailed:
+		notification.Status = domain.PaymentStatusFailed
+		notification.FailureReason = webhook.FailureReason
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", domain.ErrInvalidPaymentWebhook, webhook.Type)
+	}
+	return notification, nil
+}
+
+func (p *FakeProvider) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+)
+
+func TestFakeProvider_IsDeterministic(t *testing.T) {
+	provider, err := NewFakeProvider("secret")
+	assert.NoError(t, err)
+	intent := &domain.PaymentIntent{ID: "pay-1", Amount: domain.NewMoney(125000, "KZT")}
+
+	ref, clientSecret, err := provider.CreatePayment(intent)
+	assert.NoError(t, err)
+	assert.Equal(t, "fake_pi_pay-1", ref)
+
+	other, err := NewFakeProvider("secret")
+	assert.NoError(t, err)
+	sameRef, sameSecret, err := other.CreatePayment(intent)
+	assert.NoError(t, err)
+	assert.Equal(t, ref, sameRef)
+	assert.Equal(t, clientSecret, sameSecret)
+
+	_, _, err = provider.CreatePayment(&domain.PaymentIntent{ID: "pay-2"})
+	assert.ErrorIs(t, err, domain.ErrInvalidMoney)
+}
+
+func TestFakeProvider_ParsesOnlyWebhooksItSigned(t *testing.T) {
+	provider, err := NewFakeProvider("secret")
+	assert.NoError(t, err)
+
+	payload, signature, err := provider.Webhook("fake_pi_pay-1", domain.PaymentStatusFailed, "")
+	assert.NoError(t, err)
+
+	notification, err := provider.ParseWebhook(payload, signature)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.PaymentNotification{
+		EventID:       "evt_fake_pi_pay-1_failed",
+		ProviderRef:   "fake_pi_pay-1",
+		Status:        domain.PaymentStatusFailed,
+		FailureReason: "card declined",
+	}, notification)
+
+	_, err = provider.ParseWebhook(payload, "bad")
+	assert.ErrorIs(t, err, domain.ErrInvalidPaymentWebhook)
+
+	// A webhook signed with another secret is rejected too.
+	other, err := NewFakeProvider("other")
+	assert.NoError(t, err)
+	payload, signature, err = other.Webhook("fake_pi_pay-1", domain.PaymentStatusSucceeded, "")
+	assert.NoError(t, err)
+	_, err = provider.ParseWebhook(payload, signature)
+	assert.ErrorIs(t, err, domain.ErrInvalidPaymentWebhook)
+
+	_, _, err = provider.Webhook("fake_pi_pay-1", domain.PaymentStatusRefunded, "")
+	assert.Error(t, err)
+}
+
+func TestFakeProvider_RefundsOncePerIdempotencyKey(t *testing.T) {
+	provider, err := NewFakeProvider("secret")
+	assert.NoError(t, err)
+	intent := &domain.PaymentIntent{ID: "pay-1", ProviderRef: "fake_pi_pay-1"}
+
+	assert.NoError(t, provider.Refund(intent, "pay-1"))
+	assert.NoError(t, provider.Refund(intent, "pay-1"))
+	assert.Equal(t, 1, provider.Refunds("fake_pi_pay-1"))
+
+	assert.Error(t, provider.Refund(intent, ""))
+}
@@ -0,0 +1,132 @@
+// Package tax provides repository.TaxCalculator implementations.
+package tax
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"AdvProg2/domain"
+)
+
+// RulesFile is the format read by LoadRulesTable:
+//
+//	{"rules": [
+//	    {"rate": "0.12", "inclusive": true},
+//	    {"category_id": "books", "rate": "0"},
+//	    {"region": "US-CA", "rate": "0.0725"}
+//	]}
+type RulesFile struct {
+	Rules []domain.TaxRule `json:"rules"`
+}
+
+// RulesTable charges each line the rate of the most specific rule that
+// matches it: one for its category and the region, then one for its
+// category in any region, then the same for each of the category's
+// ancestors, nearest first, then one for the region, then one for anything.
+// Lines no rule matches are not taxed. Regions are compared ignoring case.
+type RulesTable struct {
+	rules map[ruleKey]domain.TaxRule
+}
+
+type ruleKey struct {
+	categoryID string
+	region     string
+}
+
+func NewRulesTable(rules []domain.TaxRule) (*RulesTable, error) {
+	t := &RulesTable{rules: make(map[ruleKey]domain.TaxRule, len(rules))}
+
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("tax: %w", err)
+		}
+
+		key := ruleKey{categoryID: rule.CategoryID, region: strings.ToUpper(rule.Region)}
+		if _, ok := t.rules[key]; ok {
+			return nil, fmt.Errorf("tax: %w: more than one rule for category %q in region %q",
+				domain.ErrInvalidTaxRule, rule.CategoryID, rule.Region)
+		}
+		t.rules[key] = rule
+	}
+
+	return t, nil
+}
+
+// LoadRulesTable reads a RulesFile from path.
+func LoadRulesTable(path string) (*RulesTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("tax: reading %s: %w", path, err)
+	}
+
+	var file RulesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("tax: parsing %s: %w", path, err)
+	}
+
+	return NewRulesTable(file.Rules)
+}
+
+// NewCalculatorFromEnv loads the file named by TAX_RULES_FILE. Without it
+// orders are not taxed.
+func NewCalculatorFromEnv() (*RulesTable, error) {
+	path := os.Getenv("TAX_RULES_FILE")
+	if path == "" {
+		log.Println("TAX_RULES_FILE not set, orders are not taxed")
+		return NewRulesTable(nil)
+	}
+
+	table, err := LoadRulesTable(path)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Loaded %d tax rules from %s", len(table.rules), path)
+	return table, nil
+}
+
+func (t *RulesTable) rule(line domain.TaxableLine, region string) (domain.TaxRule, bool) {
+	region = strings.ToUpper(region)
+	var keys []ruleKey
+	if line.CategoryID != "" {
+		for _, categoryID := range append([]string{line.CategoryID}, line.CategoryAncestors...) {
+			keys = append(keys, ruleKey{categoryID: categoryID, region: region}, ruleKey{categoryID: categoryID})
+		}
+	}
+	for _, key := range append(keys, ruleKey{region: region}, ruleKey{}) {
+		if rule, ok := t.rules[key]; ok {
+			return rule, true
+		}
+	}
+	return domain.TaxRule{}, false
+}
+
+func (t *RulesTable) Calculate(region string, lines []domain.TaxableLine) (domain.TaxBreakdown, error) {
+	var breakdown domain.TaxBreakdown
+
+	for _, line := range lines {
+		rule, ok := t.rule(line, region)
+		if !ok {
+			continue
+		}
+
+		tax, err := rule.Tax(line.Amount)
+		if err != nil {
+			return domain.TaxBreakdown{}, err
+		}
+
+		if breakdown.Tax, err = breakdown.Tax.Add(tax); err != nil {
+			return domain.TaxBreakdown{}, err
+		}
+		if !rule.Inclusive {
+			if breakdown.Added, err = breakdown.Added.Add(tax); err != nil {
+				return domain.TaxBreakdown{}, err
+			}
+		}
+	}
+
+	return breakdown, nil
+}
@@ -0,0 +1,92 @@
+package tax
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+)
+
+func TestRulesTable_PicksMostSpecificRule(t *testing.T) {
+	table, err := NewRulesTable([]domain.TaxRule{
+		{Rate: "0.12", Inclusive: true},
+		{CategoryID: "books", Rate: "0"},
+		{Region: "us-ca", Rate: "0.0725"},
+		{CategoryID: "books", Region: "US-CA", Rate: "0.05"},
+	})
+	assert.NoError(t, err)
+
+	lines := []domain.TaxableLine{
+		{OrderItemID: "1", CategoryID: "fruit", Amount: domain.NewMoney(1120, "KZT")},
+		{OrderItemID: "2", CategoryID: "books", Amount: domain.NewMoney(1000, "KZT")},
+		{OrderItemID: "3", Amount: domain.NewMoney(560, "KZT")},
+	}
+
+	breakdown, err := table.Calculate("KZ", lines)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(180, "KZT"), breakdown.Tax)
+	assert.True(t, breakdown.Added.IsZero())
+
+	breakdown, err = table.Calculate("US-CA", lines)
+	assert.NoError(t, err)
+	// 1120 * 0.0725 = 81.2, 1000 * 0.05 = 50 and 560 * 0.0725 = 40.6.
+	assert.Equal(t, domain.NewMoney(172, "KZT"), breakdown.Tax)
+	assert.Equal(t, breakdown.Tax, breakdown.Added)
+}
+
+func TestRulesTable_FallsBackToNearestAncestorCategory(t *testing.T) {
+	table, err := NewRulesTable([]domain.TaxRule{
+		{Rate: "0.12"},
+		{CategoryID: "food", Rate: "0.05"},
+		{CategoryID: "fruit", Region: "KZ", Rate: "0"},
+	})
+	assert.NoError(t, err)
+
+	// apples is in fruit, which is in food.
+	lines := []domain.TaxableLine{
+		{OrderItemID: "1", CategoryID: "apples", CategoryAncestors: []string{"fruit", "food"}, Amount: domain.NewMoney(1000, "KZT")},
+	}
+
+	breakdown, err := table.Calculate("KZ", lines)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(0, "KZT"), breakdown.Tax)
+
+	breakdown, err = table.Calculate("US", lines)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(50, "KZT"), breakdown.Tax)
+}
+
+func TestRulesTable_WithoutRulesChargesNothing(t *testing.T) {
+	table, err := NewRulesTable(nil)
+	assert.NoError(t, err)
+
+	breakdown, err := table.Calculate("KZ", []domain.TaxableLine{{Amount: domain.NewMoney(1000, "KZT")}})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TaxBreakdown{}, breakdown)
+}
+
+func TestNewRulesTable_RejectsBadRules(t *testing.T) {
+	_, err := NewRulesTable([]domain.TaxRule{{Rate: "-0.1"}})
+	assert.ErrorIs(t, err, domain.ErrInvalidTaxRule)
+
+	_, err = NewRulesTable([]domain.TaxRule{{Region: "kz", Rate: "0.12"}, {Region: "KZ", Rate: "0.1"}})
+	assert.ErrorIs(t, err, domain.ErrInvalidTaxRule)
+}
+
+func TestLoadRulesTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tax.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"rules": [{"region": "KZ", "rate": "0.12", "inclusive": true}]}`), 0o644))
+
+	table, err := LoadRulesTable(path)
+	assert.NoError(t, err)
+
+	breakdown, err := table.Calculate("kz", []domain.TaxableLine{{Amount: domain.NewMoney(1000, "KZT")}})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(107, "KZT"), breakdown.Tax)
+
+	_, err = LoadRulesTable(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -19,6 +19,7 @@ func AuthMiddleware() gin.HandlerFunc {
            path == "/register" || 
            path == "/api/users/login" || 
            path == "/api/users/register" ||
+           path == "/api/payments/webhook" ||
            strings.HasPrefix(path, "/static/") {
             log.Printf("Public route accessed: %s", path)
             c.Next()
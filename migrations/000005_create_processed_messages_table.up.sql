@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS processed_messages (
+    consumer VARCHAR(100) NOT NULL,
+    message_id VARCHAR(36) NOT NULL,
+    processed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (consumer, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_messages_expires_at ON processed_messages (expires_at);
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+
+UPDATE orders SET status = 'completed' WHERE status = 'delivered';
+UPDATE orders SET status = 'pending' WHERE status IN ('confirmed', 'preparing', 'ready', 'out_for_delivery');
+UPDATE orders SET status = 'cancelled' WHERE status = 'refunded';
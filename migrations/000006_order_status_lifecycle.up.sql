@@ -0,0 +1,5 @@
+UPDATE orders SET status = 'delivered' WHERE status = 'completed';
+
+ALTER TABLE orders ADD CONSTRAINT orders_status_check CHECK (
+    status IN ('pending', 'confirmed', 'preparing', 'ready', 'out_for_delivery', 'delivered', 'cancelled', 'refunded')
+);
@@ -0,0 +1,10 @@
+ALTER TABLE order_items ALTER COLUMN price_cents TYPE DECIMAL(10, 2) USING price_cents / 100.0;
+ALTER TABLE order_items RENAME COLUMN price_cents TO price;
+
+ALTER TABLE orders DROP COLUMN currency;
+ALTER TABLE orders ALTER COLUMN total_price_cents TYPE DECIMAL(10, 2) USING total_price_cents / 100.0;
+ALTER TABLE orders RENAME COLUMN total_price_cents TO total_price;
+
+ALTER TABLE products DROP COLUMN currency;
+ALTER TABLE products ALTER COLUMN price_cents TYPE DECIMAL(10, 2) USING price_cents / 100.0;
+ALTER TABLE products RENAME COLUMN price_cents TO price;
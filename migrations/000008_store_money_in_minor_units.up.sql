@@ -0,0 +1,10 @@
+ALTER TABLE products RENAME COLUMN price TO price_cents;
+ALTER TABLE products ALTER COLUMN price_cents TYPE BIGINT USING ROUND(price_cents * 100);
+ALTER TABLE products ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'KZT';
+
+ALTER TABLE orders RENAME COLUMN total_price TO total_price_cents;
+ALTER TABLE orders ALTER COLUMN total_price_cents TYPE BIGINT USING ROUND(total_price_cents * 100);
+ALTER TABLE orders ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'KZT';
+
+ALTER TABLE order_items RENAME COLUMN price TO price_cents;
+ALTER TABLE order_items ALTER COLUMN price_cents TYPE BIGINT USING ROUND(price_cents * 100);
@@ -0,0 +1,4 @@
+ALTER TABLE orders ADD COLUMN base_currency VARCHAR(3) NOT NULL DEFAULT 'KZT';
+ALTER TABLE orders ADD COLUMN exchange_rate NUMERIC(20, 10) NOT NULL DEFAULT 1;
+
+UPDATE orders SET base_currency = currency;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    parent_id VARCHAR(36) REFERENCES categories(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+ALTER TABLE products ADD COLUMN category_id VARCHAR(36) REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+
+CREATE TABLE IF NOT EXISTS product_tags (
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (product_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_tags_tag ON product_tags (tag);
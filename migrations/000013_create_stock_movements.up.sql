@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id VARCHAR(36) PRIMARY KEY,
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    delta INT NOT NULL,
+    reason VARCHAR(50) NOT NULL CHECK (
+        reason IN ('order', 'cancellation', 'restock', 'adjustment', 'import', 'reconciliation')
+    ),
+    reference_id VARCHAR(255) NOT NULL DEFAULT '',
+    stock_after INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements (product_id, created_at DESC, id DESC);
+
+-- The stock products already have opens the ledger.
+INSERT INTO stock_movements (id, product_id, delta, reason, reference_id, stock_after)
+SELECT md5('stock-opening-' || id)::uuid::text, id, stock, 'reconciliation', 'opening balance', stock
+FROM products
+WHERE stock <> 0;
@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN reorder_threshold INT NOT NULL DEFAULT 0 CHECK (reorder_threshold >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_products_low_stock ON products (stock) WHERE stock < reorder_threshold;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id VARCHAR(36) PRIMARY KEY,
+    order_id VARCHAR(36) NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'committed', 'released')),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_order_id ON stock_reservations (order_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires_at ON stock_reservations (expires_at) WHERE status = 'active';
+
+-- Orders already pending hold their stock with no end. They get a day to be
+-- confirmed before the reaper cancels them.
+INSERT INTO stock_reservations (id, order_id, product_id, quantity, expires_at)
+SELECT md5('stock-reservation-' || oi.id)::uuid::text, oi.order_id, oi.product_id, oi.quantity, NOW() + INTERVAL '1 day'
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+WHERE o.status = 'pending';
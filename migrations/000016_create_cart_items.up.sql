@@ -0,0 +1,12 @@
+-- Products are not referenced, so an item for a deleted product stays in
+-- the cart and is reported as unavailable.
+CREATE TABLE IF NOT EXISTS cart_items (
+    user_id VARCHAR(255) NOT NULL,
+    product_id VARCHAR(36) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    added_price_cents BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'KZT',
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, product_id)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_cents;
+DROP TABLE IF EXISTS order_discounts;
+DROP TABLE IF EXISTS promotion_redemptions;
+DROP TABLE IF EXISTS promotions;
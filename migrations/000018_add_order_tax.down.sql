@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS tax_region;
+ALTER TABLE orders DROP COLUMN IF EXISTS tax_cents;
+ALTER TABLE orders DROP COLUMN IF EXISTS subtotal_cents;
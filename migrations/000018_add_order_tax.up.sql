@@ -0,0 +1,6 @@
+ALTER TABLE orders ADD COLUMN subtotal_cents BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN tax_cents BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN tax_region VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Orders placed before tax was charged paid their items less discounts.
+UPDATE orders SET subtotal_cents = total_price_cents + discount_cents;
@@ -0,0 +1,9 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_ends_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_starts_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_slot_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_zone;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_address;
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_fee_cents;
+
+DROP TABLE IF EXISTS delivery_slots;
+DROP TABLE IF EXISTS addresses;
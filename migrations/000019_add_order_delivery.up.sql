@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS addresses (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    recipient VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    line1 VARCHAR(255) NOT NULL,
+    line2 VARCHAR(255) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL,
+    region VARCHAR(64) NOT NULL DEFAULT '',
+    postal_code VARCHAR(20) NOT NULL DEFAULT '',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_addresses_user_id ON addresses (user_id);
+-- A user has at most one default address.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_default ON addresses (user_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS delivery_slots (
+    id VARCHAR(36) PRIMARY KEY,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    capacity INT NOT NULL CHECK (capacity > 0),
+    booked INT NOT NULL DEFAULT 0 CHECK (booked >= 0 AND booked <= capacity),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_slots_starts_at ON delivery_slots (starts_at);
+
+ALTER TABLE orders ADD COLUMN delivery_fee_cents BIGINT NOT NULL DEFAULT 0;
+-- Copy of the address as it was when the order was placed.
+ALTER TABLE orders ADD COLUMN delivery_address JSONB;
+ALTER TABLE orders ADD COLUMN delivery_zone VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN delivery_slot_id VARCHAR(36) REFERENCES delivery_slots(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN delivery_starts_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE orders ADD COLUMN delivery_ends_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS payment_intents (
+    id VARCHAR(36) PRIMARY KEY,
+    order_id VARCHAR(36) NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'KZT',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    provider VARCHAR(50) NOT NULL,
+    provider_ref VARCHAR(255) NOT NULL,
+    client_secret VARCHAR(255) NOT NULL DEFAULT '',
+    failure_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_intents_provider_ref ON payment_intents (provider, provider_ref);
+-- An order is paid through at most one intent at a time; failed attempts are
+-- kept alongside it.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_intents_open ON payment_intents (order_id) WHERE status IN ('pending', 'succeeded');
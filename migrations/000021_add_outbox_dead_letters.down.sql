@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_outbox_dead_at;
+DROP INDEX IF EXISTS idx_outbox_pending_heads;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS dead_at;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (seq) WHERE delivered_at IS NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE outbox ADD COLUMN dead_at TIMESTAMP WITH TIME ZONE;
+
+-- The relay reads the oldest live message of each aggregate.
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_heads ON outbox (aggregate_type, aggregate_id, seq)
+    WHERE delivered_at IS NULL AND dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_dead_at ON outbox (dead_at) WHERE dead_at IS NOT NULL;
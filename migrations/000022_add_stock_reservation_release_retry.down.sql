@@ -0,0 +1 @@
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS release_retry_at;
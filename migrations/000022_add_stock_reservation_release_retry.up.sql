@@ -0,0 +1,3 @@
+-- Orders the reaper failed to release are retried after release_retry_at,
+-- so they do not hold up the orders that expired after them.
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS release_retry_at TIMESTAMP WITH TIME ZONE;
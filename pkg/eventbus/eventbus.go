@@ -0,0 +1,150 @@
+// Package eventbus publishes and subscribes to typed events over any
+// transport that moves domain.Message envelopes, such as the NATS producer
+// and consumer. Every subject carries exactly one event type, recorded with
+// Register, so publishers and subscribers cannot disagree about it.
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]reflect.Type)
+)
+
+// Register records T as the event type carried on subject. It panics if the
+// subject is already registered with a different type.
+func Register[T any](subject string) {
+	eventType := typeOf[T]()
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if existing, ok := registry[subject]; ok && existing != eventType {
+		panic(fmt.Sprintf("eventbus: subject %q already registered for %s, cannot register %s",
+			subject, existing, eventType))
+	}
+	registry[subject] = eventType
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func checkType[T any](subject string) error {
+	registryMu.RLock()
+	registered, ok := registry[subject]
+	registryMu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("eventbus: no event type registered for subject %q", subject)
+	}
+	if eventType := typeOf[T](); eventType != registered {
+		return fmt.Errorf("eventbus: subject %q carries %s, not %s", subject, registered, eventType)
+	}
+	return nil
+}
+
+// Encode wraps event in a new domain.Message envelope and returns the
+// envelope together with its JSON encoding.
+func Encode[T any](subject string, event T) (domain.Message, []byte, error) {
+	if err := checkType[T](subject); err != nil {
+		return domain.Message{}, nil, err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return domain.Message{}, nil, fmt.Errorf("eventbus: marshalling %s event: %w", subject, err)
+	}
+
+	message := domain.Message{
+		ID:        uuid.New().String(),
+		Type:      subject,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	envelope, err := json.Marshal(message)
+	if err != nil {
+		return domain.Message{}, nil, fmt.Errorf("eventbus: marshalling %s envelope: %w", subject, err)
+	}
+
+	return message, envelope, nil
+}
+
+// Decode unwraps a domain.Message envelope carrying a T. Errors wrap
+// repository.ErrMalformedMessage.
+func Decode[T any](envelope []byte) (domain.MessageMetadata, T, error) {
+	var event T
+
+	var message domain.Message
+	if err := json.Unmarshal(envelope, &message); err != nil {
+		return domain.MessageMetadata{}, event, fmt.Errorf("%w: envelope: %v", repository.ErrMalformedMessage, err)
+	}
+
+	meta := domain.MessageMetadata{
+		ID:        message.ID,
+		CreatedAt: message.CreatedAt,
+	}
+
+	if err := json.Unmarshal(message.Data, &event); err != nil {
+		return meta, event, fmt.Errorf("%w: %s event: %v", repository.ErrMalformedMessage, message.Type, err)
+	}
+
+	return meta, event, nil
+}
+
+// Publish sends event on subject through publisher.
+func Publish[T any](publisher repository.EventPublisher, subject string, event T) error {
+	message, envelope, err := Encode(subject, event)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", subject, err)
+		return err
+	}
+
+	log.Printf("Publishing %s event in message %s", subject, message.ID)
+
+	if err := publisher.PublishEnvelope(subject, envelope); err != nil {
+		log.Printf("Error publishing %s event: %v", subject, err)
+		return err
+	}
+
+	return nil
+}
+
+// Subscribe calls handler with every T published on subject. Messages that
+// do not decode are rejected with repository.ErrMalformedMessage without
+// reaching handler.
+func Subscribe[T any](subscriber repository.EventSubscriber, subject string, handler func(meta domain.MessageMetadata, event T) error) error {
+	if err := checkType[T](subject); err != nil {
+		return err
+	}
+
+	return subscriber.SubscribeEnvelope(subject, func(envelope []byte) error {
+		meta, event, err := Decode[T](envelope)
+		if err != nil {
+			log.Printf("Error decoding %s message: %v", subject, err)
+			return err
+		}
+
+		log.Printf("Received %s event in message %s", subject, meta.ID)
+
+		if err := handler(meta, event); err != nil {
+			log.Printf("Error handling %s event in message %s: %v", subject, meta.ID, err)
+			return err
+		}
+
+		return nil
+	})
+}
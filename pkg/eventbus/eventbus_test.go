@@ -0,0 +1,104 @@
+package eventbus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type memoryBus struct {
+	handlers map[string][]func(envelope []byte) error
+	errs     []error
+}
+
+func newMemoryBus() *memoryBus {
+	return &memoryBus{handlers: make(map[string][]func(envelope []byte) error)}
+}
+
+func (b *memoryBus) PublishEnvelope(subject string, envelope []byte) error {
+	for _, handler := range b.handlers[subject] {
+		b.errs = append(b.errs, handler(envelope))
+	}
+	return nil
+}
+
+func (b *memoryBus) SubscribeEnvelope(subject string, handler func(envelope []byte) error) error {
+	b.handlers[subject] = append(b.handlers[subject], handler)
+	return nil
+}
+
+func TestPublishSubscribe_RoundTrip(t *testing.T) {
+	bus := newMemoryBus()
+
+	var received domain.ProductUpdatedEvent
+	var receivedMeta domain.MessageMetadata
+	err := Subscribe(bus, domain.EventProductUpdated, func(meta domain.MessageMetadata, event domain.ProductUpdatedEvent) error {
+		receivedMeta = meta
+		received = event
+		return nil
+	})
+	assert.NoError(t, err)
+
+	sent := domain.ProductUpdatedEvent{ProductID: "p1", Name: "Apple", Price: domain.NewMoney(150, "KZT"), Stock: 3, UpdatedAt: time.Now().UTC()}
+	assert.NoError(t, Publish(bus, domain.EventProductUpdated, sent))
+
+	assert.Equal(t, sent.ProductID, received.ProductID)
+	assert.Equal(t, sent.Name, received.Name)
+	assert.True(t, sent.UpdatedAt.Equal(received.UpdatedAt))
+	assert.NotEmpty(t, receivedMeta.ID)
+	assert.Equal(t, []error{nil}, bus.errs)
+}
+
+func TestPublish_RejectsWrongEventType(t *testing.T) {
+	bus := newMemoryBus()
+
+	err := Publish(bus, domain.EventProductUpdated, domain.ProductDeletedEvent{ProductID: "p1"})
+	assert.Error(t, err)
+
+	err = Publish(bus, "no.such.subject", domain.ProductDeletedEvent{ProductID: "p1"})
+	assert.Error(t, err)
+}
+
+func TestSubscribe_RejectsWrongEventType(t *testing.T) {
+	bus := newMemoryBus()
+
+	err := Subscribe(bus, domain.EventOrderCreated, func(meta domain.MessageMetadata, event domain.ProductCreatedEvent) error {
+		return nil
+	})
+
+	assert.Error(t, err)
+	assert.Empty(t, bus.handlers)
+}
+
+func TestSubscribe_ReportsMalformedMessages(t *testing.T) {
+	bus := newMemoryBus()
+
+	called := false
+	assert.NoError(t, Subscribe(bus, domain.EventOrderCreated, func(meta domain.MessageMetadata, event domain.OrderCreatedEvent) error {
+		called = true
+		return nil
+	}))
+
+	assert.NoError(t, bus.PublishEnvelope(domain.EventOrderCreated, []byte("not json")))
+	assert.NoError(t, bus.PublishEnvelope(domain.EventOrderCreated, []byte(`{"id":"m1","data":"bm90IGpzb24="}`)))
+
+	assert.False(t, called)
+	assert.Len(t, bus.errs, 2)
+	for _, err := range bus.errs {
+		assert.True(t, errors.Is(err, repository.ErrMalformedMessage))
+	}
+}
+
+func TestRegister_PanicsOnConflictingType(t *testing.T) {
+	assert.Panics(t, func() {
+		Register[domain.OrderCreatedEvent](domain.EventProductCreated)
+	})
+	assert.NotPanics(t, func() {
+		Register[domain.ProductCreatedEvent](domain.EventProductCreated)
+	})
+}
@@ -0,0 +1,23 @@
+package eventbus
+
+import "AdvProg2/domain"
+
+// Event types carried on each subject. New events need a type and subject in
+// domain and a line here.
+func init() {
+	Register[domain.OrderCreatedEvent](domain.EventOrderCreated)
+	Register[domain.OrderStatusChangedEvent](domain.EventOrderStatusChanged)
+	Register[domain.OrderCancelledEvent](domain.EventOrderCancelled)
+	Register[domain.OrderCompletedEvent](domain.EventOrderCompleted)
+	Register[domain.ProductCreatedEvent](domain.EventProductCreated)
+	Register[domain.ProductUpdatedEvent](domain.EventProductUpdated)
+	Register[domain.ProductDeletedEvent](domain.EventProductDeleted)
+	Register[domain.LowStockEvent](domain.EventInventoryLowStock)
+	Register[domain.StockReservationEvent](domain.EventStockReserved)
+	Register[domain.StockReservationEvent](domain.EventStockReservationCommitted)
+	Register[domain.StockReservationEvent](domain.EventStockReservationReleased)
+	Register[domain.PaymentEvent](domain.EventPaymentIntentCreated)
+	Register[domain.PaymentEvent](domain.EventPaymentSucceeded)
+	Register[domain.PaymentEvent](domain.EventPaymentFailed)
+	Register[domain.PaymentEvent](domain.EventPaymentRefunded)
+}
@@ -0,0 +1,65 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestMake_FitsWithinSizeKeepingAspectRatio(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	for x := 0; x < 800; x++ {
+		for y := 0; y < 400; y++ {
+			// The left half is red and the right half transparent.
+			if x < 400 {
+				src.Set(x, y, color.NRGBA{R: 255, A: 255})
+			}
+		}
+	}
+
+	data, contentType, err := Make(encodePNG(t, src), 200)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	thumb, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 200, 100), thumb.Bounds())
+
+	r, _, _, a := thumb.At(10, 50).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+	assert.Equal(t, uint32(0xffff), a)
+	_, _, _, a = thumb.At(190, 50).RGBA()
+	assert.Equal(t, uint32(0), a)
+}
+
+func TestMake_KeepsSmallImagesAndJPEGFormat(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 60, 90)), nil))
+
+	data, contentType, err := Make(buf.Bytes(), 200)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 60, config.Width)
+	assert.Equal(t, 90, config.Height)
+}
+
+func TestMake_RejectsOtherData(t *testing.T) {
+	_, _, err := Make([]byte("<svg></svg>"), 200)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
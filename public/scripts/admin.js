@@ -113,14 +113,17 @@ async function fetchProducts(page = currentPage, filters = {}) {
     data.products.forEach((product) => {
       const id = product.ID || product.id || "";
       const name = product.Name || product.name || "Unnamed";
-      const price = product.Price !== undefined ? product.Price : 
-                    product.price !== undefined ? product.price : 0;
+      const price = moneyToNumber(product.Price !== undefined ? product.Price : 
+                    product.price !== undefined ? product.price : 0);
       const stock = product.Stock !== undefined ? product.Stock : 
                     product.stock !== undefined ? product.stock : 0;
 
       const productItem = document.createElement("div");
+      const thumbnail = productThumbnail(product);
+
       productItem.className = "main__products-item";
       productItem.innerHTML = `
+        ${thumbnail ? `<img class="main__products-item-image" src="${thumbnail}" alt="${name}">` : ""}
         <div class="main__products-item-wrap">
           <div class="main__products-item-name">${name}</div>
           <button class="main__products-item-edit" onclick="editProduct('${id}', '${name}', ${price}, ${stock})">✏️</button>
@@ -128,6 +131,10 @@ async function fetchProducts(page = currentPage, filters = {}) {
         </div>
         <div class="main__products-item-price">${parseFloat(price).toFixed(2)}₸</div>
         <div class="main__products-item-stock">Stock: ${stock}</div>
+        <label class="main__products-item-upload">
+          Add image
+          <input type="file" accept="image/jpeg,image/png,image/gif" onchange="uploadProductImage(event, '${id}')" hidden />
+        </label>
       `;
       productsList.appendChild(productItem);
     });
@@ -200,6 +207,36 @@ async function deleteProduct(productId) {
   }
 }
 
+async function uploadProductImage(event, productId) {
+  const file = event.target.files[0];
+  if (!file) {
+    return;
+  }
+
+  const formData = new FormData();
+  formData.append("image", file);
+
+  try {
+    const response = await fetch(`http://localhost:8082/api/admin/products/${productId}/images`, {
+      method: "POST",
+      headers: {
+        "Accept": "application/json",
+        "X-User-Role": "admin"
+      },
+      body: formData,
+    });
+
+    if (response.ok) {
+      fetchProducts(currentPage);
+    } else {
+      const error = await response.text();
+      alert(`Failed to upload image: ${error}`);
+    }
+  } catch (error) {
+    alert(`Failed to upload image: ${error}`);
+  }
+}
+
 function editProduct(id, name, price, stock) {
   const productItem = document
     .querySelector(`button[onclick*="editProduct('${id}'"]`)
@@ -24,4 +24,20 @@ window.authenticatedFetch = function(url, options = {}) {
 
 function isAuthenticated() {
     return !!localStorage.getItem('token');
-}
\ No newline at end of file
+}
+
+// Prices arrive as {amount, currency} in minor units. Older responses sent a
+// plain number, which is returned as is.
+function moneyToNumber(money) {
+    if (money && typeof money === 'object') {
+        return (money.amount || 0) / 100;
+    }
+    return parseFloat(money) || 0;
+}
+
+// Returns the thumbnail of the first image in the product's gallery, or an
+// empty string for a product without images.
+function productThumbnail(product) {
+    const images = product.images || product.Images || [];
+    return images.length > 0 ? images[0].thumbnail_url : '';
+}
@@ -9,6 +9,7 @@ const state = {
         minPrice: '',
         maxPrice: ''
     },
+    subtotal: 0,
     userId: '',
     orders: []
 };
@@ -46,11 +47,10 @@ document.addEventListener('DOMContentLoaded', () => {
     DOM.viewOrdersButton.addEventListener('click', fetchOrders);
     DOM.userIdInput.addEventListener('input', updateCheckoutButton);
 
-    const savedCart = localStorage.getItem('cart');
-    if (savedCart) {
-        state.cart = JSON.parse(savedCart);
-        updateCart();
-    }
+    // The cart is kept by the order service, so it survives a refresh and
+    // follows the user to other devices.
+    localStorage.removeItem('cart');
+    fetchCart();
 
     const savedUserId = localStorage.getItem('userId');
     if (savedUserId) {
@@ -116,8 +116,8 @@ function renderProducts() {
     state.products.forEach(product => {
         const id = product.ID || product.id;
         const name = product.Name || product.name;
-        const price = product.Price !== undefined ? product.Price : 
-                      product.price !== undefined ? product.price : 0;
+        const price = moneyToNumber(product.Price !== undefined ? product.Price : 
+                      product.price !== undefined ? product.price : 0);
         const stock = product.Stock !== undefined ? product.Stock : 
                       product.stock !== undefined ? product.stock : 0;
 
@@ -127,7 +127,10 @@ function renderProducts() {
         const isInCart = state.cart.some(item => item.id === id);
         const isOutOfStock = stock <= 0;
         
+        const thumbnail = productThumbnail(product);
+
         productElement.innerHTML = `
+            ${thumbnail ? `<img class="main__product-item-image" src="${thumbnail}" alt="${name}">` : ''}
             <div class="main__product-item-name">${name}</div>
             <div class="main__product-item-price">${parseFloat(price).toFixed(2)} ₸</div>
             <div class="main__product-item-stock">Stock: ${stock}</div>
@@ -189,35 +192,68 @@ function renderPagination() {
     }
 }
 
-function addToCart(event) {
-    const button = event.target;
-    const id = button.dataset.id;
-    const name = button.dataset.name;
-    const price = parseFloat(button.dataset.price);
-    const stock = parseInt(button.dataset.stock);
-    
-    const existingItem = state.cart.find(item => item.id === id);
-    
-    if (existingItem) {
-        existingItem.quantity += 1;
-    } else {
-        state.cart.push({
-            id,
-            name,
-            price,
-            stock,
-            quantity: 1
+// Sends a change to the cart and shows the cart the server returns.
+async function cartRequest(path, method, body) {
+    try {
+        const response = await authenticatedFetch(path, {
+            method,
+            headers: {
+                'Content-Type': 'application/json',
+                'Accept': 'application/json'
+            },
+            body: body ? JSON.stringify(body) : undefined
         });
+
+        if (response.status === 401) {
+            window.location.href = '/login';
+            return;
+        }
+        if (!response.ok) {
+            const errorText = await response.text();
+            throw new Error(errorText);
+        }
+
+        setCart(await response.json());
+    } catch (error) {
+        console.error('Error updating cart:', error);
+        alert(`Failed to update cart: ${error.message}`);
     }
-    
-    button.disabled = true;
-    button.textContent = 'In cart';
-    
-    localStorage.setItem('cart', JSON.stringify(state.cart));
-    
+}
+
+function fetchCart() {
+    return cartRequest('/api/cart', 'GET');
+}
+
+// Items are shown with their product as it is now. An item whose product is
+// gone keeps the price it was added at.
+function setCart(cart) {
+    state.cart = (cart.items || []).map(item => ({
+        id: item.product_id,
+        name: item.product ? item.product.name : 'No longer available',
+        price: moneyToNumber(item.product ? item.product.price : item.added_price),
+        stock: item.product ? item.product.stock : 0,
+        quantity: item.quantity,
+        issue: item.issue || ''
+    }));
+    state.subtotal = moneyToNumber(cart.subtotal);
+
     updateCart();
+    renderProducts();
 }
 
+function addToCart(event) {
+    const button = event.target;
+    button.disabled = true;
+
+    cartRequest('/api/cart/items', 'POST', { product_id: button.dataset.id, quantity: 1 });
+}
+
+const cartIssueText = {
+    unavailable: 'No longer available',
+    insufficient_stock: 'Not enough in stock',
+    price_changed: 'Price has changed'
+};
+
 function updateCart() {
     const totalItems = state.cart.reduce((sum, item) => sum + item.quantity, 0);
     DOM.cartCount.textContent = totalItems;
@@ -234,18 +270,14 @@ function updateCart() {
     
     DOM.cartItems.innerHTML = '';
     
-    let totalPrice = 0;
-    
     state.cart.forEach(item => {
-        const itemTotal = item.price * item.quantity;
-        totalPrice += itemTotal;
-        
         const cartItem = document.createElement('div');
         cartItem.className = 'main__cart-item';
         cartItem.innerHTML = `
             <div class="main__cart-item-details">
                 <div class="main__cart-item-name">${item.name}</div>
                 <div class="main__cart-item-price">${item.price.toFixed(2)} ₸ x ${item.quantity}</div>
+                ${item.issue ? `<div class="main__cart-item-issue">${cartIssueText[item.issue] || item.issue}</div>` : ''}
             </div>
             <div class="main__cart-item-quantity">
                 <button class="decrease" data-id="${item.id}" ${item.quantity <= 1 ? 'disabled' : ''}>-</button>
@@ -266,7 +298,7 @@ function updateCart() {
         DOM.cartItems.appendChild(cartItem);
     });
     
-    DOM.cartTotal.textContent = `${totalPrice.toFixed(2)} ₸`;
+    DOM.cartTotal.textContent = `${state.subtotal.toFixed(2)} ₸`;
     
     updateCheckoutButton();
 }
@@ -275,13 +307,10 @@ function decreaseQuantity(id) {
     const item = state.cart.find(item => item.id === id);
     if (!item) return;
     
-    item.quantity -= 1;
-    
-    if (item.quantity <= 0) {
+    if (item.quantity <= 1) {
         removeFromCart(id);
     } else {
-        localStorage.setItem('cart', JSON.stringify(state.cart));
-        updateCart();
+        cartRequest(`/api/cart/items/${encodeURIComponent(id)}`, 'PUT', { quantity: item.quantity - 1 });
     }
 }
 
@@ -289,22 +318,11 @@ function increaseQuantity(id) {
     const item = state.cart.find(item => item.id === id);
     if (!item || item.quantity >= item.stock) return;
     
-    item.quantity += 1;
-    localStorage.setItem('cart', JSON.stringify(state.cart));
-    updateCart();
+    cartRequest(`/api/cart/items/${encodeURIComponent(id)}`, 'PUT', { quantity: item.quantity + 1 });
 }
 
 function removeFromCart(id) {
-    state.cart = state.cart.filter(item => item.id !== id);
-    localStorage.setItem('cart', JSON.stringify(state.cart));
-    
-    const productButton = document.querySelector(`button[data-id="${id}"]`);
-    if (productButton) {
-        productButton.disabled = false;
-        productButton.textContent = 'Add to cart';
-    }
-    
-    updateCart();
+    cartRequest(`/api/cart/items/${encodeURIComponent(id)}`, 'DELETE');
 }
 
 function applyFilters() {
@@ -328,7 +346,8 @@ function updateCheckoutButton() {
     const userId = DOM.userIdInput.value.trim();
     state.userId = userId;
     
-    if (userId && state.cart.length > 0) {
+    const blocked = state.cart.some(item => item.issue === 'unavailable' || item.issue === 'insufficient_stock');
+    if (userId && state.cart.length > 0 && !blocked) {
         DOM.checkoutButton.disabled = false;
     } else {
         DOM.checkoutButton.disabled = true;
@@ -342,28 +361,16 @@ function updateCheckoutButton() {
 async function placeOrder(event) {
     event.preventDefault();
     
-    const userId = DOM.userIdInput.value.trim();
-    if (!userId || state.cart.length === 0) return;
+    if (state.cart.length === 0) return;
     
     try {
-        const orderData = {
-            user_id: userId,
-            items: state.cart.map(item => ({
-                product_id: item.id,
-                quantity: item.quantity
-            }))
-        };
-        
-        console.log("Sending order data:", orderData);
-        
-        const response = await fetch('http://localhost:8093/api/orders', {
+        const response = await authenticatedFetch('/api/cart/checkout', {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
-                'Accept': 'application/json',
-                'Origin': window.location.origin
+                'Accept': 'application/json'
             },
-            body: JSON.stringify(orderData)
+            body: JSON.stringify({})
         });
         
         if (!response.ok) {
@@ -374,9 +381,7 @@ async function placeOrder(event) {
         const result = await response.json();
         console.log("Order created:", result);
         
-        state.cart = [];
-        localStorage.setItem('cart', JSON.stringify(state.cart));
-        updateCart();
+        await fetchCart();
         
         fetchProducts();
         fetchOrders();
@@ -386,6 +391,7 @@ async function placeOrder(event) {
     } catch (error) {
         console.error('Error creating order:', error);
         alert(`Failed to create order: ${error.message}`);
+        fetchCart();
     }
 }
 
@@ -465,7 +471,7 @@ function renderOrders() {
         
         const id = order.id || order.ID;
         const status = order.status || order.Status || 'unknown';
-        const totalPrice = order.total_price || order.TotalPrice || 0;
+        const totalPrice = moneyToNumber(order.total_price || order.TotalPrice || 0);
         const items = order.items || order.Items || [];
         
         orderElement.innerHTML = `
@@ -502,7 +508,7 @@ function renderOrderItems(items) {
         const product = item.product || item.Product;
         const productName = product ? (product.Name || product.name || 'Unknown product') : 'Product details unavailable';
         const quantity = item.quantity || item.Quantity || 0;
-        const price = item.price || item.Price || 0;
+        const price = moneyToNumber(item.price || item.Price || 0);
         
         html += `
             <div class="main__order-item-product">
@@ -520,16 +526,13 @@ function renderOrderItems(items) {
 function renderOrderActions(order) {
     const status = order.status || order.Status || 'unknown';
     
-    if (status === 'completed' || status === 'cancelled') {
+    if (status !== 'pending' && status !== 'confirmed') {
         return '';
     }
     
     return `
         <div class="main__order-item-actions">
-            ${status === 'pending' ? `
-                <button class="main__order-item-button complete" data-status="completed">Complete</button>
-                <button class="main__order-item-button cancel" data-status="cancelled">Cancel</button>
-            ` : ''}
+            <button class="main__order-item-button cancel" data-status="cancelled">Cancel</button>
         </div>
     `;
 }
@@ -572,10 +575,20 @@ function getStatusText(status) {
     switch (status) {
         case 'pending':
             return 'Pending';
-        case 'completed':
-            return 'Completed';
+        case 'confirmed':
+            return 'Confirmed';
+        case 'preparing':
+            return 'Preparing';
+        case 'ready':
+            return 'Ready';
+        case 'out_for_delivery':
+            return 'Out for delivery';
+        case 'delivered':
+            return 'Delivered';
         case 'cancelled':
             return 'Cancelled';
+        case 'refunded':
+            return 'Refunded';
         default:
             return status;
     }
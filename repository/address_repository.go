@@ -0,0 +1,21 @@
+package repository
+
+import (
+    "errors"
+
+    "AdvProg2/domain"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+// AddressRepository stores users' saved addresses. Saving an address with
+// IsDefault set clears the flag on the user's other addresses.
+type AddressRepository interface {
+    Create(address *domain.Address) error
+    GetByID(id string) (*domain.Address, error)
+    Update(address *domain.Address) error
+    Delete(id string) error
+    // ListByUserID returns the user's addresses, the default first and the
+    // rest oldest first.
+    ListByUserID(userID string) ([]*domain.Address, error)
+}
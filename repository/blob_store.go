@@ -0,0 +1,12 @@
+package repository
+
+// BlobStore keeps uploaded files, such as product images, under
+// slash-separated keys.
+type BlobStore interface {
+    // Put stores data under key, replacing any blob already there.
+    Put(key, contentType string, data []byte) error
+    // Delete removes the blob. Deleting a missing blob is not an error.
+    Delete(key string) error
+    // URL returns the address clients download the blob from.
+    URL(key string) string
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+    "errors"
+
+    "AdvProg2/domain"
+)
+
+var ErrCartItemNotFound = errors.New("cart item not found")
+
+// CartRepository stores users' carts. A user without items has an empty
+// cart rather than none.
+type CartRepository interface {
+    // GetItems returns the items in the user's cart, oldest first.
+    GetItems(userID string) ([]*domain.CartItem, error)
+    // AddItem puts item in the user's cart, adding its quantity to the item
+    // already there for the product, if any, and taking its AddedPrice.
+    AddItem(userID string, item *domain.CartItem) error
+    // UpdateItem sets the quantity and AddedPrice of the user's item for
+    // item.ProductID, failing with ErrCartItemNotFound if there is none.
+    UpdateItem(userID string, item *domain.CartItem) error
+    // RemoveItem fails with ErrCartItemNotFound if the cart has no item for
+    // productID.
+    RemoveItem(userID, productID string) error
+    // Clear removes every item from the user's cart.
+    Clear(userID string) error
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+    "errors"
+
+    "AdvProg2/domain"
+)
+
+var (
+    ErrCategoryNotFound = errors.New("category not found")
+    // ErrCategoryInUse is returned when deleting a category that still has
+    // subcategories.
+    ErrCategoryInUse = errors.New("category has subcategories")
+)
+
+type CategoryRepository interface {
+    Create(category *domain.Category) error
+    GetByID(id string) (*domain.Category, error)
+    Update(category *domain.Category) error
+    // Delete removes a category without subcategories. Products in it are
+    // left without a category.
+    Delete(id string) error
+    // List returns every category ordered by name.
+    List() ([]*domain.Category, error)
+}
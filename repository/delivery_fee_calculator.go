@@ -0,0 +1,12 @@
+package repository
+
+import "AdvProg2/domain"
+
+// DeliveryFeeCalculator works out what delivering an order worth
+// orderValue to address costs. orderValue is priced in the currency rate
+// converts catalog prices into, and so is the fee. Orders without an address
+// are not delivered and cost nothing; addresses it does not deliver to fail
+// with domain.ErrNotDeliverable.
+type DeliveryFeeCalculator interface {
+    Quote(address *domain.Address, orderValue domain.Money, rate domain.ExchangeRate) (domain.DeliveryQuote, error)
+}
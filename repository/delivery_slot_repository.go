@@ -0,0 +1,37 @@
+package repository
+
+import (
+    "errors"
+    "time"
+
+    "AdvProg2/domain"
+)
+
+var (
+    ErrDeliverySlotNotFound = errors.New("delivery slot not found")
+    // ErrDeliverySlotFull is returned when booking a slot that is booked up
+    // to its capacity.
+    ErrDeliverySlotFull = errors.New("delivery slot is full")
+    // ErrDeliverySlotBooked is returned when deleting a slot that orders
+    // have booked.
+    ErrDeliverySlotBooked = errors.New("delivery slot has bookings")
+)
+
+// DeliverySlotRepository stores delivery slots and how many orders booked
+// each. Booked only changes through Book and Release.
+type DeliverySlotRepository interface {
+    Create(slot *domain.DeliverySlot) error
+    GetByID(id string) (*domain.DeliverySlot, error)
+    // Delete fails with ErrDeliverySlotBooked if the slot has bookings.
+    Delete(id string) error
+    // ListFrom returns the slots starting after from, earliest first.
+    ListFrom(from time.Time) ([]*domain.DeliverySlot, error)
+
+    // Book takes one place in the slot for an order placed at now. It
+    // fails with ErrDeliverySlotFull if the slot has none left and with
+    // domain.ErrDeliverySlotClosed if it has started. Concurrent bookings
+    // never take the slot over its capacity.
+    Book(id string, now time.Time) (*domain.DeliverySlot, error)
+    // Release gives back a place taken by Book.
+    Release(id string) error
+}
@@ -0,0 +1,6 @@
+package repository
+
+// EmailSender delivers plain-text emails.
+type EmailSender interface {
+    Send(to, subject, body string) error
+}
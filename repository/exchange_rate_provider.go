@@ -0,0 +1,9 @@
+package repository
+
+import "AdvProg2/domain"
+
+// ExchangeRateProvider looks up the rate for converting amounts between two
+// currencies. Unknown currencies fail with domain.ErrUnsupportedCurrency.
+type ExchangeRateProvider interface {
+    Rate(from, to string) (domain.ExchangeRate, error)
+}
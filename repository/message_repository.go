@@ -1,19 +1,20 @@
 package repository
 
-import "AdvProg2/domain"
+import "errors"
 
-type MessageProducer interface {
-	PublishOrderCreated(event domain.OrderCreatedEvent) error
-	PublishProductCreated(event domain.ProductCreatedEvent) error
-	PublishProductUpdated(event domain.ProductUpdatedEvent) error
-	PublishProductDeleted(event domain.ProductDeletedEvent) error
-	Close() error
+// ErrMalformedMessage is returned for a message that cannot be decoded into
+// the event type registered for its subject. Retrying it cannot succeed.
+var ErrMalformedMessage = errors.New("malformed message")
+
+// EventPublisher delivers an already encoded domain.Message envelope. Use
+// eventbus.Publish to send typed events.
+type EventPublisher interface {
+	PublishEnvelope(subject string, envelope []byte) error
 }
 
-type MessageConsumer interface {
-	SubscribeToOrderCreated(handler func(event domain.OrderCreatedEvent) error) error
-	SubscribeToProductCreated(handler func(event domain.ProductCreatedEvent) error) error
-	SubscribeToProductUpdated(handler func(event domain.ProductUpdatedEvent) error) error
-	SubscribeToProductDeleted(handler func(event domain.ProductDeletedEvent) error) error
-	Close() error
+// EventSubscriber passes every envelope published on subject to handler. A
+// handler error asks for the message to be redelivered where the transport
+// supports it. Use eventbus.Subscribe to receive typed events.
+type EventSubscriber interface {
+	SubscribeEnvelope(subject string, handler func(envelope []byte) error) error
 }
@@ -1,13 +1,32 @@
 package repository
 
 import (
+    "errors"
+
     "AdvProg2/domain"
 )
 
+var (
+    ErrOrderNotFound = errors.New("order not found")
+    // ErrOrderStatusChanged is returned when an order no longer has the
+    // status a transition started from, because another request changed it
+    // first.
+    ErrOrderStatusChanged = errors.New("order status was changed by another request")
+)
+
 type OrderRepository interface {
     Create(order *domain.Order) error
     GetByID(id string) (*domain.Order, error)
     GetByUserID(userID string, page, limit int32) ([]*domain.Order, int32, error)
-    UpdateStatus(id, status string) error
+    // GetPageByUserID returns the page of the user's orders that follows
+    // page.Token, newest first.
+    GetPageByUserID(userID string, page PageRequest) (*Page[*domain.Order], error)
+    // UpdateStatus moves the order from one status to another, failing with
+    // ErrOrderStatusChanged if it is no longer in the from status.
+    UpdateStatus(id string, from, to domain.OrderStatus) error
+    // AddStatusChange appends an entry to the order's status history.
+    AddStatusChange(change *domain.OrderStatusChange) error
+    // GetStatusHistory returns the order's status history, oldest first.
+    GetStatusHistory(orderID string) ([]*domain.OrderStatusChange, error)
     Delete(id string) error
 }
\ No newline at end of file
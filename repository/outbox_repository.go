@@ -0,0 +1,24 @@
+package repository
+
+import (
+    "time"
+
+    "AdvProg2/domain"
+)
+
+type OutboxRepository interface {
+    Add(message *domain.OutboxMessage) error
+    // ListPending returns, in insertion order, the oldest undelivered message
+    // of each aggregate that is not dead, if its next attempt is due.
+    ListPending(limit int32) ([]*domain.OutboxMessage, error)
+    MarkDelivered(id string) error
+    MarkFailed(id string, lastError string, nextAttemptAt time.Time) error
+    // MarkDead records a message's last failed attempt and stops relaying it,
+    // letting the aggregate's later messages through.
+    MarkDead(id string, lastError string) error
+    DeleteDeliveredBefore(before time.Time) (int64, error)
+    // TryLock takes a lock held until the surrounding transaction ends, so
+    // only one relay drains the outbox at a time. It returns false if another
+    // relay already holds it.
+    TryLock() (bool, error)
+}
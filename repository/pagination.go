@@ -0,0 +1,29 @@
+package repository
+
+import "errors"
+
+// ErrInvalidPageToken is returned for a page token that is malformed or was
+// issued for a listing with a different sort order.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// PageRequest asks for one page of a listing paged by keyset: each page
+// starts right after the last row of the previous one, so pages stay fast
+// however deep they go and do not shift when rows are added.
+type PageRequest struct {
+    // Token is the NextPageToken of the previous page, or empty for the
+    // first page.
+    Token string
+    Limit int32
+    // WithTotal also counts every matching row, which costs an extra query.
+    WithTotal bool
+}
+
+// Page is one page of a listing paged by keyset.
+type Page[T any] struct {
+    Items []T
+    // NextPageToken requests the following page. It is empty on the last
+    // page.
+    NextPageToken string
+    // Total is the number of matching rows if PageRequest.WithTotal was set.
+    Total *int32
+}
@@ -0,0 +1,22 @@
+package repository
+
+import "AdvProg2/domain"
+
+// PaymentProvider takes payments through an external payment gateway, which
+// reports what became of each payment by calling a webhook.
+type PaymentProvider interface {
+    // Name identifies the provider. It is stored with every intent.
+    Name() string
+    // CreatePayment registers the intent with the gateway and returns the
+    // gateway's reference for it and the secret the customer's client
+    // completes the payment with.
+    CreatePayment(intent *domain.PaymentIntent) (ref, clientSecret string, err error)
+    // Refund returns the whole amount captured for the intent to the
+    // customer. The gateway makes at most one refund per idempotencyKey, so
+    // a refund that is retried with the same key is not paid twice.
+    Refund(intent *domain.PaymentIntent, idempotencyKey string) error
+    // ParseWebhook checks the signature of a webhook call and returns what
+    // it reports. Calls that fail the check return
+    // domain.ErrInvalidPaymentWebhook.
+    ParseWebhook(payload []byte, signature string) (*domain.PaymentNotification, error)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+    "errors"
+    "time"
+
+    "AdvProg2/domain"
+)
+
+var (
+    ErrPaymentNotFound = errors.New("payment not found")
+    // ErrPaymentStatusChanged is returned when a payment intent no longer has
+    // the status a change started from, because another request changed it
+    // first.
+    ErrPaymentStatusChanged = errors.New("payment status was changed by another request")
+    // ErrOrderHasOpenPayment is returned when creating a payment intent for
+    // an order that already has one pending or succeeded, or when moving one
+    // of its other intents to pending or succeeded.
+    ErrOrderHasOpenPayment = errors.New("order already has an open payment")
+)
+
+type PaymentRepository interface {
+    // Create fails with ErrOrderHasOpenPayment if the order already has a
+    // pending or succeeded intent.
+    Create(intent *domain.PaymentIntent) error
+    GetByID(id string) (*domain.PaymentIntent, error)
+    // GetByProviderRef returns the intent the provider knows as ref.
+    GetByProviderRef(provider, ref string) (*domain.PaymentIntent, error)
+    // ListByOrderID returns the order's payment intents, newest first.
+    ListByOrderID(orderID string) ([]*domain.PaymentIntent, error)
+    // UpdateStatus moves the intent from one status to another and records
+    // failureReason, failing with ErrPaymentStatusChanged if it is no longer
+    // in the from status and with ErrOrderHasOpenPayment if the order already
+    // has another pending or succeeded intent.
+    UpdateStatus(id string, from, to domain.PaymentStatus, failureReason string, at time.Time) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "time"
+
+// ProcessedMessageRepository remembers which message envelopes a consumer has
+// already handled, so redelivered messages can be skipped.
+type ProcessedMessageRepository interface {
+    // Process claims messageID for ttl and runs handle in the transaction
+    // that records the claim, so the claim and everything handle writes
+    // through tx commit or roll back together. A concurrent delivery of the
+    // same message waits for the claim to settle. It returns false without
+    // running handle if the message was already processed.
+    Process(messageID string, ttl time.Duration, handle func(tx Transaction) error) (bool, error)
+    DeleteExpired() (int64, error)
+}
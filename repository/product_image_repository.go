@@ -0,0 +1,23 @@
+package repository
+
+import (
+    "errors"
+
+    "AdvProg2/domain"
+)
+
+var ErrProductImageNotFound = errors.New("product image not found")
+
+type ProductImageRepository interface {
+    // Add appends image to the end of its product's gallery and sets its
+    // position.
+    Add(image *domain.ProductImage) error
+    GetByID(id string) (*domain.ProductImage, error)
+    // ListByProduct returns the product's images in gallery order.
+    ListByProduct(productID string) ([]domain.ProductImage, error)
+    // Reorder moves the product's images to the positions of their IDs in
+    // imageIDs.
+    Reorder(productID string, imageIDs []string) error
+    // Delete removes the image and closes the gap it leaves in the gallery.
+    Delete(id string) error
+}
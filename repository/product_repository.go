@@ -1,15 +1,90 @@
 package repository
 
-import "AdvProg2/domain"
+import (
+    "errors"
 
+    "AdvProg2/domain"
+)
+
+var (
+    ErrProductNotFound   = errors.New("product not found")
+    ErrInsufficientStock = errors.New("not enough stock")
+    // ErrInvalidProductFilter is returned for a filter that cannot be
+    // applied, such as an unknown sort field.
+    ErrInvalidProductFilter = errors.New("invalid product filter")
+)
+
+// ProductSortField is a column a product listing can be sorted by.
+type ProductSortField string
+
+const (
+    ProductSortByName  ProductSortField = "name"
+    ProductSortByPrice ProductSortField = "price"
+    ProductSortByStock ProductSortField = "stock"
+)
+
+// IsValid reports whether the listing can be sorted by f.
+func (f ProductSortField) IsValid() bool {
+    switch f {
+    case ProductSortByName, ProductSortByPrice, ProductSortByStock:
+        return true
+    }
+    return false
+}
+
+// ProductFilter narrows and orders a product listing. Zero fields do not
+// filter, and products are sorted by name by default.
+type ProductFilter struct {
+    Name     string
+    MinPrice domain.Money
+    MaxPrice domain.Money
+    // PriceCurrency matches products priced in it. Amounts in different
+    // currencies do not compare, so the price bounds must be in it and
+    // sorting by price needs it.
+    PriceCurrency string
+    // CategoryID matches products in the category and in all of its
+    // descendants.
+    CategoryID string
+    // Tags matches products that have every one of the tags.
+    Tags []string
+    // InStock leaves out products with no stock.
+    InStock bool
+
+    SortBy     ProductSortField
+    Descending bool
+}
+
+// ProductRepository stores products. Create and Update leave stock and the
+// reorder threshold alone: a new product starts with neither, stock only
+// changes through StockMovementRepository and the threshold through
+// SetReorderThreshold.
 type ProductRepository interface {
     Create(product *domain.Product) error
     GetByID(id string) (*domain.Product, error)
     Update(product *domain.Product) error
     Delete(id string) error
     List(page, limit int32) ([]*domain.Product, int32, error)
-    
+
     SearchByName(name string, page, limit int32) ([]*domain.Product, int32, error)
-    SearchByPriceRange(minPrice, maxPrice float64, page, limit int32) ([]*domain.Product, int32, error)
-    SearchByFilters(name string, minPrice, maxPrice float64, page, limit int32) ([]*domain.Product, int32, error)
-}
\ No newline at end of file
+    SearchByPriceRange(minPrice, maxPrice domain.Money, page, limit int32) ([]*domain.Product, int32, error)
+    SearchByFilters(filter ProductFilter, page, limit int32) ([]*domain.Product, int32, error)
+    // ListPage returns the page of products matching filter that follows
+    // page.Token, in the filter's sort order.
+    ListPage(filter ProductFilter, page PageRequest) (*Page[*domain.Product], error)
+    // SearchText ranks products by how well their names match text,
+    // tolerating typos. filter.Name and the sort order are ignored.
+    SearchText(text string, filter ProductFilter, page, limit int32) ([]*domain.ProductSearchResult, int32, error)
+    // Suggest returns up to limit products for autocompleting prefix.
+    Suggest(prefix string, limit int32) ([]*domain.ProductSuggestion, error)
+
+    SetReorderThreshold(id string, threshold int32) error
+    // ListLowStock returns the products whose stock is below their reorder
+    // threshold.
+    ListLowStock() ([]*domain.Product, error)
+    // ListIDsInCategory returns those of productIDs that are in the category
+    // or in one of its descendants.
+    ListIDsInCategory(categoryID string, productIDs []string) ([]string, error)
+    // ListCategoryAncestors returns the ancestors of each of categoryIDs,
+    // nearest first. Top-level and unknown categories are left out.
+    ListCategoryAncestors(categoryIDs []string) (map[string][]string, error)
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+    "errors"
+    "time"
+
+    "AdvProg2/domain"
+)
+
+var (
+    ErrPromotionNotFound = errors.New("promotion not found")
+    // ErrPromotionCodeTaken is returned when saving a promotion with the
+    // coupon code of another one.
+    ErrPromotionCodeTaken = errors.New("coupon code is already used by another promotion")
+    // ErrPromotionUsageLimitReached is returned when redeeming a promotion
+    // that has been used as often as its usage limit allows.
+    ErrPromotionUsageLimitReached = errors.New("promotion usage limit reached")
+    // ErrPromotionUserLimitReached is returned when redeeming a promotion
+    // that the user has used as often as its per-user limit allows.
+    ErrPromotionUserLimitReached = errors.New("promotion per-user limit reached")
+)
+
+// PromotionRepository stores promotions and the orders that redeemed them.
+// Create and Update leave TimesUsed alone; it only changes through Redeem
+// and ReleaseRedemptions.
+type PromotionRepository interface {
+    Create(promotion *domain.Promotion) error
+    GetByID(id string) (*domain.Promotion, error)
+    // GetByCode returns the promotion with the normalized coupon code.
+    GetByCode(code string) (*domain.Promotion, error)
+    Update(promotion *domain.Promotion) error
+    Delete(id string) error
+    // List returns every promotion, newest first.
+    List() ([]*domain.Promotion, error)
+    // ListAutomatic returns the promotions without a coupon code that are
+    // running at now, oldest first.
+    ListAutomatic(now time.Time) ([]*domain.Promotion, error)
+
+    // Redeem records that the user's order used the promotion, failing with
+    // ErrPromotionUsageLimitReached or ErrPromotionUserLimitReached if that
+    // would exceed one of its limits.
+    Redeem(promotionID, orderID, userID string) error
+    // ReleaseRedemptions undoes the redemptions of the order, so they no
+    // longer count towards the promotions' limits.
+    ReleaseRedemptions(orderID string) error
+}
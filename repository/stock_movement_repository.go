@@ -0,0 +1,28 @@
+package repository
+
+import "AdvProg2/domain"
+
+// StockMovementRepository keeps the stock ledger. It is the only way stock
+// changes, so products.stock always equals the sum of the product's
+// movements.
+type StockMovementRepository interface {
+    // Record applies movement.Delta to the product's stock and appends the
+    // movement in one statement, filling in its StockAfter and CreatedAt.
+    // It returns the product with its new stock, and fails with
+    // ErrInsufficientStock instead of letting stock go negative.
+    Record(movement *domain.StockMovement) (*domain.Product, error)
+    // SetStock sets the product's stock to stock and appends movement for
+    // the difference, filling in its Delta, StockAfter and CreatedAt.
+    // Nothing is appended when the stock is already at that level.
+    SetStock(movement *domain.StockMovement, stock int32) error
+    // ListByProduct returns the product's movements, newest first, that
+    // follow page.Token.
+    ListByProduct(productID string, page PageRequest) (*Page[*domain.StockMovement], error)
+    // FindDiscrepancies returns the products whose stock differs from the
+    // sum of their movements.
+    FindDiscrepancies() ([]*domain.StockDiscrepancy, error)
+    // Reconcile appends a reconciliation movement for every discrepancy, so
+    // that the ledger sums to products.stock again, and returns the
+    // discrepancies it fixed.
+    Reconcile() ([]*domain.StockDiscrepancy, error)
+}
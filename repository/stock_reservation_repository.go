@@ -0,0 +1,28 @@
+package repository
+
+import (
+    "time"
+
+    "AdvProg2/domain"
+)
+
+// StockReservationRepository keeps the stock held for pending orders. It
+// only tracks reservations; the stock itself is taken and returned through
+// StockMovementRepository.
+type StockReservationRepository interface {
+    // Create stores new reservations.
+    Create(reservations []*domain.StockReservation) error
+    ListByOrder(orderID string) ([]*domain.StockReservation, error)
+    // Resolve moves the order's active reservations to status at the given
+    // time and returns them. It returns none if the order has no active
+    // reservations left.
+    Resolve(orderID string, status domain.StockReservationStatus, at time.Time) ([]*domain.StockReservation, error)
+    // ListExpiredOrderIDs returns up to limit orders holding active
+    // reservations that expired by now, longest expired first. Orders whose
+    // release was deferred to after now are left out.
+    ListExpiredOrderIDs(now time.Time, limit int32) ([]string, error)
+    // DeferRelease keeps the order's active reservations out of
+    // ListExpiredOrderIDs until retryAt, so that an order that failed to be
+    // released does not hold up the ones listed after it.
+    DeferRelease(orderID string, retryAt time.Time) error
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "AdvProg2/domain"
+
+// TaxCalculator works out the tax on the lines of an order shipped to
+// region.
+type TaxCalculator interface {
+    Calculate(region string, lines []domain.TaxableLine) (domain.TaxBreakdown, error)
+}
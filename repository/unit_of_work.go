@@ -0,0 +1,21 @@
+package repository
+
+// Transaction exposes repositories bound to a single database transaction.
+// Everything written through them is committed or rolled back together.
+type Transaction interface {
+    Orders() OrderRepository
+    Products() ProductRepository
+    StockMovements() StockMovementRepository
+    StockReservations() StockReservationRepository
+    Promotions() PromotionRepository
+    Addresses() AddressRepository
+    DeliverySlots() DeliverySlotRepository
+    Payments() PaymentRepository
+    Outbox() OutboxRepository
+}
+
+// UnitOfWork runs fn inside a transaction. The transaction is committed when
+// fn returns nil and rolled back when it returns an error.
+type UnitOfWork interface {
+    Do(fn func(tx Transaction) error) error
+}
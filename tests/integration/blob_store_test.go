@@ -0,0 +1,34 @@
+package integration
+
+import (
+	"AdvProg2/infrastructure/blob"
+	"os"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestS3StoreAgainstMinIO needs a running MinIO (or other S3-compatible
+// service) with the bucket named by S3_BUCKET already created.
+func TestS3StoreAgainstMinIO(t *testing.T) {
+	godotenv.Load("../../.env")
+
+	if os.Getenv("S3_ENDPOINT") == "" {
+		t.Skip("S3_ENDPOINT is not set")
+	}
+
+	store, err := blob.NewS3Store(blob.S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		Region:    os.Getenv("S3_REGION"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+	})
+	assert.NoError(t, err)
+
+	key := "integration/blob-store-test.txt"
+	assert.NoError(t, store.Put(key, "text/plain", []byte("hello")))
+	assert.NoError(t, store.Delete(key))
+	assert.NoError(t, store.Delete(key))
+}
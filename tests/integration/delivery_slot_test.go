@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/delivery"
+	"AdvProg2/infrastructure/exchangerate"
+	"AdvProg2/infrastructure/tax"
+	"AdvProg2/usecase"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentOrdersDoNotOverbookDeliverySlots(t *testing.T) {
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		t.Fatalf("Error loading .env file: %v", err)
+	}
+
+	dbConn, err := db.NewPostgresConnection()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbConn.Close()
+
+	orderRepo := db.NewPostgresOrderRepository(dbConn)
+	productRepo := db.NewPostgresProductRepository(dbConn)
+	addressRepo := db.NewPostgresAddressRepository(dbConn)
+	slotRepo := db.NewPostgresDeliverySlotRepository(dbConn)
+	rates, err := exchangerate.NewStaticProvider(domain.DefaultCurrency, nil)
+	assert.NoError(t, err)
+	taxes, err := tax.NewRulesTable(nil)
+	assert.NoError(t, err)
+	fees, err := delivery.NewZoneTable(nil)
+	assert.NoError(t, err)
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, db.NewPostgresStockReservationRepository(dbConn), db.NewPostgresUnitOfWork(dbConn), rates, taxes, fees, 0)
+
+	const capacity = 3
+	const buyers = 10
+	const userID = "delivery-test-user"
+
+	testProduct := &domain.Product{
+		ID:    uuid.New().String(),
+		Name:  "Delivery Test Product",
+		Price: domain.NewMoney(1000, "KZT"),
+		Stock: buyers,
+	}
+	createStockedProduct(t, dbConn, testProduct)
+
+	address := &domain.Address{ID: uuid.New().String(), UserID: userID, Line1: "Abay 10", City: "Almaty", CreatedAt: time.Now()}
+	assert.NoError(t, addressRepo.Create(address))
+
+	startsAt := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	slot := &domain.DeliverySlot{ID: uuid.New().String(), StartsAt: startsAt, EndsAt: startsAt.Add(2 * time.Hour), Capacity: capacity, CreatedAt: time.Now()}
+	assert.NoError(t, slotRepo.Create(slot))
+
+	var mu sync.Mutex
+	var orderIDs []string
+	var wg sync.WaitGroup
+
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order, err := orderUseCase.CreateOrder(userID, []orderItemInput{
+				{ProductID: testProduct.ID, Quantity: 1},
+			}, usecase.OrderOptions{AddressID: address.ID, DeliverySlotID: slot.ID})
+			if err == nil {
+				mu.Lock()
+				orderIDs = append(orderIDs, order.ID)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	defer func() {
+		for _, id := range orderIDs {
+			orderRepo.Delete(id)
+		}
+		slotRepo.Delete(slot.ID)
+		addressRepo.Delete(address.ID)
+		productRepo.Delete(testProduct.ID)
+	}()
+
+	assert.Equal(t, capacity, len(orderIDs))
+
+	booked, err := slotRepo.GetByID(slot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(capacity), booked.Booked)
+
+	// Cancelling an order gives its place back.
+	assert.NoError(t, orderUseCase.CancelOrder(orderIDs[0], domain.Actor{ID: userID, Role: domain.ActorRoleUser}, ""))
+	booked, err = slotRepo.GetByID(slot.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(capacity-1), booked.Booked)
+}
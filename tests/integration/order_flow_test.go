@@ -3,9 +3,13 @@ package integration
 import (
 	"AdvProg2/domain"
 	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/delivery"
+	"AdvProg2/infrastructure/exchangerate"
+	"AdvProg2/infrastructure/tax"
 	"AdvProg2/usecase"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,18 +29,22 @@ func TestOrderCreationFlow(t *testing.T) {
 	orderRepo := db.NewPostgresOrderRepository(dbConn)
 	productRepo := db.NewPostgresProductRepository(dbConn)
 
-	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, nil)
+	rates, err := exchangerate.NewStaticProvider(domain.DefaultCurrency, nil)
+	assert.NoError(t, err)
+	taxes, err := tax.NewRulesTable(nil)
+	assert.NoError(t, err)
+	fees, err := delivery.NewZoneTable(nil)
+	assert.NoError(t, err)
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, db.NewPostgresStockReservationRepository(dbConn), db.NewPostgresUnitOfWork(dbConn), rates, taxes, fees, 0)
 
 	testProduct := &domain.Product{
+		ID:    uuid.New().String(),
 		Name:  "Integration Test Product",
-		Price: 25.99,
+		Price: domain.NewMoney(2599, "KZT"),
 		Stock: 10,
 	}
 
-	err = productRepo.Create(testProduct)
-	if err != nil {
-		t.Fatalf("Failed to create test product: %v", err)
-	}
+	createStockedProduct(t, dbConn, testProduct)
 
 	defer func() {
 		productRepo.Delete(testProduct.ID)
@@ -53,7 +61,7 @@ func TestOrderCreationFlow(t *testing.T) {
 	}
 
 	testUserID := "integration-test-user"
-	order, err := orderUseCase.CreateOrder(testUserID, orderItems)
+	order, err := orderUseCase.CreateOrder(testUserID, orderItems, usecase.OrderOptions{})
 
 	defer func() {
 		if order != nil {
@@ -64,8 +72,8 @@ func TestOrderCreationFlow(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, order)
 	assert.Equal(t, testUserID, order.UserID)
-	assert.Equal(t, "pending", order.Status)
-	assert.Equal(t, 2*testProduct.Price, order.TotalPrice)
+	assert.Equal(t, domain.OrderStatusPending, order.Status)
+	assert.Equal(t, testProduct.Price.Multiply(2), order.TotalPrice)
 	assert.Equal(t, 1, len(order.Items))
 
 	savedOrder, err := orderRepo.GetByID(order.ID)
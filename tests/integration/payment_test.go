@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/delivery"
+	"AdvProg2/infrastructure/exchangerate"
+	"AdvProg2/infrastructure/payment"
+	"AdvProg2/infrastructure/tax"
+	"AdvProg2/usecase"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaidOrdersAreConfirmedAndRefundedOnCancellation(t *testing.T) {
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		t.Fatalf("Error loading .env file: %v", err)
+	}
+
+	dbConn, err := db.NewPostgresConnection()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbConn.Close()
+
+	orderRepo := db.NewPostgresOrderRepository(dbConn)
+	productRepo := db.NewPostgresProductRepository(dbConn)
+	paymentRepo := db.NewPostgresPaymentRepository(dbConn)
+	unitOfWork := db.NewPostgresUnitOfWork(dbConn)
+	rates, err := exchangerate.NewStaticProvider(domain.DefaultCurrency, nil)
+	assert.NoError(t, err)
+	taxes, err := tax.NewRulesTable(nil)
+	assert.NoError(t, err)
+	fees, err := delivery.NewZoneTable(nil)
+	assert.NoError(t, err)
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, db.NewPostgresStockReservationRepository(dbConn), unitOfWork, rates, taxes, fees, 0)
+
+	provider, err := payment.NewFakeProvider("test-secret")
+	assert.NoError(t, err)
+	paymentUseCase := usecase.NewPaymentUseCase(paymentRepo, unitOfWork, orderUseCase, provider, nil)
+
+	const userID = "payment-test-user"
+	testProduct := &domain.Product{
+		ID:    uuid.New().String(),
+		Name:  "Payment Test Product",
+		Price: domain.NewMoney(1000, "KZT"),
+		Stock: 5,
+	}
+	createStockedProduct(t, dbConn, testProduct)
+
+	order, err := orderUseCase.CreateOrder(userID, []orderItemInput{
+		{ProductID: testProduct.ID, Quantity: 2},
+	}, usecase.OrderOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		orderRepo.Delete(order.ID)
+		productRepo.Delete(testProduct.ID)
+	}()
+
+	intent, err := paymentUseCase.CreatePayment(userID, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, order.TotalPrice, intent.Amount)
+
+	// A second request gets the same intent.
+	again, err := paymentUseCase.CreatePayment(userID, order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, intent.ID, again.ID)
+
+	payload, signature, err := provider.Webhook(intent.ProviderRef, domain.PaymentStatusSucceeded, "")
+	assert.NoError(t, err)
+	assert.NoError(t, paymentUseCase.HandleWebhook(payload, signature))
+	assert.NoError(t, paymentUseCase.HandleWebhook(payload, signature))
+
+	confirmed, err := orderUseCase.GetOrder(order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusConfirmed, confirmed.Status)
+
+	assert.NoError(t, orderUseCase.CancelOrder(order.ID, domain.Actor{ID: userID, Role: domain.ActorRoleUser}, ""))
+	assert.NoError(t, paymentUseCase.HandleOrderCancelledEvent(domain.MessageMetadata{}, domain.OrderCancelledEvent{OrderID: order.ID}))
+
+	assert.Equal(t, 1, provider.Refunds(intent.ProviderRef))
+	refunded, err := paymentRepo.GetByID(intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, refunded.Status)
+
+	final, err := orderUseCase.GetOrder(order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusRefunded, final.Status)
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/delivery"
+	"AdvProg2/infrastructure/exchangerate"
+	"AdvProg2/infrastructure/tax"
+	"AdvProg2/repository"
+	"AdvProg2/usecase"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// createStockedProduct creates product and restocks it to product.Stock,
+// since new products start without stock.
+func createStockedProduct(t *testing.T, dbConn *sql.DB, product *domain.Product) {
+	t.Helper()
+
+	if err := db.NewPostgresProductRepository(dbConn).Create(product); err != nil {
+		t.Fatalf("Failed to create test product: %v", err)
+	}
+
+	if product.Stock == 0 {
+		return
+	}
+
+	_, err := db.NewPostgresStockMovementRepository(dbConn).Record(&domain.StockMovement{
+		ID:        uuid.New().String(),
+		ProductID: product.ID,
+		Delta:     product.Stock,
+		Reason:    domain.StockMovementRestock,
+	})
+	if err != nil {
+		t.Fatalf("Failed to restock test product: %v", err)
+	}
+}
+
+func TestStockLedgerRecordsOrdersAndCancellations(t *testing.T) {
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		t.Fatalf("Error loading .env file: %v", err)
+	}
+
+	dbConn, err := db.NewPostgresConnection()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbConn.Close()
+
+	orderRepo := db.NewPostgresOrderRepository(dbConn)
+	productRepo := db.NewPostgresProductRepository(dbConn)
+	movements := db.NewPostgresStockMovementRepository(dbConn)
+	rates, err := exchangerate.NewStaticProvider(domain.DefaultCurrency, nil)
+	assert.NoError(t, err)
+	taxes, err := tax.NewRulesTable(nil)
+	assert.NoError(t, err)
+	fees, err := delivery.NewZoneTable(nil)
+	assert.NoError(t, err)
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, db.NewPostgresStockReservationRepository(dbConn), db.NewPostgresUnitOfWork(dbConn), rates, taxes, fees, 0)
+
+	product := &domain.Product{ID: uuid.New().String(), Name: "Ledger Test Product", Price: domain.NewMoney(700, "KZT"), Stock: 5}
+	createStockedProduct(t, dbConn, product)
+	defer productRepo.Delete(product.ID)
+
+	order, err := orderUseCase.CreateOrder("ledger-test-user", []orderItemInput{{ProductID: product.ID, Quantity: 2}}, usecase.OrderOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	defer orderRepo.Delete(order.ID)
+
+	assert.NoError(t, orderUseCase.CancelOrder(order.ID, domain.SystemActor, "ledger test"))
+
+	page, err := movements.ListByProduct(product.ID, repository.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Items, 3) {
+		assert.Equal(t, domain.StockMovementCancellation, page.Items[0].Reason)
+		assert.Equal(t, int32(5), page.Items[0].StockAfter)
+		assert.Equal(t, domain.StockMovementOrder, page.Items[1].Reason)
+		assert.Equal(t, order.ID, page.Items[1].ReferenceID)
+		assert.Equal(t, int32(-2), page.Items[1].Delta)
+		assert.Equal(t, domain.StockMovementRestock, page.Items[2].Reason)
+	}
+
+	discrepancies, err := movements.FindDiscrepancies()
+	assert.NoError(t, err)
+	for _, d := range discrepancies {
+		assert.NotEqual(t, product.ID, d.ProductID)
+	}
+}
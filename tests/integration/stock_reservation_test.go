@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"AdvProg2/domain"
+	"AdvProg2/infrastructure/db"
+	"AdvProg2/infrastructure/delivery"
+	"AdvProg2/infrastructure/exchangerate"
+	"AdvProg2/infrastructure/tax"
+	"AdvProg2/usecase"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderItemInput = struct {
+	ProductID string
+	Quantity  int32
+}
+
+func TestConcurrentOrdersDoNotOversell(t *testing.T) {
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		t.Fatalf("Error loading .env file: %v", err)
+	}
+
+	dbConn, err := db.NewPostgresConnection()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbConn.Close()
+
+	orderRepo := db.NewPostgresOrderRepository(dbConn)
+	productRepo := db.NewPostgresProductRepository(dbConn)
+	rates, err := exchangerate.NewStaticProvider(domain.DefaultCurrency, nil)
+	assert.NoError(t, err)
+	taxes, err := tax.NewRulesTable(nil)
+	assert.NoError(t, err)
+	fees, err := delivery.NewZoneTable(nil)
+	assert.NoError(t, err)
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, db.NewPostgresStockReservationRepository(dbConn), db.NewPostgresUnitOfWork(dbConn), rates, taxes, fees, 0)
+
+	const stock = 5
+	const buyers = 20
+
+	testProduct := &domain.Product{
+		ID:    uuid.New().String(),
+		Name:  "Concurrency Test Product",
+		Price: domain.NewMoney(1000, "KZT"),
+		Stock: stock,
+	}
+
+	createStockedProduct(t, dbConn, testProduct)
+
+	var mu sync.Mutex
+	var orderIDs []string
+	var wg sync.WaitGroup
+
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order, err := orderUseCase.CreateOrder("concurrency-test-user", []orderItemInput{
+				{ProductID: testProduct.ID, Quantity: 1},
+			}, usecase.OrderOptions{})
+			if err == nil {
+				mu.Lock()
+				orderIDs = append(orderIDs, order.ID)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	defer func() {
+		for _, id := range orderIDs {
+			orderRepo.Delete(id)
+		}
+		productRepo.Delete(testProduct.ID)
+	}()
+
+	assert.Equal(t, stock, len(orderIDs))
+
+	updatedProduct, err := productRepo.GetByID(testProduct.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), updatedProduct.Stock)
+}
+
+func TestFailedOrderRollsBackStock(t *testing.T) {
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		t.Fatalf("Error loading .env file: %v", err)
+	}
+
+	dbConn, err := db.NewPostgresConnection()
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbConn.Close()
+
+	orderRepo := db.NewPostgresOrderRepository(dbConn)
+	productRepo := db.NewPostgresProductRepository(dbConn)
+	rates, err := exchangerate.NewStaticProvider(domain.DefaultCurrency, nil)
+	assert.NoError(t, err)
+	taxes, err := tax.NewRulesTable(nil)
+	assert.NoError(t, err)
+	fees, err := delivery.NewZoneTable(nil)
+	assert.NoError(t, err)
+	orderUseCase := usecase.NewOrderUseCase(orderRepo, productRepo, db.NewPostgresStockReservationRepository(dbConn), db.NewPostgresUnitOfWork(dbConn), rates, taxes, fees, 0)
+
+	available := &domain.Product{ID: uuid.New().String(), Name: "Rollback Available", Price: domain.NewMoney(500, "KZT"), Stock: 10}
+	scarce := &domain.Product{ID: uuid.New().String(), Name: "Rollback Scarce", Price: domain.NewMoney(500, "KZT"), Stock: 1}
+
+	for _, p := range []*domain.Product{available, scarce} {
+		createStockedProduct(t, dbConn, p)
+	}
+	defer func() {
+		productRepo.Delete(available.ID)
+		productRepo.Delete(scarce.ID)
+	}()
+
+	order, err := orderUseCase.CreateOrder("rollback-test-user", []orderItemInput{
+		{ProductID: available.ID, Quantity: 3},
+		{ProductID: scarce.ID, Quantity: 2},
+	}, usecase.OrderOptions{})
+
+	assert.Error(t, err)
+	assert.Nil(t, order)
+
+	reloaded, err := productRepo.GetByID(available.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, available.Stock, reloaded.Stock)
+
+	reloaded, err = productRepo.GetByID(scarce.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, scarce.Stock, reloaded.Stock)
+}
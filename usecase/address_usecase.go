@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+// AddressUseCase manages the addresses users save for deliveries. Users
+// only ever see their own addresses; anyone else's are reported as not
+// found.
+type AddressUseCase struct {
+	addressRepo repository.AddressRepository
+}
+
+func NewAddressUseCase(addressRepo repository.AddressRepository) *AddressUseCase {
+	return &AddressUseCase{
+		addressRepo: addressRepo,
+	}
+}
+
+// CreateAddress validates and saves a new address for the user. The user's
+// first address becomes their default.
+func (uc *AddressUseCase) CreateAddress(userID string, address *domain.Address) (*domain.Address, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := uc.addressRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	address.ID = uuid.New().String()
+	address.UserID = userID
+	address.CreatedAt = time.Now().UTC()
+	if len(existing) == 0 {
+		address.IsDefault = true
+	}
+
+	if err := uc.addressRepo.Create(address); err != nil {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+func (uc *AddressUseCase) GetAddress(userID, id string) (*domain.Address, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	address, err := uc.addressRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if address.UserID != userID {
+		return nil, repository.ErrAddressNotFound
+	}
+
+	return address, nil
+}
+
+// UpdateAddress replaces the user's address with update. Unsetting
+// IsDefault leaves the user without a default address.
+func (uc *AddressUseCase) UpdateAddress(userID, id string, update *domain.Address) (*domain.Address, error) {
+	current, err := uc.GetAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	update.ID = current.ID
+	update.UserID = current.UserID
+	update.CreatedAt = current.CreatedAt
+
+	if err := uc.addressRepo.Update(update); err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// DeleteAddress removes the user's address. Orders keep the copy they were
+// delivered to.
+func (uc *AddressUseCase) DeleteAddress(userID, id string) error {
+	if _, err := uc.GetAddress(userID, id); err != nil {
+		return err
+	}
+
+	return uc.addressRepo.Delete(id)
+}
+
+// ListAddresses returns the user's addresses, the default first.
+func (uc *AddressUseCase) ListAddresses(userID string) ([]*domain.Address, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	return uc.addressRepo.ListByUserID(userID)
+}
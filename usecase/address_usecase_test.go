@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+func (r *memoryAddressRepository) Create(address *domain.Address) error {
+	copied := *address
+	r.addresses = append(r.addresses, &copied)
+	return nil
+}
+
+func (r *memoryAddressRepository) Update(address *domain.Address) error {
+	for i, stored := range r.addresses {
+		if stored.ID == address.ID {
+			copied := *address
+			r.addresses[i] = &copied
+			return nil
+		}
+	}
+	return repository.ErrAddressNotFound
+}
+
+func (r *memoryAddressRepository) Delete(id string) error {
+	for i, stored := range r.addresses {
+		if stored.ID == id {
+			r.addresses = append(r.addresses[:i], r.addresses[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrAddressNotFound
+}
+
+func (r *memoryAddressRepository) ListByUserID(userID string) ([]*domain.Address, error) {
+	var addresses []*domain.Address
+	for _, address := range r.addresses {
+		if address.UserID == userID {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
+
+func TestCreateAddress_MakesTheFirstAddressTheDefault(t *testing.T) {
+	repo := &memoryAddressRepository{}
+	uc := NewAddressUseCase(repo)
+
+	_, err := uc.CreateAddress("u1", &domain.Address{Line1: " ", City: "Almaty"})
+	assert.ErrorIs(t, err, domain.ErrInvalidAddress)
+
+	first, err := uc.CreateAddress("u1", &domain.Address{UserID: "u2", Line1: " Abay 10 ", City: "Almaty"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, "u1", first.UserID)
+	assert.Equal(t, "Abay 10", first.Line1)
+	assert.True(t, first.IsDefault)
+
+	second, err := uc.CreateAddress("u1", &domain.Address{Line1: "Dostyk 5", City: "Almaty"})
+	assert.NoError(t, err)
+	assert.False(t, second.IsDefault)
+
+	other, err := uc.CreateAddress("u2", &domain.Address{Line1: "Satpaev 2", City: "Almaty"})
+	assert.NoError(t, err)
+	assert.True(t, other.IsDefault)
+}
+
+func TestAddressUseCase_HidesOtherUsersAddresses(t *testing.T) {
+	repo := &memoryAddressRepository{}
+	uc := NewAddressUseCase(repo)
+
+	address, err := uc.CreateAddress("u1", &domain.Address{Line1: "Abay 10", City: "Almaty"})
+	assert.NoError(t, err)
+
+	_, err = uc.GetAddress("u2", address.ID)
+	assert.ErrorIs(t, err, repository.ErrAddressNotFound)
+	_, err = uc.UpdateAddress("u2", address.ID, &domain.Address{Line1: "Satpaev 2", City: "Almaty"})
+	assert.ErrorIs(t, err, repository.ErrAddressNotFound)
+	assert.ErrorIs(t, uc.DeleteAddress("u2", address.ID), repository.ErrAddressNotFound)
+
+	updated, err := uc.UpdateAddress("u1", address.ID, &domain.Address{UserID: "u2", Line1: "Abay 12", City: "Almaty", IsDefault: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", updated.UserID)
+	assert.True(t, address.CreatedAt.Equal(updated.CreatedAt))
+
+	assert.NoError(t, uc.DeleteAddress("u1", address.ID))
+	addresses, err := uc.ListAddresses("u1")
+	assert.NoError(t, err)
+	assert.Empty(t, addresses)
+}
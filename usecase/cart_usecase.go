@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+// CartUseCase keeps users' carts and turns them into orders.
+type CartUseCase struct {
+	cartRepo     repository.CartRepository
+	productRepo  repository.ProductRepository
+	rates        repository.ExchangeRateProvider
+	orderUseCase *OrderUseCase
+}
+
+func NewCartUseCase(cartRepo repository.CartRepository, productRepo repository.ProductRepository, rates repository.ExchangeRateProvider, orderUseCase *OrderUseCase) *CartUseCase {
+	return &CartUseCase{
+		cartRepo:     cartRepo,
+		productRepo:  productRepo,
+		rates:        rates,
+		orderUseCase: orderUseCase,
+	}
+}
+
+// GetCart returns the user's cart with every item checked against its
+// product's current price and stock. The subtotal is in the currency of the
+// first item counted in it; prices in other currencies are converted at the
+// current exchange rate.
+func (uc *CartUseCase) GetCart(userID string) (*domain.Cart, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	items, err := uc.cartRepo.GetItems(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart := &domain.Cart{
+		UserID:   userID,
+		Items:    items,
+		Subtotal: domain.NewMoney(0, domain.DefaultCurrency),
+	}
+	counted := false
+
+	for _, item := range items {
+		product, err := uc.productRepo.GetByID(item.ProductID)
+		if errors.Is(err, repository.ErrProductNotFound) {
+			product, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		item.Check(product)
+		if product == nil || item.Issue.BlocksCheckout() {
+			continue
+		}
+
+		line := product.Price.Multiply(int64(item.Quantity))
+		if !counted {
+			cart.Subtotal = domain.NewMoney(0, line.Currency)
+			counted = true
+		}
+		if line.Currency != cart.Subtotal.Currency {
+			rate, err := uc.rates.Rate(line.Currency, cart.Subtotal.Currency)
+			if err != nil {
+				return nil, err
+			}
+			if line, err = line.Convert(rate); err != nil {
+				return nil, err
+			}
+		}
+
+		cart.Subtotal, err = cart.Subtotal.Add(line)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cart, nil
+}
+
+// AddItem adds quantity of the product to the user's cart at its current
+// price and returns the cart.
+func (uc *CartUseCase) AddItem(userID, productID string, quantity int32) (*domain.Cart, error) {
+	item, err := uc.newItem(userID, productID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.cartRepo.AddItem(userID, item); err != nil {
+		return nil, err
+	}
+
+	return uc.GetCart(userID)
+}
+
+// UpdateItem sets the quantity of the product in the user's cart and
+// returns the cart. The item takes the product's current price, so a price
+// change is accepted by updating the item.
+func (uc *CartUseCase) UpdateItem(userID, productID string, quantity int32) (*domain.Cart, error) {
+	item, err := uc.newItem(userID, productID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.cartRepo.UpdateItem(userID, item); err != nil {
+		return nil, err
+	}
+
+	return uc.GetCart(userID)
+}
+
+func (uc *CartUseCase) newItem(userID, productID string, quantity int32) (*domain.CartItem, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+	if productID == "" {
+		return nil, errors.New("product ID cannot be empty")
+	}
+	if quantity <= 0 {
+		return nil, domain.ErrInvalidCartQuantity
+	}
+
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CartItem{
+		ProductID:  productID,
+		Quantity:   quantity,
+		AddedPrice: product.Price,
+	}, nil
+}
+
+// RemoveItem takes the product out of the user's cart and returns the cart.
+func (uc *CartUseCase) RemoveItem(userID, productID string) (*domain.Cart, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	if err := uc.cartRepo.RemoveItem(userID, productID); err != nil {
+		return nil, err
+	}
+
+	return uc.GetCart(userID)
+}
+
+func (uc *CartUseCase) ClearCart(userID string) error {
+	if userID == "" {
+		return errors.New("user ID cannot be empty")
+	}
+
+	return uc.cartRepo.Clear(userID)
+}
+
+// Checkout places an order for everything in the user's cart with options,
+// as OrderUseCase.CreateOrder does, and empties the cart. It fails with
+// domain.ErrCartNotOrderable if an item's product is gone or short of stock.
+func (uc *CartUseCase) Checkout(userID string, options OrderOptions) (*domain.Order, error) {
+	cart, err := uc.GetCart(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cart.CheckOrderable(); err != nil {
+		return nil, err
+	}
+
+	orderItems := make([]struct {
+		ProductID string
+		Quantity  int32
+	}, len(cart.Items))
+	for i, item := range cart.Items {
+		orderItems[i].ProductID = item.ProductID
+		orderItems[i].Quantity = item.Quantity
+	}
+
+	order, err := uc.orderUseCase.CreateOrder(userID, orderItems, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The order is placed either way; a cart left behind only means the
+	// user has to empty it.
+	if err := uc.cartRepo.Clear(userID); err != nil {
+		log.Printf("Failed to clear cart of user %s after placing order %s: %v", userID, order.ID, err)
+	}
+
+	return order, nil
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type memoryCart struct {
+	items map[string][]*domain.CartItem
+}
+
+func (r *memoryCart) find(userID, productID string) *domain.CartItem {
+	for _, item := range r.items[userID] {
+		if item.ProductID == productID {
+			return item
+		}
+	}
+	return nil
+}
+
+func (r *memoryCart) GetItems(userID string) ([]*domain.CartItem, error) {
+	items := []*domain.CartItem{}
+	for _, item := range r.items[userID] {
+		copied := *item
+		items = append(items, &copied)
+	}
+	return items, nil
+}
+
+func (r *memoryCart) AddItem(userID string, item *domain.CartItem) error {
+	if existing := r.find(userID, item.ProductID); existing != nil {
+		existing.Quantity += item.Quantity
+		existing.AddedPrice = item.AddedPrice
+		return nil
+	}
+	r.items[userID] = append(r.items[userID], item)
+	return nil
+}
+
+func (r *memoryCart) UpdateItem(userID string, item *domain.CartItem) error {
+	existing := r.find(userID, item.ProductID)
+	if existing == nil {
+		return repository.ErrCartItemNotFound
+	}
+	existing.Quantity = item.Quantity
+	existing.AddedPrice = item.AddedPrice
+	return nil
+}
+
+func (r *memoryCart) RemoveItem(userID, productID string) error {
+	for i, item := range r.items[userID] {
+		if item.ProductID == productID {
+			r.items[userID] = append(r.items[userID][:i], r.items[userID][i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrCartItemNotFound
+}
+
+func (r *memoryCart) Clear(userID string) error {
+	delete(r.items, userID)
+	return nil
+}
+
+func newCartUseCase() (*CartUseCase, *memoryCart, *memoryTransaction) {
+	uc, tx := newReservingOrderUseCase()
+	carts := &memoryCart{items: map[string][]*domain.CartItem{}}
+	return NewCartUseCase(carts, tx.products, memoryRates{}, uc), carts, tx
+}
+
+func TestCartUseCase_AddItemMergesQuantitiesAndTotalsAtCurrentPrices(t *testing.T) {
+	uc, _, _ := newCartUseCase()
+
+	_, err := uc.AddItem("u1", "p1", 2)
+	assert.NoError(t, err)
+	_, err = uc.AddItem("u1", "p2", 1)
+	assert.NoError(t, err)
+	cart, err := uc.AddItem("u1", "p1", 1)
+	assert.NoError(t, err)
+
+	assert.Len(t, cart.Items, 2)
+	assert.Equal(t, int32(3), cart.Items[0].Quantity)
+	assert.Equal(t, "p1", cart.Items[0].Product.ID)
+	assert.Equal(t, domain.NewMoney(500, "KZT"), cart.Subtotal)
+
+	_, err = uc.AddItem("u1", "missing", 1)
+	assert.ErrorIs(t, err, repository.ErrProductNotFound)
+	_, err = uc.AddItem("u1", "p1", 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidCartQuantity)
+}
+
+func TestCartUseCase_GetCartFlagsPriceAndStockChanges(t *testing.T) {
+	uc, _, tx := newCartUseCase()
+
+	_, err := uc.AddItem("u1", "p1", 4)
+	assert.NoError(t, err)
+	_, err = uc.AddItem("u1", "p2", 1)
+	assert.NoError(t, err)
+
+	tx.products.stock["p1"] = 3
+	tx.products.prices["p2"] = domain.NewMoney(250, "KZT")
+
+	cart, err := uc.GetCart("u1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CartItemInsufficientStock, cart.Items[0].Issue)
+	assert.Equal(t, domain.CartItemPriceChanged, cart.Items[1].Issue)
+	// The short item is left out of the subtotal, the other is at its new
+	// price.
+	assert.Equal(t, domain.NewMoney(250, "KZT"), cart.Subtotal)
+
+	// Updating an item accepts its current price.
+	cart, err = uc.UpdateItem("u1", "p2", 2)
+	assert.NoError(t, err)
+	assert.Empty(t, cart.Items[1].Issue)
+
+	delete(tx.products.stock, "p1")
+	cart, err = uc.GetCart("u1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CartItemUnavailable, cart.Items[0].Issue)
+	assert.Nil(t, cart.Items[0].Product)
+
+	_, err = uc.UpdateItem("u1", "p3", 1)
+	assert.ErrorIs(t, err, repository.ErrProductNotFound)
+}
+
+func TestCartUseCase_TotalsProductsPricedInOtherCurrencies(t *testing.T) {
+	uc, carts, tx := newCartUseCase()
+	tx.products.stock["p3"] = 5
+	tx.products.prices["p3"] = domain.NewMoney(1500, "USD")
+
+	cart, err := uc.AddItem("u1", "p3", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(3000, "USD"), cart.Subtotal)
+
+	// Items in other currencies are converted into the subtotal's.
+	uc.rates = memoryRates{"USD": "0.01"}
+	cart, err = uc.AddItem("u1", "p1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(3001, "USD"), cart.Subtotal)
+	cart, err = uc.UpdateItem("u1", "p1", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(3003, "USD"), cart.Subtotal)
+	cart, err = uc.RemoveItem("u1", "p1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(3000, "USD"), cart.Subtotal)
+
+	order, err := uc.Checkout("u1", OrderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(3000, "USD"), order.TotalPrice)
+	assert.Empty(t, carts.items["u1"])
+}
+
+func TestCartUseCase_CheckoutPlacesOrderAndEmptiesCart(t *testing.T) {
+	uc, carts, tx := newCartUseCase()
+
+	_, err := uc.Checkout("u1", OrderOptions{})
+	assert.ErrorIs(t, err, domain.ErrEmptyCart)
+
+	_, err = uc.AddItem("u1", "p1", 2)
+	assert.NoError(t, err)
+	_, err = uc.AddItem("u1", "p2", 3)
+	assert.NoError(t, err)
+
+	order, err := uc.Checkout("u1", OrderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", order.UserID)
+	assert.Len(t, order.Items, 2)
+	assert.Equal(t, domain.NewMoney(800, "KZT"), order.TotalPrice)
+	assert.Equal(t, int32(8), tx.products.stock["p1"])
+	assert.Empty(t, carts.items["u1"])
+}
+
+func TestCartUseCase_CheckoutRefusesCartsThatCannotBeOrdered(t *testing.T) {
+	uc, carts, tx := newCartUseCase()
+
+	_, err := uc.AddItem("u1", "p1", 11)
+	assert.NoError(t, err)
+
+	_, err = uc.Checkout("u1", OrderOptions{})
+	assert.ErrorIs(t, err, domain.ErrCartNotOrderable)
+	assert.Empty(t, tx.orders.orders)
+	assert.Len(t, carts.items["u1"], 1)
+}
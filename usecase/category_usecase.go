@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type CategoryUseCase struct {
+	categoryRepo repository.CategoryRepository
+}
+
+func NewCategoryUseCase(categoryRepo repository.CategoryRepository) *CategoryUseCase {
+	return &CategoryUseCase{
+		categoryRepo: categoryRepo,
+	}
+}
+
+// CreateCategory adds a category under parentID, or at the top level when
+// parentID is empty.
+func (uc *CategoryUseCase) CreateCategory(name, parentID string) (*domain.Category, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name cannot be empty", domain.ErrInvalidCategory)
+	}
+
+	if parentID != "" {
+		if _, err := uc.categoryRepo.GetByID(parentID); err != nil {
+			return nil, err
+		}
+	}
+
+	category := &domain.Category{
+		ID:       uuid.New().String(),
+		Name:     name,
+		ParentID: parentID,
+	}
+
+	if err := uc.categoryRepo.Create(category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (uc *CategoryUseCase) GetCategory(id string) (*domain.Category, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: ID cannot be empty", domain.ErrInvalidCategory)
+	}
+
+	return uc.categoryRepo.GetByID(id)
+}
+
+// UpdateCategory renames the category and moves it under parentID. An empty
+// name and a nil parentID leave the current value in place; an empty
+// parentID moves the category to the top level.
+func (uc *CategoryUseCase) UpdateCategory(id, name string, parentID *string) (*domain.Category, error) {
+	category, err := uc.GetCategory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name = strings.TrimSpace(name); name != "" {
+		category.Name = name
+	}
+
+	if parentID != nil {
+		// Walk up from the new parent so that a category is never moved
+		// under itself or one of its own subcategories.
+		for ancestor := *parentID; ancestor != ""; {
+			if ancestor == id {
+				return nil, fmt.Errorf("%w: %s cannot be moved under itself", domain.ErrInvalidCategory, id)
+			}
+
+			parent, err := uc.categoryRepo.GetByID(ancestor)
+			if err != nil {
+				return nil, err
+			}
+			ancestor = parent.ParentID
+		}
+
+		category.ParentID = *parentID
+	}
+
+	if err := uc.categoryRepo.Update(category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory removes a category that has no subcategories. Its products
+// are left without a category.
+func (uc *CategoryUseCase) DeleteCategory(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: ID cannot be empty", domain.ErrInvalidCategory)
+	}
+
+	return uc.categoryRepo.Delete(id)
+}
+
+// ListCategories returns every category. Clients build the tree from
+// ParentID.
+func (uc *CategoryUseCase) ListCategories() ([]*domain.Category, error) {
+	return uc.categoryRepo.List()
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type memoryCategoryRepository struct {
+	repository.CategoryRepository
+	categories map[string]*domain.Category
+}
+
+func (r *memoryCategoryRepository) Create(category *domain.Category) error {
+	copied := *category
+	r.categories[category.ID] = &copied
+	return nil
+}
+
+func (r *memoryCategoryRepository) GetByID(id string) (*domain.Category, error) {
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, repository.ErrCategoryNotFound
+	}
+	copied := *category
+	return &copied, nil
+}
+
+func (r *memoryCategoryRepository) Update(category *domain.Category) error {
+	return r.Create(category)
+}
+
+func TestUpdateCategory_RejectsMovingUnderItsOwnSubtree(t *testing.T) {
+	repo := &memoryCategoryRepository{categories: map[string]*domain.Category{}}
+	uc := NewCategoryUseCase(repo)
+
+	food, err := uc.CreateCategory("Food", "")
+	assert.NoError(t, err)
+	fruit, err := uc.CreateCategory(" Fruit ", food.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Fruit", fruit.Name)
+	apples, err := uc.CreateCategory("Apples", fruit.ID)
+	assert.NoError(t, err)
+
+	for _, parentID := range []string{food.ID, apples.ID} {
+		_, err = uc.UpdateCategory(food.ID, "", &parentID)
+		assert.ErrorIs(t, err, domain.ErrInvalidCategory, parentID)
+	}
+	assert.Equal(t, "", repo.categories[food.ID].ParentID)
+
+	root := ""
+	moved, err := uc.UpdateCategory(apples.ID, "Green apples", &root)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Category{ID: apples.ID, Name: "Green apples"}, moved)
+
+	renamed, err := uc.UpdateCategory(fruit.ID, "Fresh fruit", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, food.ID, renamed.ParentID)
+}
+
+func TestCreateCategory_Validation(t *testing.T) {
+	uc := NewCategoryUseCase(&memoryCategoryRepository{categories: map[string]*domain.Category{}})
+
+	_, err := uc.CreateCategory("  ", "")
+	assert.ErrorIs(t, err, domain.ErrInvalidCategory)
+
+	_, err = uc.CreateCategory("Fruit", "missing")
+	assert.ErrorIs(t, err, repository.ErrCategoryNotFound)
+}
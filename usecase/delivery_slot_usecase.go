@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+// DeliverySlotUseCase manages the delivery slots that orders book.
+type DeliverySlotUseCase struct {
+	slotRepo repository.DeliverySlotRepository
+}
+
+func NewDeliverySlotUseCase(slotRepo repository.DeliverySlotRepository) *DeliverySlotUseCase {
+	return &DeliverySlotUseCase{
+		slotRepo: slotRepo,
+	}
+}
+
+// CreateSlot validates and stores a new, unbooked delivery slot.
+func (uc *DeliverySlotUseCase) CreateSlot(slot *domain.DeliverySlot) (*domain.DeliverySlot, error) {
+	if err := slot.Validate(); err != nil {
+		return nil, err
+	}
+
+	slot.ID = uuid.New().String()
+	slot.StartsAt = slot.StartsAt.UTC()
+	slot.EndsAt = slot.EndsAt.UTC()
+	slot.Booked = 0
+	slot.CreatedAt = time.Now().UTC()
+
+	if err := uc.slotRepo.Create(slot); err != nil {
+		return nil, err
+	}
+
+	return slot, nil
+}
+
+// DeleteSlot removes a slot no order has booked.
+func (uc *DeliverySlotUseCase) DeleteSlot(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: ID cannot be empty", domain.ErrInvalidDeliverySlot)
+	}
+
+	return uc.slotRepo.Delete(id)
+}
+
+// ListSlots returns every slot that has not started yet, earliest first.
+func (uc *DeliverySlotUseCase) ListSlots() ([]*domain.DeliverySlot, error) {
+	return uc.slotRepo.ListFrom(time.Now())
+}
+
+// ListAvailableSlots returns the slots an order placed now can book,
+// earliest first.
+func (uc *DeliverySlotUseCase) ListAvailableSlots() ([]*domain.DeliverySlot, error) {
+	now := time.Now()
+
+	slots, err := uc.slotRepo.ListFrom(now)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]*domain.DeliverySlot, 0, len(slots))
+	for _, slot := range slots {
+		if slot.IsBookableAt(now) {
+			available = append(available, slot)
+		}
+	}
+
+	return available, nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+// InventoryAlertUseCase emails admins about products that are running low.
+type InventoryAlertUseCase struct {
+	productRepo   repository.ProductRepository
+	sender        repository.EmailSender
+	recipients    []string
+	processedRepo repository.ProcessedMessageRepository
+}
+
+// NewInventoryAlertUseCase creates the alert use case. processedRepo may be
+// nil, in which case a redelivered event is emailed again.
+func NewInventoryAlertUseCase(productRepo repository.ProductRepository, sender repository.EmailSender, recipients []string, processedRepo repository.ProcessedMessageRepository) *InventoryAlertUseCase {
+	return &InventoryAlertUseCase{
+		productRepo:   productRepo,
+		sender:        sender,
+		recipients:    recipients,
+		processedRepo: processedRepo,
+	}
+}
+
+func (uc *InventoryAlertUseCase) HandleLowStockEvent(meta domain.MessageMetadata, event domain.LowStockEvent) error {
+	return handleOnce(uc.processedRepo, meta, func() error {
+		return uc.notifyLowStock(meta, event)
+	})
+}
+
+// notifyLowStock emails every recipient about the event, unless the product
+// has been deleted or restocked since. Each email is recorded once sent, so
+// when some fail only those are sent again on redelivery.
+func (uc *InventoryAlertUseCase) notifyLowStock(meta domain.MessageMetadata, event domain.LowStockEvent) error {
+	log.Printf("Product %s (%s) is down to %d, below its reorder threshold of %d",
+		event.ProductID, event.Name, event.Stock, event.ReorderThreshold)
+
+	product, err := uc.productRepo.GetByID(event.ProductID)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		log.Printf("Skipping low stock alert for product %s: it no longer exists", event.ProductID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !product.IsLowStock() {
+		log.Printf("Skipping low stock alert for product %s: stock is back at %d", product.ID, product.Stock)
+		return nil
+	}
+
+	if len(uc.recipients) == 0 {
+		log.Printf("No admin emails configured, low stock alert for product %s not sent", product.ID)
+		return nil
+	}
+
+	subject, body := lowStockEmail(product, event)
+	var errs []error
+	for _, to := range uc.recipients {
+		err := handleOnce(uc.processedRepo, recipientMessage(meta, to), func() error {
+			return uc.sender.Send(to, subject, body)
+		})
+		if err != nil {
+			log.Printf("Failed to send low stock alert for product %s to %s: %v", product.ID, to, err)
+			errs = append(errs, fmt.Errorf("%s: %w", to, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	log.Printf("Sent low stock alert for product %s to %d admins", product.ID, len(uc.recipients))
+	return nil
+}
+
+// recipientMessage identifies the delivery of the message to one recipient.
+// The ID is derived from both, in the same form as message IDs.
+func recipientMessage(meta domain.MessageMetadata, recipient string) domain.MessageMetadata {
+	if meta.ID == "" {
+		return meta
+	}
+	meta.ID = uuid.NewSHA1(uuid.NameSpaceURL, []byte(meta.ID+"/"+recipient)).String()
+	return meta
+}
+
+func lowStockEmail(product *domain.Product, event domain.LowStockEvent) (subject, body string) {
+	subject = fmt.Sprintf("Low stock: %s", product.Name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s is running low and should be reordered.\n\n", product.Name)
+	fmt.Fprintf(&b, "Product ID: %s\n", product.ID)
+	fmt.Fprintf(&b, "Stock: %d\n", product.Stock)
+	fmt.Fprintf(&b, "Reorder threshold: %d\n", product.ReorderThreshold)
+	fmt.Fprintf(&b, "Dropped below the threshold: %s (%s", event.DetectedAt.Format(time.RFC1123), event.Reason)
+	if event.ReferenceID != "" {
+		fmt.Fprintf(&b, " %s", event.ReferenceID)
+	}
+	b.WriteString(")\n")
+
+	return subject, b.String()
+}
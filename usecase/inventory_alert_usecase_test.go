@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type stockedProductRepository struct {
+	repository.ProductRepository
+	products map[string]*domain.Product
+}
+
+func (r *stockedProductRepository) GetByID(id string) (*domain.Product, error) {
+	product, ok := r.products[id]
+	if !ok {
+		return nil, repository.ErrProductNotFound
+	}
+	return product, nil
+}
+
+type sentEmail struct {
+	to, subject, body string
+}
+
+// recordingEmailSender fails every email with err, and those to failTo.
+type recordingEmailSender struct {
+	sent   []sentEmail
+	err    error
+	failTo string
+}
+
+func (s *recordingEmailSender) Send(to, subject, body string) error {
+	if s.err != nil {
+		return s.err
+	}
+	if to == s.failTo {
+		return errors.New("mailbox unavailable")
+	}
+	s.sent = append(s.sent, sentEmail{to: to, subject: subject, body: body})
+	return nil
+}
+
+func TestInventoryAlertUseCase_EmailsAdminsOncePerEvent(t *testing.T) {
+	products := &stockedProductRepository{products: map[string]*domain.Product{
+		"milk": {ID: "milk", Name: "Milk", Stock: 3, ReorderThreshold: 10},
+	}}
+	sender := &recordingEmailSender{}
+	processed := &memoryProcessedMessages{processed: map[string]time.Time{}}
+	uc := NewInventoryAlertUseCase(products, sender, []string{"a@example.com", "b@example.com"}, processed)
+
+	meta := domain.MessageMetadata{ID: "message-1"}
+	event := domain.LowStockEvent{
+		ProductID:        "milk",
+		Name:             "Milk",
+		Stock:            3,
+		ReorderThreshold: 10,
+		Reason:           domain.StockMovementOrder,
+		ReferenceID:      "order-1",
+		DetectedAt:       time.Now(),
+	}
+
+	assert.NoError(t, uc.HandleLowStockEvent(meta, event))
+	assert.NoError(t, uc.HandleLowStockEvent(meta, event))
+
+	assert.Len(t, sender.sent, 2)
+	assert.Equal(t, "a@example.com", sender.sent[0].to)
+	assert.Equal(t, "b@example.com", sender.sent[1].to)
+	assert.Equal(t, "Low stock: Milk", sender.sent[0].subject)
+	assert.Contains(t, sender.sent[0].body, "Stock: 3\n")
+	assert.Contains(t, sender.sent[0].body, "Reorder threshold: 10\n")
+	assert.Contains(t, sender.sent[0].body, "(order order-1)")
+}
+
+func TestInventoryAlertUseCase_SkipsRestockedAndDeletedProducts(t *testing.T) {
+	products := &stockedProductRepository{products: map[string]*domain.Product{
+		"milk": {ID: "milk", Name: "Milk", Stock: 40, ReorderThreshold: 10},
+	}}
+	sender := &recordingEmailSender{}
+	uc := NewInventoryAlertUseCase(products, sender, []string{"a@example.com"}, nil)
+
+	assert.NoError(t, uc.HandleLowStockEvent(domain.MessageMetadata{ID: "message-1"}, domain.LowStockEvent{ProductID: "milk"}))
+	assert.NoError(t, uc.HandleLowStockEvent(domain.MessageMetadata{ID: "message-2"}, domain.LowStockEvent{ProductID: "bread"}))
+	assert.Empty(t, sender.sent)
+}
+
+func TestInventoryAlertUseCase_RetriesWhenSendingFails(t *testing.T) {
+	products := &stockedProductRepository{products: map[string]*domain.Product{
+		"milk": {ID: "milk", Name: "Milk", Stock: 3, ReorderThreshold: 10},
+	}}
+	sender := &recordingEmailSender{err: errors.New("smtp unavailable")}
+	processed := &memoryProcessedMessages{processed: map[string]time.Time{}}
+	uc := NewInventoryAlertUseCase(products, sender, []string{"a@example.com"}, processed)
+
+	meta := domain.MessageMetadata{ID: "message-1"}
+	assert.Error(t, uc.HandleLowStockEvent(meta, domain.LowStockEvent{ProductID: "milk"}))
+
+	sender.err = nil
+	assert.NoError(t, uc.HandleLowStockEvent(meta, domain.LowStockEvent{ProductID: "milk"}))
+	assert.Len(t, sender.sent, 1)
+}
+
+func TestInventoryAlertUseCase_ResendsOnlyToFailedRecipients(t *testing.T) {
+	products := &stockedProductRepository{products: map[string]*domain.Product{
+		"milk": {ID: "milk", Name: "Milk", Stock: 3, ReorderThreshold: 10},
+	}}
+	sender := &recordingEmailSender{failTo: "a@example.com"}
+	processed := &memoryProcessedMessages{processed: map[string]time.Time{}}
+	uc := NewInventoryAlertUseCase(products, sender, []string{"a@example.com", "b@example.com"}, processed)
+
+	meta := domain.MessageMetadata{ID: "message-1"}
+	err := uc.HandleLowStockEvent(meta, domain.LowStockEvent{ProductID: "milk"})
+	assert.ErrorContains(t, err, "a@example.com")
+	assert.Len(t, sender.sent, 1)
+
+	sender.failTo = ""
+	assert.NoError(t, uc.HandleLowStockEvent(meta, domain.LowStockEvent{ProductID: "milk"}))
+	assert.NoError(t, uc.HandleLowStockEvent(meta, domain.LowStockEvent{ProductID: "milk"}))
+
+	if assert.Len(t, sender.sent, 2) {
+		assert.Equal(t, "b@example.com", sender.sent[0].to)
+		assert.Equal(t, "a@example.com", sender.sent[1].to)
+	}
+}
@@ -1,53 +1,51 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"log"
 	"time"
 
 	"AdvProg2/domain"
 	"AdvProg2/pkg/cache"
+	"AdvProg2/pkg/eventbus"
 	"AdvProg2/repository"
 )
 
+// processedMessageTTL is how long handled message IDs are remembered. It
+// matches how long the JetStream stream keeps messages, so anything that can
+// still be redelivered is recognised.
+const (
+	processedMessageTTL             = 7 * 24 * time.Hour
+	processedMessageCleanupInterval = time.Hour
+)
+
 type MessageUseCase struct {
-	producer    repository.MessageProducer
-	productRepo repository.ProductRepository
-	cache       *cache.Cache
+	publisher     repository.EventPublisher
+	productRepo   repository.ProductRepository
+	cache         *cache.Cache
+	processedRepo repository.ProcessedMessageRepository
 }
 
-func NewMessageUseCase(producer repository.MessageProducer, productRepo repository.ProductRepository, cache *cache.Cache) *MessageUseCase {
+// NewMessageUseCase creates the message use case. processedRepo may be nil
+// for services that only publish; handlers then process every delivery.
+func NewMessageUseCase(publisher repository.EventPublisher, productRepo repository.ProductRepository, cache *cache.Cache, processedRepo repository.ProcessedMessageRepository) *MessageUseCase {
 	return &MessageUseCase{
-		producer:    producer,
-		productRepo: productRepo,
-		cache:       cache,
+		publisher:     publisher,
+		productRepo:   productRepo,
+		cache:         cache,
+		processedRepo: processedRepo,
 	}
 }
 
 func (uc *MessageUseCase) PublishOrderCreatedEvent(order *domain.Order) error {
-	if uc.producer == nil {
-		return errors.New("message producer not configured")
-	}
-
-	// Create order items 
-	itemEvents := make([]domain.OrderItemEvent, 0, len(order.Items))
-	for _, item := range order.Items {
-		itemEvents = append(itemEvents, domain.OrderItemEvent{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
-		})
+	if uc.publisher == nil {
+		return errors.New("message publisher not configured")
 	}
 
-	event := domain.OrderCreatedEvent{
-		OrderID:    order.ID,
-		UserID:     order.UserID,
-		TotalPrice: order.TotalPrice,
-		Items:      itemEvents,
-		CreatedAt:  order.CreatedAt,
-	}
+	event := newOrderCreatedEvent(order)
 
-	if err := uc.producer.PublishOrderCreated(event); err != nil {
+	if err := eventbus.Publish(uc.publisher, domain.EventOrderCreated, event); err != nil {
 		log.Printf("Failed to publish order created event: %v", err)
 		return err
 	}
@@ -56,21 +54,15 @@ func (uc *MessageUseCase) PublishOrderCreatedEvent(order *domain.Order) error {
 }
 
 func (uc *MessageUseCase) PublishProductCreatedEvent(product *domain.Product) error {
-	if uc.producer == nil {
-		return errors.New("message producer not configured")
+	if uc.publisher == nil {
+		return errors.New("message publisher not configured")
 	}
 
-	event := domain.ProductCreatedEvent{
-		ProductID: product.ID,
-		Name:      product.Name,
-		Price:     product.Price,
-		Stock:     product.Stock,
-		CreatedAt: time.Now(),
-	}
+	event := newProductCreatedEvent(product)
 
 	log.Printf("Message UseCase publishing product.created event: %+v", event)
 
-	if err := uc.producer.PublishProductCreated(event); err != nil {
+	if err := eventbus.Publish(uc.publisher, domain.EventProductCreated, event); err != nil {
 		log.Printf("Failed to publish product created event: %v", err)
 		return err
 	}
@@ -79,24 +71,18 @@ func (uc *MessageUseCase) PublishProductCreatedEvent(product *domain.Product) er
 }
 
 func (uc *MessageUseCase) PublishProductUpdatedEvent(product *domain.Product) error {
-	if uc.producer == nil {
-		return errors.New("message producer not configured")
+	if uc.publisher == nil {
+		return errors.New("message publisher not configured")
 	}
 
-	log.Printf("Creating product updated event for ID=%s, Name=%s, Price=%.2f, Stock=%d",
+	log.Printf("Creating product updated event for ID=%s, Name=%s, Price=%s, Stock=%d",
 		product.ID, product.Name, product.Price, product.Stock)
 
-	event := domain.ProductUpdatedEvent{
-		ProductID: product.ID,
-		Name:      product.Name,
-		Price:     product.Price,
-		Stock:     product.Stock,
-		UpdatedAt: time.Now(),
-	}
+	event := newProductUpdatedEvent(product)
 
 	log.Printf("Message UseCase publishing product.updated event: %+v", event)
 
-	if err := uc.producer.PublishProductUpdated(event); err != nil {
+	if err := eventbus.Publish(uc.publisher, domain.EventProductUpdated, event); err != nil {
 		log.Printf("Failed to publish product updated event: %v", err)
 		return err
 	}
@@ -106,21 +92,18 @@ func (uc *MessageUseCase) PublishProductUpdatedEvent(product *domain.Product) er
 }
 
 func (uc *MessageUseCase) PublishProductDeletedEvent(productID string) error {
-	if uc.producer == nil {
-		return errors.New("message producer not configured")
+	if uc.publisher == nil {
+		return errors.New("message publisher not configured")
 	}
 
 	log.Printf("Creating product deleted event for ID=%s at %s",
 		productID, time.Now().Format(time.RFC3339))
 
-	event := domain.ProductDeletedEvent{
-		ProductID: productID,
-		DeletedAt: time.Now(),
-	}
+	event := newProductDeletedEvent(productID)
 
 	log.Printf("Message UseCase publishing product.deleted event: %+v", event)
 
-	if err := uc.producer.PublishProductDeleted(event); err != nil {
+	if err := eventbus.Publish(uc.publisher, domain.EventProductDeleted, event); err != nil {
 		log.Printf("Failed to publish product deleted event: %v", err)
 		return err
 	}
@@ -129,9 +112,147 @@ func (uc *MessageUseCase) PublishProductDeletedEvent(productID string) error {
 	return nil
 }
 
-func (uc *MessageUseCase) HandleOrderCreatedEvent(event domain.OrderCreatedEvent) error {
+func newOrderCreatedEvent(order *domain.Order) domain.OrderCreatedEvent {
+	itemEvents := make([]domain.OrderItemEvent, 0, len(order.Items))
+	for _, item := range order.Items {
+		itemEvents = append(itemEvents, domain.OrderItemEvent{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		})
+	}
+
+	return domain.OrderCreatedEvent{
+		OrderID:       order.ID,
+		UserID:        order.UserID,
+		TotalPrice:    order.TotalPrice,
+		Subtotal:      order.Subtotal,
+		DiscountTotal: order.DiscountTotal,
+		TaxTotal:      order.TaxTotal,
+		DeliveryFee:   order.DeliveryFee,
+		Items:         itemEvents,
+		CreatedAt:     order.CreatedAt,
+	}
+}
+
+func newOrderStatusChangedEvent(order *domain.Order, change *domain.OrderStatusChange, restocked []*domain.OrderItem) domain.OrderStatusChangedEvent {
+	var restockedEvents []domain.OrderItemEvent
+	for _, item := range restocked {
+		restockedEvents = append(restockedEvents, domain.OrderItemEvent{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		})
+	}
+
+	return domain.OrderStatusChangedEvent{
+		OrderID:        order.ID,
+		UserID:         order.UserID,
+		OldStatus:      change.FromStatus,
+		NewStatus:      change.ToStatus,
+		TotalPrice:     order.TotalPrice,
+		RestockedItems: restockedEvents,
+		Actor:          change.Actor,
+		Reason:         change.Reason,
+		ChangedAt:      change.ChangedAt,
+	}
+}
+
+func newProductCreatedEvent(product *domain.Product) domain.ProductCreatedEvent {
+	return domain.ProductCreatedEvent{
+		ProductID:  product.ID,
+		Name:       product.Name,
+		Price:      product.Price,
+		Stock:      product.Stock,
+		CategoryID: product.CategoryID,
+		Tags:       product.Tags,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func newProductUpdatedEvent(product *domain.Product) domain.ProductUpdatedEvent {
+	return domain.ProductUpdatedEvent{
+		ProductID:  product.ID,
+		Name:       product.Name,
+		Price:      product.Price,
+		Stock:      product.Stock,
+		CategoryID: product.CategoryID,
+		Tags:       product.Tags,
+		UpdatedAt:  time.Now(),
+	}
+}
+
+func newProductDeletedEvent(productID string) domain.ProductDeletedEvent {
+	return domain.ProductDeletedEvent{
+		ProductID: productID,
+		DeletedAt: time.Now(),
+	}
+}
+
+func (uc *MessageUseCase) handleOnce(meta domain.MessageMetadata, handle func() error) error {
+	return handleOnce(uc.processedRepo, meta, handle)
+}
+
+// handleOnce runs handle unless the message was already processed. The
+// message is claimed before handle runs, in the same transaction, so two
+// deliveries of it cannot both be handled, and a failed handle releases the
+// claim for the retry. Work handle commits on its own, such as a refund, is
+// not undone with the claim and must be safe to repeat. A nil processedRepo
+// handles every delivery.
+func handleOnce(processedRepo repository.ProcessedMessageRepository, meta domain.MessageMetadata, handle func() error) error {
+	if processedRepo == nil || meta.ID == "" {
+		return handle()
+	}
+
+	processed, err := processedRepo.Process(meta.ID, processedMessageTTL, func(repository.Transaction) error {
+		return handle()
+	})
+	if err != nil {
+		log.Printf("Failed to process message %s: %v", meta.ID, err)
+		return err
+	}
+	if !processed {
+		log.Printf("Skipping message %s created at %s: already processed",
+			meta.ID, meta.CreatedAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// RunProcessedMessageCleanup deletes expired processed message IDs until ctx
+// is cancelled.
+func (uc *MessageUseCase) RunProcessedMessageCleanup(ctx context.Context) {
+	if uc.processedRepo == nil {
+		return
+	}
+
+	ticker := time.NewTicker(processedMessageCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := uc.processedRepo.DeleteExpired()
+			if err != nil {
+				log.Printf("Failed to delete expired processed messages: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Deleted %d expired processed messages", deleted)
+			}
+		}
+	}
+}
+
+func (uc *MessageUseCase) HandleOrderCreatedEvent(meta domain.MessageMetadata, event domain.OrderCreatedEvent) error {
+	return uc.handleOnce(meta, func() error {
+		return uc.handleOrderCreated(event)
+	})
+}
+
+func (uc *MessageUseCase) handleOrderCreated(event domain.OrderCreatedEvent) error {
 	log.Printf("Processing order created event for order %s", event.OrderID)
-	log.Printf("User %s created an order for $%.2f", event.UserID, event.TotalPrice)
+	log.Printf("User %s created an order for %s", event.UserID, event.TotalPrice)
 
 	for _, item := range event.Items {
 		product, err := uc.productRepo.GetByID(item.ProductID)
@@ -147,16 +268,68 @@ func (uc *MessageUseCase) HandleOrderCreatedEvent(event domain.OrderCreatedEvent
 	return nil
 }
 
-func (uc *MessageUseCase) HandleProductCreatedEvent(event domain.ProductCreatedEvent) error {
+func (uc *MessageUseCase) HandleOrderStatusChangedEvent(meta domain.MessageMetadata, event domain.OrderStatusChangedEvent) error {
+	return uc.handleOnce(meta, func() error {
+		log.Printf("Order %s of user %s changed from %s to %s by %s %s",
+			event.OrderID, event.UserID, event.OldStatus, event.NewStatus, event.Actor.Role, event.Actor.ID)
+
+		if uc.cache != nil {
+			uc.cache.Delete("order:" + event.OrderID)
+			uc.cache.Delete("user:" + event.UserID + ":orders")
+			log.Printf("Cache invalidated for order %s", event.OrderID)
+		}
+
+		return nil
+	})
+}
+
+func (uc *MessageUseCase) HandleOrderCancelledEvent(meta domain.MessageMetadata, event domain.OrderCancelledEvent) error {
+	return uc.handleOnce(meta, func() error {
+		log.Printf("Order %s of user %s was cancelled by %s %s, %d items returned to stock",
+			event.OrderID, event.UserID, event.Actor.Role, event.Actor.ID, len(event.RestockedItems))
+
+		// Restocking changed these products' stock, so cached copies are stale.
+		for _, item := range event.RestockedItems {
+			log.Printf("Returned %d units of product %s to stock", item.Quantity, item.ProductID)
+			if uc.cache != nil {
+				uc.cache.Delete("product:" + item.ProductID)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (uc *MessageUseCase) HandleOrderCompletedEvent(meta domain.MessageMetadata, event domain.OrderCompletedEvent) error {
+	return uc.handleOnce(meta, func() error {
+		log.Printf("Order %s of user %s for %s was completed by %s %s",
+			event.OrderID, event.UserID, event.TotalPrice, event.Actor.Role, event.Actor.ID)
+		return nil
+	})
+}
+
+func (uc *MessageUseCase) HandleProductCreatedEvent(meta domain.MessageMetadata, event domain.ProductCreatedEvent) error {
+	return uc.handleOnce(meta, func() error {
+		return uc.handleProductCreated(event)
+	})
+}
+
+func (uc *MessageUseCase) handleProductCreated(event domain.ProductCreatedEvent) error {
 	log.Printf("Processing product created event for product %s", event.ProductID)
-	log.Printf("New product added: %s, Price: $%.2f, Stock: %d", event.Name, event.Price, event.Stock)
+	log.Printf("New product added: %s, Price: %s, Stock: %d", event.Name, event.Price, event.Stock)
 
 	return nil
 }
 
-func (uc *MessageUseCase) HandleProductUpdatedEvent(event domain.ProductUpdatedEvent) error {
+func (uc *MessageUseCase) HandleProductUpdatedEvent(meta domain.MessageMetadata, event domain.ProductUpdatedEvent) error {
+	return uc.handleOnce(meta, func() error {
+		return uc.handleProductUpdated(event)
+	})
+}
+
+func (uc *MessageUseCase) handleProductUpdated(event domain.ProductUpdatedEvent) error {
 	log.Printf("Processing product updated event for product %s", event.ProductID)
-	log.Printf("Product updated: %s, New price: $%.2f, New stock: %d, Updated at: %s",
+	log.Printf("Product updated: %s, New price: %s, New stock: %d, Updated at: %s",
 		event.Name, event.Price, event.Stock, event.UpdatedAt.Format(time.RFC3339))
 
 	product, err := uc.productRepo.GetByID(event.ProductID)
@@ -177,7 +350,13 @@ func (uc *MessageUseCase) HandleProductUpdatedEvent(event domain.ProductUpdatedE
 	return nil
 }
 
-func (uc *MessageUseCase) HandleProductDeletedEvent(event domain.ProductDeletedEvent) error {
+func (uc *MessageUseCase) HandleProductDeletedEvent(meta domain.MessageMetadata, event domain.ProductDeletedEvent) error {
+	return uc.handleOnce(meta, func() error {
+		return uc.handleProductDeleted(event)
+	})
+}
+
+func (uc *MessageUseCase) handleProductDeleted(event domain.ProductDeletedEvent) error {
 	log.Printf("Processing product deleted event for product %s", event.ProductID)
 	log.Printf("Product %s was deleted at %s",
 		event.ProductID, event.DeletedAt.Format(time.RFC3339))
@@ -199,3 +378,20 @@ func (uc *MessageUseCase) HandleProductDeletedEvent(event domain.ProductDeletedE
 
 	return nil
 }
+
+// HandleStockReservationEvent evicts the reserved products from the cache,
+// since reserving or releasing stock changes how much is left.
+func (uc *MessageUseCase) HandleStockReservationEvent(meta domain.MessageMetadata, event domain.StockReservationEvent) error {
+	return uc.handleOnce(meta, func() error {
+		log.Printf("Stock reservations for order %s are %s (%d items) %s",
+			event.OrderID, event.Status, len(event.Items), event.Reason)
+
+		if uc.cache != nil {
+			for _, item := range event.Items {
+				uc.cache.Delete("product:" + item.ProductID)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/pkg/cache"
+	"AdvProg2/repository"
+)
+
+type memoryProcessedMessages struct {
+	processed map[string]time.Time
+	checkErr  error
+}
+
+func (r *memoryProcessedMessages) Process(messageID string, ttl time.Duration, handle func(tx repository.Transaction) error) (bool, error) {
+	if r.checkErr != nil {
+		return false, r.checkErr
+	}
+	if expiresAt, ok := r.processed[messageID]; ok && expiresAt.After(time.Now()) {
+		return false, nil
+	}
+
+	r.processed[messageID] = time.Now().Add(ttl)
+	if err := handle(nil); err != nil {
+		delete(r.processed, messageID)
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *memoryProcessedMessages) DeleteExpired() (int64, error) {
+	return 0, nil
+}
+
+type countingProductRepository struct {
+	repository.ProductRepository
+	lookups int
+}
+
+func (r *countingProductRepository) GetByID(id string) (*domain.Product, error) {
+	r.lookups++
+	return &domain.Product{ID: id}, nil
+}
+
+func TestMessageUseCase_SkipsAlreadyProcessedMessages(t *testing.T) {
+	products := &countingProductRepository{}
+	processed := &memoryProcessedMessages{processed: map[string]time.Time{}}
+	uc := NewMessageUseCase(nil, products, cache.New(), processed)
+
+	meta := domain.MessageMetadata{ID: "message-1", CreatedAt: time.Now()}
+	event := domain.ProductUpdatedEvent{ProductID: "p1", UpdatedAt: time.Now()}
+
+	assert.NoError(t, uc.HandleProductUpdatedEvent(meta, event))
+	assert.NoError(t, uc.HandleProductUpdatedEvent(meta, event))
+	assert.Equal(t, 1, products.lookups)
+
+	assert.NoError(t, uc.HandleProductUpdatedEvent(domain.MessageMetadata{ID: "message-2"}, event))
+	assert.Equal(t, 2, products.lookups)
+}
+
+func TestMessageUseCase_ClaimsMessagesBeforeHandlingThem(t *testing.T) {
+	processed := &memoryProcessedMessages{processed: map[string]time.Time{}}
+	meta := domain.MessageMetadata{ID: "message-1"}
+
+	// A redelivery arriving while the first delivery is being handled finds
+	// the message claimed.
+	handled := 0
+	err := handleOnce(processed, meta, func() error {
+		handled++
+		return handleOnce(processed, meta, func() error {
+			handled++
+			return nil
+		})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handled)
+
+	// A failed attempt gives the claim up, so the message is retried.
+	meta = domain.MessageMetadata{ID: "message-2"}
+	assert.Error(t, handleOnce(processed, meta, func() error { return errors.New("cache unavailable") }))
+	assert.NoError(t, handleOnce(processed, meta, func() error {
+		handled++
+		return nil
+	}))
+	assert.Equal(t, 2, handled)
+}
+
+func TestMessageUseCase_ReturnsErrorWhenDedupStoreFails(t *testing.T) {
+	products := &countingProductRepository{}
+	processed := &memoryProcessedMessages{
+		processed: map[string]time.Time{},
+		checkErr:  errors.New("connection refused"),
+	}
+	uc := NewMessageUseCase(nil, products, cache.New(), processed)
+
+	err := uc.HandleProductUpdatedEvent(domain.MessageMetadata{ID: "message-1"}, domain.ProductUpdatedEvent{ProductID: "p1"})
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, products.lookups)
+}
@@ -1,8 +1,11 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"AdvProg2/domain"
@@ -11,24 +14,79 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultStockReservationTTL is how long a pending order holds its stock
+// when no other TTL is configured.
+const DefaultStockReservationTTL = 15 * time.Minute
+
+const (
+	reservationReapInterval  = time.Minute
+	reservationReapBatchSize = 100
+	// reservationRetryDelay is how long the reaper leaves an order alone
+	// after failing to release it.
+	reservationRetryDelay = 10 * time.Minute
+)
+
 type OrderUseCase struct {
-	orderRepo      repository.OrderRepository
-	productRepo    repository.ProductRepository
-	messageUseCase *MessageUseCase
+	orderRepo       repository.OrderRepository
+	productRepo     repository.ProductRepository
+	reservationRepo repository.StockReservationRepository
+	unitOfWork      repository.UnitOfWork
+	rates           repository.ExchangeRateProvider
+	taxes           repository.TaxCalculator
+	fees            repository.DeliveryFeeCalculator
+	reservationTTL  time.Duration
 }
 
-func NewOrderUseCase(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, messageUseCase *MessageUseCase) *OrderUseCase {
+// OrderOptions are the choices a user makes when placing an order besides
+// its items.
+type OrderOptions struct {
+	// Currency the order is priced in. Empty keeps the catalog's base
+	// currency.
+	Currency string
+	// CouponCode of a coupon to redeem, if any.
+	CouponCode string
+	// Region the order is taxed for. Empty uses the delivery address's
+	// region.
+	Region string
+	// AddressID of one of the user's saved addresses to deliver to. Orders
+	// without one are not delivered and pay no delivery fee.
+	AddressID string
+	// DeliverySlotID of the slot to deliver in, if any.
+	DeliverySlotID string
+}
+
+// NewOrderUseCase creates the order use case. New orders hold their stock
+// for reservationTTL, or DefaultStockReservationTTL if it is not positive,
+// and are cancelled by RunReservationReaper if they are still pending then.
+func NewOrderUseCase(orderRepo repository.OrderRepository, productRepo repository.ProductRepository, reservationRepo repository.StockReservationRepository, unitOfWork repository.UnitOfWork, rates repository.ExchangeRateProvider, taxes repository.TaxCalculator, fees repository.DeliveryFeeCalculator, reservationTTL time.Duration) *OrderUseCase {
+	if reservationTTL <= 0 {
+		reservationTTL = DefaultStockReservationTTL
+	}
+
 	return &OrderUseCase{
-		orderRepo:      orderRepo,
-		productRepo:    productRepo,
-		messageUseCase: messageUseCase,
+		orderRepo:       orderRepo,
+		productRepo:     productRepo,
+		reservationRepo: reservationRepo,
+		unitOfWork:      unitOfWork,
+		rates:           rates,
+		taxes:           taxes,
+		fees:            fees,
+		reservationTTL:  reservationTTL,
 	}
 }
 
+// CreateOrder places an order priced in options.Currency, converting catalog
+// prices at the current exchange rate. The running automatic promotions and
+// the coupon with options.CouponCode, if given, are taken off the total; see
+// applyPromotions. Tax is then charged on what is left of each line at the
+// rates for the order's region. Orders delivered to one of the user's
+// addresses pay the fee quoted for it on their discounted value and book a
+// place in the chosen delivery slot, failing with
+// repository.ErrDeliverySlotFull if there is none left.
 func (uc *OrderUseCase) CreateOrder(userID string, orderItems []struct {
 	ProductID string
 	Quantity  int32
-}) (*domain.Order, error) {
+}, options OrderOptions) (*domain.Order, error) {
 	if userID == "" {
 		return nil, errors.New("user ID cannot be empty")
 	}
@@ -37,64 +95,334 @@ func (uc *OrderUseCase) CreateOrder(userID string, orderItems []struct {
 		return nil, errors.New("order must have at least one item")
 	}
 
-	var totalPrice float64
-	var orderItemsEntities []*domain.OrderItem
-
 	for _, item := range orderItems {
 		if item.Quantity <= 0 {
 			return nil, errors.New("product quantity must be positive")
 		}
+	}
+
+	// Reserve stock in product ID order so that concurrent orders lock rows
+	// in the same sequence and cannot deadlock each other. Orders lock their
+	// products, then their promotions, then their delivery slot, the same
+	// order changeStatus takes them in when an order is cancelled.
+	lockOrder := make([]int, len(orderItems))
+	for i := range lockOrder {
+		lockOrder[i] = i
+	}
+	sort.SliceStable(lockOrder, func(a, b int) bool {
+		return orderItems[lockOrder[a]].ProductID < orderItems[lockOrder[b]].ProductID
+	})
+
+	var order *domain.Order
+	orderID := uuid.New().String()
+
+	err := uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		var subtotal domain.Money
+		var rate domain.ExchangeRate
+		orderItemsEntities := make([]*domain.OrderItem, len(orderItems))
+		reservations := make([]*domain.StockReservation, 0, len(orderItems))
+		now := time.Now()
+
+		region := options.Region
+		var address *domain.Address
+		if options.AddressID != "" {
+			saved, err := tx.Addresses().GetByID(options.AddressID)
+			if err == nil && saved.UserID != userID {
+				err = repository.ErrAddressNotFound
+			}
+			if err != nil {
+				return err
+			}
+			address = saved
+			if region == "" {
+				region = address.Region
+			}
+		}
+
+		if options.DeliverySlotID != "" && address == nil {
+			return fmt.Errorf("%w: only orders with a delivery address can book one", domain.ErrInvalidDeliverySlot)
+		}
+
+		for _, i := range lockOrder {
+			item := orderItems[i]
+
+			movement := &domain.StockMovement{
+				ID:          uuid.New().String(),
+				ProductID:   item.ProductID,
+				Delta:       -item.Quantity,
+				Reason:      domain.StockMovementOrder,
+				ReferenceID: orderID,
+			}
+			product, err := tx.StockMovements().Record(movement)
+			if err != nil {
+				return err
+			}
+
+			if err := addLowStockAlert(tx, product, movement); err != nil {
+				return err
+			}
+
+			reservations = append(reservations, &domain.StockReservation{
+				ID:        uuid.New().String(),
+				OrderID:   orderID,
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				Status:    domain.StockReservationActive,
+				ExpiresAt: now.Add(uc.reservationTTL),
+				CreatedAt: now,
+			})
+
+			if rate.From == "" {
+				to := options.Currency
+				if to == "" {
+					to = product.Price.Currency
+				}
+				if rate, err = uc.rates.Rate(product.Price.Currency, to); err != nil {
+					return err
+				}
+			}
+
+			// Prices in one order must share a base currency so that a
+			// single rate applies to all of them.
+			price, err := product.Price.Convert(rate)
+			if err != nil {
+				return err
+			}
+
+			orderItemsEntities[i] = &domain.OrderItem{
+				ID:        uuid.New().String(),
+				ProductID: product.ID,
+				Quantity:  item.Quantity,
+				Price:     price,
+				Product:   product,
+			}
+			subtotal, err = subtotal.Add(price.Multiply(int64(item.Quantity)))
+			if err != nil {
+				return err
+			}
+		}
 
-		product, err := uc.productRepo.GetByID(item.ProductID)
+		discounts, discountTotal, err := applyPromotions(tx, orderID, userID, options.CouponCode, orderItemsEntities, subtotal, rate, now)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		if product.Stock < item.Quantity {
-			return nil, errors.New("not enough stock for product: " + product.Name)
+		var slot *domain.DeliverySlot
+		if options.DeliverySlotID != "" {
+			if slot, err = tx.DeliverySlots().Book(options.DeliverySlotID, now); err != nil {
+				return err
+			}
 		}
 
-		product.Stock -= item.Quantity
-		err = uc.productRepo.Update(product)
+		lines, err := taxableLines(tx, orderItemsEntities, discounts)
+		if err != nil {
+			return err
+		}
+		tax, err := uc.taxes.Calculate(region, lines)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		// The zero Money has no currency, so untaxed orders start from a
+		// zero amount in the order's currency.
+		taxTotal, err := domain.NewMoney(0, subtotal.Currency).Add(tax.Tax)
+		if err != nil {
+			return err
+		}
+		totalPrice, err := subtotal.Sub(discountTotal)
+		if err != nil {
+			return err
+		}
+		delivery, err := uc.fees.Quote(address, totalPrice, rate)
+		if err != nil {
+			return err
+		}
+		if totalPrice, err = totalPrice.Add(tax.Added); err != nil {
+			return err
+		}
+		if totalPrice, err = totalPrice.Add(delivery.Fee); err != nil {
+			return err
+		}
+
+		order = &domain.Order{
+			ID:            orderID,
+			UserID:        userID,
+			Status:        domain.OrderStatusPending,
+			TotalPrice:    totalPrice,
+			Subtotal:      subtotal,
+			DiscountTotal: discountTotal,
+			TaxTotal:      taxTotal,
+			TaxRegion:     region,
+			DeliveryFee:   delivery.Fee,
+			DeliveryZone:  delivery.Zone,
+			DeliverySlot:  slot,
+			ExchangeRate:  rate,
+			CreatedAt:     now,
+			Items:         orderItemsEntities,
+			Discounts:     discounts,
+		}
+		if address != nil {
+			// The order keeps the address as it was when it was placed.
+			delivered := *address
+			order.DeliveryAddress = &delivered
 		}
 
-		orderItem := &domain.OrderItem{
+		if err := tx.Orders().Create(order); err != nil {
+			return err
+		}
+
+		if err := tx.StockReservations().Create(reservations); err != nil {
+			return err
+		}
+
+		err = tx.Orders().AddStatusChange(&domain.OrderStatusChange{
 			ID:        uuid.New().String(),
-			ProductID: product.ID,
-			Quantity:  item.Quantity,
-			Price:     product.Price,
-			Product:   product,
+			OrderID:   order.ID,
+			ToStatus:  order.Status,
+			Actor:     domain.Actor{ID: userID, Role: domain.ActorRoleUser},
+			ChangedAt: order.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		message, err := newOutboxMessage("order", order.ID, domain.EventOrderCreated, newOrderCreatedEvent(order))
+		if err != nil {
+			return err
+		}
+		if err := tx.Outbox().Add(message); err != nil {
+			return err
+		}
+
+		event := newStockReservationEvent(order.ID, reservations, domain.StockReservationActive, "", now)
+		message, err = newOutboxMessage("order", order.ID, domain.EventStockReserved, event)
+		if err != nil {
+			return err
+		}
+
+		return tx.Outbox().Add(message)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// applyPromotions redeems for the order the running automatic promotions
+// and the coupon with couponCode, if given, and returns the discounts they
+// give on items and their sum. Each promotion is worked out on the full
+// prices. Automatic promotions are skipped when they take nothing off, are
+// used up or would take the total below zero; the coupon fails the order
+// with domain.ErrCouponNotApplicable instead.
+func applyPromotions(tx repository.Transaction, orderID, userID, couponCode string, items []*domain.OrderItem, subtotal domain.Money, rate domain.ExchangeRate, now time.Time) ([]*domain.OrderDiscount, domain.Money, error) {
+	discountTotal := domain.NewMoney(0, subtotal.Currency)
+
+	promotions, err := tx.Promotions().ListAutomatic(now)
+	if err != nil {
+		return nil, discountTotal, err
+	}
+
+	if couponCode = domain.NormalizeCouponCode(couponCode); couponCode != "" {
+		coupon, err := tx.Promotions().GetByCode(couponCode)
+		if errors.Is(err, repository.ErrPromotionNotFound) || (err == nil && !coupon.IsRunningAt(now)) {
+			return nil, discountTotal, fmt.Errorf("%w: %s is not valid", domain.ErrCouponNotApplicable, couponCode)
 		}
+		if err != nil {
+			return nil, discountTotal, err
+		}
+		promotions = append(promotions, coupon)
+	}
 
-		orderItemsEntities = append(orderItemsEntities, orderItem)
-		totalPrice += product.Price * float64(item.Quantity)
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
 	}
 
-	order := &domain.Order{
-		ID:         uuid.New().String(),
-		UserID:     userID,
-		Status:     "pending",
-		TotalPrice: totalPrice,
-		CreatedAt:  time.Now(),
-		Items:      orderItemsEntities,
+	var discounts []*domain.OrderDiscount
+	for _, promotion := range promotions {
+		isCoupon := promotion.Code != ""
+
+		covers := func(string) bool { return true }
+		if promotion.CategoryID != "" {
+			ids, err := tx.Products().ListIDsInCategory(promotion.CategoryID, productIDs)
+			if err != nil {
+				return nil, discountTotal, err
+			}
+			inCategory := make(map[string]bool, len(ids))
+			for _, id := range ids {
+				inCategory[id] = true
+			}
+			covers = func(productID string) bool { return inCategory[productID] }
+		}
+
+		promotionDiscounts, err := promotion.Discounts(items, covers, rate)
+		if err != nil {
+			return nil, discountTotal, err
+		}
+
+		total := discountTotal
+		for _, discount := range promotionDiscounts {
+			if total, err = total.Add(discount.Amount); err != nil {
+				return nil, discountTotal, err
+			}
+		}
+
+		if len(promotionDiscounts) == 0 || total.Amount > subtotal.Amount {
+			if isCoupon {
+				return nil, discountTotal, fmt.Errorf("%w: %s takes nothing off", domain.ErrCouponNotApplicable, couponCode)
+			}
+			continue
+		}
+
+		err = tx.Promotions().Redeem(promotion.ID, orderID, userID)
+		if errors.Is(err, repository.ErrPromotionUsageLimitReached) || errors.Is(err, repository.ErrPromotionUserLimitReached) {
+			if isCoupon {
+				return nil, discountTotal, fmt.Errorf("%w: %w", domain.ErrCouponNotApplicable, err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, discountTotal, err
+		}
+
+		for _, discount := range promotionDiscounts {
+			discount.ID = uuid.New().String()
+			discount.OrderID = orderID
+		}
+		discounts = append(discounts, promotionDiscounts...)
+		discountTotal = total
 	}
 
-	err := uc.orderRepo.Create(order)
+	return discounts, discountTotal, nil
+}
+
+// taxableLines returns the taxable amount of each item, with the ancestors
+// of its category so that rules for a parent category apply to it.
+func taxableLines(tx repository.Transaction, items []*domain.OrderItem, discounts []*domain.OrderDiscount) ([]domain.TaxableLine, error) {
+	lines, err := domain.TaxableLines(items, discounts)
 	if err != nil {
 		return nil, err
 	}
 
-	if uc.messageUseCase != nil {
-		if err := uc.messageUseCase.PublishOrderCreatedEvent(order); err != nil {
-			log.Printf("Warning: Failed to publish order created event: %v", err)
-		} else {
-			log.Printf("Successfully published order.created event for order %s", order.ID)
+	var categoryIDs []string
+	for _, line := range lines {
+		if line.CategoryID != "" {
+			categoryIDs = append(categoryIDs, line.CategoryID)
 		}
 	}
+	if len(categoryIDs) == 0 {
+		return lines, nil
+	}
 
-	return order, nil
+	ancestors, err := tx.Products().ListCategoryAncestors(categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		lines[i].CategoryAncestors = ancestors[lines[i].CategoryID]
+	}
+	return lines, nil
 }
 
 func (uc *OrderUseCase) GetOrder(id string) (*domain.Order, error) {
@@ -121,23 +449,53 @@ func (uc *OrderUseCase) GetUserOrders(userID string, page, limit int32) ([]*doma
 	return uc.orderRepo.GetByUserID(userID, page, limit)
 }
 
-func (uc *OrderUseCase) UpdateOrderStatus(id, status string) (*domain.Order, error) {
+// GetUserOrdersPage returns the page of the user's orders, newest first,
+// that follows page.Token. The orders are counted only when page.WithTotal
+// is set.
+func (uc *OrderUseCase) GetUserOrdersPage(userID string, page repository.PageRequest) (*repository.Page[*domain.Order], error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	if page.Limit <= 0 {
+		page.Limit = 10
+	}
+
+	return uc.orderRepo.GetPageByUserID(userID, page)
+}
+
+// GetOrderHistory returns every status the order has had, oldest first.
+// Orders of other users are reported as repository.ErrOrderNotFound unless
+// actor is an admin.
+func (uc *OrderUseCase) GetOrderHistory(actor domain.Actor, id string) ([]*domain.OrderStatusChange, error) {
 	if id == "" {
 		return nil, errors.New("order ID cannot be empty")
 	}
 
-	if status == "" {
-		return nil, errors.New("status cannot be empty")
+	order, err := uc.orderRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Role != domain.ActorRoleAdmin && order.UserID != actor.ID {
+		return nil, repository.ErrOrderNotFound
 	}
 
-	validStatuses := map[string]bool{
-		"pending":   true,
-		"completed": true,
-		"cancelled": true,
+	return uc.orderRepo.GetStatusHistory(id)
+}
+
+// UpdateOrderStatus moves the order to status on behalf of actor, following
+// the transitions allowed by domain.CanTransition, and records the change
+// with reason in the order's status history. Confirming commits the order's
+// stock reservations, and cancelling releases them and returns the ordered
+// quantities to stock. The change is published as order.status_changed, plus
+// order.cancelled or order.completed.
+func (uc *OrderUseCase) UpdateOrderStatus(id string, status domain.OrderStatus, actor domain.Actor, reason string) (*domain.Order, error) {
+	if id == "" {
+		return nil, errors.New("order ID cannot be empty")
 	}
 
-	if !validStatuses[status] {
-		return nil, errors.New("invalid status")
+	if _, err := domain.ParseOrderStatus(string(status)); err != nil {
+		return nil, err
 	}
 
 	order, err := uc.orderRepo.GetByID(id)
@@ -145,38 +503,256 @@ func (uc *OrderUseCase) UpdateOrderStatus(id, status string) (*domain.Order, err
 		return nil, err
 	}
 
-	if order.Status == "completed" || order.Status == "cancelled" {
-		return nil, errors.New("cannot change status of a completed or cancelled order")
+	if err := order.CheckTransition(status, actor); err != nil {
+		return nil, err
+	}
+
+	if err := uc.changeStatus(order, status, actor, reason, domain.ReservationReleaseCancelled); err != nil {
+		return nil, err
 	}
 
-	if status == "cancelled" && order.Status != "cancelled" {
-		for _, item := range order.Items {
-			product, err := uc.productRepo.GetByID(item.ProductID)
-			if err != nil {
-				return nil, err
+	return uc.orderRepo.GetByID(id)
+}
+
+// changeStatus moves order from its current status to status. releaseReason
+// is published if the change releases the order's reservations.
+func (uc *OrderUseCase) changeStatus(order *domain.Order, status domain.OrderStatus, actor domain.Actor, reason, releaseReason string) error {
+	return uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		// Moving from the status checked by the caller fails if a
+		// concurrent request got there first, so stock is never returned
+		// twice.
+		if err := tx.Orders().UpdateStatus(order.ID, order.Status, status); err != nil {
+			return err
+		}
+
+		change := &domain.OrderStatusChange{
+			ID:         uuid.New().String(),
+			OrderID:    order.ID,
+			FromStatus: order.Status,
+			ToStatus:   status,
+			Actor:      actor,
+			Reason:     reason,
+			ChangedAt:  time.Now(),
+		}
+		if err := tx.Orders().AddStatusChange(change); err != nil {
+			return err
+		}
+
+		var restocked []*domain.OrderItem
+		if status == domain.OrderStatusCancelled {
+			// Stock is returned in product ID order, and the promotions and
+			// the slot are released after it, so cancellations take locks in
+			// the same sequence as CreateOrder.
+			items := make([]*domain.OrderItem, len(order.Items))
+			copy(items, order.Items)
+			sort.SliceStable(items, func(a, b int) bool {
+				return items[a].ProductID < items[b].ProductID
+			})
+			for _, item := range items {
+				_, err := tx.StockMovements().Record(&domain.StockMovement{
+					ID:          uuid.New().String(),
+					ProductID:   item.ProductID,
+					Delta:       item.Quantity,
+					Reason:      domain.StockMovementCancellation,
+					ReferenceID: order.ID,
+				})
+				if err != nil {
+					return err
+				}
+				restocked = append(restocked, item)
 			}
 
-			product.Stock += item.Quantity
-			err = uc.productRepo.Update(product)
-			if err != nil {
-				return nil, err
+			// A cancelled order no longer counts towards the limits of the
+			// promotions it used.
+			if err := tx.Promotions().ReleaseRedemptions(order.ID); err != nil {
+				return err
+			}
+
+			// The order's slot has no ID if it was deleted since.
+			if order.DeliverySlot != nil && order.DeliverySlot.ID != "" {
+				if err := tx.DeliverySlots().Release(order.DeliverySlot.ID); err != nil {
+					return err
+				}
 			}
 		}
-	}
 
-	err = uc.orderRepo.UpdateStatus(id, status)
-	if err != nil {
-		return nil, err
-	}
+		if err := settleReservations(tx, order.ID, status, releaseReason, change.ChangedAt); err != nil {
+			return err
+		}
 
-	return uc.orderRepo.GetByID(id)
+		return addOrderStatusMessages(tx.Outbox(), order, change, restocked)
+	})
 }
 
-func (uc *OrderUseCase) CancelOrder(id string) error {
+func (uc *OrderUseCase) CancelOrder(id string, actor domain.Actor, reason string) error {
 	if id == "" {
 		return errors.New("order ID cannot be empty")
 	}
 
-	_, err := uc.UpdateOrderStatus(id, "cancelled")
+	_, err := uc.UpdateOrderStatus(id, domain.OrderStatusCancelled, actor, reason)
 	return err
 }
+
+// addOrderStatusMessages queues order.status_changed for the change and, for
+// cancellations and deliveries, order.cancelled or order.completed.
+func addOrderStatusMessages(outbox repository.OutboxRepository, order *domain.Order, change *domain.OrderStatusChange, restocked []*domain.OrderItem) error {
+	event := newOrderStatusChangedEvent(order, change, restocked)
+
+	message, err := newOutboxMessage("order", order.ID, domain.EventOrderStatusChanged, event)
+	if err != nil {
+		return err
+	}
+	if err := outbox.Add(message); err != nil {
+		return err
+	}
+
+	switch change.ToStatus {
+	case domain.OrderStatusCancelled:
+		message, err = newOutboxMessage("order", order.ID, domain.EventOrderCancelled, domain.OrderCancelledEvent(event))
+	case domain.OrderStatusDelivered:
+		message, err = newOutboxMessage("order", order.ID, domain.EventOrderCompleted, domain.OrderCompletedEvent(event))
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return outbox.Add(message)
+}
+
+// settleReservations commits the order's active reservations when it is
+// confirmed and releases them when it is cancelled, and queues the matching
+// event. Reservations already settled are left alone.
+func settleReservations(tx repository.Transaction, orderID string, status domain.OrderStatus, releaseReason string, at time.Time) error {
+	var resolved domain.StockReservationStatus
+	var subject string
+	switch status {
+	case domain.OrderStatusConfirmed:
+		resolved, subject, releaseReason = domain.StockReservationCommitted, domain.EventStockReservationCommitted, ""
+	case domain.OrderStatusCancelled:
+		resolved, subject = domain.StockReservationReleased, domain.EventStockReservationReleased
+	default:
+		return nil
+	}
+
+	reservations, err := tx.StockReservations().Resolve(orderID, resolved, at)
+	if err != nil || len(reservations) == 0 {
+		return err
+	}
+
+	event := newStockReservationEvent(orderID, reservations, resolved, releaseReason, at)
+	message, err := newOutboxMessage("order", orderID, subject, event)
+	if err != nil {
+		return err
+	}
+
+	return tx.Outbox().Add(message)
+}
+
+func newStockReservationEvent(orderID string, reservations []*domain.StockReservation, status domain.StockReservationStatus, reason string, at time.Time) domain.StockReservationEvent {
+	event := domain.StockReservationEvent{
+		OrderID:   orderID,
+		Items:     make([]domain.ReservedItemEvent, 0, len(reservations)),
+		Status:    status,
+		Reason:    reason,
+		ChangedAt: at,
+	}
+	for _, reservation := range reservations {
+		event.Items = append(event.Items, domain.ReservedItemEvent{
+			ProductID: reservation.ProductID,
+			Quantity:  reservation.Quantity,
+		})
+		if reservation.ExpiresAt.After(event.ExpiresAt) {
+			event.ExpiresAt = reservation.ExpiresAt
+		}
+	}
+	return event
+}
+
+// ReleaseExpiredReservations cancels up to one batch of pending orders whose
+// stock reservations expired by now, returning their stock, and reports how
+// many it cancelled. Orders confirmed or cancelled in the meantime are
+// skipped. An order that cannot be released does not hold up the rest of the
+// batch; the errors are returned together at the end.
+func (uc *OrderUseCase) ReleaseExpiredReservations(now time.Time) (int, error) {
+	orderIDs, err := uc.reservationRepo.ListExpiredOrderIDs(now, reservationReapBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	var errs []error
+	for _, id := range orderIDs {
+		released, err := uc.releaseExpiredOrder(id, now)
+		if err != nil {
+			log.Printf("Failed to release expired stock reservations of order %s: %v", id, err)
+			errs = append(errs, fmt.Errorf("order %s: %w", id, err))
+			// Put the order aside so the next runs get to the ones after
+			// it instead of listing the same failing batch again.
+			if err := uc.reservationRepo.DeferRelease(id, now.Add(reservationRetryDelay)); err != nil {
+				log.Printf("Failed to defer releasing stock reservations of order %s: %v", id, err)
+				errs = append(errs, fmt.Errorf("order %s: %w", id, err))
+			}
+			continue
+		}
+		if released {
+			cancelled++
+		}
+	}
+
+	return cancelled, errors.Join(errs...)
+}
+
+// releaseExpiredOrder cancels the order if it is still pending, reporting
+// whether it did, or settles the reservations it left behind otherwise.
+func (uc *OrderUseCase) releaseExpiredOrder(id string, now time.Time) (bool, error) {
+	order, err := uc.orderRepo.GetByID(id)
+	if err != nil {
+		return false, err
+	}
+
+	if order.Status != domain.OrderStatusPending {
+		// Only pending orders hold active reservations, so these are
+		// left over and only need settling.
+		return false, uc.unitOfWork.Do(func(tx repository.Transaction) error {
+			status := domain.OrderStatusConfirmed
+			if order.Status == domain.OrderStatusCancelled {
+				status = domain.OrderStatusCancelled
+			}
+			return settleReservations(tx, id, status, domain.ReservationReleaseExpired, now)
+		})
+	}
+
+	err = uc.changeStatus(order, domain.OrderStatusCancelled, domain.SystemActor,
+		"stock reservation expired", domain.ReservationReleaseExpired)
+	if errors.Is(err, repository.ErrOrderStatusChanged) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RunReservationReaper cancels orders whose stock reservations have expired
+// until ctx is cancelled.
+func (uc *OrderUseCase) RunReservationReaper(ctx context.Context) {
+	log.Printf("Stock reservation reaper started, reservations last %s", uc.reservationTTL)
+
+	ticker := time.NewTicker(reservationReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stock reservation reaper stopped")
+			return
+		case <-ticker.C:
+			cancelled, err := uc.ReleaseExpiredReservations(time.Now())
+			if err != nil {
+				log.Printf("Failed to release expired stock reservations: %v", err)
+			}
+			if cancelled > 0 {
+				log.Printf("Cancelled %d orders whose stock reservations expired", cancelled)
+			}
+		}
+	}
+}
@@ -0,0 +1,1033 @@
+package usecase
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type memoryOrderRepository struct {
+	repository.OrderRepository
+	orders  map[string]*domain.Order
+	history []*domain.OrderStatusChange
+}
+
+func (r *memoryOrderRepository) Create(order *domain.Order) error {
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *memoryOrderRepository) GetByID(id string) (*domain.Order, error) {
+	stored, ok := r.orders[id]
+	if !ok {
+		return nil, repository.ErrOrderNotFound
+	}
+	order := *stored
+	return &order, nil
+}
+
+func (r *memoryOrderRepository) UpdateStatus(id string, from, to domain.OrderStatus) error {
+	if r.orders[id].Status != from {
+		return repository.ErrOrderStatusChanged
+	}
+	r.orders[id].Status = to
+	return nil
+}
+
+func (r *memoryOrderRepository) AddStatusChange(change *domain.OrderStatusChange) error {
+	r.history = append(r.history, change)
+	return nil
+}
+
+func (r *memoryOrderRepository) GetStatusHistory(orderID string) ([]*domain.OrderStatusChange, error) {
+	var history []*domain.OrderStatusChange
+	for _, change := range r.history {
+		if change.OrderID == orderID {
+			history = append(history, change)
+		}
+	}
+	return history, nil
+}
+
+type memoryStockRepository struct {
+	repository.ProductRepository
+	repository.StockMovementRepository
+	stock      map[string]int32
+	prices     map[string]domain.Money
+	thresholds map[string]int32
+	categories map[string]string
+	parents    map[string]string
+	movements  []*domain.StockMovement
+}
+
+func (r *memoryStockRepository) Record(movement *domain.StockMovement) (*domain.Product, error) {
+	if r.stock[movement.ProductID]+movement.Delta < 0 {
+		return nil, repository.ErrInsufficientStock
+	}
+	r.stock[movement.ProductID] += movement.Delta
+	movement.StockAfter = r.stock[movement.ProductID]
+	r.movements = append(r.movements, movement)
+	return &domain.Product{
+		ID:               movement.ProductID,
+		Price:            r.prices[movement.ProductID],
+		Stock:            movement.StockAfter,
+		CategoryID:       r.categories[movement.ProductID],
+		ReorderThreshold: r.thresholds[movement.ProductID],
+	}, nil
+}
+
+func (r *memoryStockRepository) GetByID(id string) (*domain.Product, error) {
+	stock, ok := r.stock[id]
+	if !ok {
+		return nil, repository.ErrProductNotFound
+	}
+	return &domain.Product{ID: id, Price: r.prices[id], Stock: stock}, nil
+}
+
+// ListIDsInCategory only matches products directly in the category.
+func (r *memoryStockRepository) ListIDsInCategory(categoryID string, productIDs []string) ([]string, error) {
+	var ids []string
+	for _, id := range productIDs {
+		if r.categories[id] == categoryID {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryStockRepository) ListCategoryAncestors(categoryIDs []string) (map[string][]string, error) {
+	ancestors := map[string][]string{}
+	for _, id := range categoryIDs {
+		for parent := r.parents[id]; parent != ""; parent = r.parents[parent] {
+			ancestors[id] = append(ancestors[id], parent)
+		}
+	}
+	return ancestors, nil
+}
+
+type memoryReservationRepository struct {
+	repository.StockReservationRepository
+	reservations []*domain.StockReservation
+	retryAt      map[string]time.Time
+}
+
+func (r *memoryReservationRepository) Create(reservations []*domain.StockReservation) error {
+	r.reservations = append(r.reservations, reservations...)
+	return nil
+}
+
+func (r *memoryReservationRepository) Resolve(orderID string, status domain.StockReservationStatus, at time.Time) ([]*domain.StockReservation, error) {
+	var resolved []*domain.StockReservation
+	for _, reservation := range r.reservations {
+		if reservation.OrderID == orderID && reservation.Status == domain.StockReservationActive {
+			reservation.Status = status
+			reservation.ResolvedAt = &at
+			resolved = append(resolved, reservation)
+		}
+	}
+	return resolved, nil
+}
+
+func (r *memoryReservationRepository) ListExpiredOrderIDs(now time.Time, limit int32) ([]string, error) {
+	var orderIDs []string
+	seen := map[string]bool{}
+	for _, reservation := range r.reservations {
+		if retryAt, ok := r.retryAt[reservation.OrderID]; ok && retryAt.After(now) {
+			continue
+		}
+		if reservation.IsExpired(now) && !seen[reservation.OrderID] {
+			seen[reservation.OrderID] = true
+			orderIDs = append(orderIDs, reservation.OrderID)
+		}
+	}
+	return orderIDs, nil
+}
+
+func (r *memoryReservationRepository) DeferRelease(orderID string, retryAt time.Time) error {
+	if r.retryAt == nil {
+		r.retryAt = map[string]time.Time{}
+	}
+	r.retryAt[orderID] = retryAt
+	return nil
+}
+
+type memoryRedemption struct {
+	promotionID, orderID, userID string
+}
+
+type memoryPromotionRepository struct {
+	repository.PromotionRepository
+	promotions  []*domain.Promotion
+	redemptions []memoryRedemption
+}
+
+func (r *memoryPromotionRepository) GetByCode(code string) (*domain.Promotion, error) {
+	for _, promotion := range r.promotions {
+		if code != "" && promotion.Code == code {
+			return promotion, nil
+		}
+	}
+	return nil, repository.ErrPromotionNotFound
+}
+
+func (r *memoryPromotionRepository) ListAutomatic(now time.Time) ([]*domain.Promotion, error) {
+	var promotions []*domain.Promotion
+	for _, promotion := range r.promotions {
+		if promotion.Code == "" && promotion.IsRunningAt(now) {
+			promotions = append(promotions, promotion)
+		}
+	}
+	return promotions, nil
+}
+
+func (r *memoryPromotionRepository) Redeem(promotionID, orderID, userID string) error {
+	for _, promotion := range r.promotions {
+		if promotion.ID != promotionID {
+			continue
+		}
+		if promotion.UsageLimit > 0 && promotion.TimesUsed >= promotion.UsageLimit {
+			return repository.ErrPromotionUsageLimitReached
+		}
+		userUses := int32(0)
+		for _, redemption := range r.redemptions {
+			if redemption.promotionID == promotionID && redemption.userID == userID {
+				userUses++
+			}
+		}
+		if promotion.PerUserLimit > 0 && userUses >= promotion.PerUserLimit {
+			return repository.ErrPromotionUserLimitReached
+		}
+		promotion.TimesUsed++
+		r.redemptions = append(r.redemptions, memoryRedemption{promotionID, orderID, userID})
+		return nil
+	}
+	return repository.ErrPromotionNotFound
+}
+
+func (r *memoryPromotionRepository) ReleaseRedemptions(orderID string) error {
+	kept := r.redemptions[:0]
+	for _, redemption := range r.redemptions {
+		if redemption.orderID != orderID {
+			kept = append(kept, redemption)
+			continue
+		}
+		for _, promotion := range r.promotions {
+			if promotion.ID == redemption.promotionID {
+				promotion.TimesUsed--
+			}
+		}
+	}
+	r.redemptions = kept
+	return nil
+}
+
+type memoryAddressRepository struct {
+	repository.AddressRepository
+	addresses []*domain.Address
+}
+
+func (r *memoryAddressRepository) GetByID(id string) (*domain.Address, error) {
+	for _, address := range r.addresses {
+		if address.ID == id {
+			copied := *address
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrAddressNotFound
+}
+
+type memoryDeliverySlotRepository struct {
+	repository.DeliverySlotRepository
+	slots []*domain.DeliverySlot
+}
+
+func (r *memoryDeliverySlotRepository) slot(id string) (*domain.DeliverySlot, error) {
+	for _, slot := range r.slots {
+		if slot.ID == id {
+			return slot, nil
+		}
+	}
+	return nil, repository.ErrDeliverySlotNotFound
+}
+
+func (r *memoryDeliverySlotRepository) ListFrom(from time.Time) ([]*domain.DeliverySlot, error) {
+	var slots []*domain.DeliverySlot
+	for _, slot := range r.slots {
+		if slot.StartsAt.After(from) {
+			slots = append(slots, slot)
+		}
+	}
+	return slots, nil
+}
+
+func (r *memoryDeliverySlotRepository) Book(id string, now time.Time) (*domain.DeliverySlot, error) {
+	slot, err := r.slot(id)
+	if err != nil {
+		return nil, err
+	}
+	if !now.Before(slot.StartsAt) {
+		return nil, domain.ErrDeliverySlotClosed
+	}
+	if slot.Remaining() == 0 {
+		return nil, repository.ErrDeliverySlotFull
+	}
+	slot.Booked++
+	copied := *slot
+	return &copied, nil
+}
+
+func (r *memoryDeliverySlotRepository) Release(id string) error {
+	slot, err := r.slot(id)
+	if err == nil && slot.Booked > 0 {
+		slot.Booked--
+	}
+	return nil
+}
+
+type memoryTransaction struct {
+	orders       *memoryOrderRepository
+	products     *memoryStockRepository
+	reservations *memoryReservationRepository
+	promotions   *memoryPromotionRepository
+	addresses    *memoryAddressRepository
+	slots        *memoryDeliverySlotRepository
+	payments     *memoryPaymentRepository
+	outbox       *memoryOutbox
+}
+
+func (t *memoryTransaction) Orders() repository.OrderRepository                 { return t.orders }
+func (t *memoryTransaction) Products() repository.ProductRepository             { return t.products }
+func (t *memoryTransaction) StockMovements() repository.StockMovementRepository { return t.products }
+func (t *memoryTransaction) StockReservations() repository.StockReservationRepository {
+	return t.reservations
+}
+func (t *memoryTransaction) Outbox() repository.OutboxRepository { return t.outbox }
+
+// Promotions starts out empty for tests that do not set up promotions.
+func (t *memoryTransaction) Promotions() repository.PromotionRepository {
+	if t.promotions == nil {
+		t.promotions = &memoryPromotionRepository{}
+	}
+	return t.promotions
+}
+
+func (t *memoryTransaction) Addresses() repository.AddressRepository {
+	if t.addresses == nil {
+		t.addresses = &memoryAddressRepository{}
+	}
+	return t.addresses
+}
+
+func (t *memoryTransaction) DeliverySlots() repository.DeliverySlotRepository {
+	if t.slots == nil {
+		t.slots = &memoryDeliverySlotRepository{}
+	}
+	return t.slots
+}
+
+func (t *memoryTransaction) Payments() repository.PaymentRepository {
+	if t.payments == nil {
+		t.payments = &memoryPaymentRepository{}
+	}
+	return t.payments
+}
+
+// memoryFees charges the fee of the zone named after the address's city.
+// Orders without an address are free and cities with no zone are not
+// delivered to.
+type memoryFees map[string]domain.DeliveryZone
+
+func (f memoryFees) Quote(address *domain.Address, orderValue domain.Money, rate domain.ExchangeRate) (domain.DeliveryQuote, error) {
+	if address == nil {
+		return domain.DeliveryQuote{Fee: domain.NewMoney(0, orderValue.Currency)}, nil
+	}
+	zone, ok := f[address.City]
+	if !ok {
+		return domain.DeliveryQuote{}, domain.ErrNotDeliverable
+	}
+	return zone.Quote(orderValue, rate)
+}
+
+type memoryUnitOfWork struct {
+	tx *memoryTransaction
+}
+
+func (u *memoryUnitOfWork) Do(fn func(tx repository.Transaction) error) error {
+	return fn(u.tx)
+}
+
+// memoryRates quotes rates from any currency, keyed by the target currency.
+type memoryRates map[string]string
+
+func (r memoryRates) Rate(from, to string) (domain.ExchangeRate, error) {
+	if from == to {
+		return domain.IdentityRate(from), nil
+	}
+	rate, ok := r[to]
+	if !ok {
+		return domain.ExchangeRate{}, domain.ErrUnsupportedCurrency
+	}
+	return domain.NewExchangeRate(from, to, rate)
+}
+
+// memoryTaxes charges every line the rule for the order's region. Regions
+// without a rule are not taxed.
+type memoryTaxes map[string]domain.TaxRule
+
+func (t memoryTaxes) Calculate(region string, lines []domain.TaxableLine) (domain.TaxBreakdown, error) {
+	var breakdown domain.TaxBreakdown
+	rule, ok := t[region]
+	if !ok {
+		return breakdown, nil
+	}
+	for _, line := range lines {
+		tax, err := rule.Tax(line.Amount)
+		if err != nil {
+			return breakdown, err
+		}
+		breakdown.Tax, _ = breakdown.Tax.Add(tax)
+		if !rule.Inclusive {
+			breakdown.Added, _ = breakdown.Added.Add(tax)
+		}
+	}
+	return breakdown, nil
+}
+
+// lineRecordingTaxes charges nothing and remembers the lines it was given.
+type lineRecordingTaxes struct {
+	lines []domain.TaxableLine
+}
+
+func (t *lineRecordingTaxes) Calculate(region string, lines []domain.TaxableLine) (domain.TaxBreakdown, error) {
+	t.lines = lines
+	return domain.TaxBreakdown{}, nil
+}
+
+func newOrderUseCaseWithOrder(order *domain.Order) (*OrderUseCase, *memoryTransaction) {
+	tx := &memoryTransaction{
+		orders:       &memoryOrderRepository{orders: map[string]*domain.Order{order.ID: order}},
+		products:     &memoryStockRepository{stock: map[string]int32{}},
+		reservations: &memoryReservationRepository{},
+		outbox:       &memoryOutbox{},
+	}
+	return newOrderUseCase(tx, memoryRates{}), tx
+}
+
+func newOrderUseCase(tx *memoryTransaction, rates memoryRates) *OrderUseCase {
+	return NewOrderUseCase(tx.orders, tx.products, tx.reservations, &memoryUnitOfWork{tx: tx}, rates, memoryTaxes{}, memoryFees{}, time.Minute)
+}
+
+func outboxEvent[T any](t *testing.T, message *domain.OutboxMessage) T {
+	t.Helper()
+
+	var envelope domain.Message
+	assert.NoError(t, json.Unmarshal(message.Payload, &envelope))
+
+	var event T
+	assert.NoError(t, json.Unmarshal(envelope.Data, &event))
+	return event
+}
+
+func TestUpdateOrderStatus_CancelPublishesRestockedItems(t *testing.T) {
+	uc, tx := newOrderUseCaseWithOrder(&domain.Order{
+		ID:     "o1",
+		UserID: "u1",
+		Status: "pending",
+		Items: []*domain.OrderItem{
+			{ProductID: "p1", Quantity: 2, Price: domain.NewMoney(300, "KZT")},
+			{ProductID: "p2", Quantity: 1, Price: domain.NewMoney(500, "KZT")},
+		},
+	})
+	actor := domain.Actor{ID: "admin-1", Role: domain.ActorRoleAdmin}
+
+	assert.NoError(t, uc.CancelOrder("o1", actor, "customer asked"))
+
+	assert.Equal(t, map[string]int32{"p1": 2, "p2": 1}, tx.products.stock)
+	for _, movement := range tx.products.movements {
+		assert.Equal(t, domain.StockMovementCancellation, movement.Reason)
+		assert.Equal(t, "o1", movement.ReferenceID)
+	}
+	assert.Len(t, tx.outbox.messages, 2)
+	assert.Equal(t, domain.EventOrderStatusChanged, tx.outbox.messages[0].Subject)
+	assert.Equal(t, domain.EventOrderCancelled, tx.outbox.messages[1].Subject)
+
+	event := outboxEvent[domain.OrderCancelledEvent](t, tx.outbox.messages[1])
+	assert.Equal(t, domain.OrderStatusPending, event.OldStatus)
+	assert.Equal(t, domain.OrderStatusCancelled, event.NewStatus)
+	assert.Equal(t, actor, event.Actor)
+	assert.Equal(t, "customer asked", event.Reason)
+	assert.Equal(t, []domain.OrderItemEvent{
+		{ProductID: "p1", Quantity: 2, Price: domain.NewMoney(300, "KZT")},
+		{ProductID: "p2", Quantity: 1, Price: domain.NewMoney(500, "KZT")},
+	}, event.RestockedItems)
+}
+
+func TestGetOrderHistory_OnlyForOwnerOrAdmin(t *testing.T) {
+	uc, _ := newOrderUseCaseWithOrder(&domain.Order{ID: "o1", UserID: "u1", Status: domain.OrderStatusPending})
+	_, err := uc.UpdateOrderStatus("o1", domain.OrderStatusConfirmed, domain.SystemActor, "paid")
+	assert.NoError(t, err)
+
+	history, err := uc.GetOrderHistory(domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, "o1")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	history, err = uc.GetOrderHistory(domain.Actor{ID: "admin-1", Role: domain.ActorRoleAdmin}, "o1")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	_, err = uc.GetOrderHistory(domain.Actor{ID: "u2", Role: domain.ActorRoleUser}, "o1")
+	assert.ErrorIs(t, err, repository.ErrOrderNotFound)
+}
+
+func TestUpdateOrderStatus_DeliveryPublishesCompletedEvent(t *testing.T) {
+	uc, tx := newOrderUseCaseWithOrder(&domain.Order{ID: "o1", UserID: "u1", Status: domain.OrderStatusOutForDelivery})
+
+	order, err := uc.UpdateOrderStatus("o1", domain.OrderStatusDelivered, domain.SystemActor, "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusDelivered, order.Status)
+
+	assert.Len(t, tx.outbox.messages, 2)
+	assert.Equal(t, domain.EventOrderStatusChanged, tx.outbox.messages[0].Subject)
+	assert.Equal(t, domain.EventOrderCompleted, tx.outbox.messages[1].Subject)
+
+	event := outboxEvent[domain.OrderStatusChangedEvent](t, tx.outbox.messages[0])
+	assert.Empty(t, event.RestockedItems)
+	assert.Equal(t, domain.SystemActor, event.Actor)
+}
+
+func TestUpdateOrderStatus_RejectsTransitionsOutsideTheStateMachine(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   domain.OrderStatus
+		to     domain.OrderStatus
+		actor  domain.Actor
+		target error
+	}{
+		{"skipping steps", domain.OrderStatusPending, domain.OrderStatusDelivered, domain.Actor{ID: "a1", Role: domain.ActorRoleAdmin}, domain.ErrIllegalStatusTransition},
+		{"same status", domain.OrderStatusPending, domain.OrderStatusPending, domain.SystemActor, domain.ErrIllegalStatusTransition},
+		{"user confirming", domain.OrderStatusPending, domain.OrderStatusConfirmed, domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, domain.ErrStatusTransitionForbidden},
+		{"user cancelling someone else's order", domain.OrderStatusPending, domain.OrderStatusCancelled, domain.Actor{ID: "u2", Role: domain.ActorRoleUser}, domain.ErrStatusTransitionForbidden},
+		{"user cancelling while preparing", domain.OrderStatusPreparing, domain.OrderStatusCancelled, domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, domain.ErrStatusTransitionForbidden},
+		{"unknown status", domain.OrderStatusPending, domain.OrderStatus("completed"), domain.SystemActor, domain.ErrInvalidOrderStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, tx := newOrderUseCaseWithOrder(&domain.Order{ID: "o1", UserID: "u1", Status: tt.from})
+
+			_, err := uc.UpdateOrderStatus("o1", tt.to, tt.actor, "")
+
+			assert.ErrorIs(t, err, tt.target)
+			assert.Equal(t, tt.from, tx.orders.orders["o1"].Status)
+			assert.Empty(t, tx.orders.history)
+			assert.Empty(t, tx.outbox.messages)
+		})
+	}
+}
+
+func TestUpdateOrderStatus_RecordsHistory(t *testing.T) {
+	uc, tx := newOrderUseCaseWithOrder(&domain.Order{ID: "o1", UserID: "u1", Status: domain.OrderStatusPending})
+	admin := domain.Actor{ID: "a1", Role: domain.ActorRoleAdmin}
+
+	_, err := uc.UpdateOrderStatus("o1", domain.OrderStatusConfirmed, domain.SystemActor, "payment received")
+	assert.NoError(t, err)
+	_, err = uc.UpdateOrderStatus("o1", domain.OrderStatusPreparing, admin, "")
+	assert.NoError(t, err)
+
+	history := tx.orders.history
+	assert.Len(t, history, 2)
+
+	assert.Equal(t, "o1", history[0].OrderID)
+	assert.Equal(t, domain.OrderStatusPending, history[0].FromStatus)
+	assert.Equal(t, domain.OrderStatusConfirmed, history[0].ToStatus)
+	assert.Equal(t, domain.SystemActor, history[0].Actor)
+	assert.Equal(t, "payment received", history[0].Reason)
+
+	assert.Equal(t, domain.OrderStatusConfirmed, history[1].FromStatus)
+	assert.Equal(t, domain.OrderStatusPreparing, history[1].ToStatus)
+	assert.Equal(t, admin, history[1].Actor)
+	assert.NotEqual(t, history[0].ID, history[1].ID)
+
+	event := outboxEvent[domain.OrderStatusChangedEvent](t, tx.outbox.messages[0])
+	assert.True(t, history[0].ChangedAt.Equal(event.ChangedAt))
+}
+
+func TestCreateOrder_ConvertsPricesIntoTheRequestedCurrency(t *testing.T) {
+	tx := &memoryTransaction{
+		orders: &memoryOrderRepository{orders: map[string]*domain.Order{}},
+		products: &memoryStockRepository{
+			stock:  map[string]int32{"p1": 10, "p2": 10},
+			prices: map[string]domain.Money{"p1": domain.NewMoney(250000, "KZT"), "p2": domain.NewMoney(99900, "KZT")},
+		},
+		reservations: &memoryReservationRepository{},
+		outbox:       &memoryOutbox{},
+	}
+	uc := newOrderUseCase(tx, memoryRates{"USD": "0.0021"})
+
+	order, err := uc.CreateOrder("u1", []struct {
+		ProductID string
+		Quantity  int32
+	}{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 3}}, OrderOptions{Currency: "USD"})
+	assert.NoError(t, err)
+
+	// 2500.00 KZT is 5.25 USD and 999.00 KZT is 2.0979, rounded to 2.10.
+	assert.Equal(t, domain.NewMoney(525, "USD"), order.Items[0].Price)
+	assert.Equal(t, domain.NewMoney(210, "USD"), order.Items[1].Price)
+	assert.Equal(t, domain.NewMoney(1680, "USD"), order.TotalPrice)
+	assert.Equal(t, domain.ExchangeRate{From: "KZT", To: "USD", Rate: "0.0021"}, order.ExchangeRate)
+
+	assert.Len(t, tx.products.movements, 2)
+	for _, movement := range tx.products.movements {
+		assert.Equal(t, domain.StockMovementOrder, movement.Reason)
+		assert.Equal(t, order.ID, movement.ReferenceID)
+	}
+	assert.Equal(t, int32(-3), tx.products.movements[1].Delta)
+
+	_, err = uc.CreateOrder("u1", []struct {
+		ProductID string
+		Quantity  int32
+	}{{ProductID: "p1", Quantity: 1}}, OrderOptions{Currency: "GBP"})
+	assert.ErrorIs(t, err, domain.ErrUnsupportedCurrency)
+}
+
+func TestCreateOrder_QueuesLowStockAlertWhenStockDropsBelowThreshold(t *testing.T) {
+	tx := &memoryTransaction{
+		orders: &memoryOrderRepository{orders: map[string]*domain.Order{}},
+		products: &memoryStockRepository{
+			stock:      map[string]int32{"p1": 6, "p2": 20},
+			prices:     map[string]domain.Money{"p1": domain.NewMoney(100, "KZT"), "p2": domain.NewMoney(100, "KZT")},
+			thresholds: map[string]int32{"p1": 5, "p2": 5},
+		},
+		reservations: &memoryReservationRepository{},
+		outbox:       &memoryOutbox{},
+	}
+	uc := newOrderUseCase(tx, memoryRates{})
+
+	order, err := uc.CreateOrder("u1", []struct {
+		ProductID string
+		Quantity  int32
+	}{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 2}}, OrderOptions{})
+	assert.NoError(t, err)
+
+	assert.Len(t, tx.outbox.messages, 3)
+	assert.Equal(t, domain.EventInventoryLowStock, tx.outbox.messages[0].Subject)
+	assert.Equal(t, "p1", tx.outbox.messages[0].AggregateID)
+	event := outboxEvent[domain.LowStockEvent](t, tx.outbox.messages[0])
+	assert.Equal(t, "p1", event.ProductID)
+	assert.Equal(t, int32(4), event.Stock)
+	assert.Equal(t, int32(5), event.ReorderThreshold)
+	assert.Equal(t, domain.StockMovementOrder, event.Reason)
+	assert.Equal(t, order.ID, event.ReferenceID)
+
+	// p1 is already below its threshold, so a second order does not alert
+	// again.
+	tx.outbox.messages = nil
+	_, err = uc.CreateOrder("u1", []struct {
+		ProductID string
+		Quantity  int32
+	}{{ProductID: "p1", Quantity: 1}}, OrderOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, tx.outbox.messages, 2)
+	assert.Equal(t, domain.EventOrderCreated, tx.outbox.messages[0].Subject)
+}
+
+func newReservingOrderUseCase() (*OrderUseCase, *memoryTransaction) {
+	tx := &memoryTransaction{
+		orders: &memoryOrderRepository{orders: map[string]*domain.Order{}},
+		products: &memoryStockRepository{
+			stock:  map[string]int32{"p1": 10, "p2": 10},
+			prices: map[string]domain.Money{"p1": domain.NewMoney(100, "KZT"), "p2": domain.NewMoney(200, "KZT")},
+		},
+		reservations: &memoryReservationRepository{},
+		outbox:       &memoryOutbox{},
+	}
+	return newOrderUseCase(tx, memoryRates{}), tx
+}
+
+func placeOrder(t *testing.T, uc *OrderUseCase) *domain.Order {
+	t.Helper()
+
+	order, err := uc.CreateOrder("u1", []struct {
+		ProductID string
+		Quantity  int32
+	}{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 3}}, OrderOptions{})
+	assert.NoError(t, err)
+	return order
+}
+
+func TestCreateOrder_ReservesStockUntilTheTTL(t *testing.T) {
+	uc, tx := newReservingOrderUseCase()
+
+	before := time.Now()
+	order := placeOrder(t, uc)
+
+	assert.Len(t, tx.reservations.reservations, 2)
+	for _, reservation := range tx.reservations.reservations {
+		assert.Equal(t, order.ID, reservation.OrderID)
+		assert.Equal(t, domain.StockReservationActive, reservation.Status)
+		assert.False(t, reservation.ExpiresAt.Before(before.Add(time.Minute)))
+	}
+	assert.Equal(t, int32(8), tx.products.stock["p1"])
+
+	assert.Len(t, tx.outbox.messages, 2)
+	assert.Equal(t, domain.EventStockReserved, tx.outbox.messages[1].Subject)
+	event := outboxEvent[domain.StockReservationEvent](t, tx.outbox.messages[1])
+	assert.Equal(t, order.ID, event.OrderID)
+	assert.Equal(t, []domain.ReservedItemEvent{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 3}}, event.Items)
+	assert.True(t, event.ExpiresAt.Equal(tx.reservations.reservations[0].ExpiresAt))
+}
+
+func TestUpdateOrderStatus_ConfirmCommitsReservations(t *testing.T) {
+	uc, tx := newReservingOrderUseCase()
+	order := placeOrder(t, uc)
+	tx.outbox.messages = nil
+
+	_, err := uc.UpdateOrderStatus(order.ID, domain.OrderStatusConfirmed, domain.SystemActor, "")
+	assert.NoError(t, err)
+
+	for _, reservation := range tx.reservations.reservations {
+		assert.Equal(t, domain.StockReservationCommitted, reservation.Status)
+		assert.NotNil(t, reservation.ResolvedAt)
+	}
+	assert.Equal(t, domain.EventStockReservationCommitted, tx.outbox.messages[0].Subject)
+
+	// The stock is sold, so it stays taken once the reservations would have
+	// expired.
+	cancelled, err := uc.ReleaseExpiredReservations(time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Zero(t, cancelled)
+	assert.Equal(t, domain.OrderStatusConfirmed, tx.orders.orders[order.ID].Status)
+	assert.Equal(t, int32(7), tx.products.stock["p2"])
+}
+
+func TestUpdateOrderStatus_CancelReleasesReservations(t *testing.T) {
+	uc, tx := newReservingOrderUseCase()
+	order := placeOrder(t, uc)
+	tx.outbox.messages = nil
+
+	_, err := uc.UpdateOrderStatus(order.ID, domain.OrderStatusCancelled, domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, "")
+	assert.NoError(t, err)
+
+	for _, reservation := range tx.reservations.reservations {
+		assert.Equal(t, domain.StockReservationReleased, reservation.Status)
+	}
+	assert.Equal(t, int32(10), tx.products.stock["p1"])
+
+	assert.Equal(t, domain.EventStockReservationReleased, tx.outbox.messages[0].Subject)
+	event := outboxEvent[domain.StockReservationEvent](t, tx.outbox.messages[0])
+	assert.Equal(t, domain.ReservationReleaseCancelled, event.Reason)
+}
+
+func TestReleaseExpiredReservations_CancelsStalePendingOrders(t *testing.T) {
+	uc, tx := newReservingOrderUseCase()
+	order := placeOrder(t, uc)
+	tx.outbox.messages = nil
+
+	cancelled, err := uc.ReleaseExpiredReservations(time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, cancelled)
+
+	cancelled, err = uc.ReleaseExpiredReservations(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cancelled)
+
+	assert.Equal(t, domain.OrderStatusCancelled, tx.orders.orders[order.ID].Status)
+	assert.Equal(t, int32(10), tx.products.stock["p1"])
+	assert.Equal(t, int32(10), tx.products.stock["p2"])
+	assert.Equal(t, domain.SystemActor, tx.orders.history[len(tx.orders.history)-1].Actor)
+
+	var subjects []string
+	for _, message := range tx.outbox.messages {
+		subjects = append(subjects, message.Subject)
+	}
+	assert.Contains(t, subjects, domain.EventOrderCancelled)
+	assert.Contains(t, subjects, domain.EventStockReservationReleased)
+	for _, message := range tx.outbox.messages {
+		if message.Subject == domain.EventStockReservationReleased {
+			event := outboxEvent[domain.StockReservationEvent](t, message)
+			assert.Equal(t, domain.ReservationReleaseExpired, event.Reason)
+		}
+	}
+
+	// Released reservations are not picked up again.
+	cancelled, err = uc.ReleaseExpiredReservations(time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Zero(t, cancelled)
+}
+
+func TestReleaseExpiredReservations_ContinuesPastFailingOrders(t *testing.T) {
+	uc, tx := newReservingOrderUseCase()
+	tx.reservations.reservations = append(tx.reservations.reservations, &domain.StockReservation{
+		ID:        "r-missing",
+		OrderID:   "missing",
+		ProductID: "p1",
+		Quantity:  1,
+		Status:    domain.StockReservationActive,
+		ExpiresAt: time.Now(),
+	})
+	order := placeOrder(t, uc)
+
+	cancelled, err := uc.ReleaseExpiredReservations(time.Now().Add(time.Minute))
+	assert.ErrorIs(t, err, repository.ErrOrderNotFound)
+	assert.ErrorContains(t, err, "order missing")
+	assert.Equal(t, 1, cancelled)
+	assert.Equal(t, domain.OrderStatusCancelled, tx.orders.orders[order.ID].Status)
+
+	// The failing order is left out of the next runs until it is due again.
+	now := time.Now().Add(time.Minute)
+	orderIDs, err := tx.reservations.ListExpiredOrderIDs(now, reservationReapBatchSize)
+	assert.NoError(t, err)
+	assert.Empty(t, orderIDs)
+
+	orderIDs, err = tx.reservations.ListExpiredOrderIDs(now.Add(reservationRetryDelay), reservationReapBatchSize)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"missing"}, orderIDs)
+}
+
+func newPromotionOrderUseCase(promotions ...*domain.Promotion) (*OrderUseCase, *memoryTransaction) {
+	uc, tx := newReservingOrderUseCase()
+	// The memory unit of work does not roll back, so failed orders keep
+	// their stock.
+	tx.products.stock = map[string]int32{"p1": 100, "p2": 100}
+	tx.products.categories = map[string]string{"p1": "fruit", "p2": "bakery"}
+	tx.promotions = &memoryPromotionRepository{promotions: promotions}
+	return uc, tx
+}
+
+func placeOrderWithCoupon(uc *OrderUseCase, userID, couponCode string) (*domain.Order, error) {
+	return uc.CreateOrder(userID, []struct {
+		ProductID string
+		Quantity  int32
+	}{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 3}}, OrderOptions{CouponCode: couponCode})
+}
+
+func TestCreateOrder_ChargesTaxOnDiscountedLines(t *testing.T) {
+	uc, tx := newPromotionOrderUseCase(
+		&domain.Promotion{ID: "save10", Code: "SAVE10", Name: "10% off", Type: domain.PromotionPercentage, PercentOff: 10, Active: true},
+	)
+	uc.taxes = memoryTaxes{
+		"KZ": {Rate: "0.12", Inclusive: true},
+		"US": {Rate: "0.12"},
+	}
+	place := func(region string) *domain.Order {
+		order, err := uc.CreateOrder("u1", []struct {
+			ProductID string
+			Quantity  int32
+		}{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 3}}, OrderOptions{CouponCode: "SAVE10", Region: region})
+		assert.NoError(t, err)
+		return order
+	}
+
+	// The coupon takes 0.80 off 8.00, leaving lines of 1.80 and 5.40.
+	// Exclusive tax is 0.216 and 0.648, rounded to 0.22 and 0.65 and added
+	// on top.
+	order := place("US")
+	assert.Equal(t, domain.NewMoney(800, "KZT"), order.Subtotal)
+	assert.Equal(t, domain.NewMoney(80, "KZT"), order.DiscountTotal)
+	assert.Equal(t, domain.NewMoney(87, "KZT"), order.TaxTotal)
+	assert.Equal(t, domain.NewMoney(807, "KZT"), order.TotalPrice)
+	assert.Equal(t, "US", order.TaxRegion)
+	assert.Same(t, order, tx.orders.orders[order.ID])
+
+	// Inclusive tax is already in the prices: 1.80 is 1.61 net and 5.40 is
+	// 4.82 net.
+	order = place("KZ")
+	assert.Equal(t, domain.NewMoney(77, "KZT"), order.TaxTotal)
+	assert.Equal(t, domain.NewMoney(720, "KZT"), order.TotalPrice)
+
+	event := outboxEvent[domain.OrderCreatedEvent](t, tx.outbox.messages[len(tx.outbox.messages)-2])
+	assert.Equal(t, order.Subtotal, event.Subtotal)
+	assert.Equal(t, order.TaxTotal, event.TaxTotal)
+
+	order = place("")
+	assert.Equal(t, domain.NewMoney(0, "KZT"), order.TaxTotal)
+	assert.Equal(t, domain.NewMoney(720, "KZT"), order.TotalPrice)
+}
+
+func TestCreateOrder_AppliesAutomaticPromotionsAndCoupon(t *testing.T) {
+	uc, tx := newPromotionOrderUseCase(
+		&domain.Promotion{ID: "bogo", Name: "Fruit 1+1", Type: domain.PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1, CategoryID: "fruit", Active: true},
+		&domain.Promotion{ID: "save10", Code: "SAVE10", Name: "10% off", Type: domain.PromotionPercentage, PercentOff: 10, Active: true},
+		&domain.Promotion{ID: "other", Code: "OTHER", Name: "Not given", Type: domain.PromotionPercentage, PercentOff: 50, Active: true},
+	)
+
+	// 2 x 1.00 + 3 x 2.00: one p1 is free and the coupon takes 10% of 8.00.
+	order, err := placeOrderWithCoupon(uc, "u1", " save10 ")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(180, "KZT"), order.DiscountTotal)
+	assert.Equal(t, domain.NewMoney(620, "KZT"), order.TotalPrice)
+
+	assert.Len(t, order.Discounts, 2)
+	assert.Equal(t, "bogo", order.Discounts[0].PromotionID)
+	assert.Equal(t, order.Items[0].ID, order.Discounts[0].OrderItemID)
+	assert.Equal(t, domain.NewMoney(100, "KZT"), order.Discounts[0].Amount)
+	assert.Equal(t, "SAVE10", order.Discounts[1].Code)
+	assert.Empty(t, order.Discounts[1].OrderItemID)
+	assert.Equal(t, domain.NewMoney(80, "KZT"), order.Discounts[1].Amount)
+	for _, discount := range order.Discounts {
+		assert.Equal(t, order.ID, discount.OrderID)
+		assert.NotEmpty(t, discount.ID)
+	}
+
+	assert.Len(t, tx.promotions.redemptions, 2)
+	assert.Equal(t, int32(0), tx.promotions.promotions[2].TimesUsed)
+
+	event := outboxEvent[domain.OrderCreatedEvent](t, tx.outbox.messages[0])
+	assert.Equal(t, order.DiscountTotal, event.DiscountTotal)
+}
+
+func TestCreateOrder_RejectsCouponsThatDoNotApply(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	uc, tx := newPromotionOrderUseCase(
+		&domain.Promotion{ID: "once", Code: "ONCE", Name: "Once per user", Type: domain.PromotionFixedAmount, AmountOff: domain.NewMoney(500, "KZT"), PerUserLimit: 1, Active: true},
+		&domain.Promotion{ID: "old", Code: "OLD", Name: "Expired", Type: domain.PromotionPercentage, PercentOff: 10, EndsAt: &expired, Active: true},
+		&domain.Promotion{ID: "dairy", Code: "DAIRY", Name: "Dairy", Type: domain.PromotionPercentage, PercentOff: 10, CategoryID: "dairy", Active: true},
+	)
+
+	_, err := placeOrderWithCoupon(uc, "u1", "ONCE")
+	assert.NoError(t, err)
+
+	for _, code := range []string{"ONCE", "OLD", "DAIRY", "MISSING"} {
+		_, err = placeOrderWithCoupon(uc, "u1", code)
+		assert.ErrorIs(t, err, domain.ErrCouponNotApplicable, code)
+	}
+	_, err = placeOrderWithCoupon(uc, "u1", "ONCE")
+	assert.ErrorIs(t, err, repository.ErrPromotionUserLimitReached)
+
+	order, err := placeOrderWithCoupon(uc, "u2", "ONCE")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(300, "KZT"), order.TotalPrice)
+	assert.Equal(t, int32(2), tx.promotions.promotions[0].TimesUsed)
+}
+
+func TestCreateOrder_SkipsUsedUpAutomaticPromotions(t *testing.T) {
+	uc, _ := newPromotionOrderUseCase(
+		&domain.Promotion{ID: "first", Name: "First order", Type: domain.PromotionFixedAmount, AmountOff: domain.NewMoney(100, "KZT"), UsageLimit: 1, Active: true},
+	)
+
+	order, err := placeOrderWithCoupon(uc, "u1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(700, "KZT"), order.TotalPrice)
+
+	order, err = placeOrderWithCoupon(uc, "u2", "")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(800, "KZT"), order.TotalPrice)
+	assert.Empty(t, order.Discounts)
+	assert.True(t, order.DiscountTotal.IsZero())
+}
+
+func TestCancelOrder_ReleasesPromotionRedemptions(t *testing.T) {
+	uc, tx := newPromotionOrderUseCase(
+		&domain.Promotion{ID: "once", Code: "ONCE", Name: "Once per user", Type: domain.PromotionPercentage, PercentOff: 5, PerUserLimit: 1, Active: true},
+	)
+
+	order, err := placeOrderWithCoupon(uc, "u1", "ONCE")
+	assert.NoError(t, err)
+
+	assert.NoError(t, uc.CancelOrder(order.ID, domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, ""))
+	assert.Empty(t, tx.promotions.redemptions)
+	assert.Zero(t, tx.promotions.promotions[0].TimesUsed)
+
+	_, err = placeOrderWithCoupon(uc, "u1", "ONCE")
+	assert.NoError(t, err)
+}
+
+func newDeliveryOrderUseCase() (*OrderUseCase, *memoryTransaction) {
+	uc, tx := newPromotionOrderUseCase()
+	uc.fees = memoryFees{
+		"Almaty": {Name: "almaty", Fee: domain.NewMoney(150, "KZT"), FreeFrom: domain.NewMoney(1000, "KZT")},
+	}
+	uc.taxes = memoryTaxes{"KZ": {Rate: "0.1"}}
+	tx.addresses = &memoryAddressRepository{addresses: []*domain.Address{
+		{ID: "home", UserID: "u1", Line1: "Abay 10", City: "Almaty", Region: "KZ"},
+		{ID: "dacha", UserID: "u1", Line1: "Dacha 5", City: "Talgar", Region: "KZ"},
+		{ID: "theirs", UserID: "u2", Line1: "Satpaev 2", City: "Almaty", Region: "KZ"},
+	}}
+	tx.slots = &memoryDeliverySlotRepository{slots: []*domain.DeliverySlot{
+		{ID: "morning", StartsAt: time.Now().Add(time.Hour), EndsAt: time.Now().Add(3 * time.Hour), Capacity: 1},
+	}}
+	return uc, tx
+}
+
+func placeDeliveredOrder(uc *OrderUseCase, userID string, options OrderOptions) (*domain.Order, error) {
+	return uc.CreateOrder(userID, []struct {
+		ProductID string
+		Quantity  int32
+	}{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 3}}, options)
+}
+
+func TestCreateOrder_ChargesDeliveryAndBooksSlot(t *testing.T) {
+	uc, tx := newDeliveryOrderUseCase()
+
+	// 8.00 of goods is below the 10.00 free delivery threshold. Tax for the
+	// address's region is added on the goods only.
+	order, err := placeDeliveredOrder(uc, "u1", OrderOptions{AddressID: "home", DeliverySlotID: "morning"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(150, "KZT"), order.DeliveryFee)
+	assert.Equal(t, "almaty", order.DeliveryZone)
+	assert.Equal(t, "KZ", order.TaxRegion)
+	assert.Equal(t, domain.NewMoney(80, "KZT"), order.TaxTotal)
+	assert.Equal(t, domain.NewMoney(1030, "KZT"), order.TotalPrice)
+	assert.Equal(t, "Abay 10", order.DeliveryAddress.Line1)
+	assert.Equal(t, "morning", order.DeliverySlot.ID)
+	assert.Equal(t, int32(1), tx.slots.slots[0].Booked)
+
+	event := outboxEvent[domain.OrderCreatedEvent](t, tx.outbox.messages[0])
+	assert.Equal(t, order.DeliveryFee, event.DeliveryFee)
+
+	// The slot only takes one order.
+	_, err = placeDeliveredOrder(uc, "u1", OrderOptions{AddressID: "home", DeliverySlotID: "morning"})
+	assert.ErrorIs(t, err, repository.ErrDeliverySlotFull)
+
+	_, err = uc.UpdateOrderStatus(order.ID, domain.OrderStatusCancelled, domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, "")
+	assert.NoError(t, err)
+	assert.Zero(t, tx.slots.slots[0].Booked)
+
+	_, err = placeDeliveredOrder(uc, "u1", OrderOptions{AddressID: "home", DeliverySlotID: "morning"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), tx.slots.slots[0].Booked)
+}
+
+func TestCreateOrder_RejectsUndeliverableOrders(t *testing.T) {
+	uc, _ := newDeliveryOrderUseCase()
+
+	_, err := placeDeliveredOrder(uc, "u1", OrderOptions{AddressID: "theirs"})
+	assert.ErrorIs(t, err, repository.ErrAddressNotFound)
+
+	_, err = placeDeliveredOrder(uc, "u1", OrderOptions{AddressID: "dacha"})
+	assert.ErrorIs(t, err, domain.ErrNotDeliverable)
+
+	_, err = placeDeliveredOrder(uc, "u1", OrderOptions{DeliverySlotID: "morning"})
+	assert.ErrorIs(t, err, domain.ErrInvalidDeliverySlot)
+
+	_, err = placeDeliveredOrder(uc, "u1", OrderOptions{AddressID: "home", DeliverySlotID: "evening"})
+	assert.ErrorIs(t, err, repository.ErrDeliverySlotNotFound)
+
+	// Orders that are not delivered pay no fee.
+	order, err := placeDeliveredOrder(uc, "u1", OrderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(0, "KZT"), order.DeliveryFee)
+	assert.Nil(t, order.DeliveryAddress)
+	assert.Equal(t, domain.NewMoney(800, "KZT"), order.TotalPrice)
+}
+
+func TestCreateOrder_TaxesLinesWithTheirCategoryAncestors(t *testing.T) {
+	uc, tx := newPromotionOrderUseCase()
+	tx.products.categories = map[string]string{"p1": "apples", "p2": "bakery"}
+	tx.products.parents = map[string]string{"apples": "fruit", "fruit": "food"}
+	taxes := &lineRecordingTaxes{}
+	uc.taxes = taxes
+
+	_, err := placeOrderWithCoupon(uc, "u1", "")
+	assert.NoError(t, err)
+	assert.Len(t, taxes.lines, 2)
+	assert.Equal(t, "apples", taxes.lines[0].CategoryID)
+	assert.Equal(t, []string{"fruit", "food"}, taxes.lines[0].CategoryAncestors)
+	assert.Equal(t, "bakery", taxes.lines[1].CategoryID)
+	assert.Empty(t, taxes.lines[1].CategoryAncestors)
+}
@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"AdvProg2/domain"
+	"AdvProg2/pkg/eventbus"
+	"AdvProg2/repository"
+)
+
+const (
+	outboxBatchSize       = 100
+	outboxPollInterval    = time.Second
+	outboxBaseBackoff     = time.Second
+	outboxMaxBackoff      = 5 * time.Minute
+	outboxMaxAttempts     = 20
+	outboxRetention       = 24 * time.Hour
+	outboxCleanupInterval = time.Hour
+)
+
+// newOutboxMessage wraps event in the same domain.Message envelope
+// eventbus.Publish uses, so consumers cannot tell relayed messages from
+// direct ones.
+func newOutboxMessage[T any](aggregateType, aggregateID, subject string, event T) (*domain.OutboxMessage, error) {
+	envelope, payload, err := eventbus.Encode(subject, event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.OutboxMessage{
+		ID:            envelope.ID,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Subject:       subject,
+		Payload:       payload,
+		NextAttemptAt: envelope.CreatedAt,
+		CreatedAt:     envelope.CreatedAt,
+	}, nil
+}
+
+// OutboxRelay drains the outbox table into the message broker. Messages of
+// the same aggregate are delivered in the order they were written: once one
+// fails, the later ones wait until it has been retried successfully. A
+// message that still fails after outboxMaxAttempts is marked dead and left
+// in the table for an operator, and the aggregate's later messages go on.
+type OutboxRelay struct {
+	unitOfWork  repository.UnitOfWork
+	outboxRepo  repository.OutboxRepository
+	publisher   repository.EventPublisher
+	lastCleanup time.Time
+}
+
+func NewOutboxRelay(unitOfWork repository.UnitOfWork, outboxRepo repository.OutboxRepository, publisher repository.EventPublisher) *OutboxRelay {
+	return &OutboxRelay{
+		unitOfWork: unitOfWork,
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+	}
+}
+
+// Run relays pending messages until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	log.Println("Outbox relay started")
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayPending(); err != nil {
+			log.Printf("Outbox relay: failed to relay pending messages: %v", err)
+		}
+
+		if time.Since(r.lastCleanup) >= outboxCleanupInterval {
+			r.Cleanup()
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes up to one batch of due messages and returns how
+// many were delivered. It does nothing if another relay is draining the
+// outbox.
+func (r *OutboxRelay) RelayPending() (int, error) {
+	delivered := 0
+
+	err := r.unitOfWork.Do(func(tx repository.Transaction) error {
+		locked, err := tx.Outbox().TryLock()
+		if err != nil || !locked {
+			return err
+		}
+
+		// Each listing holds at most one message per aggregate; a delivered
+		// one makes way for the next. Failed and dead messages are no longer
+		// listed, so every round makes progress.
+		for handled := 0; handled < outboxBatchSize; {
+			messages, err := tx.Outbox().ListPending(int32(outboxBatchSize - handled))
+			if err != nil {
+				return err
+			}
+			if len(messages) == 0 {
+				break
+			}
+
+			for _, message := range messages {
+				handled++
+				if err := r.relay(tx.Outbox(), message); err != nil {
+					if errors.Is(err, errOutboxPublish) {
+						continue
+					}
+					return err
+				}
+				delivered++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if delivered > 0 {
+		log.Printf("Outbox relay: delivered %d messages", delivered)
+	}
+
+	return delivered, nil
+}
+
+// errOutboxPublish reports a message that could not be published and has
+// been scheduled for a retry or marked dead.
+var errOutboxPublish = errors.New("outbox message not published")
+
+func (r *OutboxRelay) relay(outbox repository.OutboxRepository, message *domain.OutboxMessage) error {
+	err := r.publisher.PublishEnvelope(message.Subject, message.Payload)
+	if err == nil {
+		return outbox.MarkDelivered(message.ID)
+	}
+
+	attempt := message.Attempts + 1
+	if attempt >= outboxMaxAttempts {
+		log.Printf("Outbox relay: giving up on %s message %s of %s %s after %d attempts: %v",
+			message.Subject, message.ID, message.AggregateType, message.AggregateID, attempt, err)
+		if err := outbox.MarkDead(message.ID, err.Error()); err != nil {
+			return err
+		}
+		return errOutboxPublish
+	}
+
+	nextAttemptAt := time.Now().Add(outboxBackoff(message.Attempts))
+	log.Printf("Outbox relay: failed to publish %s message %s (attempt %d), retrying at %s: %v",
+		message.Subject, message.ID, attempt, nextAttemptAt.Format(time.RFC3339), err)
+	if err := outbox.MarkFailed(message.ID, err.Error(), nextAttemptAt); err != nil {
+		return err
+	}
+	return errOutboxPublish
+}
+
+// Cleanup removes delivered messages older than the retention period.
+func (r *OutboxRelay) Cleanup() {
+	r.lastCleanup = time.Now()
+
+	deleted, err := r.outboxRepo.DeleteDeliveredBefore(r.lastCleanup.Add(-outboxRetention))
+	if err != nil {
+		log.Printf("Outbox relay: failed to clean up delivered messages: %v", err)
+		return
+	}
+
+	if deleted > 0 {
+		log.Printf("Outbox relay: removed %d delivered messages", deleted)
+	}
+}
+
+func outboxBackoff(attempts int32) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := int32(0); i < attempts; i++ {
+		backoff *= 2
+		if backoff >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type memoryOutbox struct {
+	messages []*domain.OutboxMessage
+}
+
+func (o *memoryOutbox) Add(message *domain.OutboxMessage) error {
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+func (o *memoryOutbox) ListPending(limit int32) ([]*domain.OutboxMessage, error) {
+	var pending []*domain.OutboxMessage
+	heads := make(map[string]bool)
+	now := time.Now()
+	for _, m := range o.messages {
+		aggregate := m.AggregateType + ":" + m.AggregateID
+		if m.DeliveredAt != nil || m.DeadAt != nil || heads[aggregate] {
+			continue
+		}
+		heads[aggregate] = true
+		if !m.NextAttemptAt.After(now) && int32(len(pending)) < limit {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+func (o *memoryOutbox) find(id string) *domain.OutboxMessage {
+	for _, m := range o.messages {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+func (o *memoryOutbox) MarkDelivered(id string) error {
+	now := time.Now()
+	m := o.find(id)
+	m.Attempts++
+	m.DeliveredAt = &now
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(id string, lastError string, nextAttemptAt time.Time) error {
+	m := o.find(id)
+	m.Attempts++
+	m.LastError = lastError
+	m.NextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func (o *memoryOutbox) MarkDead(id string, lastError string) error {
+	now := time.Now()
+	m := o.find(id)
+	m.Attempts++
+	m.LastError = lastError
+	m.DeadAt = &now
+	return nil
+}
+
+func (o *memoryOutbox) DeleteDeliveredBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (o *memoryOutbox) TryLock() (bool, error) {
+	return true, nil
+}
+
+type outboxOnlyTransaction struct {
+	repository.Transaction
+	outbox *memoryOutbox
+}
+
+func (t *outboxOnlyTransaction) Outbox() repository.OutboxRepository {
+	return t.outbox
+}
+
+type outboxOnlyUnitOfWork struct {
+	outbox *memoryOutbox
+}
+
+func (u *outboxOnlyUnitOfWork) Do(fn func(tx repository.Transaction) error) error {
+	return fn(&outboxOnlyTransaction{outbox: u.outbox})
+}
+
+type recordingPublisher struct {
+	failSubject string
+	published   []string
+}
+
+func (p *recordingPublisher) PublishEnvelope(subject string, envelope []byte) error {
+	if subject == p.failSubject {
+		return errors.New("nats: connection closed")
+	}
+	p.published = append(p.published, subject)
+	return nil
+}
+
+func TestOutboxRelay_KeepsOrderPerAggregate(t *testing.T) {
+	outbox := &memoryOutbox{}
+	add := func(message *domain.OutboxMessage, err error) {
+		assert.NoError(t, err)
+		outbox.Add(message)
+	}
+
+	add(newOutboxMessage("product", "p1", domain.EventProductCreated, domain.ProductCreatedEvent{ProductID: "p1"}))
+	add(newOutboxMessage("product", "p1", domain.EventProductUpdated, domain.ProductUpdatedEvent{ProductID: "p1"}))
+	add(newOutboxMessage("product", "p2", domain.EventProductDeleted, domain.ProductDeletedEvent{ProductID: "p2"}))
+
+	publisher := &recordingPublisher{failSubject: domain.EventProductCreated}
+	relay := NewOutboxRelay(&outboxOnlyUnitOfWork{outbox: outbox}, outbox, publisher)
+
+	delivered, err := relay.RelayPending()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	// p1's update must wait behind its failed create; p2 is unaffected.
+	assert.Equal(t, []string{domain.EventProductDeleted}, publisher.published)
+	assert.Equal(t, int32(1), outbox.messages[0].Attempts)
+	assert.True(t, outbox.messages[0].NextAttemptAt.After(time.Now()))
+	assert.Nil(t, outbox.messages[1].DeliveredAt)
+
+	publisher.failSubject = ""
+	outbox.messages[0].NextAttemptAt = time.Now()
+
+	delivered, err = relay.RelayPending()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, []string{domain.EventProductDeleted, domain.EventProductCreated, domain.EventProductUpdated}, publisher.published)
+}
+
+func TestOutboxRelay_BackingOffMessagesDoNotFillTheBatch(t *testing.T) {
+	outbox := &memoryOutbox{}
+	for i := 0; i < outboxBatchSize+10; i++ {
+		message, err := newOutboxMessage("product", "p1", domain.EventProductUpdated, domain.ProductUpdatedEvent{ProductID: "p1"})
+		assert.NoError(t, err)
+		message.NextAttemptAt = time.Now().Add(time.Minute)
+		outbox.Add(message)
+	}
+	message, err := newOutboxMessage("product", "p2", domain.EventProductDeleted, domain.ProductDeletedEvent{ProductID: "p2"})
+	assert.NoError(t, err)
+	outbox.Add(message)
+
+	publisher := &recordingPublisher{}
+	relay := NewOutboxRelay(&outboxOnlyUnitOfWork{outbox: outbox}, outbox, publisher)
+
+	delivered, err := relay.RelayPending()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []string{domain.EventProductDeleted}, publisher.published)
+}
+
+func TestOutboxRelay_GivesUpAfterMaxAttempts(t *testing.T) {
+	outbox := &memoryOutbox{}
+	add := func(message *domain.OutboxMessage, err error) {
+		assert.NoError(t, err)
+		outbox.Add(message)
+	}
+	add(newOutboxMessage("product", "p1", domain.EventProductCreated, domain.ProductCreatedEvent{ProductID: "p1"}))
+	add(newOutboxMessage("product", "p1", domain.EventProductUpdated, domain.ProductUpdatedEvent{ProductID: "p1"}))
+	outbox.messages[0].Attempts = outboxMaxAttempts - 1
+
+	publisher := &recordingPublisher{failSubject: domain.EventProductCreated}
+	relay := NewOutboxRelay(&outboxOnlyUnitOfWork{outbox: outbox}, outbox, publisher)
+
+	delivered, err := relay.RelayPending()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	dead := outbox.messages[0]
+	assert.NotNil(t, dead.DeadAt)
+	assert.Nil(t, dead.DeliveredAt)
+	assert.Equal(t, int32(outboxMaxAttempts), dead.Attempts)
+	assert.Equal(t, "nats: connection closed", dead.LastError)
+	assert.Equal(t, []string{domain.EventProductUpdated}, publisher.published)
+
+	delivered, err = relay.RelayPending()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, outboxBackoff(0))
+	assert.Equal(t, 8*time.Second, outboxBackoff(3))
+	assert.Equal(t, outboxMaxBackoff, outboxBackoff(30))
+}
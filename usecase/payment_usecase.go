@@ -0,0 +1,403 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+
+	"github.com/google/uuid"
+)
+
+// PaymentUseCase takes payment for pending orders through a PaymentProvider.
+// A succeeded payment confirms its order, and cancelled orders that were
+// paid for are refunded. Every change to a payment is published as a
+// payment.* event.
+type PaymentUseCase struct {
+	paymentRepo   repository.PaymentRepository
+	unitOfWork    repository.UnitOfWork
+	orderUseCase  *OrderUseCase
+	provider      repository.PaymentProvider
+	processedRepo repository.ProcessedMessageRepository
+}
+
+// NewPaymentUseCase creates the payment use case. processedRepo may be nil,
+// in which case a redelivered order.cancelled event is handled again, which
+// is harmless since refunds are only made once.
+func NewPaymentUseCase(paymentRepo repository.PaymentRepository, unitOfWork repository.UnitOfWork, orderUseCase *OrderUseCase, provider repository.PaymentProvider, processedRepo repository.ProcessedMessageRepository) *PaymentUseCase {
+	return &PaymentUseCase{
+		paymentRepo:   paymentRepo,
+		unitOfWork:    unitOfWork,
+		orderUseCase:  orderUseCase,
+		provider:      provider,
+		processedRepo: processedRepo,
+	}
+}
+
+// CreatePayment starts paying for the user's pending order. If the order
+// already has a pending intent, that intent is returned instead of a new
+// one.
+func (uc *PaymentUseCase) CreatePayment(userID, orderID string) (*domain.PaymentIntent, error) {
+	if userID == "" {
+		return nil, errors.New("user ID cannot be empty")
+	}
+
+	order, err := uc.orderUseCase.GetOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, repository.ErrOrderNotFound
+	}
+
+	intent, err := uc.openIntent(order.ID)
+	if err != nil || intent != nil {
+		return intent, err
+	}
+
+	if order.Status != domain.OrderStatusPending {
+		return nil, fmt.Errorf("%w: order is %s", domain.ErrOrderNotPayable, order.Status)
+	}
+
+	now := time.Now()
+	intent = &domain.PaymentIntent{
+		ID:        uuid.New().String(),
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Amount:    order.TotalPrice,
+		Status:    domain.PaymentStatusPending,
+		Provider:  uc.provider.Name(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	intent.ProviderRef, intent.ClientSecret, err = uc.provider.CreatePayment(intent)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		if err := tx.Payments().Create(intent); err != nil {
+			return err
+		}
+		return addPaymentMessage(tx.Outbox(), intent, domain.EventPaymentIntentCreated)
+	})
+	if errors.Is(err, repository.ErrOrderHasOpenPayment) {
+		// A concurrent request created one first.
+		return uc.openIntent(order.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return intent, nil
+}
+
+// openIntent returns the order's pending intent, or nil if it has none. It
+// fails with domain.ErrOrderAlreadyPaid if the order has been paid for.
+func (uc *PaymentUseCase) openIntent(orderID string) (*domain.PaymentIntent, error) {
+	intents, err := uc.paymentRepo.ListByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, intent := range intents {
+		switch intent.Status {
+		case domain.PaymentStatusPending:
+			return intent, nil
+		case domain.PaymentStatusSucceeded, domain.PaymentStatusRefunding, domain.PaymentStatusRefunded:
+			return nil, fmt.Errorf("%w: payment %s is %s", domain.ErrOrderAlreadyPaid, intent.ID, intent.Status)
+		}
+	}
+	return nil, nil
+}
+
+// GetPayment returns the payment intent. Users only see their own; other
+// intents fail with repository.ErrPaymentNotFound.
+func (uc *PaymentUseCase) GetPayment(actor domain.Actor, id string) (*domain.PaymentIntent, error) {
+	if id == "" {
+		return nil, errors.New("payment ID cannot be empty")
+	}
+
+	intent, err := uc.paymentRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Role != domain.ActorRoleAdmin && intent.UserID != actor.ID {
+		return nil, repository.ErrPaymentNotFound
+	}
+	return intent, nil
+}
+
+// HandleWebhook applies a webhook call from the payment provider. A
+// succeeded payment confirms its order, or is refunded if the order was
+// cancelled in the meantime. Webhooks the provider sends again are applied
+// only once.
+func (uc *PaymentUseCase) HandleWebhook(payload []byte, signature string) error {
+	notification, err := uc.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	intent, err := uc.paymentRepo.GetByProviderRef(uc.provider.Name(), notification.ProviderRef)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Payment %s of order %s: provider reported %s (event %s)",
+		intent.ID, intent.OrderID, notification.Status, notification.EventID)
+
+	switch notification.Status {
+	case domain.PaymentStatusSucceeded:
+		return uc.paymentSucceeded(intent)
+	case domain.PaymentStatusFailed:
+		return uc.paymentFailed(intent, notification.FailureReason)
+	default:
+		return fmt.Errorf("%w: unexpected status %q", domain.ErrInvalidPaymentWebhook, notification.Status)
+	}
+}
+
+func (uc *PaymentUseCase) paymentSucceeded(intent *domain.PaymentIntent) error {
+	switch intent.Status {
+	case domain.PaymentStatusPending, domain.PaymentStatusFailed:
+		// Providers may report a failed attempt and then a successful
+		// retry of the same payment.
+		err := uc.changeStatus(intent, domain.PaymentStatusSucceeded, "", domain.EventPaymentSucceeded)
+		if errors.Is(err, repository.ErrPaymentStatusChanged) {
+			return uc.reloadAnd(intent.ID, uc.paymentSucceeded)
+		}
+		if errors.Is(err, repository.ErrOrderHasOpenPayment) {
+			return uc.lateCapture(intent)
+		}
+		if err != nil {
+			return err
+		}
+	case domain.PaymentStatusRefunding:
+		// An earlier delivery claimed a refund and did not finish it.
+		return uc.refund(intent)
+	case domain.PaymentStatusRefunded:
+		return nil
+	}
+
+	// The intent has succeeded, but the order may not have been settled if
+	// an earlier delivery of this webhook failed part way.
+	return uc.settleOrder(intent)
+}
+
+// lateCapture handles a failed intent that the provider reports succeeded
+// after the order got another open intent. If that intent is still pending
+// it is superseded, and the late payment pays for the order; the provider
+// cannot take both, since a success for the superseded intent ends up here
+// too. If the order was paid through the other intent, the late payment is
+// refunded.
+func (uc *PaymentUseCase) lateCapture(intent *domain.PaymentIntent) error {
+	intents, err := uc.paymentRepo.ListByOrderID(intent.OrderID)
+	if err != nil {
+		return err
+	}
+
+	for _, open := range intents {
+		if open.ID == intent.ID {
+			continue
+		}
+		switch open.Status {
+		case domain.PaymentStatusPending:
+			log.Printf("Payment %s of order %s came through late, superseding pending payment %s", intent.ID, intent.OrderID, open.ID)
+			err := uc.changeStatus(open, domain.PaymentStatusFailed, "superseded by payment "+intent.ID, domain.EventPaymentFailed)
+			if err != nil && !errors.Is(err, repository.ErrPaymentStatusChanged) {
+				return err
+			}
+			return uc.reloadAnd(intent.ID, uc.paymentSucceeded)
+		case domain.PaymentStatusSucceeded:
+			log.Printf("Order %s was paid through payment %s before payment %s came through, refunding it", intent.OrderID, open.ID, intent.ID)
+			err := uc.changeStatus(intent, domain.PaymentStatusRefunding, "", "")
+			if errors.Is(err, repository.ErrPaymentStatusChanged) {
+				return uc.reloadAnd(intent.ID, uc.paymentSucceeded)
+			}
+			if err != nil {
+				return err
+			}
+			return uc.refundPayment(intent)
+		}
+	}
+
+	// The other intent is no longer open.
+	return uc.reloadAnd(intent.ID, uc.paymentSucceeded)
+}
+
+func (uc *PaymentUseCase) paymentFailed(intent *domain.PaymentIntent, reason string) error {
+	if intent.Status != domain.PaymentStatusPending {
+		log.Printf("Ignoring failure of payment %s: it is already %s", intent.ID, intent.Status)
+		return nil
+	}
+
+	err := uc.changeStatus(intent, domain.PaymentStatusFailed, reason, domain.EventPaymentFailed)
+	if errors.Is(err, repository.ErrPaymentStatusChanged) {
+		return uc.reloadAnd(intent.ID, func(intent *domain.PaymentIntent) error {
+			return uc.paymentFailed(intent, reason)
+		})
+	}
+	return err
+}
+
+// reloadAnd runs fn on the intent's current state, after a concurrent
+// request changed it.
+func (uc *PaymentUseCase) reloadAnd(id string, fn func(intent *domain.PaymentIntent) error) error {
+	intent, err := uc.paymentRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return fn(intent)
+}
+
+// settleOrder confirms the order of a succeeded intent, or refunds the
+// payment if the order was cancelled, or refunded through another intent,
+// before it came through.
+func (uc *PaymentUseCase) settleOrder(intent *domain.PaymentIntent) error {
+	order, err := uc.orderUseCase.GetOrder(intent.OrderID)
+	if err != nil {
+		return err
+	}
+
+	switch order.Status {
+	case domain.OrderStatusPending:
+		_, err := uc.orderUseCase.UpdateOrderStatus(order.ID, domain.OrderStatusConfirmed, domain.SystemActor, "payment received")
+		if errors.Is(err, repository.ErrOrderStatusChanged) {
+			return uc.settleOrder(intent)
+		}
+		return err
+	case domain.OrderStatusCancelled, domain.OrderStatusRefunded:
+		log.Printf("Order %s was %s before payment %s came through, refunding it", order.ID, order.Status, intent.ID)
+		return uc.refund(intent)
+	default:
+		return nil
+	}
+}
+
+// HandleOrderCancelledEvent refunds the payment of a cancelled order, if it
+// was paid for.
+func (uc *PaymentUseCase) HandleOrderCancelledEvent(meta domain.MessageMetadata, event domain.OrderCancelledEvent) error {
+	return handleOnce(uc.processedRepo, meta, func() error {
+		intents, err := uc.paymentRepo.ListByOrderID(event.OrderID)
+		if err != nil {
+			return err
+		}
+
+		for _, intent := range intents {
+			switch intent.Status {
+			case domain.PaymentStatusSucceeded, domain.PaymentStatusRefunding, domain.PaymentStatusRefunded:
+			default:
+				continue
+			}
+
+			return uc.refund(intent)
+		}
+		return nil
+	})
+}
+
+// refund returns a succeeded intent's money and moves its cancelled order to
+// refunded. Either step is skipped if it has already been done. The order is
+// read after the refund, as a concurrent call may have moved it meanwhile.
+func (uc *PaymentUseCase) refund(intent *domain.PaymentIntent) error {
+	if err := uc.refundPayment(intent); err != nil {
+		return err
+	}
+
+	order, err := uc.orderUseCase.GetOrder(intent.OrderID)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != domain.OrderStatusCancelled {
+		return nil
+	}
+
+	_, err = uc.orderUseCase.UpdateOrderStatus(order.ID, domain.OrderStatusRefunded, domain.SystemActor, "payment refunded")
+	if errors.Is(err, repository.ErrOrderStatusChanged) {
+		return nil
+	}
+	return err
+}
+
+// refundPayment returns the money captured for a succeeded intent. The
+// refund is claimed by moving the intent to refunding first, so of two
+// concurrent callers only one goes on to refund. An intent found refunding
+// was claimed by a call that did not finish; repeating the provider call is
+// safe because the intent's ID is the refund's idempotency key.
+func (uc *PaymentUseCase) refundPayment(intent *domain.PaymentIntent) error {
+	switch intent.Status {
+	case domain.PaymentStatusSucceeded:
+		err := uc.changeStatus(intent, domain.PaymentStatusRefunding, "", "")
+		if errors.Is(err, repository.ErrPaymentStatusChanged) {
+			return uc.reloadAnd(intent.ID, uc.refundPayment)
+		}
+		if err != nil {
+			return err
+		}
+	case domain.PaymentStatusRefunding:
+	default:
+		return nil
+	}
+
+	if err := uc.provider.Refund(intent, intent.ID); err != nil {
+		return err
+	}
+
+	err := uc.changeStatus(intent, domain.PaymentStatusRefunded, "", domain.EventPaymentRefunded)
+	if errors.Is(err, repository.ErrPaymentStatusChanged) {
+		// Finished by a concurrent call.
+		return nil
+	}
+	return err
+}
+
+// changeStatus moves intent to status and queues the event published on
+// subject, if any, updating intent to match.
+func (uc *PaymentUseCase) changeStatus(intent *domain.PaymentIntent, status domain.PaymentStatus, failureReason, subject string) error {
+	now := time.Now()
+
+	err := uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		if err := tx.Payments().UpdateStatus(intent.ID, intent.Status, status, failureReason, now); err != nil {
+			return err
+		}
+
+		if subject == "" {
+			return nil
+		}
+
+		changed := *intent
+		changed.Status = status
+		changed.FailureReason = failureReason
+		changed.UpdatedAt = now
+		return addPaymentMessage(tx.Outbox(), &changed, subject)
+	})
+	if err != nil {
+		return err
+	}
+
+	intent.Status = status
+	intent.FailureReason = failureReason
+	intent.UpdatedAt = now
+	return nil
+}
+
+func addPaymentMessage(outbox repository.OutboxRepository, intent *domain.PaymentIntent, subject string) error {
+	message, err := newOutboxMessage("payment", intent.ID, subject, domain.PaymentEvent{
+		PaymentID:     intent.ID,
+		OrderID:       intent.OrderID,
+		UserID:        intent.UserID,
+		Amount:        intent.Amount,
+		Status:        intent.Status,
+		Provider:      intent.Provider,
+		FailureReason: intent.FailureReason,
+		ChangedAt:     intent.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.Add(message)
+}
@@ -0,0 +1,363 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type memoryPaymentRepository struct {
+	intents []*domain.PaymentIntent
+}
+
+func (r *memoryPaymentRepository) Create(intent *domain.PaymentIntent) error {
+	for _, stored := range r.intents {
+		if stored.OrderID == intent.OrderID &&
+			(stored.Status == domain.PaymentStatusPending || stored.Status == domain.PaymentStatusSucceeded) {
+			return repository.ErrOrderHasOpenPayment
+		}
+	}
+	copied := *intent
+	r.intents = append(r.intents, &copied)
+	return nil
+}
+
+func (r *memoryPaymentRepository) find(match func(intent *domain.PaymentIntent) bool) (*domain.PaymentIntent, error) {
+	for _, intent := range r.intents {
+		if match(intent) {
+			copied := *intent
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrPaymentNotFound
+}
+
+func (r *memoryPaymentRepository) GetByID(id string) (*domain.PaymentIntent, error) {
+	return r.find(func(intent *domain.PaymentIntent) bool { return intent.ID == id })
+}
+
+func (r *memoryPaymentRepository) GetByProviderRef(provider, ref string) (*domain.PaymentIntent, error) {
+	return r.find(func(intent *domain.PaymentIntent) bool {
+		return intent.Provider == provider && intent.ProviderRef == ref
+	})
+}
+
+func (r *memoryPaymentRepository) ListByOrderID(orderID string) ([]*domain.PaymentIntent, error) {
+	var intents []*domain.PaymentIntent
+	for i := len(r.intents) - 1; i >= 0; i-- {
+		if r.intents[i].OrderID == orderID {
+			copied := *r.intents[i]
+			intents = append(intents, &copied)
+		}
+	}
+	return intents, nil
+}
+
+func (r *memoryPaymentRepository) UpdateStatus(id string, from, to domain.PaymentStatus, failureReason string, at time.Time) error {
+	for _, intent := range r.intents {
+		if intent.ID == id {
+			if intent.Status != from {
+				return repository.ErrPaymentStatusChanged
+			}
+			if to == domain.PaymentStatusPending || to == domain.PaymentStatusSucceeded {
+				for _, stored := range r.intents {
+					if stored.ID != id && stored.OrderID == intent.OrderID &&
+						(stored.Status == domain.PaymentStatusPending || stored.Status == domain.PaymentStatusSucceeded) {
+						return repository.ErrOrderHasOpenPayment
+					}
+				}
+			}
+			intent.Status = to
+			intent.FailureReason = failureReason
+			intent.UpdatedAt = at
+			return nil
+		}
+	}
+	return repository.ErrPaymentNotFound
+}
+
+// memoryPaymentProvider accepts webhooks of the form "ref:status", signed
+// "valid". It makes one refund per idempotency key; onRefund, if set, runs
+// on every call before the refund is made.
+type memoryPaymentProvider struct {
+	refunds  []string
+	keys     map[string]bool
+	onRefund func()
+}
+
+func (p *memoryPaymentProvider) Name() string { return "memory" }
+
+func (p *memoryPaymentProvider) CreatePayment(intent *domain.PaymentIntent) (string, string, error) {
+	return "ref-" + intent.ID, "secret-" + intent.ID, nil
+}
+
+func (p *memoryPaymentProvider) Refund(intent *domain.PaymentIntent, idempotencyKey string) error {
+	if p.onRefund != nil {
+		onRefund := p.onRefund
+		p.onRefund = nil
+		onRefund()
+	}
+	if p.keys == nil {
+		p.keys = map[string]bool{}
+	}
+	if !p.keys[idempotencyKey] {
+		p.keys[idempotencyKey] = true
+		p.refunds = append(p.refunds, intent.ProviderRef)
+	}
+	return nil
+}
+
+func (p *memoryPaymentProvider) ParseWebhook(payload []byte, signature string) (*domain.PaymentNotification, error) {
+	if signature != "valid" {
+		return nil, domain.ErrInvalidPaymentWebhook
+	}
+	ref, status, _ := strings.Cut(string(payload), ":")
+	return &domain.PaymentNotification{ProviderRef: ref, Status: domain.PaymentStatus(status)}, nil
+}
+
+func newPaymentUseCase(status domain.OrderStatus) (*PaymentUseCase, *memoryTransaction, *memoryPaymentProvider) {
+	orderUseCase, tx := newOrderUseCaseWithOrder(&domain.Order{
+		ID:         "o1",
+		UserID:     "u1",
+		Status:     status,
+		TotalPrice: domain.NewMoney(125000, "KZT"),
+	})
+	tx.payments = &memoryPaymentRepository{}
+	provider := &memoryPaymentProvider{}
+	processed := &memoryProcessedMessages{processed: map[string]time.Time{}}
+	return NewPaymentUseCase(tx.payments, &memoryUnitOfWork{tx: tx}, orderUseCase, provider, processed), tx, provider
+}
+
+func webhook(intent *domain.PaymentIntent, status domain.PaymentStatus) []byte {
+	return []byte(intent.ProviderRef + ":" + string(status))
+}
+
+func TestCreatePayment_ReusesThePendingIntent(t *testing.T) {
+	uc, tx, _ := newPaymentUseCase(domain.OrderStatusPending)
+
+	_, err := uc.CreatePayment("u2", "o1")
+	assert.ErrorIs(t, err, repository.ErrOrderNotFound)
+
+	intent, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPending, intent.Status)
+	assert.Equal(t, domain.NewMoney(125000, "KZT"), intent.Amount)
+	assert.Equal(t, "memory", intent.Provider)
+	assert.Equal(t, "ref-"+intent.ID, intent.ProviderRef)
+
+	assert.Len(t, tx.outbox.messages, 1)
+	assert.Equal(t, domain.EventPaymentIntentCreated, tx.outbox.messages[0].Subject)
+	event := outboxEvent[domain.PaymentEvent](t, tx.outbox.messages[0])
+	assert.Equal(t, intent.ID, event.PaymentID)
+	assert.Equal(t, "o1", event.OrderID)
+
+	again, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+	assert.Equal(t, intent.ID, again.ID)
+	assert.Len(t, tx.outbox.messages, 1)
+
+	uc, _, _ = newPaymentUseCase(domain.OrderStatusCancelled)
+	_, err = uc.CreatePayment("u1", "o1")
+	assert.ErrorIs(t, err, domain.ErrOrderNotPayable)
+}
+
+func TestHandleWebhook_ConfirmsThePaidOrder(t *testing.T) {
+	uc, tx, _ := newPaymentUseCase(domain.OrderStatusPending)
+
+	intent, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+
+	err = uc.HandleWebhook(webhook(intent, domain.PaymentStatusSucceeded), "forged")
+	assert.ErrorIs(t, err, domain.ErrInvalidPaymentWebhook)
+
+	assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusSucceeded), "valid"))
+
+	paid, err := uc.GetPayment(domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusSucceeded, paid.Status)
+	assert.Equal(t, domain.OrderStatusConfirmed, tx.orders.orders["o1"].Status)
+	assert.Equal(t, domain.SystemActor, tx.orders.history[0].Actor)
+
+	var subjects []string
+	for _, message := range tx.outbox.messages {
+		subjects = append(subjects, message.Subject)
+	}
+	assert.Equal(t, []string{domain.EventPaymentIntentCreated, domain.EventPaymentSucceeded, domain.EventOrderStatusChanged}, subjects)
+
+	// The provider sending the webhook again changes nothing.
+	assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusSucceeded), "valid"))
+	assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusFailed), "valid"))
+	assert.Len(t, tx.outbox.messages, 3)
+
+	_, err = uc.CreatePayment("u1", "o1")
+	assert.ErrorIs(t, err, domain.ErrOrderAlreadyPaid)
+
+	_, err = uc.GetPayment(domain.Actor{ID: "u2", Role: domain.ActorRoleUser}, intent.ID)
+	assert.ErrorIs(t, err, repository.ErrPaymentNotFound)
+	_, err = uc.GetPayment(domain.Actor{ID: "admin", Role: domain.ActorRoleAdmin}, intent.ID)
+	assert.NoError(t, err)
+}
+
+func TestHandleWebhook_FailedPaymentsCanBeRetried(t *testing.T) {
+	uc, tx, _ := newPaymentUseCase(domain.OrderStatusPending)
+
+	intent, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+	assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusFailed), "valid"))
+
+	failed, err := uc.GetPayment(domain.Actor{ID: "u1"}, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusFailed, failed.Status)
+	assert.Equal(t, domain.OrderStatusPending, tx.orders.orders["o1"].Status)
+
+	retry, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, intent.ID, retry.ID)
+	assert.Equal(t, domain.PaymentStatusPending, retry.Status)
+}
+
+func TestHandleWebhook_LateSuccessSupersedesThePendingRetry(t *testing.T) {
+	uc, tx, provider := newPaymentUseCase(domain.OrderStatusPending)
+
+	first, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+	assert.NoError(t, uc.HandleWebhook(webhook(first, domain.PaymentStatusFailed), "valid"))
+	retry, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+
+	// The provider reports the first attempt succeeded after all.
+	assert.NoError(t, uc.HandleWebhook(webhook(first, domain.PaymentStatusSucceeded), "valid"))
+
+	paid, err := uc.GetPayment(domain.Actor{ID: "u1"}, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusSucceeded, paid.Status)
+	superseded, err := uc.GetPayment(domain.Actor{ID: "u1"}, retry.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusFailed, superseded.Status)
+	assert.Equal(t, domain.OrderStatusConfirmed, tx.orders.orders["o1"].Status)
+
+	// The retry is then paid as well, and given back.
+	assert.NoError(t, uc.HandleWebhook(webhook(retry, domain.PaymentStatusSucceeded), "valid"))
+	assert.NoError(t, uc.HandleWebhook(webhook(retry, domain.PaymentStatusSucceeded), "valid"))
+
+	refunded, err := uc.GetPayment(domain.Actor{ID: "u1"}, retry.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, refunded.Status)
+	assert.Equal(t, []string{retry.ProviderRef}, provider.refunds)
+	assert.Equal(t, domain.OrderStatusConfirmed, tx.orders.orders["o1"].Status)
+
+	_, err = uc.CreatePayment("u1", "o1")
+	assert.ErrorIs(t, err, domain.ErrOrderAlreadyPaid)
+}
+
+func TestHandleWebhook_RefundsPaymentsForCancelledOrders(t *testing.T) {
+	uc, tx, provider := newPaymentUseCase(domain.OrderStatusPending)
+
+	intent, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+
+	// The order's reservation runs out before the payment comes through.
+	tx.orders.orders["o1"].Status = domain.OrderStatusCancelled
+
+	assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusSucceeded), "valid"))
+
+	assert.Equal(t, []string{intent.ProviderRef}, provider.refunds)
+	refunded, err := uc.GetPayment(domain.Actor{ID: "u1"}, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, refunded.Status)
+	assert.Equal(t, domain.OrderStatusRefunded, tx.orders.orders["o1"].Status)
+}
+
+func TestHandleOrderCancelledEvent_RefundsThePayment(t *testing.T) {
+	uc, tx, provider := newPaymentUseCase(domain.OrderStatusPending)
+
+	intent, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+	assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusSucceeded), "valid"))
+
+	assert.NoError(t, uc.orderUseCase.CancelOrder("o1", domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, "changed my mind"))
+
+	var cancelled *domain.OutboxMessage
+	for _, message := range tx.outbox.messages {
+		if message.Subject == domain.EventOrderCancelled {
+			cancelled = message
+		}
+	}
+	if !assert.NotNil(t, cancelled) {
+		return
+	}
+	meta := domain.MessageMetadata{ID: cancelled.ID, CreatedAt: cancelled.CreatedAt}
+	event := outboxEvent[domain.OrderCancelledEvent](t, cancelled)
+
+	assert.NoError(t, uc.HandleOrderCancelledEvent(meta, event))
+	assert.NoError(t, uc.HandleOrderCancelledEvent(meta, event))
+
+	assert.Equal(t, []string{intent.ProviderRef}, provider.refunds)
+	refunded, err := uc.GetPayment(domain.Actor{ID: "u1"}, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, refunded.Status)
+	assert.Equal(t, domain.OrderStatusRefunded, tx.orders.orders["o1"].Status)
+}
+
+func TestHandleOrderCancelledEvent_RefundsOnceWhenCalledConcurrently(t *testing.T) {
+	uc, tx, provider := newPaymentUseCase(domain.OrderStatusPending)
+
+	intent, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+	assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusSucceeded), "valid"))
+	assert.NoError(t, uc.orderUseCase.CancelOrder("o1", domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, ""))
+
+	event := domain.OrderCancelledEvent{OrderID: "o1"}
+	var claimed domain.PaymentStatus
+	provider.onRefund = func() {
+		// The refund was claimed before the provider is called, so a
+		// webhook arriving now finds nothing left to claim.
+		stored, err := tx.payments.GetByID(intent.ID)
+		assert.NoError(t, err)
+		claimed = stored.Status
+		assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusSucceeded), "valid"))
+	}
+
+	assert.NoError(t, uc.HandleOrderCancelledEvent(domain.MessageMetadata{ID: "m1"}, event))
+
+	assert.Equal(t, domain.PaymentStatusRefunding, claimed)
+	assert.Equal(t, []string{intent.ProviderRef}, provider.refunds)
+	assert.Equal(t, map[string]bool{intent.ID: true}, provider.keys)
+	assert.Equal(t, domain.OrderStatusRefunded, tx.orders.orders["o1"].Status)
+
+	var refundedEvents int
+	for _, message := range tx.outbox.messages {
+		if message.Subject == domain.EventPaymentRefunded {
+			refundedEvents++
+		}
+	}
+	assert.Equal(t, 1, refundedEvents)
+}
+
+func TestHandleOrderCancelledEvent_FinishesAnInterruptedRefund(t *testing.T) {
+	uc, tx, provider := newPaymentUseCase(domain.OrderStatusPending)
+
+	intent, err := uc.CreatePayment("u1", "o1")
+	assert.NoError(t, err)
+	assert.NoError(t, uc.HandleWebhook(webhook(intent, domain.PaymentStatusSucceeded), "valid"))
+	assert.NoError(t, uc.orderUseCase.CancelOrder("o1", domain.Actor{ID: "u1", Role: domain.ActorRoleUser}, ""))
+
+	// An earlier attempt claimed the refund and made it, then stopped.
+	assert.NoError(t, tx.payments.UpdateStatus(intent.ID, domain.PaymentStatusSucceeded, domain.PaymentStatusRefunding, "", time.Now()))
+	claimed, err := tx.payments.GetByID(intent.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, provider.Refund(claimed, intent.ID))
+
+	assert.NoError(t, uc.HandleOrderCancelledEvent(domain.MessageMetadata{ID: "m1"}, domain.OrderCancelledEvent{OrderID: "o1"}))
+
+	assert.Equal(t, []string{intent.ProviderRef}, provider.refunds)
+	refunded, err := uc.GetPayment(domain.Actor{ID: "u1"}, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusRefunded, refunded.Status)
+	assert.Equal(t, domain.OrderStatusRefunded, tx.orders.orders["o1"].Status)
+}
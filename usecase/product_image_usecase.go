@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"AdvProg2/domain"
+	"AdvProg2/pkg/cache"
+	"AdvProg2/pkg/thumbnail"
+	"AdvProg2/repository"
+)
+
+const (
+	// MaxProductImageSize caps the size of an uploaded image file.
+	MaxProductImageSize = 10 << 20
+	// productThumbnailSize is the largest side of a thumbnail, in pixels.
+	productThumbnailSize = 320
+)
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type ProductImageUseCase struct {
+	productRepo repository.ProductRepository
+	imageRepo   repository.ProductImageRepository
+	store       repository.BlobStore
+	cache       *cache.Cache
+}
+
+func NewProductImageUseCase(productRepo repository.ProductRepository, imageRepo repository.ProductImageRepository, store repository.BlobStore) *ProductImageUseCase {
+	return &ProductImageUseCase{
+		productRepo: productRepo,
+		imageRepo:   imageRepo,
+		store:       store,
+		cache:       cache.New(),
+	}
+}
+
+// AddImage stores a JPEG, PNG or GIF image with a thumbnail and appends it
+// to the product's gallery.
+func (uc *ProductImageUseCase) AddImage(productID string, data []byte) (*domain.ProductImage, error) {
+	if _, err := uc.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	if len(data) > MaxProductImageSize {
+		return nil, fmt.Errorf("%w: larger than %d bytes", domain.ErrInvalidImage, MaxProductImageSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not supported", domain.ErrInvalidImage, contentType)
+	}
+
+	thumb, thumbType, err := thumbnail.Make(data, productThumbnailSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidImage, err)
+	}
+
+	id := uuid.New().String()
+	image := &domain.ProductImage{
+		ID:           id,
+		ProductID:    productID,
+		Key:          fmt.Sprintf("products/%s/%s%s", productID, id, extension),
+		ThumbnailKey: fmt.Sprintf("products/%s/%s_thumb%s", productID, id, imageExtensions[thumbType]),
+	}
+	image.URL = uc.store.URL(image.Key)
+	image.ThumbnailURL = uc.store.URL(image.ThumbnailKey)
+
+	if err := uc.store.Put(image.Key, contentType, data); err != nil {
+		return nil, err
+	}
+	if err := uc.store.Put(image.ThumbnailKey, thumbType, thumb); err != nil {
+		uc.deleteBlobs(image)
+		return nil, err
+	}
+
+	if err := uc.imageRepo.Add(image); err != nil {
+		uc.deleteBlobs(image)
+		return nil, err
+	}
+
+	uc.cache.Delete("product:" + productID)
+
+	return image, nil
+}
+
+// ListImages returns the product's gallery in display order.
+func (uc *ProductImageUseCase) ListImages(productID string) ([]domain.ProductImage, error) {
+	if _, err := uc.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	return uc.imageRepo.ListByProduct(productID)
+}
+
+// ReorderImages puts the product's gallery in the order of imageIDs, which
+// must list each of its images once, and returns the reordered gallery.
+func (uc *ProductImageUseCase) ReorderImages(productID string, imageIDs []string) ([]domain.ProductImage, error) {
+	images, err := uc.ListImages(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(imageIDs) != len(images) {
+		return nil, domain.ErrInvalidImageOrder
+	}
+
+	remaining := make(map[string]bool, len(images))
+	for _, image := range images {
+		remaining[image.ID] = true
+	}
+	for _, id := range imageIDs {
+		if !remaining[id] {
+			return nil, domain.ErrInvalidImageOrder
+		}
+		delete(remaining, id)
+	}
+
+	if err := uc.imageRepo.Reorder(productID, imageIDs); err != nil {
+		return nil, err
+	}
+
+	uc.cache.Delete("product:" + productID)
+
+	return uc.imageRepo.ListByProduct(productID)
+}
+
+// DeleteImage removes the image from the product's gallery and deletes its
+// files.
+func (uc *ProductImageUseCase) DeleteImage(productID, imageID string) error {
+	image, err := uc.imageRepo.GetByID(imageID)
+	if err != nil {
+		return err
+	}
+
+	if image.ProductID != productID {
+		return repository.ErrProductImageNotFound
+	}
+
+	if err := uc.imageRepo.Delete(imageID); err != nil {
+		return err
+	}
+
+	uc.cache.Delete("product:" + productID)
+	uc.deleteBlobs(image)
+
+	return nil
+}
+
+// deleteBlobs removes the image's files. A file left behind is only wasted
+// space, so failures are logged rather than returned.
+func (uc *ProductImageUseCase) deleteBlobs(image *domain.ProductImage) {
+	for _, key := range []string{image.Key, image.ThumbnailKey} {
+		if err := uc.store.Delete(key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type knownProductRepository struct {
+	repository.ProductRepository
+	ids map[string]bool
+}
+
+func (r *knownProductRepository) GetByID(id string) (*domain.Product, error) {
+	if !r.ids[id] {
+		return nil, errors.New("product not found")
+	}
+	return &domain.Product{ID: id}, nil
+}
+
+type memoryProductImageRepository struct {
+	images []*domain.ProductImage
+}
+
+func (r *memoryProductImageRepository) Add(image *domain.ProductImage) error {
+	image.Position = int32(len(r.images))
+	r.images = append(r.images, image)
+	return nil
+}
+
+func (r *memoryProductImageRepository) GetByID(id string) (*domain.ProductImage, error) {
+	for _, image := range r.images {
+		if image.ID == id {
+			return image, nil
+		}
+	}
+	return nil, repository.ErrProductImageNotFound
+}
+
+func (r *memoryProductImageRepository) ListByProduct(productID string) ([]domain.ProductImage, error) {
+	images := []domain.ProductImage{}
+	for _, image := range r.images {
+		if image.ProductID == productID {
+			images = append(images, *image)
+		}
+	}
+	return images, nil
+}
+
+func (r *memoryProductImageRepository) Reorder(productID string, imageIDs []string) error {
+	for position, id := range imageIDs {
+		image, _ := r.GetByID(id)
+		image.Position = int32(position)
+	}
+	return nil
+}
+
+func (r *memoryProductImageRepository) Delete(id string) error {
+	for i, image := range r.images {
+		if image.ID == id {
+			r.images = append(r.images[:i], r.images[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrProductImageNotFound
+}
+
+type memoryBlobStore map[string]string
+
+func (s memoryBlobStore) Put(key, contentType string, data []byte) error {
+	s[key] = contentType
+	return nil
+}
+
+func (s memoryBlobStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func (s memoryBlobStore) URL(key string) string {
+	return "/media/" + key
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestProductImages_AddStoresImageAndThumbnail(t *testing.T) {
+	images := &memoryProductImageRepository{}
+	store := memoryBlobStore{}
+	uc := NewProductImageUseCase(&knownProductRepository{ids: map[string]bool{"p1": true}}, images, store)
+
+	first, err := uc.AddImage("p1", pngImage(t, 1000, 500))
+	assert.NoError(t, err)
+	second, err := uc.AddImage("p1", pngImage(t, 10, 10))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/media/products/p1/"+first.ID+".png", first.URL)
+	assert.Equal(t, "/media/products/p1/"+first.ID+"_thumb.png", first.ThumbnailURL)
+	assert.Equal(t, int32(1), second.Position)
+	assert.Equal(t, memoryBlobStore{
+		first.Key: "image/png", first.ThumbnailKey: "image/png",
+		second.Key: "image/png", second.ThumbnailKey: "image/png",
+	}, store)
+
+	_, err = uc.AddImage("p1", []byte("%PDF-1.4"))
+	assert.ErrorIs(t, err, domain.ErrInvalidImage)
+	_, err = uc.AddImage("p2", pngImage(t, 10, 10))
+	assert.Error(t, err)
+	assert.Len(t, store, 4)
+}
+
+func TestProductImages_ReorderAndDelete(t *testing.T) {
+	images := &memoryProductImageRepository{}
+	store := memoryBlobStore{}
+	uc := NewProductImageUseCase(&knownProductRepository{ids: map[string]bool{"p1": true, "p2": true}}, images, store)
+
+	a, _ := uc.AddImage("p1", pngImage(t, 10, 10))
+	b, _ := uc.AddImage("p1", pngImage(t, 10, 10))
+
+	for _, order := range [][]string{{a.ID}, {a.ID, a.ID}, {a.ID, "other"}} {
+		_, err := uc.ReorderImages("p1", order)
+		assert.ErrorIs(t, err, domain.ErrInvalidImageOrder, "%v", order)
+	}
+
+	gallery, err := uc.ReorderImages("p1", []string{b.ID, a.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), gallery[0].Position)
+	assert.Equal(t, int32(0), gallery[1].Position)
+
+	assert.ErrorIs(t, uc.DeleteImage("p2", a.ID), repository.ErrProductImageNotFound)
+	assert.NoError(t, uc.DeleteImage("p1", a.ID))
+	assert.NotContains(t, store, a.Key)
+	assert.NotContains(t, store, a.ThumbnailKey)
+	assert.Len(t, store, 2)
+}
@@ -0,0 +1,521 @@
+package usecase
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+// ProductFormat is a file format for importing and exporting the catalog.
+type ProductFormat string
+
+const (
+	// ProductFormatCSV has a header row naming the columns in
+	// productCSVColumns, in any order. Tags are separated by "|".
+	ProductFormatCSV ProductFormat = "csv"
+	// ProductFormatJSONLines has one product per line, encoded as the API
+	// returns it.
+	ProductFormatJSONLines ProductFormat = "jsonl"
+)
+
+const (
+	// productImportBatchSize is the number of rows written per transaction.
+	productImportBatchSize = 100
+	// productExportPageSize is the number of products read per query.
+	productExportPageSize = 500
+	// maxProductLineSize caps a line of a JSON Lines import.
+	maxProductLineSize = 1 << 20
+)
+
+var productCSVColumns = []string{"id", "name", "price", "currency", "stock", "category_id", "tags"}
+
+// productRow is a product read from an import file, or the reason the row
+// could not be read.
+type productRow struct {
+	line    int
+	product *domain.Product
+	err     error
+}
+
+// productRowReader returns the rows of an import file one at a time.
+type productRowReader interface {
+	// Next returns the next row, or false after the last one. When the rest
+	// of the file cannot be read, the last row carries the error.
+	Next() (*productRow, bool)
+}
+
+// importOutcome is what upsertProduct did with a row.
+type importOutcome int
+
+const (
+	importCreated importOutcome = iota
+	importUpdated
+	importUnchanged
+)
+
+// ImportProducts creates or updates a product for each row read from r. Rows
+// with an ID update that product, or create it when there is none; rows
+// without one always create a product. Every row is the whole product, so
+// empty fields are stored empty.
+//
+// Rows are validated like CreateProduct and written in batches, each in one
+// transaction with a product.created or product.updated event per changed
+// row. Rows that fail are left out and listed in the report. Only a file
+// that cannot be read at all fails with domain.ErrInvalidProductImport.
+func (uc *ProductUseCase) ImportProducts(r io.Reader, format ProductFormat) (*domain.ProductImportReport, error) {
+	var rows productRowReader
+	switch format {
+	case ProductFormatCSV:
+		reader, err := newCSVProductReader(r)
+		if err != nil {
+			return nil, err
+		}
+		rows = reader
+	case ProductFormatJSONLines:
+		rows = newJSONLinesProductReader(r)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", domain.ErrInvalidProductImport, format)
+	}
+
+	report := &domain.ProductImportReport{ID: uuid.New().String(), Errors: []domain.ProductImportError{}}
+	batch := make([]*productRow, 0, productImportBatchSize)
+
+	for {
+		row, ok := rows.Next()
+		if !ok {
+			break
+		}
+
+		if row.err == nil {
+			row.err = validateNewProduct(row.product.Name, row.product.Price, row.product.Stock)
+		}
+		if row.err != nil {
+			addImportError(report, row, row.err)
+			continue
+		}
+
+		batch = append(batch, row)
+		if len(batch) == productImportBatchSize {
+			uc.importBatch(batch, report)
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		uc.importBatch(batch, report)
+	}
+
+	// Rows failing validation are reported as they are read, ahead of rows
+	// failing in the batches around them.
+	slices.SortStableFunc(report.Errors, func(a, b domain.ProductImportError) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+
+	return report, nil
+}
+
+func addImportError(report *domain.ProductImportReport, row *productRow, err error) {
+	importError := domain.ProductImportError{Line: row.line, Error: err.Error()}
+	if row.product != nil {
+		importError.ID = row.product.ID
+	}
+
+	report.Failed++
+	report.Errors = append(report.Errors, importError)
+}
+
+// importBatch writes rows in one transaction. When that fails, they are
+// retried in a transaction each, so a bad row only fails itself.
+func (uc *ProductUseCase) importBatch(rows []*productRow, report *domain.ProductImportReport) {
+	err := uc.importRows(rows, report)
+	if err == nil {
+		return
+	}
+
+	if len(rows) == 1 {
+		addImportError(report, rows[0], err)
+		return
+	}
+
+	for _, row := range rows {
+		if err := uc.importRows([]*productRow{row}, report); err != nil {
+			addImportError(report, row, err)
+		}
+	}
+}
+
+// importRows upserts rows in one transaction and counts them in report once
+// it commits.
+func (uc *ProductUseCase) importRows(rows []*productRow, report *domain.ProductImportReport) error {
+	outcomes := make([]importOutcome, len(rows))
+	stored := make([]*domain.Product, len(rows))
+
+	err := uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		for i, row := range rows {
+			product, outcome, err := upsertProduct(tx, row.product, report.ID)
+			if err != nil {
+				return err
+			}
+			stored[i], outcomes[i] = product, outcome
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range rows {
+		switch outcomes[i] {
+		case importCreated:
+			report.Created++
+		case importUpdated:
+			report.Updated++
+		case importUnchanged:
+			report.Unchanged++
+			continue
+		}
+
+		uc.cache.Set("product:"+stored[i].ID, stored[i], 5*time.Minute)
+	}
+
+	return nil
+}
+
+// upsertProduct stores a copy of row and queues its event, unless it matches
+// the stored product exactly, and returns the copy. row is left as it was
+// read, so a row without an ID gets a new one each time it is retried and
+// is reported without one. A change of stock is recorded as a movement
+// referencing importID.
+func upsertProduct(tx repository.Transaction, row *domain.Product, importID string) (*domain.Product, importOutcome, error) {
+	copied := *row
+	product := &copied
+	product.Price = domain.NewMoney(product.Price.Amount, product.Price.Currency)
+	product.Tags = domain.NormalizeTags(product.Tags)
+
+	outcome := importCreated
+	if product.ID == "" {
+		product.ID = uuid.New().String()
+	} else {
+		existing, err := tx.Products().GetByID(product.ID)
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+		case err != nil:
+			return nil, 0, err
+		case sameProductFields(existing, product):
+			return product, importUnchanged, nil
+		default:
+			outcome = importUpdated
+			product.Images = existing.Images
+			product.ReorderThreshold = existing.ReorderThreshold
+		}
+	}
+
+	var err error
+	if outcome == importUpdated {
+		err = tx.Products().Update(product)
+	} else {
+		err = tx.Products().Create(product)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	movement := &domain.StockMovement{
+		ID:          uuid.New().String(),
+		ProductID:   product.ID,
+		Reason:      domain.StockMovementImport,
+		ReferenceID: importID,
+	}
+	if err := tx.StockMovements().SetStock(movement, product.Stock); err != nil {
+		return nil, 0, err
+	}
+
+	if err := addLowStockAlert(tx, product, movement); err != nil {
+		return nil, 0, err
+	}
+
+	var message *domain.OutboxMessage
+	if outcome == importUpdated {
+		message, err = newOutboxMessage("product", product.ID, domain.EventProductUpdated, newProductUpdatedEvent(product))
+	} else {
+		message, err = newOutboxMessage("product", product.ID, domain.EventProductCreated, newProductCreatedEvent(product))
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Outbox().Add(message); err != nil {
+		return nil, 0, err
+	}
+
+	return product, outcome, nil
+}
+
+// sameProductFields reports whether a and b have the same importable fields.
+func sameProductFields(a, b *domain.Product) bool {
+	return a.Name == b.Name &&
+		a.Price == b.Price &&
+		a.Stock == b.Stock &&
+		a.CategoryID == b.CategoryID &&
+		slices.Equal(a.Tags, b.Tags)
+}
+
+type csvProductReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	line    int
+	done    bool
+}
+
+// newCSVProductReader reads the header row, which must name the name and
+// price columns.
+func newCSVProductReader(r io.Reader) (*csvProductReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: missing CSV header", domain.ErrInvalidProductImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidProductImport, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(productCSVColumns, name) {
+			return nil, fmt.Errorf("%w: unknown CSV column %q", domain.ErrInvalidProductImport, name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate CSV column %q", domain.ErrInvalidProductImport, name)
+		}
+		columns[name] = i
+	}
+
+	for _, name := range []string{"name", "price"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing CSV column %q", domain.ErrInvalidProductImport, name)
+		}
+	}
+
+	return &csvProductReader{reader: reader, columns: columns, line: 1}, nil
+}
+
+func (r *csvProductReader) Next() (*productRow, bool) {
+	if r.done {
+		return nil, false
+	}
+
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		r.done = true
+		return nil, false
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		r.line = parseErr.StartLine
+		// A row with the wrong number of fields still ends where expected,
+		// so the rows after it can be read.
+		if !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			r.done = true
+		}
+		return &productRow{line: r.line, err: parseErr.Err}, true
+	}
+	if err != nil {
+		r.done = true
+		return &productRow{line: r.line + 1, err: err}, true
+	}
+
+	r.line, _ = r.reader.FieldPos(0)
+	product, err := r.product(record)
+	return &productRow{line: r.line, product: product, err: err}, true
+}
+
+func (r *csvProductReader) product(record []string) (*domain.Product, error) {
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	product := &domain.Product{
+		ID:         field("id"),
+		Name:       field("name"),
+		CategoryID: field("category_id"),
+	}
+
+	price, err := domain.ParseMoney(field("price"), field("currency"))
+	if err != nil {
+		return product, fmt.Errorf("price: %w", err)
+	}
+	product.Price = price
+
+	if stock := field("stock"); stock != "" {
+		parsed, err := strconv.ParseInt(stock, 10, 32)
+		if err != nil {
+			return product, fmt.Errorf("stock: invalid number %q", stock)
+		}
+		product.Stock = int32(parsed)
+	}
+
+	if tags := field("tags"); tags != "" {
+		product.Tags = strings.Split(tags, "|")
+	}
+
+	return product, nil
+}
+
+type jsonLinesProductReader struct {
+	scanner *bufio.Scanner
+	line    int
+	done    bool
+}
+
+func newJSONLinesProductReader(r io.Reader) *jsonLinesProductReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxProductLineSize)
+
+	return &jsonLinesProductReader{scanner: scanner}
+}
+
+func (r *jsonLinesProductReader) Next() (*productRow, bool) {
+	if r.done {
+		return nil, false
+	}
+
+	for r.scanner.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var product domain.Product
+		if err := json.Unmarshal([]byte(line), &product); err != nil {
+			return &productRow{line: r.line, err: err}, true
+		}
+
+		// Galleries are managed through their own endpoints.
+		product.Images = nil
+		product.ID = strings.TrimSpace(product.ID)
+
+		return &productRow{line: r.line, product: &product}, true
+	}
+
+	r.done = true
+	if err := r.scanner.Err(); err != nil {
+		return &productRow{line: r.line + 1, err: err}, true
+	}
+	return nil, false
+}
+
+// productWriter writes the products of an export.
+type productWriter interface {
+	Write(product *domain.Product) error
+	// Flush writes out buffered products.
+	Flush() error
+}
+
+// ExportProducts writes the whole catalog to w, sorted by name. Products are
+// read and written a page at a time, so the catalog is never held in memory
+// at once. The output can be imported back with ImportProducts.
+func (uc *ProductUseCase) ExportProducts(w io.Writer, format ProductFormat) error {
+	var out productWriter
+	switch format {
+	case ProductFormatCSV:
+		out = newCSVProductWriter(w)
+	case ProductFormatJSONLines:
+		out = &jsonLinesProductWriter{buf: bufio.NewWriter(w)}
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+
+	filter := repository.ProductFilter{SortBy: repository.ProductSortByName}
+	page := repository.PageRequest{Limit: productExportPageSize}
+
+	for {
+		result, err := uc.productRepo.ListPage(filter, page)
+		if err != nil {
+			return err
+		}
+
+		for _, product := range result.Items {
+			if err := out.Write(product); err != nil {
+				return err
+			}
+		}
+
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+		if result.NextPageToken == "" {
+			return nil
+		}
+		page.Token = result.NextPageToken
+	}
+}
+
+type csvProductWriter struct {
+	writer *csv.Writer
+}
+
+// newCSVProductWriter buffers the header row. Write errors are kept by the
+// csv.Writer and returned by Flush.
+func newCSVProductWriter(w io.Writer) *csvProductWriter {
+	writer := csv.NewWriter(w)
+	writer.Write(productCSVColumns)
+
+	return &csvProductWriter{writer: writer}
+}
+
+func (w *csvProductWriter) Write(product *domain.Product) error {
+	// String formats the amount as "12.50 KZT", and ParseMoney reads the
+	// part before the currency.
+	price, _, _ := strings.Cut(product.Price.String(), " ")
+
+	return w.writer.Write([]string{
+		product.ID,
+		product.Name,
+		price,
+		product.Price.Currency,
+		strconv.Itoa(int(product.Stock)),
+		product.CategoryID,
+		strings.Join(product.Tags, "|"),
+	})
+}
+
+func (w *csvProductWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonLinesProductWriter struct {
+	buf *bufio.Writer
+}
+
+func (w *jsonLinesProductWriter) Write(product *domain.Product) error {
+	// Encode ends each value with a newline.
+	return json.NewEncoder(w.buf).Encode(product)
+}
+
+func (w *jsonLinesProductWriter) Flush() error {
+	return w.buf.Flush()
+}
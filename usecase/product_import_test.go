@@ -0,0 +1,281 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+// memoryCatalog stores products by ID, with their stock movements, and
+// fails to create any product named failName.
+type memoryCatalog struct {
+	repository.ProductRepository
+	repository.StockMovementRepository
+	products  map[string]*domain.Product
+	movements []*domain.StockMovement
+	failName  string
+}
+
+func (r *memoryCatalog) Create(product *domain.Product) error {
+	if product.Name == r.failName {
+		return errors.New("insert failed")
+	}
+	copied := *product
+	copied.Stock = 0
+	r.products[product.ID] = &copied
+	return nil
+}
+
+func (r *memoryCatalog) GetByID(id string) (*domain.Product, error) {
+	product, ok := r.products[id]
+	if !ok {
+		return nil, repository.ErrProductNotFound
+	}
+	copied := *product
+	return &copied, nil
+}
+
+func (r *memoryCatalog) Update(product *domain.Product) error {
+	copied := *product
+	copied.Stock = r.products[product.ID].Stock
+	r.products[product.ID] = &copied
+	return nil
+}
+
+func (r *memoryCatalog) Record(movement *domain.StockMovement) (*domain.Product, error) {
+	product := r.products[movement.ProductID]
+	product.Stock += movement.Delta
+	movement.StockAfter = product.Stock
+	r.movements = append(r.movements, movement)
+	copied := *product
+	return &copied, nil
+}
+
+func (r *memoryCatalog) SetStock(movement *domain.StockMovement, stock int32) error {
+	product := r.products[movement.ProductID]
+	movement.Delta = stock - product.Stock
+	movement.StockAfter = stock
+	if movement.Delta != 0 {
+		product.Stock = stock
+		r.movements = append(r.movements, movement)
+	}
+	return nil
+}
+
+func (r *memoryCatalog) SetReorderThreshold(id string, threshold int32) error {
+	product, ok := r.products[id]
+	if !ok {
+		return repository.ErrProductNotFound
+	}
+	product.ReorderThreshold = threshold
+	return nil
+}
+
+// ListPage returns two products per page by name, with the offset of the
+// next page as its token.
+func (r *memoryCatalog) ListPage(filter repository.ProductFilter, page repository.PageRequest) (*repository.Page[*domain.Product], error) {
+	products := slices.SortedFunc(maps.Values(r.products), func(a, b *domain.Product) int {
+		return strings.Compare(a.Name+a.ID, b.Name+b.ID)
+	})
+
+	start, _ := strconv.Atoi(page.Token)
+	end := min(start+2, len(products))
+
+	result := &repository.Page[*domain.Product]{Items: products[start:end]}
+	if end < len(products) {
+		result.NextPageToken = strconv.Itoa(end)
+	}
+	return result, nil
+}
+
+type catalogTransaction struct {
+	repository.Transaction
+	products *memoryCatalog
+	outbox   *memoryOutbox
+}
+
+func (t *catalogTransaction) Products() repository.ProductRepository             { return t.products }
+func (t *catalogTransaction) StockMovements() repository.StockMovementRepository { return t.products }
+func (t *catalogTransaction) Outbox() repository.OutboxRepository                { return t.outbox }
+
+// catalogUnitOfWork rolls back the catalog and outbox when fn fails.
+type catalogUnitOfWork struct {
+	tx *catalogTransaction
+}
+
+func (u *catalogUnitOfWork) Do(fn func(tx repository.Transaction) error) error {
+	products := map[string]*domain.Product{}
+	for id, product := range u.tx.products.products {
+		copied := *product
+		products[id] = &copied
+	}
+	movements := len(u.tx.products.movements)
+	messages := len(u.tx.outbox.messages)
+
+	err := fn(u.tx)
+	if err != nil {
+		u.tx.products.products = products
+		u.tx.products.movements = u.tx.products.movements[:movements]
+		u.tx.outbox.messages = u.tx.outbox.messages[:messages]
+	}
+	return err
+}
+
+func newCatalogUseCase(products ...*domain.Product) (*ProductUseCase, *catalogTransaction) {
+	tx := &catalogTransaction{
+		products: &memoryCatalog{products: map[string]*domain.Product{}},
+		outbox:   &memoryOutbox{},
+	}
+	for _, product := range products {
+		tx.products.products[product.ID] = product
+	}
+	return NewProductUseCase(tx.products, &catalogUnitOfWork{tx: tx}, nil, memoryRates{}), tx
+}
+
+func outboxSubjects(outbox *memoryOutbox) map[string]string {
+	subjects := map[string]string{}
+	for _, message := range outbox.messages {
+		subjects[message.AggregateID] = message.Subject
+	}
+	return subjects
+}
+
+func TestImportProducts_CSVUpsertsAndReportsBadRows(t *testing.T) {
+	uc, tx := newCatalogUseCase(
+		&domain.Product{ID: "p1", Name: "Apple", Price: domain.NewMoney(100, "KZT"), Stock: 5, Tags: []string{"fruit"}},
+		&domain.Product{ID: "p2", Name: "Bread", Price: domain.NewMoney(250, "KZT"), Stock: 1},
+	)
+
+	csv := "name,id,price,stock,tags\n" +
+		"Apple,p1,1.00,5,Fruit\n" +
+		"Bread,p2,3,10,\n" +
+		"Cheese,p3,12.5,2,dairy|Aged\n" +
+		"Milk,,0.99,,\n" +
+		",p4,1,1,\n" +
+		"Eggs,p5,-1,1,\n" +
+		"Salt,p6,1,lots,\n" +
+		"Sugar,p7\n"
+
+	report, err := uc.ImportProducts(strings.NewReader(csv), ProductFormatCSV)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, 4, report.Failed)
+	assert.Equal(t, []domain.ProductImportError{
+		{Line: 6, ID: "p4", Error: "product name cannot be empty"},
+		{Line: 7, ID: "p5", Error: "price cannot be negative"},
+		{Line: 8, ID: "p6", Error: `stock: invalid number "lots"`},
+		{Line: 9, Error: "wrong number of fields"},
+	}, report.Errors)
+
+	assert.Equal(t, int32(10), tx.products.products["p2"].Stock)
+	assert.Equal(t, domain.NewMoney(300, "KZT"), tx.products.products["p2"].Price)
+	assert.Equal(t, []string{"aged", "dairy"}, tx.products.products["p3"].Tags)
+	assert.Len(t, tx.products.products, 4)
+
+	assert.Len(t, tx.products.movements, 2)
+	for _, movement := range tx.products.movements {
+		assert.Equal(t, domain.StockMovementImport, movement.Reason)
+		assert.Equal(t, report.ID, movement.ReferenceID)
+	}
+	assert.Equal(t, int32(9), tx.products.movements[0].Delta)
+
+	subjects := outboxSubjects(tx.outbox)
+	assert.Len(t, subjects, 3)
+	assert.Equal(t, domain.EventProductUpdated, subjects["p2"])
+	assert.Equal(t, domain.EventProductCreated, subjects["p3"])
+}
+
+func TestImportProducts_FailedRowDoesNotFailItsBatch(t *testing.T) {
+	uc, tx := newCatalogUseCase()
+	tx.products.failName = "Broken"
+
+	jsonl := `{"id": "p1", "name": "Apple", "price": 1.5}` + "\n" +
+		"\n" +
+		`{"id": "p2", "name": "Broken", "price": 2}` + "\n" +
+		`{"id": "p3", "name": "Cheese", "price": {"amount": 1250, "currency": "usd"}, "stock": 3}` + "\n" +
+		`{"id": "p4", "name": ` + "\n"
+
+	report, err := uc.ImportProducts(strings.NewReader(jsonl), ProductFormatJSONLines)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, domain.ProductImportError{Line: 3, ID: "p2", Error: "insert failed"}, report.Errors[0])
+	assert.Equal(t, 5, report.Errors[1].Line)
+
+	assert.Equal(t, domain.NewMoney(1250, "USD"), tx.products.products["p3"].Price)
+	assert.Len(t, tx.products.products, 2)
+	assert.Len(t, tx.outbox.messages, 2)
+}
+
+func TestImportProducts_FailedRowWithoutIDIsReportedWithoutOne(t *testing.T) {
+	uc, tx := newCatalogUseCase()
+	tx.products.failName = "Broken"
+
+	jsonl := `{"name": "Apple", "price": 1.5}` + "\n" +
+		`{"name": "Broken", "price": 2}` + "\n"
+
+	report, err := uc.ImportProducts(strings.NewReader(jsonl), ProductFormatJSONLines)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, []domain.ProductImportError{{Line: 2, Error: "insert failed"}}, report.Errors)
+	assert.Len(t, tx.products.products, 1)
+}
+
+func TestImportProducts_RejectsUnreadableFiles(t *testing.T) {
+	uc, tx := newCatalogUseCase()
+
+	files := map[string]ProductFormat{
+		"":                    ProductFormatCSV,
+		"name,price,colour\n": ProductFormatCSV,
+		"name,name,price\n":   ProductFormatCSV,
+		"id,name\np1,Apple\n": ProductFormatCSV,
+		`{"name": "Apple"}`:   "xml",
+	}
+
+	for file, format := range files {
+		_, err := uc.ImportProducts(strings.NewReader(file), format)
+		assert.ErrorIs(t, err, domain.ErrInvalidProductImport, "%q", file)
+	}
+	assert.Empty(t, tx.products.products)
+}
+
+func TestExportProducts_RoundTripsThroughImport(t *testing.T) {
+	uc, tx := newCatalogUseCase(
+		&domain.Product{ID: "p1", Name: "Apple, green", Price: domain.NewMoney(105, "KZT"), Stock: 5, CategoryID: "c1", Tags: []string{"fruit", "green"}},
+		&domain.Product{ID: "p2", Name: "Bread", Price: domain.NewMoney(250, "USD"), Stock: 0},
+		&domain.Product{ID: "p3", Name: "Cheese", Price: domain.NewMoney(0, "KZT"), Stock: 2},
+	)
+
+	var csvOut bytes.Buffer
+	assert.NoError(t, uc.ExportProducts(&csvOut, ProductFormatCSV))
+	assert.Equal(t, "id,name,price,currency,stock,category_id,tags\n"+
+		"p1,\"Apple, green\",1.05,KZT,5,c1,fruit|green\n"+
+		"p2,Bread,2.50,USD,0,,\n"+
+		"p3,Cheese,0.00,KZT,2,,\n", csvOut.String())
+
+	var jsonOut bytes.Buffer
+	assert.NoError(t, uc.ExportProducts(&jsonOut, ProductFormatJSONLines))
+	assert.Equal(t, 3, strings.Count(jsonOut.String(), "\n"))
+
+	for format, out := range map[ProductFormat]*bytes.Buffer{ProductFormatCSV: &csvOut, ProductFormatJSONLines: &jsonOut} {
+		report, err := uc.ImportProducts(out, format)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, report.Unchanged, format)
+		assert.Empty(t, report.Errors, format)
+	}
+	assert.Empty(t, tx.outbox.messages)
+}
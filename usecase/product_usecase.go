@@ -3,6 +3,8 @@ package usecase
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,19 +16,39 @@ import (
 
 type ProductUseCase struct {
 	productRepo    repository.ProductRepository
+	unitOfWork     repository.UnitOfWork
 	messageUseCase *MessageUseCase
+	rates          repository.ExchangeRateProvider
 	cache          *cache.Cache
 }
 
-func NewProductUseCase(productRepo repository.ProductRepository, messageUseCase *MessageUseCase) *ProductUseCase {
+func NewProductUseCase(productRepo repository.ProductRepository, unitOfWork repository.UnitOfWork, messageUseCase *MessageUseCase, rates repository.ExchangeRateProvider) *ProductUseCase {
 	return &ProductUseCase{
 		productRepo:    productRepo,
+		unitOfWork:     unitOfWork,
 		messageUseCase: messageUseCase,
+		rates:          rates,
 		cache:          cache.New(),
 	}
 }
 
-func (uc *ProductUseCase) GetProduct(id string) (*domain.Product, error) {
+// GetProduct returns the product with its price converted to currency, or in
+// its base currency when currency is empty.
+func (uc *ProductUseCase) GetProduct(id, currency string) (*domain.Product, error) {
+	product, err := uc.getProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := uc.ConvertPrices([]*domain.Product{product}, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return converted[0], nil
+}
+
+func (uc *ProductUseCase) getProduct(id string) (*domain.Product, error) {
 	if id == "" {
 		return nil, errors.New("product ID cannot be empty")
 	}
@@ -48,7 +70,9 @@ func (uc *ProductUseCase) GetProduct(id string) (*domain.Product, error) {
 
 			if price, ok := jsonMap["Price"]; ok && price != nil {
 				if priceFloat, ok := price.(float64); ok {
-					product.Price = priceFloat
+					if money, err := domain.MoneyFromFloat(priceFloat, domain.DefaultCurrency); err == nil {
+						product.Price = money
+					}
 				}
 			}
 
@@ -82,7 +106,16 @@ func (uc *ProductUseCase) GetProduct(id string) (*domain.Product, error) {
 	return product, nil
 }
 
-func (uc *ProductUseCase) ListProducts(page, limit int32) ([]*domain.Product, int32, error) {
+// ListProducts returns a page of the products matching filter, with prices
+// converted to currency, or in their base currency when currency is empty.
+func (uc *ProductUseCase) ListProducts(filter repository.ProductFilter, page, limit int32, currency string) ([]*domain.Product, int32, error) {
+	return uc.SearchProducts(filter, page, limit, currency)
+}
+
+// SearchProducts validates filter and returns a page of the products that
+// match it, with prices converted to currency, or in their base currency
+// when currency is empty.
+func (uc *ProductUseCase) SearchProducts(filter repository.ProductFilter, page, limit int32, currency string) ([]*domain.Product, int32, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -91,30 +124,224 @@ func (uc *ProductUseCase) ListProducts(page, limit int32) ([]*domain.Product, in
 		limit = 10
 	}
 
-	return uc.productRepo.List(page, limit)
+	if err := validateProductFilter(&filter); err != nil {
+		return nil, 0, err
+	}
+
+	products, total, err := uc.productRepo.SearchByFilters(filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	products, err = uc.ConvertPrices(products, currency)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
 }
 
-func (uc *ProductUseCase) CreateProduct(name string, price float64, stock int32) (*domain.Product, error) {
-	if name == "" {
-		return nil, errors.New("product name cannot be empty")
+// ListProductsPage returns the page of products matching filter that follows
+// page.Token, with prices converted to currency unless it is empty. Unlike
+// SearchProducts it pages by keyset and only counts the matches when
+// page.WithTotal is set.
+func (uc *ProductUseCase) ListProductsPage(filter repository.ProductFilter, page repository.PageRequest, currency string) (*repository.Page[*domain.Product], error) {
+	if page.Limit <= 0 {
+		page.Limit = 10
 	}
 
-	if price < 0 {
-		return nil, errors.New("price cannot be negative")
+	if err := validateProductFilter(&filter); err != nil {
+		return nil, err
 	}
 
-	if stock < 0 {
-		return nil, errors.New("stock cannot be negative")
+	result, err := uc.productRepo.ListPage(filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Items, err = uc.ConvertPrices(result.Items, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateProductFilter defaults the sort to name, and the price currency of
+// price sorts to the catalog currency, and rejects unknown sort fields and
+// invalid price ranges.
+func validateProductFilter(filter *repository.ProductFilter) error {
+	if filter.SortBy == "" {
+		filter.SortBy = repository.ProductSortByName
+	}
+
+	if !filter.SortBy.IsValid() {
+		return fmt.Errorf("%w: cannot sort by %q", repository.ErrInvalidProductFilter, filter.SortBy)
+	}
+
+	if filter.SortBy == repository.ProductSortByPrice && filter.PriceCurrency == "" {
+		filter.PriceCurrency = domain.DefaultCurrency
+	}
+
+	return validatePriceRange(filter)
+}
+
+// SearchText returns a page of the products whose names best match text,
+// most relevant first, narrowed by filter. Prices are converted to currency
+// unless it is empty.
+func (uc *ProductUseCase) SearchText(text string, filter repository.ProductFilter, page, limit int32, currency string) ([]*domain.ProductSearchResult, int32, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, 0, fmt.Errorf("%w: search text cannot be empty", repository.ErrInvalidProductFilter)
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	if err := validatePriceRange(&filter); err != nil {
+		return nil, 0, err
+	}
+
+	results, total, err := uc.productRepo.SearchText(text, filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	products := make([]*domain.Product, len(results))
+	for i, result := range results {
+		products[i] = result.Product
+	}
+
+	products, err = uc.ConvertPrices(products, currency)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i, result := range results {
+		result.Product = products[i]
+	}
+
+	return results, total, nil
+}
+
+// SuggestProducts returns up to limit products for autocompleting prefix.
+// An empty prefix has no suggestions.
+func (uc *ProductUseCase) SuggestProducts(prefix string, limit int32) ([]*domain.ProductSuggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return []*domain.ProductSuggestion{}, nil
+	}
+
+	if limit <= 0 {
+		limit = 5
+	}
+
+	if limit > 20 {
+		limit = 20
+	}
+
+	return uc.productRepo.Suggest(prefix, limit)
+}
+
+// validatePriceRange rejects negative or inverted price ranges and bounds
+// in another currency than the filter's price currency, which defaults to
+// the catalog currency when bounds are set.
+func validatePriceRange(filter *repository.ProductFilter) error {
+	if filter.MinPrice.IsNegative() || filter.MaxPrice.IsNegative() {
+		return fmt.Errorf("%w: price cannot be negative", repository.ErrInvalidProductFilter)
+	}
+
+	for _, bound := range []domain.Money{filter.MinPrice, filter.MaxPrice} {
+		if bound.Amount <= 0 {
+			continue
+		}
+		if filter.PriceCurrency == "" {
+			filter.PriceCurrency = domain.DefaultCurrency
+		}
+		if bound.Currency != filter.PriceCurrency {
+			return fmt.Errorf("%w: price bounds must be in %s", repository.ErrInvalidProductFilter, filter.PriceCurrency)
+		}
+	}
+
+	if filter.MaxPrice.Amount > 0 && filter.MinPrice.Amount > filter.MaxPrice.Amount {
+		return fmt.Errorf("%w: min price is above max price", repository.ErrInvalidProductFilter)
+	}
+
+	return nil
+}
+
+// ConvertPrices returns copies of products priced in currency. Products are
+// returned as they are when currency is empty.
+func (uc *ProductUseCase) ConvertPrices(products []*domain.Product, currency string) ([]*domain.Product, error) {
+	if currency == "" {
+		return products, nil
+	}
+
+	converted := make([]*domain.Product, len(products))
+	for i, product := range products {
+		rate, err := uc.rates.Rate(product.Price.Currency, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		price, err := product.Price.Convert(rate)
+		if err != nil {
+			return nil, err
+		}
+
+		copied := *product
+		copied.Price = price
+		converted[i] = &copied
+	}
+
+	return converted, nil
+}
+
+// CreateProduct adds a product in categoryID, which may be empty, with the
+// given tags. Its initial stock is recorded in the stock ledger as a restock.
+func (uc *ProductUseCase) CreateProduct(name string, price domain.Money, stock int32, categoryID string, tags []string) (*domain.Product, error) {
+	if err := validateNewProduct(name, price, stock); err != nil {
+		return nil, err
 	}
 
 	product := &domain.Product{
-		ID:    uuid.New().String(),
-		Name:  name,
-		Price: price,
-		Stock: stock,
+		ID:         uuid.New().String(),
+		Name:       name,
+		Price:      domain.NewMoney(price.Amount, price.Currency),
+		Stock:      stock,
+		CategoryID: categoryID,
+		Tags:       domain.NormalizeTags(tags),
 	}
 
-	err := uc.productRepo.Create(product)
+	err := uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		if err := tx.Products().Create(product); err != nil {
+			return err
+		}
+
+		if stock > 0 {
+			_, err := tx.StockMovements().Record(&domain.StockMovement{
+				ID:        uuid.New().String(),
+				ProductID: product.ID,
+				Delta:     stock,
+				Reason:    domain.StockMovementRestock,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		message, err := newOutboxMessage("product", product.ID, domain.EventProductCreated, newProductCreatedEvent(product))
+		if err != nil {
+			return err
+		}
+
+		return tx.Outbox().Add(message)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -126,11 +353,36 @@ func (uc *ProductUseCase) CreateProduct(name string, price float64, stock int32)
 	return product, nil
 }
 
-func (uc *ProductUseCase) UpdateProduct(id, name string, price float64, stock int32) (*domain.Product, error) {
+// validateNewProduct checks the fields every product must have.
+func validateNewProduct(name string, price domain.Money, stock int32) error {
+	if name == "" {
+		return errors.New("product name cannot be empty")
+	}
+
+	if price.IsNegative() {
+		return errors.New("price cannot be negative")
+	}
+
+	if stock < 0 {
+		return errors.New("stock cannot be negative")
+	}
+
+	return nil
+}
+
+// UpdateProduct changes the product's fields. An empty name, a nil price, a
+// negative stock, a nil categoryID and nil tags leave the current value in
+// place. An empty categoryID removes the product from its category. A new
+// stock level is recorded in the stock ledger as an adjustment.
+func (uc *ProductUseCase) UpdateProduct(id, name string, price *domain.Money, stock int32, categoryID *string, tags []string) (*domain.Product, error) {
 	if id == "" {
 		return nil, errors.New("product ID cannot be empty")
 	}
 
+	if price != nil && price.IsNegative() {
+		return nil, errors.New("price cannot be negative")
+	}
+
 	product, err := uc.productRepo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -140,15 +392,49 @@ func (uc *ProductUseCase) UpdateProduct(id, name string, price float64, stock in
 		product.Name = name
 	}
 
-	if price >= 0 {
-		product.Price = price
+	if price != nil {
+		product.Price = domain.NewMoney(price.Amount, price.Currency)
 	}
 
 	if stock >= 0 {
 		product.Stock = stock
 	}
 
-	err = uc.productRepo.Update(product)
+	if categoryID != nil {
+		product.CategoryID = *categoryID
+	}
+
+	if tags != nil {
+		product.Tags = domain.NormalizeTags(tags)
+	}
+
+	err = uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		if err := tx.Products().Update(product); err != nil {
+			return err
+		}
+
+		if stock >= 0 {
+			movement := &domain.StockMovement{
+				ID:        uuid.New().String(),
+				ProductID: product.ID,
+				Reason:    domain.StockMovementAdjustment,
+			}
+			if err := tx.StockMovements().SetStock(movement, stock); err != nil {
+				return err
+			}
+
+			if err := addLowStockAlert(tx, product, movement); err != nil {
+				return err
+			}
+		}
+
+		message, err := newOutboxMessage("product", product.ID, domain.EventProductUpdated, newProductUpdatedEvent(product))
+		if err != nil {
+			return err
+		}
+
+		return tx.Outbox().Add(message)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -159,6 +445,64 @@ func (uc *ProductUseCase) UpdateProduct(id, name string, price float64, stock in
 	return product, nil
 }
 
+// SetReorderThreshold sets the stock below which the product needs
+// reordering. Zero turns low-stock alerts off. Raising the threshold above
+// the product's stock queues a low-stock alert, as stock dropping below it
+// does.
+func (uc *ProductUseCase) SetReorderThreshold(id string, threshold int32) (*domain.Product, error) {
+	if id == "" {
+		return nil, errors.New("product ID cannot be empty")
+	}
+
+	if threshold < 0 {
+		return nil, errors.New("reorder threshold cannot be negative")
+	}
+
+	var product *domain.Product
+	err := uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		before, err := tx.Products().GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		// Setting the threshold locks the product, so its stock cannot
+		// change before it is checked.
+		if err := tx.Products().SetReorderThreshold(id, threshold); err != nil {
+			return err
+		}
+
+		if product, err = tx.Products().GetByID(id); err != nil {
+			return err
+		}
+
+		wasLow := *product
+		wasLow.ReorderThreshold = before.ReorderThreshold
+		if !product.IsLowStock() || wasLow.IsLowStock() {
+			return nil
+		}
+
+		message, err := newOutboxMessage("product", product.ID, domain.EventInventoryLowStock, domain.LowStockEvent{
+			ProductID:        product.ID,
+			Name:             product.Name,
+			Stock:            product.Stock,
+			ReorderThreshold: product.ReorderThreshold,
+			Reason:           domain.LowStockThresholdRaised,
+			DetectedAt:       time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Outbox().Add(message)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.cache.Set("product:"+id, product, 5*time.Minute)
+
+	return product, nil
+}
+
 func (uc *ProductUseCase) DeleteProduct(id string) error {
 	if id == "" {
 		return errors.New("product ID cannot be empty")
@@ -169,7 +513,18 @@ func (uc *ProductUseCase) DeleteProduct(id string) error {
 		return err
 	}
 
-	err = uc.productRepo.Delete(id)
+	err = uc.unitOfWork.Do(func(tx repository.Transaction) error {
+		if err := tx.Products().Delete(id); err != nil {
+			return err
+		}
+
+		message, err := newOutboxMessage("product", id, domain.EventProductDeleted, newProductDeletedEvent(id))
+		if err != nil {
+			return err
+		}
+
+		return tx.Outbox().Add(message)
+	})
 	if err != nil {
 		return err
 	}
@@ -185,7 +540,7 @@ func (uc *ProductUseCase) SearchByName(name string, page, limit int32) ([]*domai
 	return uc.productRepo.SearchByName(name, page, limit)
 }
 
-func (uc *ProductUseCase) SearchByPriceRange(minPrice, maxPrice float64, page, limit int32) ([]*domain.Product, int32, error) {
+func (uc *ProductUseCase) SearchByPriceRange(minPrice, maxPrice domain.Money, page, limit int32) ([]*domain.Product, int32, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -196,11 +551,11 @@ func (uc *ProductUseCase) SearchByPriceRange(minPrice, maxPrice float64, page, l
 
 	filters := make(map[string]interface{})
 
-	if minPrice > 0 {
+	if minPrice.Amount > 0 {
 		filters["min_price"] = minPrice
 	}
 
-	if maxPrice > 0 {
+	if maxPrice.Amount > 0 {
 		filters["max_price"] = maxPrice
 	}
 
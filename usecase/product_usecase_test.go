@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+type filterRecordingProductRepository struct {
+	repository.ProductRepository
+	filter      repository.ProductFilter
+	page, limit int32
+}
+
+func (r *filterRecordingProductRepository) SearchByFilters(filter repository.ProductFilter, page, limit int32) ([]*domain.Product, int32, error) {
+	r.filter, r.page, r.limit = filter, page, limit
+	return []*domain.Product{{ID: "p1", Price: domain.NewMoney(100000, "KZT")}}, 1, nil
+}
+
+func (r *filterRecordingProductRepository) SearchText(text string, filter repository.ProductFilter, page, limit int32) ([]*domain.ProductSearchResult, int32, error) {
+	r.filter, r.page, r.limit = filter, page, limit
+	product := &domain.Product{ID: "p1", Name: text, Price: domain.NewMoney(100000, "KZT")}
+	return []*domain.ProductSearchResult{{Product: product, Rank: 1, Highlight: "<mark>" + text + "</mark>"}}, 1, nil
+}
+
+func (r *filterRecordingProductRepository) ListPage(filter repository.ProductFilter, page repository.PageRequest) (*repository.Page[*domain.Product], error) {
+	r.filter, r.limit = filter, page.Limit
+	return &repository.Page[*domain.Product]{
+		Items:         []*domain.Product{{ID: "p1", Price: domain.NewMoney(100000, "KZT")}},
+		NextPageToken: "next",
+	}, nil
+}
+
+func TestSearchProducts_DefaultsAndConversion(t *testing.T) {
+	repo := &filterRecordingProductRepository{}
+	uc := NewProductUseCase(repo, nil, nil, memoryRates{"USD": "0.0021"})
+
+	products, total, err := uc.SearchProducts(repository.ProductFilter{Name: "apple", InStock: true}, 0, 0, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	assert.Equal(t, domain.NewMoney(210, "USD"), products[0].Price)
+
+	assert.Equal(t, repository.ProductFilter{Name: "apple", InStock: true, SortBy: repository.ProductSortByName}, repo.filter)
+	assert.Equal(t, int32(1), repo.page)
+	assert.Equal(t, int32(10), repo.limit)
+}
+
+func TestSearchProducts_RejectsInvalidFilters(t *testing.T) {
+	uc := NewProductUseCase(&filterRecordingProductRepository{}, nil, nil, memoryRates{})
+
+	filters := []repository.ProductFilter{
+		{SortBy: "popularity"},
+		{MinPrice: domain.NewMoney(-1, "KZT")},
+		{MinPrice: domain.NewMoney(500, "KZT"), MaxPrice: domain.NewMoney(100, "KZT"), PriceCurrency: "KZT"},
+		// Prices in different currencies cannot be compared.
+		{MaxPrice: domain.NewMoney(500, "USD"), PriceCurrency: "KZT"},
+		{MaxPrice: domain.NewMoney(500, "USD")},
+	}
+
+	for _, filter := range filters {
+		_, _, err := uc.SearchProducts(filter, 1, 10, "")
+		assert.ErrorIs(t, err, repository.ErrInvalidProductFilter, "%+v", filter)
+	}
+}
+
+func TestSearchText_ConvertsPricesAndRejectsEmptyText(t *testing.T) {
+	repo := &filterRecordingProductRepository{}
+	uc := NewProductUseCase(repo, nil, nil, memoryRates{"USD": "0.0021"})
+
+	results, total, err := uc.SearchText("  apple ", repository.ProductFilter{InStock: true}, 2, 5, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	assert.Equal(t, "apple", results[0].Product.Name)
+	assert.Equal(t, "<mark>apple</mark>", results[0].Highlight)
+	assert.Equal(t, domain.NewMoney(210, "USD"), results[0].Product.Price)
+	assert.Equal(t, int32(2), repo.page)
+	assert.Equal(t, int32(5), repo.limit)
+
+	_, _, err = uc.SearchText(" ", repository.ProductFilter{}, 1, 10, "")
+	assert.ErrorIs(t, err, repository.ErrInvalidProductFilter)
+}
+
+func TestListProductsPage_DefaultsAndConversion(t *testing.T) {
+	repo := &filterRecordingProductRepository{}
+	uc := NewProductUseCase(repo, nil, nil, memoryRates{"USD": "0.0021"})
+
+	page, err := uc.ListProductsPage(repository.ProductFilter{InStock: true}, repository.PageRequest{}, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewMoney(210, "USD"), page.Items[0].Price)
+	assert.Equal(t, "next", page.NextPageToken)
+	assert.Nil(t, page.Total)
+
+	assert.Equal(t, repository.ProductFilter{InStock: true, SortBy: repository.ProductSortByName}, repo.filter)
+	assert.Equal(t, int32(10), repo.limit)
+
+	_, err = uc.ListProductsPage(repository.ProductFilter{SortBy: "popularity"}, repository.PageRequest{}, "")
+	assert.ErrorIs(t, err, repository.ErrInvalidProductFilter)
+}
+
+func TestCreateAndUpdateProduct_RecordStockMovements(t *testing.T) {
+	uc, tx := newCatalogUseCase()
+
+	product, err := uc.CreateProduct("Apple", domain.NewMoney(150, "KZT"), 10, "", nil)
+	assert.NoError(t, err)
+
+	_, err = uc.UpdateProduct(product.ID, "", nil, 4, nil, nil)
+	assert.NoError(t, err)
+	_, err = uc.UpdateProduct(product.ID, "Green apple", nil, -1, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(4), tx.products.products[product.ID].Stock)
+	assert.Len(t, tx.products.movements, 2)
+	assert.Equal(t, domain.StockMovementRestock, tx.products.movements[0].Reason)
+	assert.Equal(t, int32(10), tx.products.movements[0].Delta)
+	assert.Equal(t, domain.StockMovementAdjustment, tx.products.movements[1].Reason)
+	assert.Equal(t, int32(-6), tx.products.movements[1].Delta)
+}
+
+func TestUpdateProduct_QueuesLowStockAlertBelowReorderThreshold(t *testing.T) {
+	uc, tx := newCatalogUseCase()
+
+	product, err := uc.CreateProduct("Milk", domain.NewMoney(450, "KZT"), 12, "", nil)
+	assert.NoError(t, err)
+
+	product, err = uc.SetReorderThreshold(product.ID, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10), product.ReorderThreshold)
+
+	_, err = uc.SetReorderThreshold(product.ID, -1)
+	assert.Error(t, err)
+	_, err = uc.SetReorderThreshold("missing", 5)
+	assert.ErrorIs(t, err, repository.ErrProductNotFound)
+
+	tx.outbox.messages = nil
+	_, err = uc.UpdateProduct(product.ID, "", nil, 8, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, tx.outbox.messages, 2)
+	assert.Equal(t, domain.EventInventoryLowStock, tx.outbox.messages[0].Subject)
+	assert.Equal(t, domain.EventProductUpdated, tx.outbox.messages[1].Subject)
+
+	event := outboxEvent[domain.LowStockEvent](t, tx.outbox.messages[0])
+	assert.Equal(t, int32(8), event.Stock)
+	assert.Equal(t, int32(10), event.ReorderThreshold)
+	assert.Equal(t, domain.StockMovementAdjustment, event.Reason)
+}
+
+func TestSetReorderThreshold_QueuesLowStockAlertWhenRaisedAboveStock(t *testing.T) {
+	uc, tx := newCatalogUseCase()
+
+	product, err := uc.CreateProduct("Milk", domain.NewMoney(450, "KZT"), 12, "", nil)
+	assert.NoError(t, err)
+	tx.outbox.messages = nil
+
+	_, err = uc.SetReorderThreshold(product.ID, 12)
+	assert.NoError(t, err)
+	assert.Empty(t, tx.outbox.messages)
+
+	_, err = uc.SetReorderThreshold(product.ID, 20)
+	assert.NoError(t, err)
+	if assert.Len(t, tx.outbox.messages, 1) {
+		assert.Equal(t, domain.EventInventoryLowStock, tx.outbox.messages[0].Subject)
+		event := outboxEvent[domain.LowStockEvent](t, tx.outbox.messages[0])
+		assert.Equal(t, int32(12), event.Stock)
+		assert.Equal(t, int32(20), event.ReorderThreshold)
+		assert.Equal(t, domain.LowStockThresholdRaised, event.Reason)
+	}
+
+	// Already below the old threshold, so it was reported then.
+	_, err = uc.SetReorderThreshold(product.ID, 30)
+	assert.NoError(t, err)
+	assert.Len(t, tx.outbox.messages, 1)
+}
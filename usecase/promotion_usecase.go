@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+// PromotionUseCase manages the promotions that OrderUseCase applies to new
+// orders.
+type PromotionUseCase struct {
+	promotionRepo repository.PromotionRepository
+}
+
+func NewPromotionUseCase(promotionRepo repository.PromotionRepository) *PromotionUseCase {
+	return &PromotionUseCase{
+		promotionRepo: promotionRepo,
+	}
+}
+
+// CreatePromotion validates and stores a new promotion. A fixed amount with
+// no currency is taken to be in domain.DefaultCurrency.
+func (uc *PromotionUseCase) CreatePromotion(promotion *domain.Promotion) (*domain.Promotion, error) {
+	if err := promotion.Validate(); err != nil {
+		return nil, err
+	}
+
+	promotion.ID = uuid.New().String()
+	promotion.TimesUsed = 0
+	promotion.CreatedAt = time.Now().UTC()
+	if promotion.AmountOff.Currency == "" {
+		promotion.AmountOff.Currency = domain.DefaultCurrency
+	}
+
+	if err := uc.promotionRepo.Create(promotion); err != nil {
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+func (uc *PromotionUseCase) GetPromotion(id string) (*domain.Promotion, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: ID cannot be empty", domain.ErrInvalidPromotion)
+	}
+
+	return uc.promotionRepo.GetByID(id)
+}
+
+// UpdatePromotion replaces the promotion's settings with those of update.
+// How often it has been used and when it was created are kept.
+func (uc *PromotionUseCase) UpdatePromotion(id string, update *domain.Promotion) (*domain.Promotion, error) {
+	current, err := uc.GetPromotion(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	update.ID = current.ID
+	update.TimesUsed = current.TimesUsed
+	update.CreatedAt = current.CreatedAt
+	if update.AmountOff.Currency == "" {
+		update.AmountOff.Currency = domain.DefaultCurrency
+	}
+
+	if err := uc.promotionRepo.Update(update); err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// DeletePromotion removes the promotion. Orders keep the discounts it gave.
+func (uc *PromotionUseCase) DeletePromotion(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: ID cannot be empty", domain.ErrInvalidPromotion)
+	}
+
+	return uc.promotionRepo.Delete(id)
+}
+
+// ListPromotions returns every promotion, newest first.
+func (uc *PromotionUseCase) ListPromotions() ([]*domain.Promotion, error) {
+	return uc.promotionRepo.List()
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+func (r *memoryPromotionRepository) Create(promotion *domain.Promotion) error {
+	copied := *promotion
+	r.promotions = append(r.promotions, &copied)
+	return nil
+}
+
+func (r *memoryPromotionRepository) GetByID(id string) (*domain.Promotion, error) {
+	for _, promotion := range r.promotions {
+		if promotion.ID == id {
+			copied := *promotion
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrPromotionNotFound
+}
+
+func (r *memoryPromotionRepository) Update(promotion *domain.Promotion) error {
+	for i, stored := range r.promotions {
+		if stored.ID == promotion.ID {
+			copied := *promotion
+			r.promotions[i] = &copied
+			return nil
+		}
+	}
+	return repository.ErrPromotionNotFound
+}
+
+func TestCreatePromotion_ValidatesAndNormalizesCode(t *testing.T) {
+	repo := &memoryPromotionRepository{}
+	uc := NewPromotionUseCase(repo)
+
+	_, err := uc.CreatePromotion(&domain.Promotion{Name: "Half off", Type: domain.PromotionPercentage, PercentOff: 150})
+	assert.ErrorIs(t, err, domain.ErrInvalidPromotion)
+	assert.Empty(t, repo.promotions)
+
+	promotion, err := uc.CreatePromotion(&domain.Promotion{
+		Code:      " welcome ",
+		Name:      "Welcome",
+		Type:      domain.PromotionFixedAmount,
+		AmountOff: domain.Money{Amount: 50000},
+		TimesUsed: 7,
+		Active:    true,
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, promotion.ID)
+	assert.Equal(t, "WELCOME", promotion.Code)
+	assert.Equal(t, domain.NewMoney(50000, domain.DefaultCurrency), promotion.AmountOff)
+	assert.Zero(t, promotion.TimesUsed)
+	assert.Len(t, repo.promotions, 1)
+}
+
+func TestUpdatePromotion_KeepsUsage(t *testing.T) {
+	repo := &memoryPromotionRepository{}
+	uc := NewPromotionUseCase(repo)
+
+	created, err := uc.CreatePromotion(&domain.Promotion{Name: "Spring", Type: domain.PromotionPercentage, PercentOff: 10, Active: true})
+	assert.NoError(t, err)
+	repo.promotions[0].TimesUsed = 3
+
+	updated, err := uc.UpdatePromotion(created.ID, &domain.Promotion{
+		Name:        "Spring 3 for 2",
+		Type:        domain.PromotionBuyXGetY,
+		BuyQuantity: 2,
+		GetQuantity: 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, int32(3), updated.TimesUsed)
+	assert.True(t, created.CreatedAt.Equal(updated.CreatedAt))
+	assert.False(t, repo.promotions[0].Active)
+	assert.Equal(t, domain.PromotionBuyXGetY, repo.promotions[0].Type)
+
+	_, err = uc.UpdatePromotion("missing", updated)
+	assert.ErrorIs(t, err, repository.ErrPromotionNotFound)
+}
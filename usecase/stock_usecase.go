@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"time"
+
+	"AdvProg2/domain"
+	"AdvProg2/repository"
+)
+
+// StockUseCase reads and reconciles the stock ledger. Stock itself changes
+// through the order and product use cases.
+type StockUseCase struct {
+	productRepo repository.ProductRepository
+	movements   repository.StockMovementRepository
+}
+
+func NewStockUseCase(productRepo repository.ProductRepository, movements repository.StockMovementRepository) *StockUseCase {
+	return &StockUseCase{
+		productRepo: productRepo,
+		movements:   movements,
+	}
+}
+
+// ListMovements returns the page of the product's stock movements, newest
+// first, that follows page.Token.
+func (uc *StockUseCase) ListMovements(productID string, page repository.PageRequest) (*repository.Page[*domain.StockMovement], error) {
+	if _, err := uc.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+
+	if page.Limit > 100 {
+		page.Limit = 100
+	}
+
+	return uc.movements.ListByProduct(productID, page)
+}
+
+// ListLowStock returns the products whose stock is below their reorder
+// threshold, those furthest below it first.
+func (uc *StockUseCase) ListLowStock() ([]*domain.Product, error) {
+	return uc.productRepo.ListLowStock()
+}
+
+// FindDiscrepancies returns the products whose stock does not match their
+// ledger.
+func (uc *StockUseCase) FindDiscrepancies() ([]*domain.StockDiscrepancy, error) {
+	return uc.movements.FindDiscrepancies()
+}
+
+// Reconcile records a reconciliation movement for each product whose stock
+// does not match its ledger and returns those products. The stock itself is
+// kept, since it is what orders have been taking from.
+func (uc *StockUseCase) Reconcile() ([]*domain.StockDiscrepancy, error) {
+	return uc.movements.Reconcile()
+}
+
+// addLowStockAlert queues an inventory.low_stock event in tx when movement
+// took product below its reorder threshold. product must carry the stock
+// the movement left.
+func addLowStockAlert(tx repository.Transaction, product *domain.Product, movement *domain.StockMovement) error {
+	if !movement.DropsBelow(product.ReorderThreshold) {
+		return nil
+	}
+
+	message, err := newOutboxMessage("product", product.ID, domain.EventInventoryLowStock, newLowStockEvent(product, movement))
+	if err != nil {
+		return err
+	}
+
+	return tx.Outbox().Add(message)
+}
+
+func newLowStockEvent(product *domain.Product, movement *domain.StockMovement) domain.LowStockEvent {
+	return domain.LowStockEvent{
+		ProductID:        product.ID,
+		Name:             product.Name,
+		Stock:            movement.StockAfter,
+		ReorderThreshold: product.ReorderThreshold,
+		Reason:           movement.Reason,
+		ReferenceID:      movement.ReferenceID,
+		DetectedAt:       time.Now(),
+	}
+}